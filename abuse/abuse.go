@@ -0,0 +1,176 @@
+// Package abuse throttles actions that cost something when automated, like
+// signing up or sending email, and bans addresses that keep hitting the
+// limits.
+package abuse
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gighub/db"
+	"gighub/ratelimit"
+	"gighub/utils"
+)
+
+// Throttled actions. A limit named after the action applies per IP; one
+// named "global:<action>" applies to everyone together.
+const (
+	Signup  = "signup"
+	Email   = "email"
+	Message = "message"
+)
+
+// DefaultLimits are used when none are configured. Signups allow a small
+// burst and then one a minute per address.
+var DefaultLimits = map[string]ratelimit.Limit{
+	Signup:            {PerMinute: 1, Burst: 5},
+	Email:             {PerMinute: 2, Burst: 5},
+	Message:           {PerMinute: 6, Burst: 10},
+	"global:" + Email: {PerMinute: 30, Burst: 60},
+}
+
+// An address that is turned away this many times within a few minutes is
+// banned for banDuration.
+const (
+	strikeLimit = 10
+	banDuration = time.Hour
+)
+
+// cacheTTL is how long the list of active bans is reused before it is read
+// again. Bans made through the Guard apply immediately.
+const cacheTTL = 30 * time.Second
+
+// Guard applies the throttles and the banlist.
+type Guard struct {
+	Queries *db.Queries
+	Limiter *ratelimit.Limiter
+	Limits  *ratelimit.Table
+
+	mu     sync.Mutex
+	bans   map[string]time.Time
+	loaded time.Time
+}
+
+func New(queries *db.Queries, limiter *ratelimit.Limiter, limits map[string]ratelimit.Limit) *Guard {
+	return &Guard{Queries: queries, Limiter: limiter, Limits: ratelimit.NewTable(limits)}
+}
+
+// Allow counts one action from ip against its per-IP and global limits.
+// Only going over the per-IP limit counts towards a ban.
+func (g *Guard) Allow(ctx context.Context, action, ip string) ratelimit.Result {
+	if limit, ok := g.Limits.Get("global:" + action); ok {
+		if res := g.Limiter.Allow("global:"+action, limit); !res.Allowed {
+			return res
+		}
+	}
+	limit, ok := g.Limits.Get(action)
+	if !ok {
+		return ratelimit.Result{Allowed: true}
+	}
+	res := g.Limiter.Allow(action+":"+ip, limit)
+	if !res.Allowed {
+		g.strike(ctx, ip, action)
+	}
+	return res
+}
+
+// strike records a rejected request and bans the address once it has
+// piled up too many.
+func (g *Guard) strike(ctx context.Context, ip, action string) {
+	if g.Limiter.Allow("strike:"+ip, ratelimit.Limit{PerMinute: 2, Burst: strikeLimit}).Allowed {
+		return
+	}
+	reason := fmt.Sprintf("Kept exceeding the %s limit", action)
+	if err := g.Ban(ctx, ip, reason, banDuration); err != nil {
+		log.Print(err)
+	}
+}
+
+// Ban blocks every request from ip for d.
+func (g *Guard) Ban(ctx context.Context, ip, reason string, d time.Duration) error {
+	until := time.Now().Add(d).UTC()
+	if err := g.Queries.CreateBan(ctx, db.CreateBanParams{Ip: ip, Reason: reason, ExpiresAt: until}); err != nil {
+		return fmt.Errorf("error banning %s: %w", ip, err)
+	}
+	log.Printf("Banned %s until %s: %s", ip, until.Format(time.RFC3339), reason)
+	g.mu.Lock()
+	if g.bans != nil && until.After(g.bans[ip]) {
+		g.bans[ip] = until
+	}
+	g.mu.Unlock()
+	return nil
+}
+
+// Lift removes a ban.
+func (g *Guard) Lift(ctx context.Context, id int64) error {
+	if err := g.Queries.DeleteBan(ctx, id); err != nil {
+		return fmt.Errorf("error lifting ban: %w", err)
+	}
+	g.mu.Lock()
+	g.bans = nil
+	g.mu.Unlock()
+	return nil
+}
+
+// Banned reports whether ip is banned, and until when.
+func (g *Guard) Banned(ctx context.Context, ip string) (time.Time, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.bans == nil || time.Since(g.loaded) > cacheTTL {
+		active, err := g.Queries.ListActiveBans(ctx, time.Now().UTC())
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("error loading bans: %w", err)
+		}
+		g.bans = map[string]time.Time{}
+		for _, b := range active {
+			if b.ExpiresAt.After(g.bans[b.Ip]) {
+				g.bans[b.Ip] = b.ExpiresAt
+			}
+		}
+		g.loaded = time.Now()
+	}
+	until, ok := g.bans[ip]
+	return until, ok && time.Now().Before(until), nil
+}
+
+// Block turns away banned addresses with a 403. Requests for which exempt
+// returns true, like the admin pages used to lift bans, always get through.
+func (g *Guard) Block(exempt func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exempt != nil && exempt(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			until, banned, err := g.Banned(r.Context(), utils.ClientIP(r))
+			if err != nil {
+				log.Print(err)
+			}
+			if banned {
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+				utils.LimitError(w, r, http.StatusForbidden, "Your address has been blocked for a while after too many requests")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Throttle limits requests to a route as the given action.
+func (g *Guard) Throttle(action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if res := g.Allow(r.Context(), action, utils.ClientIP(r)); !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(res.RetryAfter/time.Second)+1))
+				utils.LimitError(w, r, http.StatusTooManyRequests, "Too many requests, please try again later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package analytics counts page views and a few events, like signups,
+// without cookies. Visitors are told apart by a hash of their address,
+// with the last part dropped, and browser, salted with a secret that is
+// replaced every day and never stored. So a visitor can't be followed
+// from one day to the next, and requests asking not to be tracked with
+// DNT or Sec-GPC aren't counted at all.
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gighub/db"
+	"gighub/utils"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// PageView is the event recorded for each page served, and Exposure the
+// one recorded when a variant of an experiment is shown.
+const (
+	PageView = "pageview"
+	Exposure = "exposure"
+)
+
+// Events recorded by handlers.
+const (
+	Signup = "signup"
+	Verify = "verify"
+	Login  = "login"
+)
+
+// maxPending bounds the events held between flushes. More are dropped.
+const maxPending = 10000
+
+// Recorder buffers events and writes them in batches.
+type Recorder struct {
+	Queries *db.Queries
+
+	mu      sync.Mutex
+	pending []db.CreateAnalyticsEventParams
+	salt    []byte
+	saltDay string
+}
+
+func New(queries *db.Queries) *Recorder {
+	return &Recorder{Queries: queries}
+}
+
+// Track records a page view for each page a browser loads successfully.
+// Bots, htmx requests and pages under skip aren't counted.
+func (rec *Recorder) Track(skip ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+			if r.Method != http.MethodGet || ww.Status() != http.StatusOK || r.Header.Get("HX-Request") != "" {
+				return
+			}
+			// Pages that don't set a type are sniffed as HTML once written
+			if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+				return
+			}
+			if ct := ww.Header().Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "text/html") {
+				return
+			}
+			for _, prefix := range skip {
+				if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+					return
+				}
+			}
+			rec.record(r, db.CreateAnalyticsEventParams{Name: PageView, Path: r.URL.Path})
+		})
+	}
+}
+
+// Event records that something happened, like a signup.
+func (rec *Recorder) Event(r *http.Request, name string) {
+	rec.record(r, db.CreateAnalyticsEventParams{Name: name})
+}
+
+// Exposed records that a variant of an experiment was shown.
+func (rec *Recorder) Exposed(r *http.Request, experiment, variant string) {
+	rec.record(r, db.CreateAnalyticsEventParams{Name: Exposure, Experiment: experiment, Variant: variant})
+}
+
+func (rec *Recorder) record(r *http.Request, e db.CreateAnalyticsEventParams) {
+	if r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1" || isBot(r.UserAgent()) {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.pending) >= maxPending {
+		return
+	}
+	e.Referrer = referrer(r)
+	e.Visitor = rec.visitor(r)
+	rec.pending = append(rec.pending, e)
+}
+
+// Visitor returns the ID the request's visitor has today.
+func (rec *Recorder) Visitor(r *http.Request) string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.visitor(r)
+}
+
+// visitor hashes the truncated address and browser with the day's salt.
+// rec.mu must be held.
+func (rec *Recorder) visitor(r *http.Request) string {
+	if day := time.Now().UTC().Format(time.DateOnly); day != rec.saltDay {
+		rec.salt = make([]byte, 16)
+		rand.Read(rec.salt)
+		rec.saltDay = day
+	}
+	h := sha256.New()
+	h.Write(rec.salt)
+	h.Write([]byte(truncateIP(utils.ClientIP(r))))
+	h.Write([]byte(r.UserAgent()))
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// truncateIP keeps the network an address is in: the first three bytes
+// of an IPv4 address, or the first six of an IPv6 one.
+func truncateIP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	bits := 48
+	if addr.Unmap().Is4() {
+		addr, bits = addr.Unmap(), 24
+	}
+	prefix, _ := addr.Prefix(bits)
+	return prefix.Addr().String()
+}
+
+// referrer is the host a visitor came from, or "" when they came from
+// this site or didn't say. utm_source wins when a link sets it.
+func referrer(r *http.Request) string {
+	if source := r.URL.Query().Get("utm_source"); source != "" {
+		return strings.ToLower(source)
+	}
+	u, err := url.Parse(r.Referer())
+	if err != nil || u.Host == "" || u.Host == r.Host {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func isBot(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return true
+	}
+	for _, s := range []string{"bot", "crawl", "spider", "slurp", "curl", "wget", "python", "go-http-client", "headless"} {
+		if strings.Contains(ua, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush writes the buffered events.
+func (rec *Recorder) Flush(ctx context.Context) error {
+	rec.mu.Lock()
+	pending := rec.pending
+	rec.pending = nil
+	rec.mu.Unlock()
+	for _, p := range pending {
+		if err := rec.Queries.CreateAnalyticsEvent(ctx, p); err != nil {
+			return fmt.Errorf("error saving analytics event: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run flushes the buffered events every interval until ctx is cancelled,
+// then one final time.
+func (rec *Recorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := rec.Flush(context.Background()); err != nil {
+				log.Print(err)
+			}
+			return
+		case <-ticker.C:
+			if err := rec.Flush(ctx); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// RollUp adds up the raw events into daily counts, and deletes the ones
+// from before yesterday. Yesterday's are kept so its counts can be redone
+// once events that were still buffered at midnight are in.
+func (rec *Recorder) RollUp(ctx context.Context) error {
+	if err := rec.Queries.RollUpAnalyticsDays(ctx); err != nil {
+		return err
+	}
+	if err := rec.Queries.RollUpAnalyticsCounts(ctx); err != nil {
+		return err
+	}
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	return rec.Queries.DeleteAnalyticsEventsBefore(ctx, yesterday)
+}
+
+// Step is one step of a funnel: an event, or a view of a page.
+type Step struct {
+	Label string
+	Name  string
+	Path  string
+}
+
+// SignupFunnel follows visitors from the home page to a verified account.
+var SignupFunnel = []Step{
+	{"Viewed the home page", PageView, "/"},
+	{"Viewed the signup page", PageView, "/signup"},
+	{"Signed up", Signup, ""},
+	{"Verified their email", Verify, ""},
+}
@@ -0,0 +1,113 @@
+// Package announcements picks the banners to show across the site, like
+// notices of maintenance or of new features. Admins schedule them at
+// /admin/announcements.
+package announcements
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"gighub/db"
+)
+
+// Levels set how a banner looks.
+const (
+	Info    = "info"
+	Warning = "warning"
+)
+
+// Audiences an announcement can be shown to.
+const (
+	Everyone = "everyone"
+	Visitors = "visitors"
+	Users    = "users"
+)
+
+// cacheTTL is how long announcements are reused before they are read
+// again. Changes made through the Store show up immediately.
+const cacheTTL = 30 * time.Second
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the announcements to show.
+func NewContext(ctx context.Context, list []db.Announcement) context.Context {
+	return context.WithValue(ctx, contextKey{}, list)
+}
+
+// FromContext returns the announcements to show for the current request.
+func FromContext(ctx context.Context) []db.Announcement {
+	list, _ := ctx.Value(contextKey{}).([]db.Announcement)
+	return list
+}
+
+// Store reads announcements from the announcements table.
+type Store struct {
+	Queries *db.Queries
+
+	mu     sync.Mutex
+	list   []db.Announcement
+	loaded time.Time
+}
+
+func New(queries *db.Queries) *Store {
+	return &Store{Queries: queries}
+}
+
+// Active returns the announcements that are showing now to a user, or to
+// a visitor when userID is 0. Ones the user dismissed are left out.
+func (s *Store) Active(ctx context.Context, userID int64) ([]db.Announcement, error) {
+	all, err := s.unended(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var active []db.Announcement
+	for _, a := range all {
+		if a.StartsAt.After(now) || (a.EndsAt.Valid && !a.EndsAt.Time.After(now)) {
+			continue
+		}
+		if (a.Audience == Visitors && userID != 0) || (a.Audience == Users && userID == 0) {
+			continue
+		}
+		active = append(active, a)
+	}
+	if userID == 0 || !slices.ContainsFunc(active, func(a db.Announcement) bool { return a.Dismissible }) {
+		return active, nil
+	}
+	dismissed, err := s.Queries.ListDismissedAnnouncements(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading dismissed announcements: %w", err)
+	}
+	return slices.DeleteFunc(active, func(a db.Announcement) bool {
+		return a.Dismissible && slices.Contains(dismissed, a.ID)
+	}), nil
+}
+
+// Invalidate drops the cached announcements after they were changed.
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	s.list = nil
+	s.mu.Unlock()
+}
+
+// unended returns the announcements that are showing or scheduled.
+func (s *Store) unended(ctx context.Context) ([]db.Announcement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.list != nil && time.Since(s.loaded) < cacheTTL {
+		return s.list, nil
+	}
+	list, err := s.Queries.ListUnendedAnnouncements(ctx, sql.NullTime{Time: time.Now().UTC(), Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("error loading announcements: %w", err)
+	}
+	if list == nil {
+		list = []db.Announcement{}
+	}
+	s.list, s.loaded = list, time.Now()
+	return list, nil
+}
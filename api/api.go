@@ -0,0 +1,767 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gighub/bans"
+	"gighub/db"
+	"gighub/jobs"
+	"gighub/ratelimit"
+	"gighub/scan"
+	"gighub/storage"
+	"gighub/utils"
+	"gighub/views"
+	"gighub/webhooks"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+)
+
+// API serves the JSON endpoints mounted under /api/v1.
+type API struct {
+	Queries  *db.Queries
+	Sessions *scs.SessionManager
+	Webhooks *webhooks.Dispatcher
+	Limiter  *ratelimit.Limiter
+	// Limits holds per-minute quotas keyed by token scope, plus "session"
+	// for cookie-authenticated calls and "ip" for every client address.
+	Limits *ratelimit.Table
+	// Stopping is closed when the server begins shutting down, so event
+	// streams end instead of holding the shutdown open.
+	Stopping <-chan struct{}
+	// Storage receives finished resumable uploads, which are assembled in
+	// UploadDir and may be at most MaxResumableSize bytes. Each user's
+	// uploads together may take up StorageQuota bytes, plus what their
+	// referrals earned them.
+	Storage          storage.Store
+	UploadDir        string
+	MaxResumableSize int64
+	StorageQuota     int64
+	// Jobs scans finished uploads.
+	Jobs *jobs.Scheduler
+	// Bans turns away hard-banned users, and keeps what shadow-banned
+	// users write to themselves.
+	Bans *bans.Store
+
+	spec      *Spec
+	uploadsMu sync.Mutex
+	uploading map[string]bool
+}
+
+var userAuth = []map[string][]string{{"session": {}}, {"bearer": {}}}
+
+var webhooksResource = resource{
+	Type:        "webhooks",
+	Columns:     map[string]string{"id": "id", "url": "url", "created_at": "created_at"},
+	Attributes:  []string{"url", "secret", "events", "created_at"},
+	DefaultSort: []db.Order{{Column: "id"}},
+}
+
+var deliveriesResource = resource{
+	Type: "deliveries",
+	Columns: map[string]string{
+		"id":            "webhook_deliveries.id",
+		"event_type":    "events.type",
+		"status":        "webhook_deliveries.status",
+		"response_code": "webhook_deliveries.response_code",
+		"created_at":    "webhook_deliveries.created_at",
+	},
+	Attributes:  []string{"event_id", "event_type", "status", "attempts", "response_code", "error", "created_at"},
+	DefaultSort: []db.Order{{Column: "webhook_deliveries.id", Desc: true}},
+}
+
+var idParam = Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Format: "int64"}}
+
+var uploadIDParam = Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}
+
+var uploadOffsetParam = Parameter{
+	Name:        "Upload-Offset",
+	In:          "header",
+	Required:    true,
+	Description: "Where this chunk starts; must equal the upload's current offset.",
+	Schema:      &Schema{Type: "integer", Format: "int64"},
+}
+
+// Routes builds the API router. Every endpoint is registered through
+// router.handle so it also ends up in the OpenAPI document.
+func (a *API) Routes() chi.Router {
+	a.spec = &Spec{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "gighub API",
+			Version:     "1",
+			Description: "JSON API for integrating with gighub.",
+		},
+		Servers: []Server{{URL: "/api/v1"}},
+		Paths:   map[string]map[string]Operation{},
+		Components: Components{
+			Schemas: map[string]Schema{
+				"Error": {
+					Type:       "object",
+					Properties: map[string]Schema{"error": {Type: "string"}, "request_id": {Type: "string"}},
+					Required:   []string{"error"},
+				},
+				"User": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"id":         {Type: "integer", Format: "int64"},
+						"email":      {Type: "string", Format: "email"},
+						"verified":   {Type: "boolean"},
+						"created_at": {Type: "string", Format: "date-time"},
+					},
+					Required: []string{"id", "email", "verified"},
+				},
+				"Guestbook": {
+					Type:       "object",
+					Properties: map[string]Schema{"message": {Type: "string"}},
+					Required:   []string{"message"},
+				},
+				"Webhook": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"id":         {Type: "integer", Format: "int64"},
+						"url":        {Type: "string", Format: "uri"},
+						"secret":     {Type: "string"},
+						"events":     {Type: "array", Items: &Schema{Type: "string", Enum: webhooks.EventTypes}},
+						"created_at": {Type: "string", Format: "date-time"},
+					},
+					Required: []string{"id", "url", "secret", "events"},
+				},
+				"NewWebhook": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"url":    {Type: "string", Format: "uri"},
+						"events": {Type: "array", Items: &Schema{Type: "string", Enum: webhooks.EventTypes}},
+					},
+					Required: []string{"url", "events"},
+				},
+				"WebhookDelivery": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"id":            {Type: "integer", Format: "int64"},
+						"event_id":      {Type: "integer", Format: "int64"},
+						"event_type":    {Type: "string"},
+						"status":        {Type: "string", Enum: []string{"pending", "succeeded", "failed"}},
+						"attempts":      {Type: "integer"},
+						"response_code": {Type: "integer"},
+						"error":         {Type: "string"},
+						"created_at":    {Type: "string", Format: "date-time"},
+					},
+					Required: []string{"id", "event_id", "event_type", "status", "attempts"},
+				},
+				"RestHook": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"id":         {Type: "integer", Format: "int64"},
+						"target_url": {Type: "string", Format: "uri"},
+						"event":      {Type: "string", Enum: webhooks.EventTypes},
+						"secret":     {Type: "string"},
+					},
+					Required: []string{"id", "target_url", "event", "secret"},
+				},
+				"NewRestHook": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"target_url": {Type: "string", Format: "uri"},
+						"event":      {Type: "string", Enum: webhooks.EventTypes},
+					},
+					Required: []string{"target_url", "event"},
+				},
+				"Event": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"id":         {Type: "integer", Format: "int64"},
+						"type":       {Type: "string"},
+						"created_at": {Type: "string", Format: "date-time"},
+						"data":       {Type: "object"},
+					},
+					Required: []string{"id", "type", "created_at", "data"},
+				},
+				"Upload": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"id":           {Type: "string"},
+						"filename":     {Type: "string"},
+						"size":         {Type: "integer", Format: "int64"},
+						"offset":       {Type: "integer", Format: "int64"},
+						"complete":     {Type: "boolean"},
+						"content_type": {Type: "string"},
+						"status":       {Type: "string", Enum: []string{scan.Pending, scan.Clean, scan.Quarantined, scan.Rejected}},
+						"url":          {Type: "string", Format: "uri"},
+						"expires_at":   {Type: "string", Format: "date-time"},
+					},
+					Required: []string{"id", "filename", "size", "offset", "complete", "status"},
+				},
+				"NewUpload": {
+					Type: "object",
+					Properties: map[string]Schema{
+						"filename": {Type: "string"},
+						"size":     {Type: "integer", Format: "int64"},
+					},
+					Required: []string{"filename", "size"},
+				},
+			},
+			SecuritySchemes: map[string]SecurityScheme{
+				"session": {Type: "apiKey", In: "cookie", Name: "session"},
+				"bearer":  {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	r := &router{Router: chi.NewRouter(), spec: a.spec}
+	r.Use(a.authenticate)
+	r.Use(a.rateLimit)
+
+	r.Get("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, a.spec)
+	})
+	r.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
+		views.APIDocs("/api/v1/openapi.json").Render(r.Context(), w)
+	})
+
+	r.Group(func(g chi.Router) {
+		g.Use(a.requireUser)
+		g.Use(a.idempotent)
+		r := &router{Router: g, spec: a.spec}
+
+		r.handle(http.MethodGet, "/me", Operation{
+			Summary:     "Get the authenticated user",
+			OperationID: "getMe",
+			Tags:        []string{"users"},
+			Security:    userAuth,
+			Responses: map[string]Response{
+				"200": {Description: "The current user", Content: JSON(Ref("User"))},
+			},
+		}, a.getMe)
+
+		r.handle(http.MethodGet, "/events", Operation{
+			Summary:     "Stream events as they happen",
+			OperationID: "streamEvents",
+			Tags:        []string{"events"},
+			Security:    userAuth,
+			Parameters: []Parameter{{
+				Name:        "Last-Event-ID",
+				In:          "header",
+				Description: "Resume after this event id, replaying anything missed.",
+				Schema:      &Schema{Type: "integer", Format: "int64"},
+			}},
+			Responses: map[string]Response{
+				"200": {Description: "A text/event-stream of events; each event's data is its JSON payload", Content: map[string]MediaType{"text/event-stream": {Schema: Schema{Type: "string"}}}},
+			},
+		}, a.streamEvents)
+
+		r.handle(http.MethodGet, "/guestbook", Operation{
+			Summary:     "Get the guestbook message",
+			OperationID: "getGuestbook",
+			Tags:        []string{"guestbook"},
+			Security:    userAuth,
+			Responses: map[string]Response{
+				"200": {Description: "The current message", Content: JSON(Ref("Guestbook"))},
+			},
+		}, a.getGuestbook)
+
+		r.handle(http.MethodPut, "/guestbook", Operation{
+			Summary:     "Replace the guestbook message",
+			OperationID: "putGuestbook",
+			Tags:        []string{"guestbook"},
+			Security:    userAuth,
+			Parameters:  []Parameter{ifMatchParam},
+			RequestBody: &RequestBody{Required: true, Content: JSON(Ref("Guestbook"))},
+			Responses: map[string]Response{
+				"200": {Description: "The updated message", Content: JSON(Ref("Guestbook"))},
+				"400": {Description: "Invalid request body", Content: JSON(Ref("Error"))},
+				"412": {Description: "The message changed since the If-Match ETag", Content: JSON(Ref("Error"))},
+			},
+		}, a.putGuestbook)
+
+		r.handle(http.MethodGet, "/webhooks", Operation{
+			Summary:     "List webhook endpoints",
+			OperationID: "listWebhooks",
+			Tags:        []string{"webhooks"},
+			Security:    userAuth,
+			Parameters:  listParameters(webhooksResource),
+			Responses: map[string]Response{
+				"200": {Description: "Registered endpoints", Content: JSON(Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/Webhook"}})},
+				"400": {Description: "Invalid filter, sort or fields parameter", Content: JSON(Ref("Error"))},
+			},
+		}, a.listWebhooks)
+
+		r.handle(http.MethodPost, "/webhooks", Operation{
+			Summary:     "Register a webhook endpoint",
+			OperationID: "createWebhook",
+			Tags:        []string{"webhooks"},
+			Security:    userAuth,
+			RequestBody: &RequestBody{Required: true, Content: JSON(Ref("NewWebhook"))},
+			Responses: map[string]Response{
+				"201": {Description: "The new endpoint, including its signing secret", Content: JSON(Ref("Webhook"))},
+				"400": {Description: "Invalid request body", Content: JSON(Ref("Error"))},
+			},
+		}, a.createWebhook)
+
+		r.handle(http.MethodDelete, "/webhooks/{id}", Operation{
+			Summary:     "Delete a webhook endpoint",
+			OperationID: "deleteWebhook",
+			Tags:        []string{"webhooks"},
+			Security:    userAuth,
+			Parameters:  []Parameter{idParam},
+			Responses: map[string]Response{
+				"204": {Description: "Deleted"},
+				"404": {Description: "No such endpoint", Content: JSON(Ref("Error"))},
+			},
+		}, a.deleteWebhook)
+
+		r.handle(http.MethodGet, "/webhooks/{id}/deliveries", Operation{
+			Summary:     "List recent deliveries for an endpoint",
+			OperationID: "listWebhookDeliveries",
+			Tags:        []string{"webhooks"},
+			Security:    userAuth,
+			Parameters:  append([]Parameter{idParam}, listParameters(deliveriesResource)...),
+			Responses: map[string]Response{
+				"200": {
+					Description: "Up to 50 deliveries, most recent first unless sorted otherwise; use page[offset] for the next 50",
+					Headers:     totalCountHeaders,
+					Content:     JSON(Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/WebhookDelivery"}}),
+				},
+				"400": {Description: "Invalid filter, sort or fields parameter", Content: JSON(Ref("Error"))},
+				"404": {Description: "No such endpoint", Content: JSON(Ref("Error"))},
+			},
+		}, a.listWebhookDeliveries)
+
+		r.handle(http.MethodPost, "/hooks", Operation{
+			Summary:     "Subscribe a REST hook",
+			Description: "For Zapier, Make and similar tools. The subscription is a webhook for one event; a 410 Gone from the target unsubscribes it.",
+			OperationID: "subscribeHook",
+			Tags:        []string{"integrations"},
+			Security:    userAuth,
+			RequestBody: &RequestBody{Required: true, Content: JSON(Ref("NewRestHook"))},
+			Responses: map[string]Response{
+				"201": {Description: "The subscription; pass its id to unsubscribe", Content: JSON(Ref("RestHook"))},
+				"400": {Description: "Invalid request body", Content: JSON(Ref("Error"))},
+			},
+		}, a.subscribeHook)
+
+		r.handle(http.MethodDelete, "/hooks/{id}", Operation{
+			Summary:     "Unsubscribe a REST hook",
+			Description: "Only subscriptions made with POST /hooks can be removed here; other webhooks are not found.",
+			OperationID: "unsubscribeHook",
+			Tags:        []string{"integrations"},
+			Security:    userAuth,
+			Parameters:  []Parameter{idParam},
+			Responses: map[string]Response{
+				"204": {Description: "Unsubscribed"},
+				"404": {Description: "No such subscription", Content: JSON(Ref("Error"))},
+			},
+		}, a.unsubscribeHook)
+
+		r.handle(http.MethodGet, "/triggers/{event}", Operation{
+			Summary:     "List recent events for a polling trigger",
+			Description: "The latest 50 events of one type, newest first, shaped like webhook deliveries.",
+			OperationID: "listTriggerEvents",
+			Tags:        []string{"integrations"},
+			Security:    userAuth,
+			Parameters:  []Parameter{eventParam},
+			Responses: map[string]Response{
+				"200": {Description: "Recent events", Content: JSON(Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/Event"}})},
+				"404": {Description: "Unknown event type", Content: JSON(Ref("Error"))},
+			},
+		}, a.listTriggerEvents)
+
+		r.handle(http.MethodPost, "/uploads", Operation{
+			Summary:     "Start a resumable upload",
+			Description: "Send the file with PATCH requests afterwards. Unfinished uploads expire after 24 hours; finished ones are scanned before they can be downloaded.",
+			OperationID: "createUpload",
+			Tags:        []string{"uploads"},
+			Security:    userAuth,
+			RequestBody: &RequestBody{Required: true, Content: JSON(Ref("NewUpload"))},
+			Responses: map[string]Response{
+				"201": {Description: "The new upload; Location points at it", Content: JSON(Ref("Upload"))},
+				"400": {Description: "Invalid request body", Content: JSON(Ref("Error"))},
+				"413": {Description: "The file is larger than allowed, or than the storage quota has room for", Content: JSON(Ref("Error"))},
+			},
+		}, a.createUpload)
+
+		r.handle(http.MethodGet, "/uploads/{id}", Operation{
+			Summary:     "Get an upload's progress",
+			OperationID: "getUpload",
+			Tags:        []string{"uploads"},
+			Security:    userAuth,
+			Parameters:  []Parameter{uploadIDParam},
+			Responses: map[string]Response{
+				"200": {Description: "The upload; offset is where the next chunk starts, and url is set once the file has been scanned and found clean", Content: JSON(Ref("Upload"))},
+				"404": {Description: "No such upload, or it expired", Content: JSON(Ref("Error"))},
+			},
+		}, a.getUpload)
+
+		r.handle(http.MethodPatch, "/uploads/{id}", Operation{
+			Summary:     "Append a chunk to an upload",
+			Description: "The upload completes when its last byte arrives. If a chunk is cut off, what arrived is kept; resume from the returned Upload-Offset.",
+			OperationID: "appendUpload",
+			Tags:        []string{"uploads"},
+			Security:    userAuth,
+			Parameters:  []Parameter{uploadIDParam, uploadOffsetParam},
+			RequestBody: &RequestBody{Required: true, Content: map[string]MediaType{ChunkContentType: {Schema: Schema{Type: "string", Format: "binary"}}}},
+			Responses: map[string]Response{
+				"200": {Description: "The upload after this chunk", Content: JSON(Ref("Upload"))},
+				"400": {Description: "The chunk was cut off", Content: JSON(Ref("Error"))},
+				"404": {Description: "No such upload, or it expired", Content: JSON(Ref("Error"))},
+				"409": {Description: "Upload-Offset doesn't match, the upload is complete, or another chunk is being written", Content: JSON(Ref("Error"))},
+				"415": {Description: "The chunk isn't " + ChunkContentType, Content: JSON(Ref("Error"))},
+			},
+		}, a.appendUpload)
+
+		r.handle(http.MethodDelete, "/uploads/{id}", Operation{
+			Summary:     "Cancel or delete an upload",
+			OperationID: "deleteUpload",
+			Tags:        []string{"uploads"},
+			Security:    userAuth,
+			Parameters:  []Parameter{uploadIDParam},
+			Responses: map[string]Response{
+				"204": {Description: "Deleted"},
+				"404": {Description: "No such upload", Content: JSON(Ref("Error"))},
+			},
+		}, a.deleteUpload)
+	})
+
+	return r
+}
+
+type userResponse struct {
+	ID        int64  `json:"id"`
+	Email     string `json:"email"`
+	Verified  bool   `json:"verified"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+func (a *API) getMe(w http.ResponseWriter, r *http.Request) {
+	user, err := a.Queries.GetUser(r.Context(), userID(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSONCached(w, r, "", userResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Verified:  user.VerifiedAt.Valid,
+		CreatedAt: formatTime(user.CreatedAt),
+	})
+}
+
+type guestbookBody struct {
+	Message string `json:"message"`
+}
+
+// guestbook returns the current message. Before anything is written the
+// default message is reported as version 0.
+func (a *API) guestbook(r *http.Request) (db.Guestbook, error) {
+	gb, err := a.Queries.GetGuestbook(r.Context())
+	if err == sql.ErrNoRows {
+		return db.Guestbook{ID: 1, Message: "Hello! Welcome to the guestbook."}, nil
+	}
+	return gb, err
+}
+
+func (a *API) getGuestbook(w http.ResponseWriter, r *http.Request) {
+	gb, err := a.guestbook(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	etag := versionETag("guestbook", gb.Version)
+	if a.Bans.Shadowed(r.Context(), userID(r)) {
+		// The version says nothing about their own message
+		if msg, err := a.Queries.GetShadowMessage(r.Context(), userID(r)); err == nil {
+			gb.Message, etag = msg, ""
+		}
+	}
+	writeJSONCached(w, r, etag, guestbookBody{Message: gb.Message})
+}
+
+// putGuestbook replaces the message. With If-Match the write only happens
+// if nobody changed the message since the client read it.
+func (a *API) putGuestbook(w http.ResponseWriter, r *http.Request) {
+	var body guestbookBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Message == "" {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	// A shadow-banned user's message is only shown back to them, so it
+	// looks like it went through
+	if a.Bans.Shadowed(r.Context(), userID(r)) {
+		if err := a.Queries.UpsertShadowMessage(r.Context(), db.UpsertShadowMessageParams{UserID: userID(r), Message: body.Message}); err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if gb, err := a.guestbook(r); err == nil {
+			w.Header().Set("ETag", versionETag("guestbook", gb.Version))
+		}
+		writeJSON(w, http.StatusOK, body)
+		return
+	}
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		var updated int64
+		version, ok := parseVersionETag("guestbook", ifMatch)
+		if ok {
+			var err error
+			updated, err = a.Queries.UpdateMessageIfVersion(r.Context(), db.UpdateMessageIfVersionParams{Message: body.Message, Version: version})
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "Database error")
+				return
+			}
+		}
+		if updated == 0 {
+			if gb, err := a.guestbook(r); err == nil {
+				w.Header().Set("ETag", versionETag("guestbook", gb.Version))
+			}
+			writeError(w, http.StatusPreconditionFailed, "The guestbook changed since it was read")
+			return
+		}
+	} else if err := a.Queries.UpsertMessage(r.Context(), body.Message); err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if err := a.Webhooks.Publish(r.Context(), 0, webhooks.GuestbookUpdated, body); err != nil {
+		log.Printf("Error publishing event: %v", err)
+	}
+	gb, err := a.guestbook(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	w.Header().Set("ETag", versionETag("guestbook", gb.Version))
+	writeJSON(w, http.StatusOK, guestbookBody{Message: gb.Message})
+}
+
+type webhookResponse struct {
+	ID        int64    `json:"id"`
+	Url       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	Events    []string `json:"events"`
+	CreatedAt string   `json:"created_at,omitempty"`
+}
+
+func newWebhookResponse(hook db.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:        hook.ID,
+		Url:       hook.Url,
+		Secret:    hook.Secret,
+		Events:    strings.Split(hook.Events, ","),
+		CreatedAt: formatTime(hook.CreatedAt),
+	}
+}
+
+func (a *API) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r.URL.Query(), webhooksResource)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	hooks, err := a.Queries.FilterWebhooks(r.Context(), userID(r), params.Where, params.Order, params.Offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	resp := make([]webhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		resp = append(resp, newWebhookResponse(hook))
+	}
+	writeSparse(w, r, resp, params.Fields)
+}
+
+func (a *API) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Url    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if err := webhooks.ValidateURL(body.Url); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := webhooks.ValidateEvents(body.Events); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hook, err := a.Queries.CreateWebhook(r.Context(), db.CreateWebhookParams{
+		UserID: userID(r),
+		Url:    body.Url,
+		Secret: webhooks.NewSecret(),
+		Events: strings.Join(body.Events, ","),
+		Source: webhooks.SourceAPI,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusCreated, newWebhookResponse(hook))
+}
+
+// userWebhook loads the webhook in the URL if it belongs to the caller.
+func (a *API) userWebhook(w http.ResponseWriter, r *http.Request) (db.Webhook, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Not found")
+		return db.Webhook{}, false
+	}
+	hook, err := a.Queries.GetWebhook(r.Context(), db.GetWebhookParams{
+		ID:     id,
+		UserID: userID(r),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Not found")
+		} else {
+			writeError(w, http.StatusInternalServerError, "Database error")
+		}
+		return db.Webhook{}, false
+	}
+	return hook, true
+}
+
+func (a *API) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := a.userWebhook(w, r)
+	if !ok {
+		return
+	}
+	if err := a.Queries.DeleteWebhook(r.Context(), db.DeleteWebhookParams{ID: hook.ID, UserID: hook.UserID}); err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type deliveryResponse struct {
+	ID           int64  `json:"id"`
+	EventID      int64  `json:"event_id"`
+	EventType    string `json:"event_type"`
+	Status       string `json:"status"`
+	Attempts     int64  `json:"attempts"`
+	ResponseCode *int64 `json:"response_code,omitempty"`
+	Error        string `json:"error,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+}
+
+func (a *API) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	hook, ok := a.userWebhook(w, r)
+	if !ok {
+		return
+	}
+	params, err := parseListParams(r.URL.Query(), deliveriesResource)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	deliveries, err := a.Queries.FilterWebhookDeliveries(r.Context(), hook.ID, params.Where, params.Order, 50, params.Offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	total, err := a.Queries.CountWebhookDeliveries(r.Context(), hook.ID, params.Where)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	setTotalCount(w, total)
+	resp := make([]deliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		item := deliveryResponse{
+			ID:        d.ID,
+			EventID:   d.EventID,
+			EventType: d.EventType,
+			Status:    d.Status,
+			Attempts:  d.Attempts,
+			Error:     d.Error.String,
+			CreatedAt: formatTime(d.CreatedAt),
+		}
+		if d.ResponseCode.Valid {
+			item.ResponseCode = &d.ResponseCode.Int64
+		}
+		resp = append(resp, item)
+	}
+	writeSparse(w, r, resp, params.Fields)
+}
+
+// totalCountHeaders document setTotalCount in the spec.
+var totalCountHeaders = map[string]Header{
+	"X-Total-Count": {
+		Description: "How many items match without the page limit, counted up to 10000",
+		Schema:      Schema{Type: "integer"},
+	},
+	"X-Total-Count-Exact": {
+		Description: "false when counting stopped and X-Total-Count is only a lower bound",
+		Schema:      Schema{Type: "boolean"},
+	},
+}
+
+// setTotalCount reports how many items a list has beyond the page it
+// returned. Large lists aren't counted to the end.
+func setTotalCount(w http.ResponseWriter, c db.Count) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(c.N, 10))
+	w.Header().Set("X-Total-Count-Exact", strconv.FormatBool(c.Exact))
+}
+
+func formatTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+func writeSparse[T any](w http.ResponseWriter, r *http.Request, items []T, fields map[string]bool) {
+	resp, err := sparse(items, fields)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error encoding response")
+		return
+	}
+	writeJSONCached(w, r, "", resp)
+}
+
+// readJSON decodes the request body into v. Bodies over the size limit get
+// a 413 and ones the client was too slow to send a 408.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	switch status := utils.BodyErrorStatus(err); status {
+	case http.StatusRequestEntityTooLarge:
+		writeError(w, status, "Request body too large")
+	case http.StatusRequestTimeout:
+		writeError(w, status, "Timed out reading request body")
+	default:
+		writeError(w, status, "Invalid request body")
+	}
+	return false
+}
+
+// writeError writes an error body. It includes the request ID set by the
+// RequestID middleware so clients can quote it when reporting a problem.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	body := map[string]string{"error": msg}
+	if id := w.Header().Get(utils.RequestIDHeader); id != "" {
+		body["request_id"] = id
+	}
+	writeJSON(w, status, body)
+}
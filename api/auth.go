@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/bans"
+	"gighub/ratelimit"
+	"gighub/report"
+	"gighub/utils"
+)
+
+// Token scopes. Read tokens may only call GET endpoints.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+type ctxKey int
+
+const principalKey ctxKey = 0
+
+// principal is whoever is calling the API: a browser session or a token.
+type principal struct {
+	UserID int64
+	// Scope is the token scope, or "session" for cookie-authenticated calls.
+	Scope   string
+	TokenID int64
+}
+
+// NewToken returns a fresh API token and the hash that is stored for it.
+func NewToken() (token, hash string) {
+	b := make([]byte, 20)
+	rand.Read(b)
+	token = "gig_" + hex.EncodeToString(b)
+	return token, HashToken(token)
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticate resolves a bearer token or the session cookie into a
+// principal. A bearer token that doesn't match is rejected outright rather
+// than falling back to anonymous access.
+func (a *API) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p *principal
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			t, err := a.Queries.GetAPITokenByHash(r.Context(), HashToken(strings.TrimSpace(token)))
+			if err != nil {
+				if err == sql.ErrNoRows {
+					writeError(w, http.StatusUnauthorized, "Invalid token")
+				} else {
+					writeError(w, http.StatusInternalServerError, "Database error")
+				}
+				return
+			}
+			if err := a.Queries.TouchAPIToken(r.Context(), t.ID); err != nil {
+				log.Printf("Error updating token usage: %v", err)
+			}
+			p = &principal{UserID: t.UserID, Scope: t.Scope, TokenID: t.ID}
+		} else if a.Sessions.Exists(r.Context(), "userID") {
+			p = &principal{UserID: a.Sessions.GetInt64(r.Context(), "userID"), Scope: "session"}
+		}
+		if p != nil {
+			report.SetUser(r.Context(), p.UserID)
+			r = r.WithContext(context.WithValue(r.Context(), principalKey, p))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *API) requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := r.Context().Value(principalKey).(*principal)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "Not authenticated")
+			return
+		}
+		_, banned, err := a.Bans.Active(r.Context(), p.UserID, bans.Hard)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if banned {
+			writeError(w, http.StatusForbidden, "This account is banned")
+			return
+		}
+		if p.Scope == ScopeRead && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, http.StatusForbidden, "Token does not have the write scope")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// userID returns the authenticated user. Only valid behind requireUser.
+func userID(r *http.Request) int64 {
+	return r.Context().Value(principalKey).(*principal).UserID
+}
+
+// rateLimit counts every request against the client IP, and authenticated
+// requests also against their token or user, using the quota for the
+// token's scope. The tighter of the two decides, and its state is reported
+// in the X-RateLimit-* headers.
+func (a *API) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ipLimit, _ := a.Limits.Get("ip")
+		res := a.Limiter.Allow("ip:"+utils.ClientIP(r), ipLimit)
+
+		if p, ok := r.Context().Value(principalKey).(*principal); ok {
+			key := "user:" + strconv.FormatInt(p.UserID, 10)
+			if p.TokenID != 0 {
+				key = "token:" + strconv.FormatInt(p.TokenID, 10)
+			}
+			if limit, ok := a.Limits.Get(p.Scope); ok {
+				if pres := a.Limiter.Allow(key, limit); !pres.Allowed || res.Allowed {
+					res = pres
+				}
+			}
+		}
+
+		h := w.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(res.Reset.Unix(), 10))
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(int(res.RetryAfter/time.Second)+1))
+			writeError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DefaultLimits are the per-minute quotas used when none are configured.
+var DefaultLimits = map[string]ratelimit.Limit{
+	"ip":       {PerMinute: 120, Burst: 120},
+	"session":  {PerMinute: 120, Burst: 120},
+	ScopeRead:  {PerMinute: 120, Burst: 120},
+	ScopeWrite: {PerMinute: 60, Burst: 60},
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gighub/utils"
+)
+
+// versionETag is the strong ETag for a row with a version counter.
+func versionETag(kind string, version int64) string {
+	return fmt.Sprintf(`"%s-%d"`, kind, version)
+}
+
+// parseVersionETag extracts the version from an ETag made by versionETag.
+func parseVersionETag(kind, etag string) (int64, bool) {
+	s, ok := strings.CutPrefix(strings.Trim(etag, `"`), kind+"-")
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	return v, err == nil
+}
+
+// writeJSONCached writes v with an ETag, or just 304 Not Modified when the
+// client's If-None-Match already has it. Without an explicit etag a weak
+// one is derived from the encoded body.
+func writeJSONCached(w http.ResponseWriter, r *http.Request, etag string, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error encoding response")
+		return
+	}
+	if etag == "" {
+		sum := sha256.Sum256(buf.Bytes())
+		etag = `W/"` + hex.EncodeToString(sum[:8]) + `"`
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if utils.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf.Bytes())
+}
+
+var ifNoneMatchParam = Parameter{
+	Name:        "If-None-Match",
+	In:          "header",
+	Description: "ETag from a previous response; returns 304 if unchanged.",
+	Schema:      &Schema{Type: "string"},
+}
+
+var ifMatchParam = Parameter{
+	Name:        "If-Match",
+	In:          "header",
+	Description: "ETag the update is based on; returns 412 if the resource changed since.",
+	Schema:      &Schema{Type: "string"},
+}
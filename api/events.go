@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"gighub/db"
+)
+
+// streamEvents sends the caller's events as Server-Sent Events. Clients
+// that reconnect with Last-Event-ID get everything they missed first; new
+// connections start from the latest event.
+func (a *API) streamEvents(w http.ResponseWriter, r *http.Request) {
+	uid := sql.NullInt64{Int64: userID(r), Valid: true}
+
+	lastID, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil {
+		if lastID, err = a.Queries.LatestEventID(r.Context()); err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+	}
+
+	list := func(ctx context.Context, after int64) ([]db.Event, error) {
+		return a.Queries.ListEventsForUserSince(ctx, db.ListEventsForUserSinceParams{ID: after, UserID: uid})
+	}
+	a.Webhooks.Stream(w, r, lastID, a.Stopping, list, func(w io.Writer, e db.Event) {
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Payload)
+	})
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"gighub/db"
+	"gighub/webhooks"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// REST hooks are how Zapier and Make subscribe: turning a zap on
+// subscribes a target URL to one event, and turning it off unsubscribes
+// it. Subscriptions are ordinary webhooks, so they're signed and retried
+// the same way, and also show up under /webhooks. Unsubscribing only ever
+// removes subscriptions, never webhooks the user registered themselves.
+
+var eventParam = Parameter{Name: "event", In: "path", Required: true, Schema: &Schema{Type: "string", Enum: webhooks.EventTypes}}
+
+type restHookResponse struct {
+	ID        int64  `json:"id"`
+	TargetURL string `json:"target_url"`
+	Event     string `json:"event"`
+	Secret    string `json:"secret"`
+}
+
+func (a *API) subscribeHook(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		TargetURL string `json:"target_url"`
+		Event     string `json:"event"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if err := webhooks.ValidateURL(body.TargetURL); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !slices.Contains(webhooks.EventTypes, body.Event) {
+		writeError(w, http.StatusBadRequest, "Unknown event type: "+body.Event)
+		return
+	}
+	hook, err := a.Queries.CreateWebhook(r.Context(), db.CreateWebhookParams{
+		UserID: userID(r),
+		Url:    body.TargetURL,
+		Secret: webhooks.NewSecret(),
+		Events: body.Event,
+		Source: webhooks.SourceRestHook,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusCreated, restHookResponse{
+		ID:        hook.ID,
+		TargetURL: hook.Url,
+		Event:     hook.Events,
+		Secret:    hook.Secret,
+	})
+}
+
+// unsubscribeHook deletes a subscription made through subscribeHook.
+// Webhooks registered on the dashboard or through /webhooks aren't
+// subscriptions, so an integration can't remove them.
+func (a *API) unsubscribeHook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	deleted, err := a.Queries.DeleteRestHook(r.Context(), db.DeleteRestHookParams{ID: id, UserID: userID(r)})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if deleted == 0 {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listTriggerEvents is the polling counterpart of REST hooks: the latest
+// events of one type, newest first, in the shape webhooks deliver them.
+// Pollers tell new events from seen ones by id.
+func (a *API) listTriggerEvents(w http.ResponseWriter, r *http.Request) {
+	eventType := chi.URLParam(r, "event")
+	if !slices.Contains(webhooks.EventTypes, eventType) {
+		writeError(w, http.StatusNotFound, "Unknown event type: "+eventType)
+		return
+	}
+	events, err := a.Queries.ListRecentEventsForUser(r.Context(), db.ListRecentEventsForUserParams{
+		Type:   eventType,
+		UserID: sql.NullInt64{Int64: userID(r), Valid: true},
+		Limit:  50,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	resp := make([]webhooks.Envelope, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, webhooks.NewEnvelope(e.ID, e.Type, e.CreatedAt, e.Payload))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gighub/db"
+)
+
+// idempotencyTTL is how long a stored response is replayed for a key.
+const idempotencyTTL = 24 * time.Hour
+
+// recorder captures a response so it can be stored for replay.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotent makes POST requests carrying an Idempotency-Key header safe to
+// retry: the first response for a key is stored and replayed for later
+// requests with the same key and body. Reusing a key with a different body
+// is rejected, as is a retry that races the original request. Server errors
+// aren't stored, so the client can retry them for real.
+func (a *API) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > 255 {
+			writeError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		hash := hex.EncodeToString(sum[:])
+		uid := userID(r)
+
+		created, err := a.Queries.CreateIdempotencyKey(r.Context(), db.CreateIdempotencyKeyParams{
+			UserID:      uid,
+			Key:         key,
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			RequestHash: hash,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		if created == 0 {
+			stored, err := a.Queries.GetIdempotencyKey(r.Context(), db.GetIdempotencyKeyParams{UserID: uid, Key: key})
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "Database error")
+				return
+			}
+			switch {
+			case stored.RequestHash != hash:
+				writeError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case !stored.StatusCode.Valid:
+				writeError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(int(stored.StatusCode.Int64))
+				w.Write(stored.ResponseBody)
+			}
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// Store the outcome even if the client went away mid-request.
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= 500 || rec.status == 0 {
+			if err := a.Queries.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{UserID: uid, Key: key}); err != nil {
+				log.Printf("Error releasing idempotency key: %v", err)
+			}
+			return
+		}
+		if err := a.Queries.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+			StatusCode:   sql.NullInt64{Int64: int64(rec.status), Valid: true},
+			ResponseBody: rec.body.Bytes(),
+			UserID:       uid,
+			Key:          key,
+		}); err != nil {
+			log.Printf("Error storing idempotent response: %v", err)
+		}
+	})
+}
+
+// PruneIdempotencyKeys deletes stored responses older than a day.
+func (a *API) PruneIdempotencyKeys(ctx context.Context) error {
+	return a.Queries.DeleteIdempotencyKeysBefore(ctx, sql.NullTime{Time: time.Now().Add(-idempotencyTTL).UTC(), Valid: true})
+}
+
+var idempotencyKeyParam = Parameter{
+	Name:        "Idempotency-Key",
+	In:          "header",
+	Description: "Unique key that makes retries of this request safe. Responses are replayed for " + strconv.Itoa(int(idempotencyTTL.Hours())) + " hours.",
+	Schema:      &Schema{Type: "string"},
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gighub/db"
+)
+
+func TestIdempotent(t *testing.T) {
+	ctx := context.Background()
+	conn, queries, err := db.Setup(t.TempDir(), "test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var users [2]int64
+	for i := range users {
+		u, err := queries.CreateUser(ctx, db.CreateUserParams{Email: fmt.Sprintf("%d@example.com", i), PasswordHash: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[i] = u.ID
+	}
+	// A request for the same key that hasn't finished yet
+	sum := sha256.Sum256([]byte("POST /webhooks\n201"))
+	if _, err := queries.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+		UserID: users[0], Key: "running", Method: http.MethodPost, Path: "/webhooks", RequestHash: hex.EncodeToString(sum[:]),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The handler answers with the status in the request body, and counts
+	// how often it ran
+	calls := 0
+	a := &API{Queries: queries}
+	h := a.idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var status int
+		fmt.Fscan(r.Body, &status)
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	}))
+
+	for _, tt := range []struct {
+		name     string
+		user     int
+		method   string
+		path     string
+		key      string
+		body     string
+		status   int
+		replayed bool
+		want     string
+	}{
+		{"first", 0, http.MethodPost, "/webhooks", "a", "201", 201, false, `{"call":1}`},
+		{"retry", 0, http.MethodPost, "/webhooks", "a", "201", 201, true, `{"call":1}`},
+		{"retry again", 0, http.MethodPost, "/webhooks", "a", "201", 201, true, `{"call":1}`},
+		{"different body", 0, http.MethodPost, "/webhooks", "a", "200", 422, false, ""},
+		{"different path", 0, http.MethodPost, "/tokens", "a", "201", 422, false, ""},
+		{"another user", 1, http.MethodPost, "/webhooks", "a", "201", 201, false, `{"call":2}`},
+		{"no key", 0, http.MethodPost, "/webhooks", "", "201", 201, false, `{"call":3}`},
+		{"not a POST", 0, http.MethodPut, "/webhooks", "a", "200", 200, false, `{"call":4}`},
+		{"client error is kept", 0, http.MethodPost, "/webhooks", "b", "400", 400, false, `{"call":5}`},
+		{"client error replayed", 0, http.MethodPost, "/webhooks", "b", "400", 400, true, `{"call":5}`},
+		{"server error", 0, http.MethodPost, "/webhooks", "c", "500", 500, false, `{"call":6}`},
+		{"server error retried for real", 0, http.MethodPost, "/webhooks", "c", "500", 500, false, `{"call":7}`},
+		{"in progress", 0, http.MethodPost, "/webhooks", "running", "201", 409, false, ""},
+		{"long key", 0, http.MethodPost, "/webhooks", strings.Repeat("k", 256), "201", 400, false, ""},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req = req.WithContext(context.WithValue(req.Context(), principalKey, &principal{UserID: users[tt.user], Scope: "write"}))
+		if tt.key != "" {
+			req.Header.Set("Idempotency-Key", tt.key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.replayed {
+			t.Errorf("%s: replayed %v, want %v", tt.name, replayed, tt.replayed)
+		}
+		if tt.want != "" && w.Body.String() != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, w.Body, tt.want)
+		}
+	}
+	if calls != 7 {
+		t.Errorf("handler ran %d times, want 7", calls)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		write  func(http.ResponseWriter)
+		status int
+		body   string
+	}{
+		{"nothing", func(http.ResponseWriter) {}, 0, ""},
+		{"implicit 200", func(w http.ResponseWriter) { w.Write([]byte("ok")) }, 200, "ok"},
+		{"status only", func(w http.ResponseWriter) { w.WriteHeader(204) }, 204, ""},
+		{"status and body", func(w http.ResponseWriter) {
+			w.WriteHeader(201)
+			w.Write([]byte("a"))
+			w.Write([]byte("b"))
+		}, 201, "ab"},
+	} {
+		w := httptest.NewRecorder()
+		rec := &recorder{ResponseWriter: w}
+		tt.write(rec)
+		if rec.status != tt.status || rec.body.String() != tt.body {
+			t.Errorf("%s: recorded %d %q, want %d %q", tt.name, rec.status, rec.body.String(), tt.status, tt.body)
+		}
+		// The client still gets the response as written
+		if w.Body.String() != tt.body || (tt.status != 0 && w.Code != tt.status) {
+			t.Errorf("%s: sent %d %q", tt.name, w.Code, w.Body)
+		}
+	}
+}
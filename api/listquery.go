@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gighub/db"
+)
+
+// resource describes a list endpoint for JSON:API-style query parameters:
+//
+//	filter[status]=failed,pending  sort=-created_at,id  fields[deliveries]=status,attempts  page[offset]=50
+//
+// Only attributes listed in columns can be filtered or sorted on, which is
+// what keeps user input out of the SQL text.
+type resource struct {
+	// Type is the name used in fields[...].
+	Type string
+	// Columns maps filterable and sortable attributes to SQL columns.
+	Columns map[string]string
+	// Attributes lists every field the response objects carry.
+	Attributes []string
+	// DefaultSort applies when no sort parameter is given.
+	DefaultSort []db.Order
+}
+
+type listParams struct {
+	Where  []db.Condition
+	Order  []db.Order
+	Fields map[string]bool
+	// Offset is how many items to skip, for the pages after the first.
+	Offset int64
+}
+
+func parseListParams(q url.Values, res resource) (listParams, error) {
+	var p listParams
+	for key, values := range q {
+		switch {
+		case strings.HasPrefix(key, "filter[") && strings.HasSuffix(key, "]"):
+			attr := key[len("filter[") : len(key)-1]
+			col, ok := res.Columns[attr]
+			if !ok {
+				return p, fmt.Errorf("cannot filter by %q", attr)
+			}
+			cond := db.Condition{Column: col}
+			for _, v := range values {
+				for _, part := range strings.Split(v, ",") {
+					cond.Values = append(cond.Values, part)
+				}
+			}
+			p.Where = append(p.Where, cond)
+
+		case key == "sort":
+			for _, attr := range strings.Split(q.Get("sort"), ",") {
+				desc := strings.HasPrefix(attr, "-")
+				attr = strings.TrimPrefix(attr, "-")
+				col, ok := res.Columns[attr]
+				if !ok {
+					return p, fmt.Errorf("cannot sort by %q", attr)
+				}
+				p.Order = append(p.Order, db.Order{Column: col, Desc: desc})
+			}
+
+		case key == "page[offset]":
+			n, err := strconv.ParseInt(q.Get(key), 10, 64)
+			if err != nil || n < 0 {
+				return p, fmt.Errorf("page[offset] must be a number of items to skip")
+			}
+			p.Offset = n
+
+		case key == "fields["+res.Type+"]":
+			p.Fields = map[string]bool{"id": true}
+			for _, attr := range strings.Split(q.Get(key), ",") {
+				if !slices.Contains(res.Attributes, attr) {
+					return p, fmt.Errorf("unknown field %q", attr)
+				}
+				p.Fields[attr] = true
+			}
+		}
+	}
+	if p.Order == nil {
+		p.Order = res.DefaultSort
+	}
+	return p, nil
+}
+
+// sparse drops every attribute not requested in fields[...]. With no
+// fieldset the items are returned unchanged.
+func sparse[T any](items []T, fields map[string]bool) (any, error) {
+	if fields == nil {
+		return items, nil
+	}
+	out := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+		for k := range m {
+			if !fields[k] {
+				delete(m, k)
+			}
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// listParameters documents the query parameters for a resource in the spec.
+func listParameters(res resource) []Parameter {
+	return []Parameter{
+		{Name: "filter", In: "query", Style: "deepObject", Explode: true, Description: "Filter by attribute, e.g. filter[status]=failed. Comma-separate values to match any of them.", Schema: &Schema{Type: "object"}},
+		{Name: "sort", In: "query", Description: "Comma-separated attributes to sort by; prefix with - for descending.", Schema: &Schema{Type: "string"}},
+		{Name: "fields[" + res.Type + "]", In: "query", Description: "Comma-separated attributes to include in each object.", Schema: &Schema{Type: "string"}},
+		{Name: "page[offset]", In: "query", Description: "How many items to skip, to get the next page.", Schema: &Schema{Type: "integer"}},
+	}
+}
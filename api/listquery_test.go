@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"gighub/db"
+)
+
+func TestParseListParams(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		want  listParams
+	}{
+		{"", listParams{Order: deliveriesResource.DefaultSort}},
+		{"filter[status]=failed", listParams{
+			Where: []db.Condition{{Column: "webhook_deliveries.status", Values: []any{"failed"}}},
+			Order: deliveriesResource.DefaultSort,
+		}},
+		{"filter[status]=failed,pending&filter[status]=succeeded", listParams{
+			Where: []db.Condition{{Column: "webhook_deliveries.status", Values: []any{"failed", "pending", "succeeded"}}},
+			Order: deliveriesResource.DefaultSort,
+		}},
+		{"sort=-created_at,id", listParams{Order: []db.Order{
+			{Column: "webhook_deliveries.created_at", Desc: true},
+			{Column: "webhook_deliveries.id"},
+		}}},
+		{"fields[deliveries]=status,attempts", listParams{
+			Order:  deliveriesResource.DefaultSort,
+			Fields: map[string]bool{"id": true, "status": true, "attempts": true},
+		}},
+		{"page[offset]=50", listParams{Order: deliveriesResource.DefaultSort, Offset: 50}},
+		// Other resources' fieldsets and unknown parameters are left alone
+		{"fields[webhooks]=url&limit=5&filter[status=failed", listParams{Order: deliveriesResource.DefaultSort}},
+	} {
+		q, _ := url.ParseQuery(tt.query)
+		got, err := parseListParams(q, deliveriesResource)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseListParamsRefuses(t *testing.T) {
+	for _, tt := range []struct{ key, value string }{
+		// Only allow-listed columns, so nothing reaches the SQL text
+		{"filter[url]", "x"},
+		{"filter[webhook_deliveries.status]", "failed"},
+		{"filter[status) OR 1=1 --]", "x"},
+		{"filter[]", "x"},
+		{"sort", "url"},
+		{"sort", "status; DROP TABLE users"},
+		{"sort", ""},
+		{"sort", "id,"},
+		{"sort", "--id"},
+		{"fields[deliveries]", "secret"},
+		{"fields[deliveries]", ""},
+		{"fields[deliveries]", "status,"},
+		{"page[offset]", "-1"},
+		{"page[offset]", "x"},
+		{"page[offset]", ""},
+	} {
+		q := url.Values{tt.key: {tt.value}}
+		if p, err := parseListParams(q, deliveriesResource); err == nil {
+			t.Errorf("%s=%s: got %+v, want an error", tt.key, tt.value, p)
+		}
+	}
+}
+
+func TestSparse(t *testing.T) {
+	type item struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	items := []item{{1, "failed", "timeout"}, {2, "succeeded", ""}}
+	for _, tt := range []struct {
+		name   string
+		fields map[string]bool
+		want   string
+	}{
+		{"no fieldset", nil, `[{"id":1,"status":"failed","error":"timeout"},{"id":2,"status":"succeeded","error":""}]`},
+		{"id only", map[string]bool{"id": true}, `[{"id":1},{"id":2}]`},
+		{"some", map[string]bool{"id": true, "status": true}, `[{"id":1,"status":"failed"},{"id":2,"status":"succeeded"}]`},
+	} {
+		out, err := sparse(items, tt.fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := json.Marshal(out)
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	// An empty list is still a list, not null
+	if out, _ := sparse([]item{}, map[string]bool{"id": true}); !reflect.DeepEqual(out, []map[string]json.RawMessage{}) {
+		t.Errorf("empty list: got %#v", out)
+	}
+}
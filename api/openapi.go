@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Spec is a minimal OpenAPI 3 document. Only the fields the API actually
+// uses are modelled; everything is built in code next to the handlers.
+type Spec struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers,omitempty"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas         map[string]Schema         `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+type Operation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     bool    `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string `json:"description,omitempty"`
+	Schema      Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref        string            `json:"$ref,omitempty"`
+	Type       string            `json:"type,omitempty"`
+	Format     string            `json:"format,omitempty"`
+	Properties map[string]Schema `json:"properties,omitempty"`
+	Items      *Schema           `json:"items,omitempty"`
+	Required   []string          `json:"required,omitempty"`
+	Enum       []string          `json:"enum,omitempty"`
+}
+
+// Ref returns a schema pointing at a named component schema.
+func Ref(name string) Schema {
+	return Schema{Ref: "#/components/schemas/" + name}
+}
+
+// JSON wraps a schema as an application/json body.
+func JSON(s Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+// router mounts handlers on a chi router and records each one in the spec,
+// so the document can't drift from the routes that are actually served.
+type router struct {
+	chi.Router
+	spec *Spec
+}
+
+func (r *router) handle(method, pattern string, op Operation, h http.HandlerFunc) {
+	r.Method(method, pattern, h)
+
+	if r.spec.Paths[pattern] == nil {
+		r.spec.Paths[pattern] = map[string]Operation{}
+	}
+	if _, ok := op.Responses["401"]; !ok && op.Security != nil {
+		op.Responses["401"] = Response{Description: "Not authenticated", Content: JSON(Ref("Error"))}
+	}
+	if _, ok := op.Responses["200"].Content["application/json"]; ok && method == http.MethodGet {
+		op.Parameters = append(op.Parameters, ifNoneMatchParam)
+		op.Responses["304"] = Response{Description: "Not modified since the If-None-Match ETag"}
+	}
+	if method == http.MethodPost {
+		op.Parameters = append(op.Parameters, idempotencyKeyParam)
+		op.Responses["409"] = Response{Description: "A request with the same Idempotency-Key is in progress", Content: JSON(Ref("Error"))}
+		op.Responses["422"] = Response{Description: "Idempotency-Key was reused with a different body", Content: JSON(Ref("Error"))}
+	}
+	if _, ok := op.Responses["429"]; !ok {
+		op.Responses["429"] = Response{Description: "Rate limit exceeded; see Retry-After", Content: JSON(Ref("Error"))}
+	}
+	r.spec.Paths[pattern][strings.ToLower(method)] = op
+}
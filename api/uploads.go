@@ -0,0 +1,354 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gighub/db"
+	"gighub/scan"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Resumable uploads let clients send a large file in chunks and pick up
+// where they left off after a dropped connection. A client creates the
+// upload with its total size, then PATCHes chunks, each starting at the
+// Upload-Offset the server has so far. Partial data is kept in UploadDir;
+// once the last byte arrives the file moves to Storage and is scanned (see
+// package scan). Only files found clean get a download URL.
+
+// uploadTTL is how long an unfinished upload is kept.
+const uploadTTL = 24 * time.Hour
+
+// ChunkContentType is the media type of PATCH bodies, as in tus.
+const ChunkContentType = "application/offset+octet-stream"
+
+type uploadResponse struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	Offset      int64  `json:"offset"`
+	Complete    bool   `json:"complete"`
+	ContentType string `json:"content_type,omitempty"`
+	Status      string `json:"status"`
+	URL         string `json:"url,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+}
+
+// uploadURLTTL is how long download links in responses work.
+const uploadURLTTL = time.Hour
+
+func (a *API) uploadResponse(u db.Upload) uploadResponse {
+	resp := uploadResponse{
+		ID:          u.ID,
+		Filename:    u.Filename,
+		Size:        u.Size,
+		Offset:      u.Received,
+		Complete:    u.CompletedAt.Valid,
+		ContentType: u.ContentType.String,
+		Status:      u.Status,
+	}
+	if !resp.Complete {
+		resp.ExpiresAt = u.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if u.Status == scan.Clean && u.StorageKey.Valid {
+		if url, err := a.Storage.URL(u.StorageKey.String, uploadURLTTL); err == nil {
+			resp.URL = url
+		}
+	}
+	return resp
+}
+
+func (a *API) partPath(id string) string {
+	return filepath.Join(a.UploadDir, id+".part")
+}
+
+// UploadKey is where a finished upload is kept in Storage.
+func UploadKey(u db.Upload) string {
+	return fmt.Sprintf("uploads/%d/%s", u.UserID, u.ID)
+}
+
+func (a *API) createUpload(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Filename string `json:"filename"`
+		Size     int64  `json:"size"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Size <= 0 {
+		writeError(w, http.StatusBadRequest, "size must be positive")
+		return
+	}
+	if body.Size > a.MaxResumableSize {
+		writeError(w, http.StatusRequestEntityTooLarge, "Files can be at most "+strconv.FormatInt(a.MaxResumableSize, 10)+" bytes")
+		return
+	}
+	quota, err := a.Quota(r.Context(), userID(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	// Unfinished uploads count in full. The usage is checked by the insert
+	// itself, so several at once can't overrun the quota.
+	b := make([]byte, 16)
+	rand.Read(b)
+	u, err := a.Queries.CreateUpload(r.Context(), db.CreateUploadParams{
+		ID:        hex.EncodeToString(b),
+		UserID:    userID(r),
+		Filename:  filepath.Base(body.Filename),
+		Size:      body.Size,
+		ExpiresAt: time.Now().Add(uploadTTL).UTC(),
+		Quota:     quota,
+	})
+	if err == sql.ErrNoRows {
+		used, _ := a.Queries.GetStorageUsage(r.Context(), userID(r))
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Not enough storage left: %d of %d bytes used", used, quota))
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	w.Header().Set("Location", "/api/v1/uploads/"+u.ID)
+	w.Header().Set("Upload-Offset", "0")
+	writeJSON(w, http.StatusCreated, a.uploadResponse(u))
+}
+
+// userUpload loads the upload in the URL if it belongs to the caller.
+func (a *API) userUpload(w http.ResponseWriter, r *http.Request) (db.Upload, bool) {
+	u, err := a.Queries.GetUpload(r.Context(), db.GetUploadParams{ID: chi.URLParam(r, "id"), UserID: userID(r)})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "Not found")
+		} else {
+			writeError(w, http.StatusInternalServerError, "Database error")
+		}
+		return db.Upload{}, false
+	}
+	return u, true
+}
+
+func (a *API) getUpload(w http.ResponseWriter, r *http.Request) {
+	u, ok := a.userUpload(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Received, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(u.Size, 10))
+	writeJSONCached(w, r, "", a.uploadResponse(u))
+}
+
+// appendUpload writes one chunk. Whatever arrives is kept even if the
+// connection drops halfway, and the response or a later GET tells the
+// client where to resume.
+func (a *API) appendUpload(w http.ResponseWriter, r *http.Request) {
+	u, ok := a.userUpload(w, r)
+	if !ok {
+		return
+	}
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != ChunkContentType {
+		writeError(w, http.StatusUnsupportedMediaType, "Chunks must be sent as "+ChunkContentType)
+		return
+	}
+	if !a.lockUpload(u.ID) {
+		writeError(w, http.StatusConflict, "Another chunk for this upload is being written")
+		return
+	}
+	defer a.unlockUpload(u.ID)
+
+	// Reload now that no other request can move the offset
+	u, ok = a.userUpload(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Received, 10))
+	if u.CompletedAt.Valid {
+		writeError(w, http.StatusConflict, "Upload is already complete")
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset != u.Received {
+		writeError(w, http.StatusConflict, "Upload-Offset must be "+strconv.FormatInt(u.Received, 10))
+		return
+	}
+
+	if err := os.MkdirAll(a.UploadDir, 0o755); err != nil {
+		writeError(w, http.StatusInternalServerError, "Storage error")
+		return
+	}
+	f, err := os.OpenFile(a.partPath(u.ID), os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Storage error")
+		return
+	}
+	// Drop anything past the recorded offset, left by a write that failed
+	// before it was recorded
+	if err := f.Truncate(u.Received); err != nil {
+		f.Close()
+		writeError(w, http.StatusInternalServerError, "Storage error")
+		return
+	}
+	f.Seek(u.Received, io.SeekStart)
+	n, copyErr := io.Copy(f, io.LimitReader(r.Body, u.Size-u.Received))
+	if err := errors.Join(f.Sync(), f.Close()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Storage error")
+		return
+	}
+	if n > 0 {
+		if _, err := a.Queries.AdvanceUpload(r.Context(), db.AdvanceUploadParams{
+			Received:   u.Received + n,
+			ID:         u.ID,
+			Received_2: u.Received,
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		u.Received += n
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Received, 10))
+	if copyErr != nil {
+		log.Printf("Upload %s interrupted at %d bytes: %v", u.ID, u.Received, copyErr)
+		writeError(w, http.StatusBadRequest, "Chunk was cut off, resume from Upload-Offset")
+		return
+	}
+
+	if u.Received == u.Size {
+		if err := a.finishUpload(r.Context(), &u); err != nil {
+			log.Printf("Error finishing upload %s: %v", u.ID, err)
+			writeError(w, http.StatusInternalServerError, "Storage error")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, a.uploadResponse(u))
+}
+
+// finishUpload moves the assembled file into Storage and queues it for
+// scanning.
+func (a *API) finishUpload(ctx context.Context, u *db.Upload) error {
+	f, err := os.Open(a.partPath(u.ID))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	contentType := http.DetectContentType(head[:n])
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	key := UploadKey(*u)
+	h := sha256.New()
+	if err := a.Storage.Put(ctx, key, io.TeeReader(f, h), u.Size, contentType); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if err := a.Queries.CompleteUpload(ctx, db.CompleteUploadParams{
+		ContentType: sql.NullString{String: contentType, Valid: true},
+		StorageKey:  sql.NullString{String: key, Valid: true},
+		Sha256:      sql.NullString{String: hex.EncodeToString(h.Sum(nil)), Valid: true},
+		CompletedAt: sql.NullTime{Time: now, Valid: true},
+		ID:          u.ID,
+	}); err != nil {
+		return err
+	}
+	u.ContentType = sql.NullString{String: contentType, Valid: true}
+	u.StorageKey = sql.NullString{String: key, Valid: true}
+	u.CompletedAt = sql.NullTime{Time: now, Valid: true}
+	os.Remove(a.partPath(u.ID))
+	return a.Jobs.Enqueue(ctx, "scan-upload", map[string]string{"id": u.ID}, time.Time{})
+}
+
+func (a *API) deleteUpload(w http.ResponseWriter, r *http.Request) {
+	u, ok := a.userUpload(w, r)
+	if !ok {
+		return
+	}
+	if err := a.DeleteUpload(r.Context(), u); err != nil {
+		if err == ErrUploadBusy {
+			writeError(w, http.StatusConflict, "A chunk for this upload is being written")
+		} else {
+			writeError(w, http.StatusInternalServerError, "Database error")
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ErrUploadBusy means a chunk is being written to the upload.
+var ErrUploadBusy = errors.New("upload busy")
+
+// DeleteUpload removes an upload and its data, finished or not.
+func (a *API) DeleteUpload(ctx context.Context, u db.Upload) error {
+	if !a.lockUpload(u.ID) {
+		return ErrUploadBusy
+	}
+	defer a.unlockUpload(u.ID)
+	if err := a.Queries.DeleteUpload(ctx, db.DeleteUploadParams{ID: u.ID, UserID: u.UserID}); err != nil {
+		return err
+	}
+	if u.StorageKey.Valid {
+		if err := a.Storage.Delete(ctx, u.StorageKey.String); err != nil {
+			log.Printf("Error deleting upload %s: %v", u.ID, err)
+		}
+	}
+	if err := os.Remove(a.partPath(u.ID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Error deleting partial upload %s: %v", u.ID, err)
+	}
+	return nil
+}
+
+// Quota is how many bytes of uploads the user may keep: StorageQuota
+// plus the storage their referrals earned.
+func (a *API) Quota(ctx context.Context, userID int64) (int64, error) {
+	bonus, err := a.Queries.GetReferralStorageBonus(ctx, userID)
+	return a.StorageQuota + bonus, err
+}
+
+// PruneUploads removes uploads abandoned before they were finished.
+func (a *API) PruneUploads(ctx context.Context) error {
+	now := time.Now().UTC()
+	ids, err := a.Queries.ListExpiredUploads(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := os.Remove(a.partPath(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return a.Queries.DeleteExpiredUploads(ctx, now)
+}
+
+// lockUpload claims an upload so two chunks can't be appended at once.
+func (a *API) lockUpload(id string) bool {
+	a.uploadsMu.Lock()
+	defer a.uploadsMu.Unlock()
+	if a.uploading == nil {
+		a.uploading = map[string]bool{}
+	}
+	if a.uploading[id] {
+		return false
+	}
+	a.uploading[id] = true
+	return true
+}
+
+func (a *API) unlockUpload(id string) {
+	a.uploadsMu.Lock()
+	delete(a.uploading, id)
+	a.uploadsMu.Unlock()
+}
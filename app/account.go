@@ -0,0 +1,158 @@
+package app
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gighub/api"
+	"gighub/db"
+	"gighub/locale"
+	"gighub/sessionstore"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request) {
+	userID := s.userID(r)
+	log.Printf("User ID from session: %d", userID)
+	s.renderAccount(w, r, userID, "", "")
+}
+
+// renderAccount shows the account page. newToken is the plaintext of a
+// token that was just created; it is only ever shown this once. formError
+// explains why an avatar upload or a file deletion failed.
+func (s *Server) renderAccount(w http.ResponseWriter, r *http.Request, userID int64, newToken, formError string) {
+	user, err := s.Queries.GetUser(r.Context(), userID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	tokens, err := s.Queries.ListAPITokensByUser(r.Context(), userID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	sessions, err := s.Queries.ListUserSessions(r.Context(), db.ListUserSessionsParams{
+		UserID: sql.NullInt64{Int64: userID, Valid: true},
+		Expiry: time.Now().UTC(),
+	})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	used, err := s.Queries.GetStorageUsage(r.Context(), userID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	largest, err := s.Queries.ListLargestUploads(r.Context(), db.ListLargestUploadsParams{UserID: userID, Limit: 5})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	quota, err := s.API.Quota(r.Context(), userID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	storage := views.StorageUsage{Used: used, Quota: quota, Largest: largest}
+	current := sessionstore.HashToken(s.Sessions.Token(r.Context()))
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	views.Account(user.Email, s.avatarURL(userID, user.Avatar, 256), user.Locale, formError, storage, tokens, newToken, sessions, current).Render(r.Context(), w)
+}
+
+// setLocale saves the locale dates and numbers are shown in. An empty one
+// goes back to following the browser. Other devices pick it up when they
+// next log in.
+func (s *Server) setLocale(w http.ResponseWriter, r *http.Request) {
+	code := r.FormValue("locale")
+	if _, ok := locale.Parse(code); !ok && code != "" {
+		http.Error(w, "Unknown locale", http.StatusBadRequest)
+		return
+	}
+	if err := s.Queries.SetUserLocale(r.Context(), db.SetUserLocaleParams{Locale: code, ID: s.userID(r)}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.Sessions.Put(r.Context(), "locale", code)
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (s *Server) createAPIToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	userID := s.userID(r)
+	scope := r.FormValue("scope")
+	if scope != api.ScopeWrite {
+		scope = api.ScopeRead
+	}
+	name := r.FormValue("name")
+	if name == "" {
+		name = "API token"
+	}
+	token, hash := api.NewToken()
+	if _, err := s.Queries.CreateAPIToken(r.Context(), db.CreateAPITokenParams{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hash,
+		Scope:     scope,
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.renderAccount(w, r, userID, token, "")
+}
+
+func (s *Server) deleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.Queries.DeleteAPIToken(r.Context(), db.DeleteAPITokenParams{
+		ID:     id,
+		UserID: s.userID(r),
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+// deleteSession signs the user out on another device.
+func (s *Server) deleteSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.Queries.DeleteUserSession(r.Context(), db.DeleteUserSessionParams{
+		ID:     id,
+		UserID: sql.NullInt64{Int64: s.userID(r), Valid: true},
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+// deleteOtherSessions signs the user out everywhere but here.
+func (s *Server) deleteOtherSessions(w http.ResponseWriter, r *http.Request) {
+	if err := s.Queries.DeleteOtherUserSessions(r.Context(), db.DeleteOtherUserSessionsParams{
+		UserID:    sql.NullInt64{Int64: s.userID(r), Valid: true},
+		TokenHash: sessionstore.HashToken(s.Sessions.Token(r.Context())),
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
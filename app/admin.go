@@ -0,0 +1,244 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"gighub/buildinfo"
+	"gighub/db"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxDashboardDays bounds the date range on the admin dashboard and
+// analytics.
+const maxDashboardDays = 366
+
+// getAdmin shows the dashboard, for the last 30 days unless from and to
+// pick other days.
+func (s *Server) getAdmin(w http.ResponseWriter, r *http.Request) {
+	from, to, days, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	start := sql.NullTime{Time: from, Valid: true}
+	end := sql.NullTime{Time: to.AddDate(0, 0, 1), Valid: true}
+
+	d := views.Dashboard{
+		From:        from,
+		To:          to,
+		Signups:     make([]int64, days),
+		JobRuns:     make([]int64, days),
+		JobFailures: make([]int64, days),
+		Mail:        views.MailStats(s.Mailer.Stats()),
+	}
+	// Days without rows stay at zero
+	day := func(date string) (int, bool) {
+		t, err := time.Parse(time.DateOnly, date)
+		i := int(t.Sub(from).Hours() / 24)
+		return i, err == nil && i >= 0 && i < days
+	}
+	signups, err := s.Queries.CountSignupsByDay(r.Context(), db.CountSignupsByDayParams{CreatedAt: start, CreatedAt_2: end})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	for _, row := range signups {
+		if i, ok := day(row.Day); ok {
+			d.Signups[i] = row.Signups
+		}
+	}
+	jobs, err := s.Queries.CountJobsByDay(r.Context(), db.CountJobsByDayParams{FinishedAt: start, FinishedAt_2: end})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	for _, row := range jobs {
+		if i, ok := day(row.Day); ok {
+			d.JobRuns[i], d.JobFailures[i] = row.Runs, row.Failures
+		}
+	}
+	// Emails on the job queue wait there rather than in the mailer
+	queued, err := s.Queries.CountPendingJobsByName(r.Context(), sendEmailJob)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	d.Mail.Queued += queued
+	users, err := s.Queries.CountUsers(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	d.Users, d.Verified = users.Total, users.Verified
+	// Sessions last a day, so a live one means a sign-in since yesterday
+	if d.Active, err = s.Queries.CountActiveUsers(r.Context(), time.Now().UTC()); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	d.Runtime = views.RuntimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  mem.HeapAlloc,
+		NumGC:      mem.NumGC,
+		GoVersion:  runtime.Version(),
+		GitSHA:     buildinfo.Get().Commit,
+	}
+	views.AdminHome(d, s.Config.Admin.Debug).Render(r.Context(), w)
+}
+
+// dateRange reads the days from and to in the query, the last 30 days
+// when they're missing. A bad range is answered with a 400.
+func dateRange(w http.ResponseWriter, r *http.Request) (from, to time.Time, days int, ok bool) {
+	to = time.Now().UTC().Truncate(24 * time.Hour)
+	from = to.AddDate(0, 0, -29)
+	var err error
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(time.DateOnly, v); err != nil {
+			http.Error(w, "from must be a date like 2006-01-02", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(time.DateOnly, v); err != nil {
+			http.Error(w, "to must be a date like 2006-01-02", http.StatusBadRequest)
+			return
+		}
+	}
+	days = int(to.Sub(from).Hours()/24) + 1
+	if days < 1 || days > maxDashboardDays {
+		http.Error(w, fmt.Sprintf("The range must be from 1 to %d days", maxDashboardDays), http.StatusBadRequest)
+		return
+	}
+	return from, to, days, true
+}
+
+func (s *Server) getAdminQueries(w http.ResponseWriter, r *http.Request) {
+	views.AdminQueries(db.Stats.Snapshot(), db.Stats.SlowThreshold).Render(r.Context(), w)
+}
+
+func (s *Server) getAdminJobs(w http.ResponseWriter, r *http.Request) {
+	schedules, err := s.Queries.ListJobSchedules(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	pending, err := s.Queries.ListPendingJobs(r.Context(), 50)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	failed, err := s.Queries.ListFailedJobs(r.Context(), 20)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	recent, err := s.Queries.ListRecentJobs(r.Context(), 50)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	outcomes, err := s.Queries.ListJobOutcomes(r.Context(), sql.NullTime{Time: time.Now().Add(-time.Hour).UTC(), Valid: true})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	views.AdminJobs(schedules, pending, failed, recent, outcomes, s.Config.Admin.JobFailureRate).Render(r.Context(), w)
+}
+
+// getAdminEmails shows the emails sent in the background, which are
+// send-email jobs.
+func (s *Server) getAdminEmails(w http.ResponseWriter, r *http.Request) {
+	var lists [3][]db.Job
+	var err error
+	if lists[0], err = s.Queries.ListPendingJobsByName(r.Context(), db.ListPendingJobsByNameParams{Name: sendEmailJob, Limit: 50}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if lists[1], err = s.Queries.ListFailedJobsByName(r.Context(), db.ListFailedJobsByNameParams{Name: sendEmailJob, Limit: 50}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if lists[2], err = s.Queries.ListRecentJobsByName(r.Context(), db.ListRecentJobsByNameParams{Name: sendEmailJob, Limit: 50}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	outcomes, err := s.Queries.ListJobOutcomes(r.Context(), sql.NullTime{Time: time.Now().Add(-time.Hour).UTC(), Valid: true})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	var lastHour db.ListJobOutcomesRow
+	for _, o := range outcomes {
+		if o.Name == sendEmailJob {
+			lastHour = o
+		}
+	}
+	// The bodies hold verification links, so only who and what are shown
+	var emails [3][]views.Email
+	for i, list := range lists {
+		for _, job := range list {
+			var e queuedEmail
+			json.Unmarshal([]byte(job.Payload), &e)
+			emails[i] = append(emails[i], views.Email{Job: job, To: e.To, Subject: e.Subject})
+		}
+	}
+	views.AdminEmails(emails[0], emails[1], emails[2], lastHour, s.Config.Admin.JobFailureRate).Render(r.Context(), w)
+}
+
+// jobReturn is the page a job's buttons were pressed on, to go back to.
+func jobReturn(r *http.Request) string {
+	if r.FormValue("from") == "emails" {
+		return "/admin/emails"
+	}
+	return "/admin/jobs"
+}
+
+// retryAdminJob runs a failed job again, with its attempts reset.
+func (s *Server) retryAdminJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	retried, err := s.Queries.RetryJob(r.Context(), db.RetryJobParams{RunAt: time.Now().UTC(), ID: id})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if retried > 0 {
+		s.audit(r, "job.retry", "job "+strconv.FormatInt(id, 10), "")
+	}
+	http.Redirect(w, r, jobReturn(r), http.StatusSeeOther)
+}
+
+// cancelAdminJob stops a pending job from running, or discards a failed
+// one. Running jobs can't be cancelled.
+func (s *Server) cancelAdminJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	cancelled, err := s.Queries.CancelJob(r.Context(), db.CancelJobParams{
+		FinishedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:         id,
+	})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if cancelled > 0 {
+		s.audit(r, "job.cancel", "job "+strconv.FormatInt(id, 10), "")
+	}
+	http.Redirect(w, r, jobReturn(r), http.StatusSeeOther)
+}
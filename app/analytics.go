@@ -0,0 +1,56 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"gighub/analytics"
+	"gighub/db"
+	"gighub/utils"
+	"gighub/views"
+)
+
+// getAdminAnalytics shows traffic, where it came from and how much of it
+// signed up, for the last 30 days unless from and to pick other days.
+func (s *Server) getAdminAnalytics(w http.ResponseWriter, r *http.Request) {
+	from, to, days, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	first, last := from.Format(time.DateOnly), to.Format(time.DateOnly)
+	a := views.Analytics{
+		From:     from,
+		To:       to,
+		Views:    make([]int64, days),
+		Visitors: make([]int64, days),
+	}
+	rows, err := s.Queries.ListAnalyticsDays(r.Context(), db.ListAnalyticsDaysParams{Day: first, Day_2: last})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	// Days without rows stay at zero
+	for _, row := range rows {
+		t, err := time.Parse(time.DateOnly, row.Day)
+		if i := int(t.Sub(from).Hours() / 24); err == nil && i >= 0 && i < days {
+			a.Views[i], a.Visitors[i] = row.Views, row.Visitors
+		}
+	}
+	if a.Pages, err = s.Queries.ListTopPages(r.Context(), db.ListTopPagesParams{Day: first, Day_2: last, Limit: 20}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if a.Referrers, err = s.Queries.ListTopReferrers(r.Context(), db.ListTopReferrersParams{Day: first, Day_2: last, Limit: 20}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	for _, step := range analytics.SignupFunnel {
+		n, err := s.Queries.CountAnalyticsEvents(r.Context(), db.CountAnalyticsEventsParams{Name: step.Name, Path: step.Path, Day: first, Day_2: last})
+		if err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+		a.Funnel = append(a.Funnel, views.FunnelStep{Label: step.Label, Count: n})
+	}
+	views.AdminAnalytics(a).Render(r.Context(), w)
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/announcements"
+	"gighub/db"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxAnnouncementLength keeps banners to a line or two.
+const maxAnnouncementLength = 300
+
+// The admin form picks times in UTC with datetime-local inputs.
+const announcementTimeLayout = "2006-01-02T15:04"
+
+func (s *Server) getAdminAnnouncements(w http.ResponseWriter, r *http.Request) {
+	s.renderAdminAnnouncements(w, r, "")
+}
+
+func (s *Server) renderAdminAnnouncements(w http.ResponseWriter, r *http.Request, formError string) {
+	list, err := s.Queries.ListAnnouncements(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	views.AdminAnnouncements(list, time.Now(), formError).Render(r.Context(), w)
+}
+
+func (s *Server) createAnnouncement(w http.ResponseWriter, r *http.Request) {
+	p := db.CreateAnnouncementParams{
+		Message:     strings.TrimSpace(r.FormValue("message")),
+		Level:       r.FormValue("level"),
+		Audience:    r.FormValue("audience"),
+		StartsAt:    time.Now().UTC(),
+		Dismissible: r.FormValue("dismissible") == "on",
+	}
+	if p.Message == "" || len(p.Message) > maxAnnouncementLength {
+		s.renderAdminAnnouncements(w, r, fmt.Sprintf("The message must be 1 to %d characters.", maxAnnouncementLength))
+		return
+	}
+	if p.Level != announcements.Info && p.Level != announcements.Warning {
+		s.renderAdminAnnouncements(w, r, "Pick a level.")
+		return
+	}
+	if p.Audience != announcements.Everyone && p.Audience != announcements.Visitors && p.Audience != announcements.Users {
+		s.renderAdminAnnouncements(w, r, "Pick an audience.")
+		return
+	}
+	if v := r.FormValue("starts_at"); v != "" {
+		t, err := time.Parse(announcementTimeLayout, v)
+		if err != nil {
+			s.renderAdminAnnouncements(w, r, "The start time isn't valid.")
+			return
+		}
+		p.StartsAt = t
+	}
+	if v := r.FormValue("ends_at"); v != "" {
+		t, err := time.Parse(announcementTimeLayout, v)
+		if err != nil {
+			s.renderAdminAnnouncements(w, r, "The end time isn't valid.")
+			return
+		}
+		if !t.After(p.StartsAt) {
+			s.renderAdminAnnouncements(w, r, "The end time must be after the start.")
+			return
+		}
+		p.EndsAt = sql.NullTime{Time: t, Valid: true}
+	}
+	if err := s.Queries.CreateAnnouncement(r.Context(), p); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.audit(r, "announcement.create", "announcement", p.Message)
+	s.announcementsChanged(r.Context())
+	http.Redirect(w, r, "/admin/announcements", http.StatusSeeOther)
+}
+
+func (s *Server) deleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.Queries.DeleteAnnouncement(r.Context(), id); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.audit(r, "announcement.delete", "announcement "+strconv.FormatInt(id, 10), "")
+	s.announcementsChanged(r.Context())
+	http.Redirect(w, r, "/admin/announcements", http.StatusSeeOther)
+}
+
+// announcementsChanged drops the cached announcements, and the cached
+// pages showing them.
+func (s *Server) announcementsChanged(ctx context.Context) {
+	s.Announcements.Invalidate()
+	s.Pages.Invalidate(ctx, "pages", "status")
+}
+
+// dismissAnnouncement hides an announcement from the logged-in user for
+// good, and goes back to the page it was dismissed on.
+func (s *Server) dismissAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.Queries.DismissAnnouncement(r.Context(), db.DismissAnnouncementParams{AnnouncementID: id, UserID: s.userID(r)}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	back := "/"
+	if u, err := url.Parse(r.Referer()); err == nil && u.Host == r.Host && u.Path != "" {
+		back = u.RequestURI()
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
@@ -0,0 +1,634 @@
+// Package app wires the HTML site, JSON API and background workers around a
+// Server that holds every dependency, so the whole application can be built
+// from a config and a database without touching globals.
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gighub/abuse"
+	"gighub/analytics"
+	"gighub/announcements"
+	"gighub/api"
+	"gighub/bans"
+	"gighub/buildinfo"
+	"gighub/config"
+	"gighub/db"
+	"gighub/emails"
+	"gighub/experiments"
+	"gighub/flags"
+	"gighub/health"
+	"gighub/imageproxy"
+	"gighub/jobs"
+	"gighub/keys"
+	"gighub/pagecache"
+	"gighub/ratelimit"
+	"gighub/report"
+	"gighub/scan"
+	"gighub/sessionstore"
+	"gighub/settings"
+	"gighub/storage"
+	"gighub/utils"
+	"gighub/views"
+	"gighub/webhooks"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/sessions"
+	"github.com/justinas/nosurf"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/providers/google"
+	"golang.org/x/crypto/acme"
+)
+
+// Server holds the application's dependencies. Handlers are methods on it.
+type Server struct {
+	Config        *config.Config
+	DB            *sql.DB
+	Queries       *db.Queries
+	Sessions      *scs.SessionManager
+	Mailer        *Mailer
+	Webhooks      *webhooks.Dispatcher
+	Receiver      *webhooks.Receiver
+	Jobs          *jobs.Scheduler
+	Limiter       *ratelimit.Limiter
+	Flags         *flags.Store
+	Abuse         *abuse.Guard
+	Health        *health.Monitor
+	Storage       storage.Store
+	CDN           *storage.Purger
+	Images        *imageproxy.Proxy
+	Scanner       scan.Pipeline
+	Pages         *pagecache.Cache
+	API           *api.API
+	Reporter      report.Reporter
+	Settings      *settings.Store
+	Emails        *emails.Store
+	Analytics     *analytics.Recorder
+	Announcements *announcements.Store
+	Bans          *bans.Store
+
+	// stopping is closed when Run's context ends, to close event streams.
+	stopping chan struct{}
+
+	// authMu guards goth's providers while the Apple client secret, valid
+	// until appleSecretExpiry, is renewed.
+	authMu            sync.RWMutex
+	appleSecretExpiry time.Time
+}
+
+// New builds a Server from a loaded config and an open, migrated database.
+func New(cfg *config.Config, dbConn *sql.DB, queries *db.Queries) (*Server, error) {
+	// Errors and panics go to Sentry when it is configured
+	var reporter report.Reporter = report.Log{}
+	if cfg.SentryDSN != "" {
+		sentry, err := report.NewSentry(cfg.SentryDSN, cfg.Env, buildinfo.Get().Commit)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring Sentry: %w", err)
+		}
+		reporter = sentry
+	}
+
+	s := &Server{
+		Config:        cfg,
+		DB:            dbConn,
+		Queries:       queries,
+		Mailer:        &Mailer{Config: cfg.SMTP, Reporter: reporter},
+		Reporter:      reporter,
+		Webhooks:      webhooks.New(queries),
+		Receiver:      webhooks.NewReceiver(queries),
+		Jobs:          jobs.New(queries),
+		Limiter:       ratelimit.New(),
+		Flags:         flags.New(queries),
+		Analytics:     analytics.New(queries),
+		Announcements: announcements.New(queries),
+		Bans:          bans.New(queries),
+		stopping:      make(chan struct{}),
+	}
+	s.Jobs.Reporter = reporter
+	s.Mailer.Jobs = s.Jobs
+
+	// Initialize session manager
+	s.Sessions = scs.New()
+	s.Sessions.Store = sessionstore.New(queries, "userID")
+	s.Sessions.Lifetime = 24 * time.Hour
+	s.Sessions.Cookie.Persist = true
+	s.Sessions.Cookie.SameSite = http.SameSiteLaxMode
+	s.Sessions.Cookie.Secure = cfg.Production()
+
+	// Configure Goth for Social Login
+	goth.UseProviders(
+		google.New(cfg.Google.ClientID, cfg.Google.ClientSecret, cfg.BaseURL+"/auth/google/callback"),
+	)
+	// Apple is registered with a client secret signed now, and again when
+	// it is about to expire
+	if err := s.renewAppleSecret(); err != nil {
+		return nil, fmt.Errorf("error signing the Sign in with Apple client secret: %w", err)
+	}
+	gothic.GetProviderName = func(req *http.Request) (string, error) {
+		provider := chi.URLParam(req, "provider")
+		if provider == "" {
+			return "", fmt.Errorf("provider not found")
+		}
+		return provider, nil
+	}
+
+	// Configure Gothic session store
+	// Cookies are signed with the first session key and accepted with any,
+	// so a key can be rotated without failing logins in progress.
+	var keyPairs [][]byte
+	for _, k := range cfg.SessionKeys {
+		keyPairs = append(keyPairs, k.Secret, nil)
+	}
+	if len(keyPairs) == 0 {
+		keyPairs = [][]byte{nil}
+	}
+	store := sessions.NewCookieStore(keyPairs...)
+	store.MaxAge(86400 * 30)
+	store.Options.Path = "/"
+	store.Options.HttpOnly = true
+	store.Options.Secure = cfg.Production()
+	gothic.Store = store
+
+	// Accept inbound webhooks from integrations
+	s.Receiver.StripeKeys = cfg.Webhooks.StripeKeys
+	s.Receiver.CalendlyKeys = cfg.Webhooks.CalendlyKeys
+	s.Receiver.Handle(webhooks.SourceCustom, "guestbook.update", s.inboundGuestbookUpdate)
+
+	// Settings admins change at /admin/settings. The environment only
+	// provides their defaults.
+	s.Settings = settings.New(dbConn, queries, settings.Settings{
+		SiteName:      "gighub",
+		SignupsOpen:   true,
+		APIRateLimits: cfg.API.RateLimits,
+		Throttles:     cfg.Throttles.Limits,
+	})
+	s.Settings.OnChange = s.settingsChanged
+	set, err := s.Settings.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	s.Mailer.Settings = s.Settings
+	// Wording of the emails users get, which admins can change
+	s.Emails = emails.New(queries, s.Settings)
+	s.Emails.Reporter = reporter
+
+	// API rate limits, e.g. "read=120,write=60,ip=120" (requests per minute)
+	limits, err := ratelimit.ParseLimits(set.APIRateLimits, api.DefaultLimits)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Limiter.Load(context.Background(), queries); err != nil {
+		return nil, err
+	}
+	// Uploaded files, and links to them signed with ring
+	ring := cfg.Storage.SigningKeys
+	if len(ring) == 0 {
+		// Without a configured key, links only last until a restart
+		secret := make([]byte, 32)
+		rand.Read(secret)
+		ring = keys.Ring{{ID: "ephemeral", Secret: secret}}
+	}
+	origin := cfg.BaseURL
+	if cfg.Storage.CDNURL != "" {
+		origin = cfg.Storage.CDNURL
+	}
+	if cfg.Storage.S3() {
+		s.Storage = storage.NewS3(cfg.Storage.S3Endpoint, cfg.Storage.S3Region, cfg.Storage.S3Bucket, cfg.Storage.S3AccessKey, cfg.Storage.S3SecretKey)
+	} else {
+		s.Storage = storage.NewLocal(cfg.Storage.Dir, origin+"/files", ring)
+	}
+	views.CDNURL = cfg.Storage.CDNURL
+	if cfg.Production() {
+		if err := views.FingerprintAssets("assets"); err != nil {
+			return nil, fmt.Errorf("error fingerprinting assets: %w", err)
+		}
+	}
+	if cfg.Storage.CDNPurgeURL != "" {
+		s.CDN = storage.NewPurger(cfg.Storage.CDNPurgeURL, cfg.Storage.CDNPurgeToken)
+	}
+	// External images are served through /img, see IMAGE_PROXY_HOSTS
+	s.Images = imageproxy.New(cfg.Storage.ImageProxyHosts, origin+"/img", ring, s.Storage)
+	// Finished uploads are scanned before anyone can download them
+	s.Scanner = scan.Pipeline{scan.Duplicates{Queries: queries}}
+	if cfg.Storage.ModerationURL != "" {
+		s.Scanner = append(s.Scanner, scan.NewModeration(cfg.Storage.ModerationURL, cfg.Storage.ModerationToken))
+	}
+
+	// Public pages are cached for anonymous visitors, in Redis when it's set
+	var backend pagecache.Backend = pagecache.NewMemory(1000)
+	if cfg.Cache.RedisURL != "" {
+		redis, err := pagecache.NewRedis(cfg.Cache.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		// Pages are rendered uncached while Redis is down, so it needn't
+		// be up for the server to start
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := redis.Ping(ctx); err != nil {
+			log.Printf("Page cache: Redis at %s is unreachable: %v", redis.Addr, err)
+		}
+		cancel()
+		backend = redis
+	}
+	s.Pages = &pagecache.Cache{
+		Backend: backend,
+		TTL:     cfg.Cache.TTL,
+		Anonymous: func(r *http.Request) bool {
+			return !s.Sessions.Exists(r.Context(), "userID")
+		},
+		Vary:    pageVary,
+		Private: func(r *http.Request) bool { return experiments.Exposed(r.Context()) },
+	}
+
+	s.API = &api.API{
+		Queries:          queries,
+		Sessions:         s.Sessions,
+		Webhooks:         s.Webhooks,
+		Limiter:          s.Limiter,
+		Limits:           ratelimit.NewTable(limits),
+		Stopping:         s.stopping,
+		Storage:          s.Storage,
+		UploadDir:        filepath.Join(cfg.DataDir, "uploads"),
+		MaxResumableSize: cfg.Limits.MaxResumableSize,
+		StorageQuota:     cfg.Limits.StorageQuota,
+		Jobs:             s.Jobs,
+		Bans:             s.Bans,
+	}
+
+	// Per-IP throttles on signups, email and messages
+	throttles, err := ratelimit.ParseLimits(set.Throttles, abuse.DefaultLimits)
+	if err != nil {
+		return nil, err
+	}
+	s.Abuse = abuse.New(queries, s.Limiter, throttles)
+
+	// Self-monitoring, shown at /status
+	s.Health = &health.Monitor{
+		Queries:    queries,
+		Checks:     []health.Check{health.Database(dbConn)},
+		AlertAfter: 3,
+		Alert:      s.healthAlert,
+	}
+	if cfg.SMTP.Enabled() {
+		s.Health.Checks = append(s.Health.Checks, health.SMTP(cfg.SMTP.Host, cfg.SMTP.Port))
+	}
+	// Jobs and emails failing more often than JOB_FAILURE_ALERT_RATE over
+	// the last hour
+	s.Health.Checks = append(s.Health.Checks,
+		health.JobFailures("jobs", queries, func(job string) bool { return job != sendEmailJob }, time.Hour, cfg.Admin.JobFailureRate),
+		health.JobFailures("email", queries, func(job string) bool { return job == sendEmailJob }, time.Hour, cfg.Admin.JobFailureRate),
+	)
+	if cfg.Google.ClientID != "" {
+		s.Health.Checks = append(s.Health.Checks, health.HTTP("google-oauth", "https://accounts.google.com/.well-known/openid-configuration"))
+	}
+	if cfg.TLS.Enabled() {
+		s.Health.Checks = append(s.Health.Checks, health.HTTP("letsencrypt", acme.LetsEncryptURL))
+	}
+
+	// Background jobs: handlers are registered here and cron schedules
+	// enqueue them. Run history is at /admin/jobs.
+	s.Jobs.Handle("prune-idempotency-keys", func(ctx context.Context, _ json.RawMessage) error {
+		return s.API.PruneIdempotencyKeys(ctx)
+	})
+	s.Jobs.Handle("prune-jobs", func(ctx context.Context, _ json.RawMessage) error {
+		cutoff := time.Now().UTC().AddDate(0, 0, -30)
+		return queries.DeleteFinishedJobsBefore(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	})
+	s.Jobs.Handle("prune-sessions", func(ctx context.Context, _ json.RawMessage) error {
+		return queries.DeleteExpiredSessions(ctx, time.Now().UTC())
+	})
+	s.Jobs.Handle("health-check", func(ctx context.Context, _ json.RawMessage) error {
+		defer s.Pages.Invalidate(ctx, "status")
+		return s.Health.RunChecks(ctx)
+	})
+	s.Jobs.Handle("prune-health-checks", func(ctx context.Context, _ json.RawMessage) error {
+		return queries.DeleteHealthChecksBefore(ctx, time.Now().UTC().AddDate(0, 0, -7))
+	})
+	s.Jobs.Handle("prune-bans", func(ctx context.Context, _ json.RawMessage) error {
+		return queries.DeleteExpiredBans(ctx, time.Now().UTC())
+	})
+	s.Jobs.Handle("lift-expired-user-bans", func(ctx context.Context, _ json.RawMessage) error {
+		return s.Bans.LiftExpired(ctx)
+	})
+	s.Jobs.Handle("remind-unverified-users", func(ctx context.Context, _ json.RawMessage) error {
+		return s.remindUnverifiedUsers(ctx)
+	})
+	s.Jobs.Handle("scan-upload", s.scanUpload)
+	s.Jobs.Handle(sendEmailJob, s.Mailer.sendQueued)
+	s.Jobs.Handle("prune-uploads", func(ctx context.Context, _ json.RawMessage) error {
+		return s.API.PruneUploads(ctx)
+	})
+	s.Jobs.Handle("prune-unverified-users", func(ctx context.Context, _ json.RawMessage) error {
+		return s.pruneUnverifiedUsers(ctx)
+	})
+	s.Jobs.Handle("prune-drafts", func(ctx context.Context, _ json.RawMessage) error {
+		return queries.DeleteDraftsBefore(ctx, time.Now().UTC().Add(-draftTTL))
+	})
+	s.Jobs.Handle("roll-up-analytics", func(ctx context.Context, _ json.RawMessage) error {
+		if err := experiments.RollUp(ctx, queries); err != nil {
+			return err
+		}
+		return s.Analytics.RollUp(ctx)
+	})
+	for name, spec := range map[string]string{
+		"prune-idempotency-keys":  "@hourly",
+		"prune-jobs":              "@daily",
+		"prune-sessions":          "@hourly",
+		"health-check":            "*/5 * * * *",
+		"prune-health-checks":     "@daily",
+		"prune-bans":              "@daily",
+		"lift-expired-user-bans":  "@hourly",
+		"remind-unverified-users": "@hourly",
+		"prune-unverified-users":  "@daily",
+		"prune-uploads":           "@hourly",
+		"prune-drafts":            "@daily",
+		"roll-up-analytics":       "@hourly",
+	} {
+		if err := s.Jobs.Cron(name, spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Run runs the background workers until ctx is cancelled and they have all
+// stopped.
+func (s *Server) Run(ctx context.Context) {
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){
+		s.Webhooks.Run,
+		s.Receiver.Run,
+		s.Jobs.Run,
+		func(ctx context.Context) { s.Limiter.Persist(ctx, s.Queries, time.Minute) },
+		func(ctx context.Context) { s.Analytics.Run(ctx, 10*time.Second) },
+	} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+	<-ctx.Done()
+	close(s.stopping)
+	workers.Wait()
+}
+
+// longRunning are the routes exempt from the request timeout: event
+// streams and CPU profiles are meant to run long. They are picked by path,
+// since anything the client sends could be added to any request.
+var longRunning = map[string]bool{
+	"/api/v1/events":       true,
+	"/guestbook/stream":    true,
+	"/debug/pprof/profile": true,
+}
+
+// Routes returns the handler for the whole site, CSRF protection included.
+func (s *Server) Routes() http.Handler {
+	cfg := s.Config
+
+	// Initialize the router
+	r := chi.NewRouter()
+
+	// Use default middleware
+	// Compress: Brotli or gzip for text responses
+	// Recoverer: Recovers from panics, reports them and returns a 500 error instead of crashing
+	r.Use(utils.Compress(5))
+	r.Use(s.recoverer)
+	// Admin pages need credentials anyway and are where bans are lifted
+	r.Use(s.Abuse.Block(func(r *http.Request) bool {
+		return r.URL.Path == "/admin" || strings.HasPrefix(r.URL.Path, "/admin/")
+	}))
+	if cfg.TLS.Enabled() {
+		r.Use(strictTransportSecurity)
+	}
+	r.Use(s.Sessions.LoadAndSave)
+	r.Use(s.templateContext)
+	r.Use(s.featureFlags)
+	r.Use(s.localize)
+	r.Use(s.assignExperiments)
+	r.Use(s.announce)
+	r.Use(preloadHints)
+	// Page views, without cookies. Admins browsing their own pages aren't
+	// visitors.
+	r.Use(s.Analytics.Track("/admin"))
+
+	// Public pages are revalidated with ETags instead of being downloaded
+	// again, and anonymous visitors get them from the page cache
+	r.Group(func(r chi.Router) {
+		r.Use(utils.CacheControl("private, no-cache"))
+		r.Use(utils.Conditional)
+
+		r.With(s.Pages.Page("pages")).Get("/", s.getHome)
+
+		// Static pages
+		r.With(s.Pages.Page("pages")).Get("/privacy-policy", s.getPrivacyPolicy)
+		r.With(s.Pages.Page("pages")).Get("/terms", s.getTerms)
+
+		// Results of the health checks, invalidated after each run
+		r.With(s.Pages.Page("status")).Get("/status", s.getStatus)
+	})
+
+	// Cross-origin API access is off unless CORS_ALLOWED_ORIGINS lists origins.
+	// Credentialed (cookie) calls additionally need CORS_ALLOW_CREDENTIALS=true.
+	apiCORS := utils.CORS(utils.CORSOptions{
+		AllowedOrigins:   cfg.API.CORSOrigins,
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "Idempotency-Key", "Last-Event-ID", "X-CSRF-Token", "If-Match", "If-None-Match", "Upload-Offset", "Upload-Length", utils.RequestIDHeader},
+		ExposedHeaders:   []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "Idempotent-Replayed", "X-Total-Count", "X-Total-Count-Exact", "ETag", "Location", "Upload-Offset", "Upload-Length", utils.RequestIDHeader},
+		AllowCredentials: cfg.API.CORSCredentials,
+		MaxAge:           10 * time.Minute,
+	})
+	// JSON API with its OpenAPI document at /api/v1/openapi.json
+	r.With(apiCORS).Mount("/api/v1", s.API.Routes())
+
+	// Admin dashboard and pages
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireAdmin)
+		r.Use(utils.CacheControl("no-store"))
+		r.Get("/admin", s.getAdmin)
+		r.Get("/admin/commands", s.getAdminCommands)
+		r.Get("/admin/jobs", s.getAdminJobs)
+		r.Get("/admin/queries", s.getAdminQueries)
+		r.Post("/admin/jobs/{id}/retry", s.retryAdminJob)
+		r.Post("/admin/jobs/{id}/cancel", s.cancelAdminJob)
+		r.Get("/admin/emails", s.getAdminEmails)
+		r.Get("/admin/emails/templates", s.getAdminEmailTemplates)
+		r.Get("/admin/emails/templates/{name}", s.getAdminEmailTemplate)
+		r.Post("/admin/emails/templates/{name}", s.postAdminEmailTemplate)
+		r.Post("/admin/emails/templates/{name}/reset", s.resetAdminEmailTemplate)
+		r.Get("/admin/flags", s.getAdminFlags)
+		r.Post("/admin/flags", s.createFlag)
+		r.Post("/admin/flags/{name}", s.updateFlag)
+		r.Post("/admin/flags/{name}/delete", s.deleteFlag)
+		r.Post("/admin/flags/{name}/overrides", s.setFlagOverride)
+		r.Post("/admin/flags/{name}/overrides/{userID}/delete", s.deleteFlagOverride)
+		r.Get("/admin/blocks", s.getAdminBlocks)
+		r.Post("/admin/blocks", s.createBan)
+		r.Post("/admin/blocks/{id}/delete", s.deleteBan)
+		r.Get("/admin/bans", s.getAdminUserBans)
+		r.Post("/admin/bans", s.banUser)
+		r.Post("/admin/bans/{id}/lift", s.liftUserBan)
+		r.Post("/admin/bans/appeals/{id}/accept", s.acceptAppeal)
+		r.Post("/admin/bans/appeals/{id}/reject", s.rejectAppeal)
+		r.Get("/admin/moderation", s.getModeration)
+		r.Post("/admin/moderation/uploads/{id}/approve", s.approveUpload)
+		r.Post("/admin/moderation/uploads/{id}/remove", s.removeUpload)
+		r.Get("/admin/uploads", http.RedirectHandler("/admin/moderation", http.StatusMovedPermanently).ServeHTTP)
+		r.Get("/admin/audit", s.getAdminAudit)
+		r.Get("/admin/settings", s.getAdminSettings)
+		r.Post("/admin/settings", s.saveSettings)
+		r.Post("/admin/settings/reset", s.resetSettings)
+		r.Get("/admin/analytics", s.getAdminAnalytics)
+		r.Get("/admin/experiments", s.getAdminExperiments)
+		r.Get("/admin/announcements", s.getAdminAnnouncements)
+		r.Post("/admin/announcements", s.createAnnouncement)
+		r.Post("/admin/announcements/{id}/delete", s.deleteAnnouncement)
+		r.Get("/admin/console", s.getConsole)
+		r.Post("/admin/console", s.postConsole)
+
+		// Runtime profiles and expvar, only when DEBUG_ENDPOINTS=true
+		if cfg.Admin.Debug {
+			r.Mount("/debug", middleware.Profiler())
+		}
+	})
+
+	// Avatar URLs change with the picture, so they are cached for good
+	r.Get("/avatars/{userID}/{version}/{size}.png", s.getAvatar)
+	r.Get("/img", s.Images.ServeHTTP)
+
+	// Files on local disk, by signed URL
+	if local, ok := s.Storage.(*storage.Local); ok {
+		r.Mount("/files", http.StripPrefix("/files", local.Handler()))
+	}
+
+	// Inbound webhooks authenticate with signatures instead of sessions
+	r.Mount("/hooks", s.Receiver.Routes())
+
+	// Pages for logged-in users
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireAuth)
+		// Pages show secrets like webhook signing keys, keep them out of caches
+		r.Use(utils.CacheControl("no-store"))
+
+		r.Get("/guestbook", s.getGuestbook)
+		r.Get("/guestbook/stream", s.streamGuestbook)
+		r.With(s.Abuse.Throttle(abuse.Message)).Post("/guestbook", s.postGuestbook)
+
+		r.Get("/account", s.getAccount)
+		r.Post("/account/locale", s.setLocale)
+		r.Post("/account/tokens", s.createAPIToken)
+		r.Post("/account/tokens/{id}/delete", s.deleteAPIToken)
+		r.Post("/account/sessions/{id}/delete", s.deleteSession)
+		r.Post("/account/sessions/others/delete", s.deleteOtherSessions)
+		r.With(utils.LimitBody(maxAvatarSize+64<<10)).Post("/account/avatar", s.uploadAvatar)
+		r.Post("/account/avatar/delete", s.deleteAvatar)
+		r.Post("/account/uploads/{id}/delete", s.deleteUpload)
+		r.Get("/referrals", s.getReferrals)
+		r.Post("/announcements/{id}/dismiss", s.dismissAnnouncement)
+
+		// Outgoing webhook endpoints and their delivery log
+		r.Get("/webhooks", s.getWebhooks)
+		r.Post("/webhooks", s.createWebhook)
+		r.Post("/webhooks/inbound", s.createInboundHook)
+		r.Post("/webhooks/inbound/{id}/delete", s.deleteInboundHook)
+		r.Get("/webhooks/{id}", s.getWebhook)
+		r.Post("/webhooks/{id}/ping", s.pingWebhook)
+		r.Post("/webhooks/{id}/delete", s.deleteWebhook)
+	})
+
+	// Email test route
+	r.With(s.Abuse.Throttle(abuse.Email)).Get("/email", s.getEmailTest)
+
+	// Social Auth Routes
+	r.Get("/auth/{provider}", s.withProviders(gothic.BeginAuthHandler))
+	r.Get("/auth/{provider}/callback", s.withProviders(s.oauthCallback))
+	r.Post("/auth/{provider}/callback", s.appleFormPost)
+
+	// Auth routes
+	r.Get("/signup", s.getSignup)
+	// Each signup sends a verification email
+	r.With(s.Abuse.Throttle(abuse.Signup), s.Abuse.Throttle(abuse.Email)).Post("/signup", s.postSignup)
+	r.Get("/login", s.getLogin)
+	r.Post("/login", s.postLogin)
+	r.Get("/logout", s.logout)
+	r.Get("/banned", s.getBanned)
+	r.Get("/commands", s.getCommands)
+	r.With(s.Abuse.Throttle(abuse.Message)).Post("/appeal", s.postAppeal)
+	// Drafts of the guestbook message and of appeals, which are written
+	// logged out
+	r.With(utils.LimitBody(maxDraftSize)).Post("/drafts/{form}", s.saveDraft)
+	r.Get("/verify", s.verify)
+
+	// Route to display the application version (Git SHA), and more about
+	// the build as JSON
+	r.Get("/version", s.getVersion)
+	r.Get("/buildinfo", s.getBuildInfo)
+
+	// Serve static files from the ./assets directory. Fingerprinted links
+	// never change content, other requests are only reused for an hour.
+	r.With(fingerprintedAssets).Group(func(r chi.Router) {
+		utils.FileServer(r, "/assets", http.Dir("./assets"))
+	})
+
+	// Add CSRF protection middleware
+	csrfHandler := nosurf.New(r)
+	csrfHandler.ExemptGlobs("/hooks/*", "/hooks/custom/*")
+	// Apple posts the callback from its own site, and it changes nothing
+	csrfHandler.ExemptPath("/auth/apple/callback")
+	// Origins trusted for credentialed API calls still need a valid CSRF token
+	if cfg.API.CORSCredentials {
+		csrfHandler.SetIsAllowedOriginFunc(func(u *url.URL) bool {
+			return slices.Contains(cfg.API.CORSOrigins, u.Scheme+"://"+u.Host)
+		})
+	}
+	// Token-authenticated API calls carry no cookies, so there is nothing to forge
+	csrfHandler.ExemptFunc(func(r *http.Request) bool {
+		return strings.HasPrefix(r.URL.Path, "/api/") && strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+	})
+	csrfHandler.SetBaseCookie(http.Cookie{
+		HttpOnly: true,
+		Path:     "/",
+		Secure:   cfg.Production(),
+	})
+
+	// These run before the CSRF check, which reads form bodies
+	// RealIP: Takes the visitor's address from X-Forwarded-For when a
+	// trusted proxy sent it, so logs, limits and bans see the visitor
+	// RequestID: Tags each request with an X-Request-ID shown in logs and error pages
+	// Logger: Logs the start and end of each request
+	// Timeout: Bounds the whole request, including reading the body, except
+	// on the longRunning routes
+	// LimitBody: Rejects oversized bodies with a 413. Multipart forms carry
+	// uploads and get the upload limit.
+	return chi.Chain(
+		cfg.TrustedProxies.RealIP,
+		utils.RequestID,
+		middleware.Logger,
+		utils.Timeout(cfg.Limits.RequestTimeout, func(r *http.Request) bool {
+			return r.Method == http.MethodGet && longRunning[r.URL.Path]
+		}),
+		utils.LimitBodyFunc(func(r *http.Request) int64 {
+			ct := r.Header.Get("Content-Type")
+			if strings.HasPrefix(ct, "multipart/form-data") || strings.HasPrefix(ct, api.ChunkContentType) {
+				return cfg.Limits.MaxUploadSize
+			}
+			return cfg.Limits.MaxBodySize
+		}),
+	).Handler(csrfHandler)
+}
@@ -0,0 +1,295 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gighub/config"
+	"gighub/db"
+)
+
+// newTestServer serves the whole site from a fresh database, with an admin
+// login of adm:pw.
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("SESSION_SECRET", "test-secret")
+	t.Setenv("ADMIN_USERNAME", "adm")
+	t.Setenv("ADMIN_PASSWORD", "pw")
+	cfg, err := config.Load([]string{"-data-dir", dir, "-env-file", filepath.Join(dir, ".env")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, queries, err := db.Setup(cfg.DataDir, cfg.DBName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	s, err := New(cfg, conn, queries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Routes())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// browser keeps cookies like a browser, and doesn't follow redirects so
+// tests can see them.
+type browser struct {
+	t      *testing.T
+	ts     *httptest.Server
+	client *http.Client
+}
+
+func newBrowser(t *testing.T, ts *httptest.Server) *browser {
+	jar, _ := cookiejar.New(nil)
+	return &browser{t: t, ts: ts, client: &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (b *browser) do(req *http.Request) (*http.Response, string) {
+	b.t.Helper()
+	resp, err := b.client.Do(req)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func (b *browser) get(path string) (*http.Response, string) {
+	b.t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, b.ts.URL+path, nil)
+	return b.do(req)
+}
+
+// post submits a form the way a page on the site does, from the site's
+// own origin.
+func (b *browser) post(path string, form url.Values) (*http.Response, string) {
+	b.t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, b.ts.URL+path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Origin", "https://"+req.Host)
+	return b.do(req)
+}
+
+var csrfField = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// csrfToken returns the token in the form on the page at path.
+func (b *browser) csrfToken(path string) string {
+	b.t.Helper()
+	_, body := b.get(path)
+	m := csrfField.FindStringSubmatch(body)
+	if m == nil {
+		b.t.Fatalf("no CSRF token on %s", path)
+	}
+	return m[1]
+}
+
+// signUp creates an account, verified unless told otherwise.
+func (b *browser) signUp(s *Server, email, password string, verified bool) {
+	b.t.Helper()
+	resp, body := b.post("/signup", url.Values{"csrf_token": {b.csrfToken("/signup")}, "email": {email}, "password": {password}})
+	if resp.StatusCode != http.StatusOK {
+		b.t.Fatalf("signing up: %d %s", resp.StatusCode, body)
+	}
+	if verified {
+		if _, err := s.DB.Exec("UPDATE users SET verified_at = CURRENT_TIMESTAMP WHERE email = ?", email); err != nil {
+			b.t.Fatal(err)
+		}
+	}
+}
+
+func (b *browser) logIn(email, password string) *http.Response {
+	b.t.Helper()
+	resp, _ := b.post("/login", url.Values{"csrf_token": {b.csrfToken("/login")}, "email": {email}, "password": {password}})
+	return resp
+}
+
+func TestLoginSession(t *testing.T) {
+	s, ts := newTestServer(t)
+	b := newBrowser(t, ts)
+	b.signUp(s, "new@example.com", "hunter22", false)
+	b.signUp(s, "me@example.com", "hunter22", true)
+
+	if resp, _ := b.get("/account"); resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/login" {
+		t.Fatalf("logged out: got %d to %q, want a redirect to /login", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	for _, tt := range []struct {
+		name, email, password string
+		want                  int
+	}{
+		{"unknown address", "nobody@example.com", "hunter22", http.StatusUnauthorized},
+		{"wrong password", "me@example.com", "wrong", http.StatusUnauthorized},
+		{"unverified", "new@example.com", "hunter22", http.StatusUnauthorized},
+		{"right password", "me@example.com", "hunter22", http.StatusSeeOther},
+	} {
+		if resp := b.logIn(tt.email, tt.password); resp.StatusCode != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+	if resp, body := b.get("/account"); resp.StatusCode != http.StatusOK || !strings.Contains(body, "me@example.com") {
+		t.Fatalf("logged in: got %d, want the account page", resp.StatusCode)
+	}
+
+	b.get("/logout")
+	if resp, _ := b.get("/account"); resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("after logging out: got %d, want a redirect", resp.StatusCode)
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	s, ts := newTestServer(t)
+	b := newBrowser(t, ts)
+	b.signUp(s, "me@example.com", "hunter22", true)
+	form := url.Values{"email": {"me@example.com"}, "password": {"hunter22"}}
+
+	if resp, _ := b.post("/login", form); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("no token: got %d, want 400", resp.StatusCode)
+	}
+	// A token from someone else's session, as a forged form would carry
+	form.Set("csrf_token", newBrowser(t, ts).csrfToken("/login"))
+	if resp, _ := b.post("/login", form); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("another session's token: got %d, want 400", resp.StatusCode)
+	}
+	form.Set("csrf_token", b.csrfToken("/login"))
+	if resp, _ := b.post("/login", form); resp.StatusCode != http.StatusSeeOther {
+		t.Errorf("own token: got %d, want 303", resp.StatusCode)
+	}
+}
+
+func TestGuestbook(t *testing.T) {
+	s, ts := newTestServer(t)
+
+	visitor := newBrowser(t, ts)
+	if resp, _ := visitor.post("/guestbook", url.Values{"csrf_token": {visitor.csrfToken("/login")}, "message": {"hi"}}); resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/login" {
+		t.Errorf("logged out: got %d to %q, want a redirect to /login", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	b := newBrowser(t, ts)
+	b.signUp(s, "me@example.com", "hunter22", true)
+	b.logIn("me@example.com", "hunter22")
+	message := `<b>Hello</b> <script>alert(1)</script><a href="javascript:alert(1)">x</a>`
+	if resp, body := b.post("/guestbook", url.Values{"csrf_token": {b.csrfToken("/guestbook")}, "message": {message}}); resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("posting: got %d %s", resp.StatusCode, body)
+	}
+	_, body := b.get("/guestbook")
+	if !strings.Contains(body, "<b>Hello</b>") {
+		t.Error("the message isn't shown")
+	}
+	if strings.Contains(body, "alert(1)") {
+		t.Error("the message wasn't sanitized")
+	}
+}
+
+func TestAdminGuard(t *testing.T) {
+	_, ts := newTestServer(t)
+	for _, tt := range []struct {
+		name, user, password string
+		want                 int
+	}{
+		{"no login", "", "", http.StatusUnauthorized},
+		{"wrong password", "adm", "wrong", http.StatusUnauthorized},
+		{"wrong user", "root", "pw", http.StatusUnauthorized},
+		{"admin", "adm", "pw", http.StatusOK},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/admin", nil)
+		if tt.user != "" {
+			req.SetBasicAuth(tt.user, tt.password)
+		}
+		if resp, _ := newBrowser(t, ts).do(req); resp.StatusCode != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestAdminGuardUnconfigured(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.Config.Admin.Password = ""
+	ts := httptest.NewServer(s.Routes())
+	defer ts.Close()
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/admin", nil)
+	req.SetBasicAuth("adm", "")
+	if resp, _ := newBrowser(t, ts).do(req); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got %d, want 404 while no admin is configured", resp.StatusCode)
+	}
+}
+
+// api calls the JSON API with the browser's session, as a script on the
+// site does.
+func (b *browser) api(method, path, body string) (*http.Response, string) {
+	b.t.Helper()
+	token := b.csrfToken("/account")
+	req, _ := http.NewRequest(method, b.ts.URL+"/api/v1"+path, strings.NewReader(body))
+	req.Header.Set("X-CSRF-Token", token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://"+req.Host)
+	return b.do(req)
+}
+
+func TestUnsubscribeHook(t *testing.T) {
+	s, ts := newTestServer(t)
+	b := newBrowser(t, ts)
+	b.signUp(s, "me@example.com", "hunter22", true)
+	b.logIn("me@example.com", "hunter22")
+	other := newBrowser(t, ts)
+	other.signUp(s, "other@example.com", "hunter22", true)
+	other.logIn("other@example.com", "hunter22")
+
+	id := func(b *browser, path, body string) string {
+		t.Helper()
+		resp, out := b.api(http.MethodPost, path, body)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("POST %s: got %d %s", path, resp.StatusCode, out)
+		}
+		var created struct{ ID int64 }
+		json.Unmarshal([]byte(out), &created)
+		return strconv.FormatInt(created.ID, 10)
+	}
+	subscription := id(b, "/hooks", `{"target_url": "https://93.184.216.34/zap", "event": "guestbook.updated"}`)
+	othersSubscription := id(other, "/hooks", `{"target_url": "https://93.184.216.34/zap", "event": "guestbook.updated"}`)
+	registered := id(b, "/webhooks", `{"url": "https://93.184.216.34/hook", "events": ["guestbook.updated"]}`)
+	if resp, body := b.post("/webhooks", url.Values{"csrf_token": {b.csrfToken("/webhooks")}, "url": {"https://93.184.216.34/dashboard"}, "events": {"guestbook.updated"}}); resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("registering on the dashboard: got %d %s", resp.StatusCode, body)
+	}
+	var dashboard string
+	if err := s.DB.QueryRow("SELECT id FROM webhooks WHERE url = ?", "https://93.184.216.34/dashboard").Scan(&dashboard); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name, id string
+		want     int
+	}{
+		{"registered on the dashboard", dashboard, http.StatusNotFound},
+		{"registered through the API", registered, http.StatusNotFound},
+		{"someone else's subscription", othersSubscription, http.StatusNotFound},
+		{"not an id", "x", http.StatusNotFound},
+		{"own subscription", subscription, http.StatusNoContent},
+		{"already unsubscribed", subscription, http.StatusNotFound},
+	} {
+		if resp, body := b.api(http.MethodDelete, "/hooks/"+tt.id, ""); resp.StatusCode != tt.want {
+			t.Errorf("%s: got %d %s, want %d", tt.name, resp.StatusCode, body, tt.want)
+		}
+	}
+	var left int
+	s.DB.QueryRow("SELECT COUNT(*) FROM webhooks").Scan(&left)
+	if left != 3 {
+		t.Errorf("%d webhooks left, want 3", left)
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/apple"
+)
+
+// Apple accepts client secrets valid for up to six months. Each is signed
+// for a month and replaced during its last week.
+const (
+	appleSecretLifetime = 30 * 24 * time.Hour
+	appleSecretRenew    = 7 * 24 * time.Hour
+)
+
+// renewAppleSecret signs a new client secret for Sign in with Apple when
+// the current one is about to expire, and registers the provider with it.
+func (s *Server) renewAppleSecret() error {
+	cfg := s.Config.Apple
+	if !cfg.Enabled() {
+		return nil
+	}
+	s.authMu.RLock()
+	fresh := time.Until(s.appleSecretExpiry) > appleSecretRenew
+	s.authMu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+	if time.Until(s.appleSecretExpiry) > appleSecretRenew {
+		return nil
+	}
+	now := time.Now()
+	expiry := now.Add(appleSecretLifetime)
+	secret, err := apple.MakeSecret(apple.SecretParams{
+		PKCS8PrivateKey: cfg.PrivateKey,
+		TeamId:          cfg.TeamID,
+		KeyId:           cfg.KeyID,
+		ClientId:        cfg.ClientID,
+		Iat:             int(now.Unix()),
+		Exp:             int(expiry.Unix()),
+	})
+	if err != nil {
+		return err
+	}
+	goth.UseProviders(apple.New(cfg.ClientID, *secret, s.Config.BaseURL+"/auth/apple/callback", nil, apple.ScopeName, apple.ScopeEmail))
+	s.appleSecretExpiry = expiry
+	return nil
+}
+
+// withProviders runs an OAuth handler once the Apple client secret is
+// current. goth's provider registry isn't safe for concurrent use, so
+// handlers read it under authMu while a renewal replaces the provider.
+func (s *Server) withProviders(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.renewAppleSecret(); err != nil {
+			utils.ServerError(w, r, "Error signing the Sign in with Apple client secret: "+err.Error())
+			return
+		}
+		s.authMu.RLock()
+		defer s.authMu.RUnlock()
+		next(w, r)
+	}
+}
+
+// appleFormPost handles Apple's callback, which appleid.apple.com POSTs as
+// a form. Coming from another site, that POST carries none of our
+// SameSite=Lax cookies, the OAuth state included, so it is answered with a
+// page that posts the same form back from this site. That second POST has
+// the cookies and goes to gothic as it is, so the code and ID token never
+// end up in a URL or the access log.
+func (s *Server) appleFormPost(w http.ResponseWriter, r *http.Request) {
+	if chi.URLParam(r, "provider") != "apple" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("relayed") == "" {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		views.AppleRelay(r.PostForm).Render(r.Context(), w)
+		return
+	}
+	s.withProviders(s.oauthCallback)(w, r)
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"gighub/db"
+	"gighub/utils"
+	"gighub/views"
+)
+
+// audit records what an admin did to target in the audit log. Admins are
+// told apart by their basic auth username. A failure to record is
+// reported, but doesn't undo the action.
+func (s *Server) audit(r *http.Request, action, target, detail string) {
+	actor, _, _ := r.BasicAuth()
+	err := s.Queries.CreateAuditEntry(r.Context(), db.CreateAuditEntryParams{
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		Detail: detail,
+		Ip:     utils.ClientIP(r),
+	})
+	if err != nil {
+		s.Reporter.Error(r.Context(), fmt.Errorf("recording %s of %s in the audit log: %w", action, target, err))
+	}
+}
+
+func (s *Server) getAdminAudit(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.Queries.ListAuditLog(r.Context(), 200)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	views.AdminAudit(entries).Render(r.Context(), w)
+}
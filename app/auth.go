@@ -0,0 +1,358 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gighub/analytics"
+	"gighub/avatar"
+	"gighub/db"
+	"gighub/emails"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (s *Server) getSignup(w http.ResponseWriter, r *http.Request) {
+	s.rememberReferral(r)
+	views.Signup(s.settings(r).SignupsOpen, s.Config.Apple.Enabled()).Render(r.Context(), w)
+}
+
+func (s *Server) postSignup(w http.ResponseWriter, r *http.Request) {
+	if !s.settings(r).SignupsOpen {
+		http.Error(w, "Signups are closed", http.StatusForbidden)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	email := r.FormValue("email")
+	password := r.FormValue("password")
+	banned, err := s.deviceBanned(r)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if banned {
+		http.Error(w, "Signups from this device are blocked", http.StatusForbidden)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		utils.ServerError(w, r, "Server error")
+		return
+	}
+
+	// Generate verification token
+	tokenBytes := make([]byte, 16)
+	rand.Read(tokenBytes)
+	token := hex.EncodeToString(tokenBytes)
+
+	user, err := s.Queries.CreateUser(r.Context(), db.CreateUserParams{
+		Email:             email,
+		PasswordHash:      string(hashedPassword),
+		VerificationToken: sql.NullString{String: token, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error creating user: %v", err)
+		utils.ServerError(w, r, "Error creating user")
+		return
+	}
+	s.attributeReferral(r, user.ID)
+	s.recordSighting(w, r, user.ID)
+	s.Analytics.Event(r, analytics.Signup)
+
+	// Send verification email asynchronously
+	subject, body := s.Emails.Render(r.Context(), emails.Verify, map[string]string{"Link": s.verifyLink(token)})
+	s.Mailer.SendAsync(r.Context(), email, subject, body)
+
+	w.Write([]byte("User created! Please check your email to verify your account."))
+}
+
+func (s *Server) verifyLink(token string) string {
+	return fmt.Sprintf("%s/verify?token=%s", s.Config.BaseURL, token)
+}
+
+// remindUnverifiedUsers sends a second verification email to accounts
+// still unverified after Signup.RemindAfter. Each account gets one.
+func (s *Server) remindUnverifiedUsers(ctx context.Context) error {
+	if !s.Config.SMTP.Enabled() {
+		return nil
+	}
+	cutoff := time.Now().UTC().Add(-s.Config.Signup.RemindAfter)
+	users, err := s.Queries.ListUnverifiedUsersToRemind(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	if err != nil {
+		return fmt.Errorf("error listing unverified users: %w", err)
+	}
+	deadline := time.Now().Add(s.Config.Signup.DeleteAfter - s.Config.Signup.RemindAfter)
+	for _, u := range users {
+		subject, body := s.Emails.Render(ctx, emails.VerifyReminder, map[string]string{
+			"Link":     s.verifyLink(u.VerificationToken.String),
+			"Deadline": deadline.Format("January 2"),
+		})
+		if err := s.Mailer.Send(ctx, u.Email, subject, body); err != nil {
+			return fmt.Errorf("error reminding %s: %w", u.Email, err)
+		}
+		if err := s.Queries.MarkVerificationReminded(ctx, db.MarkVerificationRemindedParams{
+			VerificationRemindedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:                     u.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneUnverifiedUsers deletes accounts never verified within
+// Signup.DeleteAfter, so the address can sign up again. Their rows in other
+// tables go with them; their uploads and avatar are removed from Storage.
+func (s *Server) pruneUnverifiedUsers(ctx context.Context) error {
+	cutoff := time.Now().UTC().Add(-s.Config.Signup.DeleteAfter)
+	users, err := s.Queries.ListUnverifiedUsersBefore(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	if err != nil {
+		return fmt.Errorf("error listing unverified users: %w", err)
+	}
+	var deleted int
+	for _, u := range users {
+		// Read before the rows go with the user
+		keys, err := s.Queries.ListUploadStorageKeys(ctx, u.ID)
+		if err != nil {
+			return fmt.Errorf("error listing uploads of user %d: %w", u.ID, err)
+		}
+		// Verified since it was listed, the account stays
+		n, err := s.Queries.DeleteUnverifiedUser(ctx, u.ID)
+		if err != nil {
+			return fmt.Errorf("error deleting unverified user %d: %w", u.ID, err)
+		}
+		if n == 0 {
+			continue
+		}
+		deleted++
+		for _, key := range keys {
+			if err := s.Storage.Delete(ctx, key.String); err != nil {
+				log.Printf("Error deleting upload of user %d: %v", u.ID, err)
+			}
+		}
+		if u.Avatar.Valid {
+			for _, size := range avatar.Sizes {
+				if err := s.Storage.Delete(ctx, avatarKey(u.ID, u.Avatar.String, size)); err != nil {
+					log.Printf("Error deleting avatar of user %d: %v", u.ID, err)
+				}
+			}
+		}
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d unverified accounts", deleted)
+	}
+	return nil
+}
+
+func (s *Server) getLogin(w http.ResponseWriter, r *http.Request) {
+	views.Login(s.Config.Apple.Enabled()).Render(r.Context(), w)
+}
+
+func (s *Server) postLogin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	email := r.FormValue("email")
+	password := r.FormValue("password")
+
+	user, err := s.Queries.GetUserByEmail(r.Context(), email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		} else {
+			utils.ServerError(w, r, "Database error")
+		}
+		return
+	}
+
+	if !user.VerifiedAt.Valid {
+		http.Error(w, "Please verify your email before logging in.", http.StatusUnauthorized)
+		return
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		return
+	}
+	if s.refuseBanned(w, r, user.ID) {
+		return
+	}
+	s.recordSighting(w, r, user.ID)
+
+	// Login successful
+	if err := s.Sessions.RenewToken(r.Context()); err != nil {
+		utils.ServerError(w, r, "Server error")
+		return
+	}
+	s.Sessions.Put(r.Context(), "userID", user.ID)
+	s.Sessions.Put(r.Context(), "locale", user.Locale)
+	s.Analytics.Event(r, analytics.Login)
+
+	http.Redirect(w, r, "/guestbook", http.StatusSeeOther)
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	if err := s.Sessions.Destroy(r.Context()); err != nil {
+		utils.ServerError(w, r, "Server error")
+		return
+	}
+	// Redirect to home page after logout
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) verify(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Missing token", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := s.Queries.VerifyUser(r.Context(), sql.NullString{String: token, Valid: true})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		} else {
+			log.Printf("Verification error: %v", err)
+			utils.ServerError(w, r, "Server error")
+		}
+		return
+	}
+	s.Analytics.Event(r, analytics.Verify)
+	s.qualifyReferral(r, userID)
+
+	w.Write([]byte("Email verified successfully! You can now login."))
+}
+
+func (s *Server) oauthCallback(w http.ResponseWriter, r *http.Request) {
+	gUser, err := gothic.CompleteUserAuth(w, r)
+	if err != nil {
+		utils.ServerError(w, r, err.Error())
+		return
+	}
+	if gUser.UserID == "" {
+		http.Error(w, "The provider didn't say who you are", http.StatusBadRequest)
+		return
+	}
+
+	// Accounts are found by the provider's ID for the person. The email
+	// address, as the provider vouches for it, only links one the first
+	// time they sign in with the provider.
+	identity := db.GetUserIdentityParams{Provider: gUser.Provider, Subject: gUser.UserID}
+	var user db.User
+	userID, err := s.Queries.GetUserIdentity(r.Context(), identity)
+	if err == nil {
+		user, err = s.Queries.GetUser(r.Context(), userID)
+		if err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+	} else if err != sql.ErrNoRows {
+		utils.ServerError(w, r, "Database error")
+		return
+	} else {
+		var ok bool
+		if user, ok = s.oauthAccount(w, r, gUser); !ok {
+			return
+		}
+		if err := s.Queries.CreateUserIdentity(r.Context(), db.CreateUserIdentityParams{
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			UserID:   user.ID,
+		}); err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+	}
+
+	if s.refuseBanned(w, r, user.ID) {
+		return
+	}
+	s.recordSighting(w, r, user.ID)
+
+	// Log the user in
+	if err := s.Sessions.RenewToken(r.Context()); err != nil {
+		utils.ServerError(w, r, "Server error")
+		return
+	}
+	s.Sessions.Put(r.Context(), "userID", user.ID)
+	s.Sessions.Put(r.Context(), "locale", user.Locale)
+	s.Analytics.Event(r, analytics.Login)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// oauthAccount finds the account with the email address the provider gave,
+// verifying it if it wasn't yet, or creates one. It writes an error response
+// and returns false when there is none and none can be created.
+func (s *Server) oauthAccount(w http.ResponseWriter, r *http.Request, gUser goth.User) (db.User, bool) {
+	if gUser.Email == "" {
+		http.Error(w, "Your account didn't share an email address. Sign in again and allow sharing it, or hide it behind a relay address.", http.StatusBadRequest)
+		return db.User{}, false
+	}
+	user, err := s.Queries.GetUserByEmail(r.Context(), gUser.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if !s.settings(r).SignupsOpen {
+				http.Error(w, "Signups are closed, and there is no account for "+gUser.Email, http.StatusForbidden)
+				return db.User{}, false
+			}
+			banned, err := s.deviceBanned(r)
+			if err != nil {
+				utils.ServerError(w, r, "Database error")
+				return db.User{}, false
+			}
+			if banned {
+				http.Error(w, "Signups from this device are blocked", http.StatusForbidden)
+				return db.User{}, false
+			}
+			// Create new user with random password and token
+			pwBytes := make([]byte, 32)
+			rand.Read(pwBytes)
+			pwHash, _ := bcrypt.GenerateFromPassword(pwBytes, bcrypt.DefaultCost)
+
+			tokenBytes := make([]byte, 16)
+			rand.Read(tokenBytes)
+			token := hex.EncodeToString(tokenBytes)
+
+			user, err = s.Queries.CreateUser(r.Context(), db.CreateUserParams{
+				Email:             gUser.Email,
+				PasswordHash:      string(pwHash),
+				VerificationToken: sql.NullString{String: token, Valid: true},
+			})
+			if err != nil {
+				utils.ServerError(w, r, "Failed to create user")
+				return db.User{}, false
+			}
+
+			// Mark as verified immediately since the provider checked the address
+			s.Queries.VerifyUser(r.Context(), sql.NullString{String: token, Valid: true})
+			s.Analytics.Event(r, analytics.Signup)
+			s.Analytics.Event(r, analytics.Verify)
+			s.attributeReferral(r, user.ID)
+			s.qualifyReferral(r, user.ID)
+		} else {
+			utils.ServerError(w, r, "Database error")
+			return db.User{}, false
+		}
+	} else if !user.VerifiedAt.Valid {
+		// If user exists but wasn't verified, verify them now since we trust the provider
+		s.Queries.VerifyUser(r.Context(), user.VerificationToken)
+		s.qualifyReferral(r, user.ID)
+	}
+	return user, true
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"gighub/avatar"
+	"gighub/db"
+	"gighub/storage"
+	"gighub/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxAvatarSize bounds uploaded pictures before processing.
+const maxAvatarSize = 5 << 20
+
+// identicon is the avatar version of users without an upload.
+const identicon = "identicon"
+
+// avatarURL links to a user's avatar at one of avatar.Sizes, through the
+// CDN if there is one. The URL changes whenever the picture does, so it can
+// be cached forever.
+func (s *Server) avatarURL(userID int64, version sql.NullString, size int) string {
+	v := identicon
+	if version.Valid {
+		v = version.String
+	}
+	return fmt.Sprintf("%s/avatars/%d/%s/%d.png", s.Config.Storage.CDNURL, userID, v, size)
+}
+
+func avatarKey(userID int64, version string, size int) string {
+	return fmt.Sprintf("avatars/%d/%s/%d.png", userID, version, size)
+}
+
+func (s *Server) getAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	size, serr := strconv.Atoi(chi.URLParam(r, "size"))
+	if err != nil || serr != nil || !avatar.ValidSize(size) {
+		http.NotFound(w, r)
+		return
+	}
+	version := chi.URLParam(r, "version")
+	if version == identicon {
+		png, err := avatar.Identicon(strconv.FormatInt(userID, 10), size)
+		if err != nil {
+			utils.ServerError(w, r, "Error drawing avatar")
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Write(png)
+		return
+	}
+	f, obj, err := s.Storage.Open(r.Context(), avatarKey(userID, version, size))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+		} else {
+			log.Printf("Error opening avatar: %v", err)
+			utils.ServerError(w, r, "Storage error")
+		}
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if obj.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	}
+	io.Copy(w, f)
+}
+
+func (s *Server) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := s.userID(r)
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		if utils.BodyErrorStatus(err) == http.StatusRequestEntityTooLarge {
+			s.renderAccount(w, r, userID, "", "Pictures can be at most 5 MB.")
+		} else {
+			s.renderAccount(w, r, userID, "", "Choose a picture to upload.")
+		}
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxAvatarSize+1))
+	if err != nil {
+		utils.ServerError(w, r, "Error reading upload")
+		return
+	}
+	if len(data) > maxAvatarSize {
+		s.renderAccount(w, r, userID, "", "Pictures can be at most 5 MB.")
+		return
+	}
+	images, err := avatar.Process(data)
+	if err != nil {
+		s.renderAccount(w, r, userID, "", err.Error())
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	version := hex.EncodeToString(sum[:8])
+	for size, png := range images {
+		if err := s.Storage.Put(r.Context(), avatarKey(userID, version, size), bytes.NewReader(png), int64(len(png)), "image/png"); err != nil {
+			log.Printf("Error storing avatar: %v", err)
+			utils.ServerError(w, r, "Storage error")
+			return
+		}
+	}
+	s.replaceAvatar(w, r, userID, sql.NullString{String: version, Valid: true})
+}
+
+func (s *Server) deleteAvatar(w http.ResponseWriter, r *http.Request) {
+	s.replaceAvatar(w, r, s.userID(r), sql.NullString{})
+}
+
+// replaceAvatar points the user at a new avatar version, or back at the
+// identicon, and removes the old files.
+func (s *Server) replaceAvatar(w http.ResponseWriter, r *http.Request, userID int64, version sql.NullString) {
+	user, err := s.Queries.GetUser(r.Context(), userID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if err := s.Queries.SetUserAvatar(r.Context(), db.SetUserAvatarParams{Avatar: version, ID: userID}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if user.Avatar.Valid && user.Avatar != version {
+		var urls []string
+		for _, size := range avatar.Sizes {
+			if err := s.Storage.Delete(r.Context(), avatarKey(userID, user.Avatar.String, size)); err != nil {
+				log.Printf("Error deleting old avatar: %v", err)
+			}
+			urls = append(urls, s.avatarURL(userID, user.Avatar, size))
+		}
+		// The old URLs were cached as immutable, so the CDN would keep
+		// serving the picture the user just replaced
+		if err := s.CDN.Purge(r.Context(), urls...); err != nil {
+			log.Printf("Error purging old avatar: %v", err)
+		}
+	}
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
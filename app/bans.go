@@ -0,0 +1,65 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) getAdminBlocks(w http.ResponseWriter, r *http.Request) {
+	s.renderAdminBlocks(w, r, "")
+}
+
+func (s *Server) renderAdminBlocks(w http.ResponseWriter, r *http.Request, formError string) {
+	bans, err := s.Queries.ListActiveBans(r.Context(), time.Now().UTC())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	views.AdminBlocks(bans, s.Abuse.Limits.All(), formError).Render(r.Context(), w)
+}
+
+func (s *Server) createBan(w http.ResponseWriter, r *http.Request) {
+	ip := strings.TrimSpace(r.FormValue("ip"))
+	if net.ParseIP(ip) == nil {
+		s.renderAdminBlocks(w, r, ip+" is not an IP address.")
+		return
+	}
+	d, err := time.ParseDuration(r.FormValue("duration"))
+	if err != nil || d <= 0 {
+		s.renderAdminBlocks(w, r, "Duration must look like 30m or 24h.")
+		return
+	}
+	reason := strings.TrimSpace(r.FormValue("reason"))
+	if reason == "" {
+		reason = "Blocked by an admin"
+	}
+	if err := s.Abuse.Ban(r.Context(), ip, reason, d); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/admin/blocks", http.StatusSeeOther)
+}
+
+func (s *Server) deleteBan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.Abuse.Lift(r.Context(), id); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/admin/blocks", http.StatusSeeOther)
+}
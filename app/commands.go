@@ -0,0 +1,106 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"gighub/utils"
+
+	"github.com/justinas/nosurf"
+)
+
+// command is an entry of the command palette (Ctrl/Cmd-K): a page to go
+// to, or with Method "post" an action to submit.
+type command struct {
+	Title  string `json:"title"`
+	Group  string `json:"group"`
+	URL    string `json:"url"`
+	Method string `json:"method,omitempty"`
+}
+
+// adminCommands are the admin pages, offered on /admin/commands only.
+var adminCommands = []command{
+	{"Dashboard", "Admin", "/admin", ""},
+	{"Analytics", "Admin", "/admin/analytics", ""},
+	{"Announcements", "Admin", "/admin/announcements", ""},
+	{"Audit Log", "Admin", "/admin/audit", ""},
+	{"Background Jobs", "Admin", "/admin/jobs", ""},
+	{"Banned Users", "Admin", "/admin/bans", ""},
+	{"Blocked Addresses", "Admin", "/admin/blocks", ""},
+	{"Database Queries", "Admin", "/admin/queries", ""},
+	{"Emails", "Admin", "/admin/emails", ""},
+	{"Email Templates", "Admin", "/admin/emails/templates", ""},
+	{"Experiments", "Admin", "/admin/experiments", ""},
+	{"Feature Flags", "Admin", "/admin/flags", ""},
+	{"Moderation", "Admin", "/admin/moderation", ""},
+	{"Settings", "Admin", "/admin/settings", ""},
+	{"SQL Console", "Admin", "/admin/console", ""},
+}
+
+// userCommands lists the pages, webhooks and actions open to the visitor:
+// signing up or in when logged out, their own things when logged in.
+func (s *Server) userCommands(r *http.Request) ([]command, error) {
+	userID := s.userID(r)
+	if userID == 0 {
+		return []command{
+			{"Home", "Pages", "/", ""},
+			{"Log in", "Pages", "/login", ""},
+			{"Sign up", "Pages", "/signup", ""},
+			{"Status", "Pages", "/status", ""},
+			{"API Docs", "Pages", "/api/v1/docs", ""},
+		}, nil
+	}
+	list := []command{
+		{"Home", "Pages", "/", ""},
+		{"Guestbook", "Pages", "/guestbook", ""},
+		{"My Account", "Pages", "/account", ""},
+		{"Webhooks", "Pages", "/webhooks", ""},
+		{"Referrals", "Pages", "/referrals", ""},
+		{"Status", "Pages", "/status", ""},
+		{"API Docs", "Pages", "/api/v1/docs", ""},
+	}
+	hooks, err := s.Queries.ListWebhooksByUser(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range hooks {
+		list = append(list, command{hook.Url, "Webhooks", "/webhooks/" + strconv.FormatInt(hook.ID, 10), ""})
+	}
+	return append(list,
+		command{"Sign out other sessions", "Actions", "/account/sessions/others/delete", "post"},
+		command{"Log out", "Actions", "/logout", ""},
+	), nil
+}
+
+// getCommands serves the command palette's entries, with the CSRF token
+// its actions are posted with.
+func (s *Server) getCommands(w http.ResponseWriter, r *http.Request) {
+	list, err := s.userCommands(r)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	writeCommands(w, r, list)
+}
+
+// getAdminCommands adds the admin pages to the visitor's commands. The
+// palette asks here on admin pages, where the browser sends the admin
+// credentials.
+func (s *Server) getAdminCommands(w http.ResponseWriter, r *http.Request) {
+	list, err := s.userCommands(r)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	writeCommands(w, r, append(adminCommands, list...))
+}
+
+func writeCommands(w http.ResponseWriter, r *http.Request, list []command) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(struct {
+		CSRFToken string    `json:"csrf_token"`
+		Commands  []command `json:"commands"`
+	}{nosurf.Token(r), list})
+}
@@ -0,0 +1,243 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/views"
+)
+
+// The SQL console at /admin/console runs one statement at a time against
+// the live database. Statements are read-only unless write mode is picked,
+// and writes only run once the admin has seen their query plan and
+// confirmed. Every statement that runs is recorded in the audit log.
+const (
+	consoleTimeout      = 10 * time.Second
+	consoleDefaultLimit = 100
+	consoleMaxLimit     = 1000
+)
+
+func (s *Server) getConsole(w http.ResponseWriter, r *http.Request) {
+	views.AdminConsole(views.ConsoleForm{Limit: consoleDefaultLimit}, nil, "").Render(r.Context(), w)
+}
+
+func (s *Server) postConsole(w http.ResponseWriter, r *http.Request) {
+	form := views.ConsoleForm{
+		Query:  strings.TrimSpace(r.FormValue("query")),
+		Params: strings.ReplaceAll(r.FormValue("params"), "\r\n", "\n"),
+		Write:  r.FormValue("mode") == "write",
+	}
+	render := func(res *views.ConsoleResult, formError string) {
+		if formError != "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		views.AdminConsole(form, res, formError).Render(r.Context(), w)
+	}
+	var err error
+	if form.Limit, err = strconv.Atoi(r.FormValue("limit")); err != nil || form.Limit <= 0 || form.Limit > consoleMaxLimit {
+		form.Limit = consoleDefaultLimit
+		render(nil, fmt.Sprintf("The row limit must be between 1 and %d.", consoleMaxLimit))
+		return
+	}
+	if form.Query == "" {
+		render(nil, "Enter a statement to run.")
+		return
+	}
+	if !singleStatement(form.Query) {
+		render(nil, "Run one statement at a time.")
+		return
+	}
+	args := consoleArgs(form.Params)
+
+	ctx, cancel := context.WithTimeout(r.Context(), consoleTimeout)
+	defer cancel()
+	switch {
+	case r.FormValue("action") == "explain", form.Write && r.FormValue("confirm") != "yes":
+		// Writes are previewed with their plan before they can run
+		res, err := s.consoleRead(ctx, "EXPLAIN QUERY PLAN "+form.Query, args, form.Limit)
+		if err != nil {
+			render(nil, err.Error())
+			return
+		}
+		res.Plan = true
+		form.Confirm = form.Write && r.FormValue("action") != "explain"
+		render(res, "")
+	case form.Write:
+		res, err := s.consoleWrite(ctx, form.Query, args)
+		s.audit(r, "sql.write", "database", consoleDetail(form, res, err))
+		if err != nil {
+			render(nil, err.Error())
+			return
+		}
+		render(res, "")
+	default:
+		res, err := s.consoleRead(ctx, form.Query, args, form.Limit)
+		s.audit(r, "sql.read", "database", consoleDetail(form, res, err))
+		if err != nil {
+			render(nil, err.Error())
+			return
+		}
+		render(res, "")
+	}
+}
+
+// consoleRead runs query on a connection that refuses writes, and returns
+// up to limit rows.
+func (s *Server) consoleRead(ctx context.Context, query string, args []any, limit int) (*views.ConsoleResult, error) {
+	conn, err := s.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return nil, err
+	}
+	// The connection goes back to the pool for the app's writes. If it
+	// can't be made writable again, it's thrown away instead.
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "PRAGMA query_only = OFF"); err != nil {
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := &views.ConsoleResult{}
+	if res.Columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(res.Columns))
+	dest := make([]any, len(res.Columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(res.Rows) == limit {
+			res.Truncated = true
+			break
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			fields[i] = "NULL"
+			if v.Valid {
+				fields[i] = v.String
+			}
+		}
+		res.Rows = append(res.Rows, fields)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	res.Elapsed = time.Since(start)
+	return res, nil
+}
+
+// consoleWrite runs query in a transaction, which is rolled back if it
+// fails or runs out of time.
+func (s *Server) consoleWrite(ctx context.Context, query string, args []any) (*views.ConsoleResult, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	start := time.Now()
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &views.ConsoleResult{Changed: changed, Elapsed: time.Since(start)}, nil
+}
+
+// consoleArgs binds each line of params to the next ? in the statement.
+// Empty lines are empty strings, and NULL is NULL.
+func consoleArgs(params string) []any {
+	params = strings.TrimRight(params, "\n")
+	if params == "" {
+		return nil
+	}
+	var args []any
+	for _, p := range strings.Split(params, "\n") {
+		if p == "NULL" {
+			args = append(args, nil)
+		} else {
+			args = append(args, p)
+		}
+	}
+	return args
+}
+
+// consoleDetail describes a statement for the audit log, with how it went.
+func consoleDetail(form views.ConsoleForm, res *views.ConsoleResult, err error) string {
+	detail := form.Query
+	if form.Params != "" {
+		detail += fmt.Sprintf("\nparams: %q", strings.Split(strings.TrimRight(form.Params, "\n"), "\n"))
+	}
+	switch {
+	case err != nil:
+		detail += "\nfailed: " + err.Error()
+	case form.Write:
+		detail += fmt.Sprintf("\n%d rows changed", res.Changed)
+	default:
+		detail += fmt.Sprintf("\n%d rows", len(res.Rows))
+	}
+	return detail
+}
+
+// singleStatement reports whether nothing but comments follows the first
+// statement in query, skipping semicolons in quotes and comments. Read-only
+// mode can be turned off with a PRAGMA, so a second statement can't be
+// allowed to follow the first.
+func singleStatement(query string) bool {
+	end := -1
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := strings.IndexByte(query[i+1:], closing)
+			if j < 0 {
+				return false
+			}
+			i += j + 1
+		case strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				return true
+			}
+			i += j
+		case strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				return false
+			}
+			i += j + 3
+		case c == ';':
+			end = i
+		case end >= 0 && c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			return false
+		}
+	}
+	return true
+}
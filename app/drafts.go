@@ -0,0 +1,94 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gighub/db"
+	"gighub/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Forms whose text is autosaved as a draft while it's written.
+const (
+	draftGuestbook = "guestbook"
+	draftAppeal    = "appeal"
+)
+
+// maxDraftSize caps a saved draft, above what any of the forms accept.
+const maxDraftSize = 16 << 10
+
+// draftTTL is how long a draft nobody came back to is kept.
+const draftTTL = 30 * 24 * time.Hour
+
+// draftOwner is who the draft of form belongs to: the logged-in user, or
+// for an appeal the user the ban stopped, who isn't logged in. It is 0 for
+// unknown forms.
+func (s *Server) draftOwner(r *http.Request, form string) int64 {
+	switch form {
+	case draftGuestbook:
+		return s.userID(r)
+	case draftAppeal:
+		return s.Sessions.GetInt64(r.Context(), "bannedUserID")
+	}
+	return 0
+}
+
+// saveDraft stores what has been written in a form so far; drafts.js posts
+// here every few seconds while the text changes. An empty body discards
+// the draft.
+func (s *Server) saveDraft(w http.ResponseWriter, r *http.Request) {
+	form := chi.URLParam(r, "form")
+	userID := s.draftOwner(r, form)
+	if userID == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	body := r.FormValue("body")
+	var err error
+	if strings.TrimSpace(body) == "" {
+		err = s.Queries.DeleteDraft(r.Context(), db.DeleteDraftParams{UserID: userID, Form: form})
+	} else {
+		err = s.Queries.SaveDraft(r.Context(), db.SaveDraftParams{
+			UserID:    userID,
+			Form:      form,
+			Body:      body,
+			UpdatedAt: time.Now().UTC(),
+		})
+	}
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// draft returns the saved draft of form, to fill the form back in, or ""
+// if there is none.
+func (s *Server) draft(r *http.Request, form string) string {
+	userID := s.draftOwner(r, form)
+	if userID == 0 {
+		return ""
+	}
+	body, err := s.Queries.GetDraft(r.Context(), db.GetDraftParams{UserID: userID, Form: form})
+	if err != nil && err != sql.ErrNoRows {
+		s.Reporter.Error(r.Context(), fmt.Errorf("loading the %s draft of user %d: %w", form, userID, err))
+	}
+	return body
+}
+
+// discardDraft removes the draft of a form once it was sent. Failures are
+// reported; the draft then expires after draftTTL.
+func (s *Server) discardDraft(r *http.Request, form string) {
+	userID := s.draftOwner(r, form)
+	if userID == 0 {
+		return
+	}
+	if err := s.Queries.DeleteDraft(r.Context(), db.DeleteDraftParams{UserID: userID, Form: form}); err != nil {
+		s.Reporter.Error(r.Context(), fmt.Errorf("discarding the %s draft of user %d: %w", form, userID, err))
+	}
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"gighub/emails"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) getAdminEmailTemplates(w http.ResponseWriter, r *http.Request) {
+	list, changed, err := s.Emails.List(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	views.AdminEmailTemplates(list, changed).Render(r.Context(), w)
+}
+
+func (s *Server) getAdminEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if _, ok := emails.Lookup(name); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	t, changed, err := s.Emails.Get(r.Context(), name)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.renderEmailTemplate(w, r, views.EmailTemplateForm{
+		Template: t,
+		Changed:  !changed.IsZero(),
+		TestTo:   s.Config.Admin.AlertEmail,
+	})
+}
+
+// renderEmailTemplate shows the editor, with the built-in wording next to
+// the form and a preview of the wording in it.
+func (s *Server) renderEmailTemplate(w http.ResponseWriter, r *http.Request, form views.EmailTemplateForm) {
+	form.Builtin, _ = emails.Lookup(form.Template.Name)
+	if form.Error != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		form.Preview.Subject, form.Preview.Body = s.exampleEmail(r, form.Template)
+		// The mailer adds the footer to every email
+		if set := s.settings(r); set.EmailFooter != "" {
+			form.Preview.Body += "\n\n-- \n" + set.EmailFooter
+		}
+	}
+	views.AdminEmailTemplate(form).Render(r.Context(), w)
+}
+
+// exampleEmail renders a validated template with the example values and
+// the real site name.
+func (s *Server) exampleEmail(r *http.Request, t emails.Template) (subject, body string) {
+	vars := t.Examples()
+	vars["SiteName"] = s.settings(r).SiteName
+	subject, body, _ = t.Render(vars)
+	return subject, body
+}
+
+// postAdminEmailTemplate previews, test sends or saves the wording in the
+// editor, as the button pressed asks. Previews and test sends use the
+// example values and don't save anything.
+func (s *Server) postAdminEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	t, ok := emails.Lookup(chi.URLParam(r, "name"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, changed, err := s.Emails.Get(r.Context(), t.Name)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	t.Subject = strings.TrimSpace(r.FormValue("subject"))
+	t.Body = strings.TrimSpace(strings.ReplaceAll(r.FormValue("body"), "\r\n", "\n"))
+	form := views.EmailTemplateForm{
+		Template: t,
+		Changed:  !changed.IsZero(),
+		TestTo:   strings.TrimSpace(r.FormValue("test_to")),
+	}
+	if err := t.Validate(); err != nil {
+		form.Error = err.Error()
+		s.renderEmailTemplate(w, r, form)
+		return
+	}
+
+	switch r.FormValue("action") {
+	case "preview":
+		s.renderEmailTemplate(w, r, form)
+	case "test":
+		if form.TestTo == "" {
+			form.Error = "Enter an address to send the test to."
+			s.renderEmailTemplate(w, r, form)
+			return
+		}
+		subject, body := s.exampleEmail(r, t)
+		if err := s.Mailer.Send(r.Context(), form.TestTo, "[Test] "+subject, body); err != nil {
+			form.Error = "The test couldn't be sent: " + err.Error()
+			s.renderEmailTemplate(w, r, form)
+			return
+		}
+		s.audit(r, "email_template.test", t.Name, form.TestTo)
+		form.Notice = "Sent a test to " + form.TestTo + "."
+		s.renderEmailTemplate(w, r, form)
+	default:
+		if err := s.Emails.Save(r.Context(), t); err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+		s.audit(r, "email_template.save", t.Name, t.Subject)
+		http.Redirect(w, r, "/admin/emails/templates", http.StatusSeeOther)
+	}
+}
+
+func (s *Server) resetAdminEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if _, ok := emails.Lookup(name); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.Emails.Reset(r.Context(), name); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.audit(r, "email_template.reset", name, "")
+	http.Redirect(w, r, "/admin/emails/templates", http.StatusSeeOther)
+}
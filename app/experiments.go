@@ -0,0 +1,46 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"gighub/db"
+	"gighub/experiments"
+	"gighub/utils"
+	"gighub/views"
+)
+
+// getAdminExperiments compares the variants of each running experiment,
+// for the last 30 days unless from and to pick other days.
+func (s *Server) getAdminExperiments(w http.ResponseWriter, r *http.Request) {
+	from, to, _, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	var reports []views.ExperimentReport
+	for _, e := range experiments.All {
+		rows, err := s.Queries.ListExperimentResults(r.Context(), db.ListExperimentResultsParams{
+			Experiment: e.Name,
+			Day:        from.Format(time.DateOnly),
+			Day_2:      to.Format(time.DateOnly),
+		})
+		if err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+		// Variants in the order they're defined, the control first, and
+		// ones nobody saw yet at zero
+		report := views.ExperimentReport{Name: e.Name, Goal: e.Goal}
+		for _, variant := range e.Variants {
+			result := views.VariantResult{Name: variant}
+			for _, row := range rows {
+				if row.Variant == variant {
+					result.Visitors, result.Conversions = row.Visitors, row.Conversions
+				}
+			}
+			report.Variants = append(report.Variants, result)
+		}
+		reports = append(reports, report)
+	}
+	views.AdminExperiments(from, to, reports).Render(r.Context(), w)
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gighub/db"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// flagName keeps flag names usable as plain identifiers in code and URLs.
+var flagName = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+func (s *Server) getAdminFlags(w http.ResponseWriter, r *http.Request) {
+	s.renderAdminFlags(w, r, "")
+}
+
+func (s *Server) renderAdminFlags(w http.ResponseWriter, r *http.Request, formError string) {
+	list, err := s.Queries.ListFeatureFlags(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	overrides, err := s.Queries.ListFeatureFlagOverrides(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	views.AdminFlags(list, overrides, formError).Render(r.Context(), w)
+}
+
+func (s *Server) createFlag(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.FormValue("name"))
+	if !flagName.MatchString(name) {
+		s.renderAdminFlags(w, r, "Flag names use lowercase letters, digits and underscores.")
+		return
+	}
+	n, err := s.Queries.CreateFeatureFlag(r.Context(), db.CreateFeatureFlagParams{
+		Name:        name,
+		Description: strings.TrimSpace(r.FormValue("description")),
+	})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if n == 0 {
+		s.renderAdminFlags(w, r, "A flag named "+name+" already exists.")
+		return
+	}
+	s.flagsChanged(r.Context())
+	http.Redirect(w, r, "/admin/flags", http.StatusSeeOther)
+}
+
+func (s *Server) updateFlag(w http.ResponseWriter, r *http.Request) {
+	percent, err := strconv.ParseInt(r.FormValue("rollout_percent"), 10, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		s.renderAdminFlags(w, r, "Rollout must be a percentage from 0 to 100.")
+		return
+	}
+	n, err := s.Queries.UpdateFeatureFlag(r.Context(), db.UpdateFeatureFlagParams{
+		Enabled:        r.FormValue("enabled") == "on",
+		RolloutPercent: percent,
+		Name:           chi.URLParam(r, "name"),
+	})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if n == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	s.flagsChanged(r.Context())
+	http.Redirect(w, r, "/admin/flags", http.StatusSeeOther)
+}
+
+func (s *Server) deleteFlag(w http.ResponseWriter, r *http.Request) {
+	if err := s.Queries.DeleteFeatureFlag(r.Context(), chi.URLParam(r, "name")); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.flagsChanged(r.Context())
+	http.Redirect(w, r, "/admin/flags", http.StatusSeeOther)
+}
+
+// setFlagOverride turns a flag on or off for one user, whatever the rollout
+// says, e.g. to let staff try a feature before anyone else.
+func (s *Server) setFlagOverride(w http.ResponseWriter, r *http.Request) {
+	user, err := s.Queries.GetUserByEmail(r.Context(), strings.TrimSpace(r.FormValue("email")))
+	if err == sql.ErrNoRows {
+		s.renderAdminFlags(w, r, "No user with that email.")
+		return
+	}
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if err := s.Queries.UpsertFeatureFlagOverride(r.Context(), db.UpsertFeatureFlagOverrideParams{
+		FlagName: chi.URLParam(r, "name"),
+		UserID:   user.ID,
+		Enabled:  r.FormValue("enabled") == "true",
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/admin/flags", http.StatusSeeOther)
+}
+
+func (s *Server) deleteFlagOverride(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.Queries.DeleteFeatureFlagOverride(r.Context(), db.DeleteFeatureFlagOverrideParams{
+		FlagName: chi.URLParam(r, "name"),
+		UserID:   userID,
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/admin/flags", http.StatusSeeOther)
+}
+
+// flagsChanged drops cached flag evaluations, and the cached pages they
+// were rendered with, since anonymous visitors see flagged features too.
+func (s *Server) flagsChanged(ctx context.Context) {
+	s.Flags.Invalidate()
+	s.Pages.Invalidate(ctx, "pages", "status")
+}
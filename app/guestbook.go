@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"gighub/db"
+	"gighub/sanitize"
+	"gighub/utils"
+	"gighub/views"
+	"gighub/webhooks"
+)
+
+func (s *Server) getGuestbook(w http.ResponseWriter, r *http.Request) {
+	// The page streams the changes after this event, so it's read first
+	after, err := s.Queries.LatestEventID(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	msg, err := s.Queries.GetMessage(r.Context())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			msg = "Hello! Welcome to the guestbook."
+		} else {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+	}
+	stream := "/guestbook/stream?after=" + strconv.FormatInt(after, 10)
+	if s.Bans.Shadowed(r.Context(), s.userID(r)) {
+		if own, err := s.Queries.GetShadowMessage(r.Context(), s.userID(r)); err == nil {
+			msg = own
+		}
+		stream = ""
+	}
+	views.Guestbook(s.messageHTML(msg), s.draft(r, draftGuestbook), stream).Render(r.Context(), w)
+}
+
+// messageWidth is the width images in the guestbook message are scaled to.
+const messageWidth = 640
+
+// messageHTML cleans the guestbook message for display. Besides the Inline
+// formatting it keeps images from IMAGE_PROXY_HOSTS, served through the
+// image proxy so visitors never load them from the other host.
+func (s *Server) messageHTML(message string) string {
+	return sanitize.Inline.WithImages(func(src string) (string, bool) {
+		if !s.Images.Allowed(src) {
+			return "", false
+		}
+		return s.Images.URL(src, messageWidth), true
+	}).HTML(message)
+}
+
+// streamGuestbook sends each change of the guestbook message to the page
+// as a Server-Sent Event carrying the sanitized HTML. Reconnecting clients
+// send Last-Event-ID and get the changes they missed; new ones start after
+// the event the page was rendered at.
+func (s *Server) streamGuestbook(w http.ResponseWriter, r *http.Request) {
+	// Shadow-banned users keep seeing their own message. 204 tells
+	// EventSource not to reconnect.
+	if s.Bans.Shadowed(r.Context(), s.userID(r)) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	lastID, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil {
+		lastID, err = strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+	}
+	if err != nil {
+		if lastID, err = s.Queries.LatestEventID(r.Context()); err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+	}
+
+	list := func(ctx context.Context, after int64) ([]db.Event, error) {
+		return s.Queries.ListEventsOfTypeSince(ctx, db.ListEventsOfTypeSinceParams{Type: webhooks.GuestbookUpdated, ID: after})
+	}
+	s.Webhooks.Stream(w, r, lastID, s.stopping, list, func(w io.Writer, e db.Event) {
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal([]byte(e.Payload), &body)
+		data, _ := json.Marshal(map[string]string{"html": s.messageHTML(body.Message)})
+		fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.ID, data)
+	})
+}
+
+func (s *Server) postGuestbook(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	message := r.FormValue("message")
+	// Shadow-banned users see their message in place of everyone else's
+	if userID := s.userID(r); s.Bans.Shadowed(r.Context(), userID) {
+		if err := s.Queries.UpsertShadowMessage(r.Context(), db.UpsertShadowMessageParams{UserID: userID, Message: message}); err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+		s.discardDraft(r, draftGuestbook)
+		http.Redirect(w, r, "/guestbook", http.StatusSeeOther)
+		return
+	}
+	if err := s.Queries.UpsertMessage(r.Context(), message); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.discardDraft(r, draftGuestbook)
+	if err := s.Webhooks.Publish(r.Context(), 0, webhooks.GuestbookUpdated, map[string]string{"message": message}); err != nil {
+		log.Printf("Error publishing event: %v", err)
+	}
+	http.Redirect(w, r, "/guestbook", http.StatusSeeOther)
+}
+
+// inboundGuestbookUpdate lets a custom inbound webhook set the message.
+func (s *Server) inboundGuestbookUpdate(ctx context.Context, e webhooks.InboundEvent) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(e.Payload, &body); err != nil || body.Message == "" {
+		return fmt.Errorf("payload must contain a message")
+	}
+	if err := s.Queries.UpsertMessage(ctx, body.Message); err != nil {
+		return err
+	}
+	return s.Webhooks.Publish(ctx, 0, webhooks.GuestbookUpdated, body)
+}
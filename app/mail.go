@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/smtp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gighub/config"
+	"gighub/jobs"
+	"gighub/report"
+	"gighub/settings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// sendEmailJob is the job SendAsync queues each email as.
+const sendEmailJob = "send-email"
+
+// Mailer sends plain text email over SMTP.
+type Mailer struct {
+	Config   config.SMTP
+	Reporter report.Reporter
+	// Settings provides the footer added to every email, when set.
+	Settings *settings.Store
+	// Jobs queues emails sent in the background, so they are retried when
+	// sending fails and survive a restart. Without it they are sent from a
+	// goroutine, once.
+	Jobs *jobs.Scheduler
+
+	// pending tracks emails sent in the background so shutdown can wait.
+	pending sync.WaitGroup
+
+	queued, sent, failed atomic.Int64
+	mu                   sync.Mutex
+	lastErr              string
+	lastErrAt            time.Time
+}
+
+// MailStats counts the emails sent since startup, for the admin dashboard.
+type MailStats struct {
+	// Queued are being sent in the background.
+	Queued      int64
+	Sent        int64
+	Failed      int64
+	LastError   string
+	LastErrorAt time.Time
+}
+
+// Send sends an email. The request ID from ctx is added as a header so a
+// message can be traced back to the request that sent it.
+func (m *Mailer) Send(ctx context.Context, to, subject, body string) error {
+	conf := m.Config
+	if !conf.Enabled() {
+		return fmt.Errorf("SMTP environment variables are not set")
+	}
+
+	auth := smtp.PlainAuth("", conf.User, conf.Pass, conf.Host)
+	headers := fmt.Sprintf("To: %s\r\nSubject: %s\r\n", to, subject)
+	if id := middleware.GetReqID(ctx); id != "" {
+		headers += fmt.Sprintf("X-Request-ID: %s\r\n", id)
+	}
+	if m.Settings != nil {
+		// Background sends outlive the request ctx came from
+		if set, _ := m.Settings.Get(context.WithoutCancel(ctx)); set.EmailFooter != "" {
+			body += "\n\n-- \n" + set.EmailFooter
+		}
+	}
+	msg := []byte(headers + "\r\n" + body)
+
+	err := smtp.SendMail(conf.Host+":"+conf.Port, auth, conf.From, []string{to}, msg)
+	if err != nil {
+		m.failed.Add(1)
+		m.mu.Lock()
+		m.lastErr, m.lastErrAt = err.Error(), time.Now()
+		m.mu.Unlock()
+		return err
+	}
+	m.sent.Add(1)
+	return nil
+}
+
+// queuedEmail is the payload of a send-email job.
+type queuedEmail struct {
+	To        string `json:"to"`
+	Subject   string `json:"subject"`
+	Body      string `json:"body"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// SendAsync sends an email in the background. It's queued as a job when
+// there is a queue, and otherwise failures are logged.
+func (m *Mailer) SendAsync(ctx context.Context, to, subject, body string) {
+	if m.Jobs != nil {
+		err := m.Jobs.Enqueue(ctx, sendEmailJob, queuedEmail{To: to, Subject: subject, Body: body, RequestID: middleware.GetReqID(ctx)}, time.Time{})
+		if err == nil {
+			return
+		}
+		log.Printf("Error queueing email to %s, sending it now: %v", to, err)
+	}
+	m.pending.Add(1)
+	m.queued.Add(1)
+	go func() {
+		defer m.pending.Done()
+		defer m.queued.Add(-1)
+		if err := m.Send(ctx, to, subject, body); err != nil {
+			log.Printf("Failed to send email to %s: %v", to, err)
+			report.SetTag(ctx, "task", "email")
+			m.Reporter.Error(ctx, fmt.Errorf("sending %q email: %w", subject, err))
+		}
+	}()
+}
+
+// sendQueued is the handler of send-email jobs. The request ID of the
+// request that queued the email is put back, for the X-Request-ID header.
+func (m *Mailer) sendQueued(ctx context.Context, payload json.RawMessage) error {
+	var e queuedEmail
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return err
+	}
+	if e.RequestID != "" {
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, e.RequestID)
+	}
+	return m.Send(ctx, e.To, e.Subject, e.Body)
+}
+
+// Wait blocks until background emails have been sent.
+func (m *Mailer) Wait() {
+	m.pending.Wait()
+}
+
+// Stats returns the counts since startup.
+func (m *Mailer) Stats() MailStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return MailStats{
+		Queued:      m.queued.Load(),
+		Sent:        m.sent.Load(),
+		Failed:      m.failed.Load(),
+		LastError:   m.lastErr,
+		LastErrorAt: m.lastErrAt,
+	}
+}
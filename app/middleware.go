@@ -0,0 +1,199 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/announcements"
+	"gighub/experiments"
+	"gighub/flags"
+	"gighub/locale"
+	"gighub/report"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/justinas/nosurf"
+)
+
+// recoverer turns a panic into a 500 page and reports it with whatever
+// the handlers learned about the request by then.
+func (s *Server) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := report.NewContext(r.Context(), r)
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			middleware.PrintPrettyStack(rec)
+			s.Reporter.Error(ctx, fmt.Errorf("panic: %v", rec))
+			if r.Header.Get("Connection") != "Upgrade" {
+				utils.ServerError(w, r, "Server error")
+			}
+		}()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// templateContext exposes the login state, CSRF token and site name to
+// the views.
+func (s *Server) templateContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Sessions.Exists(r.Context(), "userID") {
+			report.SetUser(r.Context(), s.userID(r))
+		}
+		ctx := context.WithValue(r.Context(), "isLoggedIn", s.Sessions.Exists(r.Context(), "userID"))
+		ctx = context.WithValue(ctx, "csrf", nosurf.Token(r))
+		ctx = context.WithValue(ctx, "siteName", s.settings(r).SiteName)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// featureFlags evaluates the feature flags for the logged-in user so
+// handlers and views can check them with flags.Enabled. If they can't be
+// loaded, every flag is off.
+func (s *Server) featureFlags(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		set, err := s.Flags.Evaluate(r.Context(), s.userID(r))
+		if err != nil {
+			s.Reporter.Error(r.Context(), err)
+		}
+		next.ServeHTTP(w, r.WithContext(flags.NewContext(r.Context(), set)))
+	})
+}
+
+// localize picks the locale views format dates and numbers in: the one
+// the user chose, or else the closest to what their browser asks for.
+func (s *Server) localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l, ok := locale.Parse(s.Sessions.GetString(r.Context(), "locale"))
+		if !ok {
+			l = locale.Match(r.Header.Get("Accept-Language"))
+		}
+		next.ServeHTTP(w, r.WithContext(locale.NewContext(r.Context(), l)))
+	})
+}
+
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.Sessions.Exists(r.Context(), "userID") {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		// Banning deletes the user's sessions, but one may be mid-request
+		if s.refuseBanned(w, r, s.userID(r)) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// assignExperiments picks the request's experiment variants, by user
+// when someone is logged in and otherwise by the visitor ID analytics has
+// for them today.
+func (s *Server) assignExperiments(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := "visitor:" + s.Analytics.Visitor(r)
+		if userID := s.userID(r); userID != 0 {
+			id = "user:" + strconv.FormatInt(userID, 10)
+		}
+		ctx := experiments.NewContext(r.Context(), id, func(experiment, variant string) {
+			s.Analytics.Exposed(r, experiment, variant)
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// announce puts the announcements to show into the context of page
+// requests, for the layout. If they can't be loaded, none are shown.
+func (s *Server) announce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !wantsHTML(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		list, err := s.Announcements.Active(r.Context(), s.userID(r))
+		if err != nil {
+			s.Reporter.Error(r.Context(), err)
+		}
+		next.ServeHTTP(w, r.WithContext(announcements.NewContext(r.Context(), list)))
+	})
+}
+
+func wantsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// pageVary is what cached pages differ by for anonymous visitors besides
+// the URL: the locale, the experiment variants, and the announcements in
+// the layout, which only HTML requests get. Listing the announcements
+// shown makes scheduled ones appear and end on time.
+func pageVary(r *http.Request) string {
+	ids := "none"
+	if wantsHTML(r) {
+		ids = "html"
+		for _, a := range announcements.FromContext(r.Context()) {
+			ids += "," + strconv.FormatInt(a.ID, 10)
+		}
+	}
+	return locale.FromContext(r.Context()).Code + "|" + experiments.Variants(r.Context()) + "|" + ids
+}
+
+// requireAdmin guards the admin pages with the admin credentials. The
+// pages don't exist when those aren't configured.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	admin := s.Config.Admin
+	if admin.Username == "" || admin.Password == "" {
+		return http.NotFoundHandler()
+	}
+	return middleware.BasicAuth("gighub admin", map[string]string{admin.Username: admin.Password})(next)
+}
+
+// userID returns the logged-in user's ID.
+func (s *Server) userID(r *http.Request) int64 {
+	return s.Sessions.GetInt64(r.Context(), "userID")
+}
+
+// strictTransportSecurity tells browsers to only use HTTPS from now on.
+func strictTransportSecurity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// fingerprintedAssets serves fingerprinted asset paths from what was built
+// for them at startup, cached for a year.
+func fingerprintedAssets(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, data, ok := views.AssetContent(r.URL.Path); ok {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// preloadHints tells browsers about the stylesheet and logo every page
+// uses in the response headers, so they are fetched before the HTML that
+// links them has been parsed.
+func preloadHints(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Add("Link", "<"+views.Asset(views.CssPath)+">; rel=preload; as=style")
+			w.Header().Add("Link", "<"+views.Asset("/assets/logo.svg")+">; rel=preload; as=image")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gighub/api"
+	"gighub/db"
+	"gighub/scan"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// The moderation queue holds what was flagged for an admin to decide on.
+// For now that's uploads the scanner held back.
+
+func (s *Server) getModeration(w http.ResponseWriter, r *http.Request) {
+	uploads, err := s.Queries.ListQuarantinedUploads(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	// Links for previewing each file, long enough to review a page of them
+	previews := map[string]string{}
+	for _, u := range uploads {
+		if url, err := s.Storage.URL(api.UploadKey(db.Upload{ID: u.ID, UserID: u.UserID}), 30*time.Minute); err == nil {
+			previews[u.ID] = url
+		}
+	}
+	views.AdminModeration(uploads, previews).Render(r.Context(), w)
+}
+
+func (s *Server) approveUpload(w http.ResponseWriter, r *http.Request) {
+	s.reviewUpload(w, r, scan.Clean)
+}
+
+func (s *Server) removeUpload(w http.ResponseWriter, r *http.Request) {
+	s.reviewUpload(w, r, scan.Rejected)
+}
+
+// reviewUpload records an admin's decision on a quarantined upload, in the
+// upload and in the audit log.
+func (s *Server) reviewUpload(w http.ResponseWriter, r *http.Request, status string) {
+	u, err := s.Queries.GetUploadByID(r.Context(), chi.URLParam(r, "id"))
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if status == scan.Rejected && u.StorageKey.Valid {
+		if err := s.Storage.Delete(r.Context(), u.StorageKey.String); err != nil {
+			log.Printf("Error deleting rejected upload %s: %v", u.ID, err)
+			utils.ServerError(w, r, "Storage error")
+			return
+		}
+	}
+	if err := s.Queries.SetUploadStatus(r.Context(), db.SetUploadStatusParams{
+		Status:       status,
+		StatusReason: u.StatusReason,
+		ReviewedAt:   sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:           u.ID,
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	action := "upload.approve"
+	if status == scan.Rejected {
+		action = "upload.remove"
+	}
+	s.audit(r, action, "upload "+u.ID, fmt.Sprintf("%s by user %d: %s", u.Filename, u.UserID, u.StatusReason.String))
+	http.Redirect(w, r, "/admin/moderation", http.StatusSeeOther)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gighub/buildinfo"
+	"gighub/db"
+	"gighub/utils"
+	"gighub/views"
+)
+
+func (s *Server) getHome(w http.ResponseWriter, r *http.Request) {
+	views.Home().Render(r.Context(), w)
+}
+
+func (s *Server) getPrivacyPolicy(w http.ResponseWriter, r *http.Request) {
+	views.PrivacyPolicy().Render(r.Context(), w)
+}
+
+func (s *Server) getTerms(w http.ResponseWriter, r *http.Request) {
+	views.Terms().Render(r.Context(), w)
+}
+
+func (s *Server) getVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(buildinfo.Get().Commit))
+}
+
+// getBuildInfo describes the build, and the schema version of the
+// database next to the newest migration the build knows, which differ
+// while another instance is migrating.
+func (s *Server) getBuildInfo(w http.ResponseWriter, r *http.Request) {
+	version, err := db.Version(s.DB)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		buildinfo.Info
+		SchemaVersion int `json:"schema_version"`
+		Migrations    int `json:"migrations"`
+	}{buildinfo.Get(), version, db.Latest()})
+}
+
+func (s *Server) getEmailTest(w http.ResponseWriter, r *http.Request) {
+	to := r.URL.Query().Get("to")
+	if err := s.Mailer.Send(r.Context(), to, "Test Email", "This is a test email from your Go app."); err != nil {
+		utils.ServerError(w, r, "Failed to send email: "+err.Error())
+		return
+	}
+	w.Write([]byte("Email sent successfully to " + to))
+}
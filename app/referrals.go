@@ -0,0 +1,146 @@
+package app
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gighub/db"
+	"gighub/utils"
+	"gighub/views"
+)
+
+// maxReferralRewards caps how many referrals earn their referrer extra
+// storage. Later ones are still counted.
+const maxReferralRewards = 10
+
+// Statuses of new referrals; verifying the account makes a pending one
+// "qualified".
+const (
+	referralPending  = "pending"
+	referralRejected = "rejected"
+)
+
+// rememberReferral keeps the code from a referral link (/signup?ref=...)
+// in the session, so it still applies after an OAuth round trip.
+func (s *Server) rememberReferral(r *http.Request) {
+	if code := r.URL.Query().Get("ref"); code != "" && len(code) <= 32 {
+		s.Sessions.Put(r.Context(), "referralCode", code)
+	}
+}
+
+// attributeReferral records that the new user signed up through a referral
+// link. It must run before the signup's sighting is recorded. Failures are
+// reported but don't stop the signup.
+func (s *Server) attributeReferral(r *http.Request, userID int64) {
+	code := s.Sessions.PopString(r.Context(), "referralCode")
+	if code == "" {
+		return
+	}
+	if err := s.createReferral(r, code, userID); err != nil {
+		s.Reporter.Error(r.Context(), fmt.Errorf("attributing the signup of user %d to a referral: %w", userID, err))
+	}
+}
+
+// createReferral adds the referral for code, if it names a referrer.
+// Referrals from an address or device the referrer used are kept but
+// rejected, so nobody earns storage by referring themselves.
+func (s *Server) createReferral(r *http.Request, code string, userID int64) error {
+	referrerID, err := s.Queries.GetReferrerByCode(r.Context(), code)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// A device seen before has its cookie; a new one can't be shared
+	var device string
+	if c, err := r.Cookie(deviceCookie); err == nil {
+		device = c.Value
+	}
+	shared, err := s.Queries.CountReferrerSightings(r.Context(), db.CountReferrerSightingsParams{
+		Ip:     utils.ClientIP(r),
+		Device: device,
+		UserID: referrerID,
+	})
+	if err != nil {
+		return err
+	}
+	status, reason := referralPending, ""
+	if shared.SharedDevices > 0 {
+		status, reason = referralRejected, "Signed up on a device you use"
+	} else if shared.SharedIps > 0 {
+		status, reason = referralRejected, "Signed up from an address you use"
+	}
+	return s.Queries.CreateReferral(r.Context(), db.CreateReferralParams{
+		ReferrerID: referrerID,
+		ReferredID: userID,
+		Status:     status,
+		Reason:     reason,
+	})
+}
+
+// qualifyReferral completes a pending referral once the referred account
+// is verified. Failures are reported but don't stop the verification.
+func (s *Server) qualifyReferral(r *http.Request, userID int64) {
+	if err := s.rewardReferral(r, userID); err != nil {
+		s.Reporter.Error(r.Context(), fmt.Errorf("qualifying the referral of user %d: %w", userID, err))
+	}
+}
+
+// rewardReferral marks the user's pending referral qualified, and gives
+// the referrer Limits.ReferralBonus of storage while they have fewer than
+// maxReferralRewards.
+func (s *Server) rewardReferral(r *http.Request, userID int64) error {
+	ref, err := s.Queries.GetPendingReferral(r.Context(), userID)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	rewarded, err := s.Queries.CountRewardedReferrals(r.Context(), ref.ReferrerID)
+	if err != nil {
+		return err
+	}
+	var reward int64
+	if rewarded < maxReferralRewards {
+		reward = s.Config.Limits.ReferralBonus
+	}
+	return s.Queries.QualifyReferral(r.Context(), db.QualifyReferralParams{
+		RewardBytes: reward,
+		QualifiedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:          ref.ID,
+	})
+}
+
+// getReferrals shows the user's referral link and how their referrals are
+// doing.
+func (s *Server) getReferrals(w http.ResponseWriter, r *http.Request) {
+	userID := s.userID(r)
+	b := make([]byte, 6)
+	rand.Read(b)
+	code, err := s.Queries.EnsureReferralCode(r.Context(), db.EnsureReferralCodeParams{UserID: userID, Code: hex.EncodeToString(b)})
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	referrals, err := s.Queries.ListReferrals(r.Context(), userID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	p := views.ReferralProgress{
+		Link:       s.Config.BaseURL + "/signup?ref=" + code,
+		Bonus:      s.Config.Limits.ReferralBonus,
+		MaxRewards: maxReferralRewards,
+	}
+	for _, ref := range referrals {
+		if ref.RewardBytes > 0 {
+			p.Rewarded++
+			p.Earned += ref.RewardBytes
+		}
+	}
+	views.Referrals(p, referrals).Render(r.Context(), w)
+}
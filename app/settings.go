@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"gighub/abuse"
+	"gighub/api"
+	"gighub/ratelimit"
+	"gighub/settings"
+	"gighub/utils"
+	"gighub/views"
+)
+
+// settings returns the current settings. If they can't be loaded, the
+// error is reported and the defaults are used.
+func (s *Server) settings(r *http.Request) settings.Settings {
+	set, err := s.Settings.Get(r.Context())
+	if err != nil {
+		s.Reporter.Error(r.Context(), err)
+	}
+	return set
+}
+
+// settingsChanged applies the settings that are only read at startup, and
+// drops cached pages that show the site name.
+func (s *Server) settingsChanged(set settings.Settings) {
+	if limits, err := ratelimit.ParseLimits(set.APIRateLimits, api.DefaultLimits); err == nil {
+		s.API.Limits.Set(limits)
+	} else {
+		log.Printf("Error applying API rate limits: %v", err)
+	}
+	if limits, err := ratelimit.ParseLimits(set.Throttles, abuse.DefaultLimits); err == nil {
+		s.Abuse.Limits.Set(limits)
+	} else {
+		log.Printf("Error applying throttles: %v", err)
+	}
+	s.Pages.Invalidate(context.Background(), "pages", "status")
+}
+
+func (s *Server) getAdminSettings(w http.ResponseWriter, r *http.Request) {
+	set, err := s.Settings.Get(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	views.AdminSettings(set, s.Settings.Defaults, "").Render(r.Context(), w)
+}
+
+func (s *Server) saveSettings(w http.ResponseWriter, r *http.Request) {
+	old, err := s.Settings.Get(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	set := settings.Settings{
+		SiteName:      strings.TrimSpace(r.FormValue("site_name")),
+		SignupsOpen:   r.FormValue("signups_open") == "on",
+		APIRateLimits: strings.TrimSpace(r.FormValue("api_rate_limits")),
+		Throttles:     strings.TrimSpace(r.FormValue("throttle_limits")),
+		EmailFooter:   strings.TrimSpace(strings.ReplaceAll(r.FormValue("email_footer"), "\r\n", "\n")),
+	}
+	if err := set.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		views.AdminSettings(set, s.Settings.Defaults, err.Error()).Render(r.Context(), w)
+		return
+	}
+	if err := s.Settings.Save(r.Context(), set); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if changes := settings.Changes(old, set); len(changes) > 0 {
+		s.audit(r, "settings.update", "settings", strings.Join(changes, ", "))
+	}
+	http.Redirect(w, r, "/admin/settings", http.StatusSeeOther)
+}
+
+func (s *Server) resetSettings(w http.ResponseWriter, r *http.Request) {
+	if err := s.Settings.Reset(r.Context()); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.audit(r, "settings.reset", "settings", "")
+	http.Redirect(w, r, "/admin/settings", http.StatusSeeOther)
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/a-h/templ"
+)
+
+func (s *Server) getStatus(w http.ResponseWriter, r *http.Request) {
+	latest, err := s.Queries.ListLatestHealthChecks(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	// The table only changes when a check runs, so the uptime query is
+	// skipped while the newest run is the one it was rendered for
+	var newest time.Time
+	for _, c := range latest {
+		if c.CheckedAt.After(newest) {
+			newest = c.CheckedAt
+		}
+	}
+	checks := views.Fragment("status-checks", newest.Format(time.RFC3339Nano), func(ctx context.Context) (templ.Component, error) {
+		uptime, err := s.Queries.ListHealthCheckUptime(ctx, time.Now().UTC().Add(-24*time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		return views.StatusChecks(latest, uptime), nil
+	})
+	// The page is streamed, so a failure here can only be reported
+	if err := views.Status(latest, checks).Render(r.Context(), w); err != nil {
+		s.Reporter.Error(r.Context(), err)
+	}
+}
+
+// healthAlert tells the admin that a check keeps failing, or that it
+// passes again when err is nil.
+func (s *Server) healthAlert(ctx context.Context, name string, err error) {
+	subject := fmt.Sprintf("[gighub] %s check recovered", name)
+	body := fmt.Sprintf("The %s check is passing again.\n\n%s/status", name, s.Config.BaseURL)
+	if err != nil {
+		subject = fmt.Sprintf("[gighub] %s check failing", name)
+		body = fmt.Sprintf("The %s check failed %d times in a row: %v\n\n%s/status", name, s.Health.AlertAfter, err, s.Config.BaseURL)
+		s.Reporter.Error(ctx, fmt.Errorf("%s check failing: %w", name, err))
+	}
+	log.Print(subject)
+	if s.Config.Admin.AlertEmail != "" {
+		s.Mailer.SendAsync(ctx, s.Config.Admin.AlertEmail, subject, body)
+	}
+}
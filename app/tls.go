@@ -0,0 +1,43 @@
+package app
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// CertManager obtains and renews certificates for the configured domains,
+// caching them on disk so restarts don't hit Let's Encrypt's rate limits.
+func (s *Server) CertManager() *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(s.Config.TLS.Domains...),
+		Cache:      autocert.DirCache(s.Config.TLS.CacheDir),
+		Email:      s.Config.TLS.Email,
+	}
+}
+
+// TLSConfig returns the TLS settings for the HTTPS listener.
+func TLSConfig(m *autocert.Manager) *tls.Config {
+	c := m.TLSConfig()
+	c.MinVersion = tls.VersionTLS12
+	return c
+}
+
+// RedirectToHTTPS answers ACME HTTP challenges and sends everything else to
+// the HTTPS listener.
+func (s *Server) RedirectToHTTPS(m *autocert.Manager) http.Handler {
+	port := s.Config.TLS.HTTPSPort
+	return m.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	}))
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"gighub/api"
+	"gighub/db"
+	"gighub/scan"
+	"gighub/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// scanUpload runs a finished upload through the scan pipeline. Rejected
+// files are deleted right away; their row stays so copies are caught too.
+func (s *Server) scanUpload(ctx context.Context, payload json.RawMessage) error {
+	var p struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	u, err := s.Queries.GetUploadByID(ctx, p.ID)
+	if err == sql.ErrNoRows {
+		return nil // deleted before it was scanned
+	}
+	if err != nil {
+		return err
+	}
+	if u.Status != scan.Pending || !u.StorageKey.Valid {
+		return nil
+	}
+	verdict, err := s.Scanner.Scan(ctx, scan.File{
+		ID:          u.ID,
+		UserID:      u.UserID,
+		SHA256:      u.Sha256.String,
+		ContentType: u.ContentType.String,
+		Size:        u.Size,
+		Open: func(ctx context.Context) (io.ReadCloser, error) {
+			f, _, err := s.Storage.Open(ctx, u.StorageKey.String)
+			return f, err
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error scanning upload %s: %w", u.ID, err)
+	}
+	if verdict.Status == scan.Rejected {
+		if err := s.Storage.Delete(ctx, u.StorageKey.String); err != nil {
+			return err
+		}
+	}
+	if verdict.Status != scan.Clean {
+		log.Printf("Upload %s %s: %s", u.ID, verdict.Status, verdict.Reason)
+	}
+	return s.Queries.SetUploadStatus(ctx, db.SetUploadStatusParams{
+		Status:       verdict.Status,
+		StatusReason: sql.NullString{String: verdict.Reason, Valid: verdict.Reason != ""},
+		ID:           u.ID,
+	})
+}
+
+// deleteUpload lets users free up storage from the account page.
+func (s *Server) deleteUpload(w http.ResponseWriter, r *http.Request) {
+	userID := s.userID(r)
+	u, err := s.Queries.GetUpload(r.Context(), db.GetUploadParams{ID: chi.URLParam(r, "id"), UserID: userID})
+	if err == sql.ErrNoRows {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if err := s.API.DeleteUpload(r.Context(), u); err != nil {
+		if err == api.ErrUploadBusy {
+			s.renderAccount(w, r, userID, "", u.Filename+" is still uploading. Try again in a moment.")
+		} else {
+			utils.ServerError(w, r, "Database error")
+		}
+		return
+	}
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
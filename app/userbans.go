@@ -0,0 +1,315 @@
+package app
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/bans"
+	"gighub/db"
+	"gighub/emails"
+	"gighub/utils"
+	"gighub/views"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// userBansShown is how many of the latest bans the admin page lists.
+const userBansShown = 100
+
+const maxAppealLength = 2000
+
+// deviceCookie holds a random ID for the browser, kept for two years.
+// Logins record it with the address, so accounts used from the same
+// device can be told apart from ones that merely share a network.
+const (
+	deviceCookie = "device"
+	deviceMaxAge = 2 * 365 * 24 * time.Hour
+)
+
+// device returns the browser's device ID, giving it one if it has none.
+func (s *Server) device(w http.ResponseWriter, r *http.Request) string {
+	if c, err := r.Cookie(deviceCookie); err == nil && len(c.Value) == 32 {
+		return c.Value
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     deviceCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(deviceMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   s.Config.Production(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+// recordSighting notes the address and device a user logged in from. A
+// failure is reported, but doesn't stop the login.
+func (s *Server) recordSighting(w http.ResponseWriter, r *http.Request, userID int64) {
+	err := s.Queries.RecordUserSighting(r.Context(), db.RecordUserSightingParams{
+		UserID: userID,
+		Ip:     utils.ClientIP(r),
+		Device: s.device(w, r),
+	})
+	if err != nil {
+		s.Reporter.Error(r.Context(), fmt.Errorf("recording the login of user %d: %w", userID, err))
+	}
+}
+
+// deviceBanned reports whether an account that is hard-banned now was
+// used from the request's device, to refuse new accounts from it.
+func (s *Server) deviceBanned(r *http.Request) (bool, error) {
+	c, err := r.Cookie(deviceCookie)
+	if err != nil {
+		return false, nil
+	}
+	n, err := s.Queries.CountActiveBansOnDevice(r.Context(), db.CountActiveBansOnDeviceParams{
+		Device:    c.Value,
+		ExpiresAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	return n > 0, err
+}
+
+// refuseBanned sends a hard-banned user to the page saying why instead of
+// letting them in, logging them out if need be, and reports whether it did. The session remembers who
+// they are so they can appeal without being logged in.
+func (s *Server) refuseBanned(w http.ResponseWriter, r *http.Request, userID int64) bool {
+	_, banned, err := s.Bans.Active(r.Context(), userID, bans.Hard)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return true
+	}
+	if !banned {
+		return false
+	}
+	s.Sessions.Remove(r.Context(), "userID")
+	s.Sessions.Put(r.Context(), "bannedUserID", userID)
+	http.Redirect(w, r, "/banned", http.StatusSeeOther)
+	return true
+}
+
+func (s *Server) getBanned(w http.ResponseWriter, r *http.Request) {
+	s.renderBanned(w, r, "")
+}
+
+// renderBanned shows the ban that stopped the user logging in, and the
+// appeal form or what became of their appeal.
+func (s *Server) renderBanned(w http.ResponseWriter, r *http.Request, formError string) {
+	ban, banned, err := s.Bans.Active(r.Context(), s.Sessions.GetInt64(r.Context(), "bannedUserID"), bans.Hard)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if !banned {
+		s.Sessions.Remove(r.Context(), "bannedUserID")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	appeal, err := s.Queries.GetBanAppeal(r.Context(), ban.ID)
+	if err != nil && err != sql.ErrNoRows {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		w.WriteHeader(http.StatusForbidden)
+	}
+	views.Banned(ban, appeal, s.draft(r, draftAppeal), formError).Render(r.Context(), w)
+}
+
+// postAppeal records an appeal of the ban shown at /banned. Each ban can
+// be appealed once.
+func (s *Server) postAppeal(w http.ResponseWriter, r *http.Request) {
+	ban, banned, err := s.Bans.Active(r.Context(), s.Sessions.GetInt64(r.Context(), "bannedUserID"), bans.Hard)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if !banned {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	message := strings.TrimSpace(r.FormValue("message"))
+	if message == "" || len(message) > maxAppealLength {
+		s.renderBanned(w, r, fmt.Sprintf("The appeal must be 1 to %d characters.", maxAppealLength))
+		return
+	}
+	if _, err := s.Queries.GetBanAppeal(r.Context(), ban.ID); err == nil {
+		s.renderBanned(w, r, "This ban has already been appealed.")
+		return
+	} else if err != sql.ErrNoRows {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if err := s.Queries.CreateBanAppeal(r.Context(), db.CreateBanAppealParams{BanID: ban.ID, Message: message}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	s.discardDraft(r, draftAppeal)
+	http.Redirect(w, r, "/banned", http.StatusSeeOther)
+}
+
+func (s *Server) getAdminUserBans(w http.ResponseWriter, r *http.Request) {
+	s.renderAdminUserBans(w, r, "")
+}
+
+func (s *Server) renderAdminUserBans(w http.ResponseWriter, r *http.Request, formError string) {
+	list, err := s.Queries.ListUserBans(r.Context(), userBansShown)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	appeals, err := s.Queries.ListPendingBanAppeals(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	// Accounts sharing an address or device with someone banned now
+	now := time.Now()
+	associated := map[int64][]db.ListAssociatedUsersRow{}
+	for _, b := range list {
+		ended := b.LiftedAt.Valid || (b.ExpiresAt.Valid && !b.ExpiresAt.Time.After(now))
+		if _, done := associated[b.UserID]; done || ended {
+			continue
+		}
+		if associated[b.UserID], err = s.Queries.ListAssociatedUsers(r.Context(), b.UserID); err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+	}
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	views.AdminUserBans(list, appeals, associated, now, formError).Render(r.Context(), w)
+}
+
+func (s *Server) banUser(w http.ResponseWriter, r *http.Request) {
+	email := strings.TrimSpace(r.FormValue("email"))
+	kind := r.FormValue("kind")
+	if kind != bans.Hard && kind != bans.Shadow {
+		s.renderAdminUserBans(w, r, "Pick a kind of ban.")
+		return
+	}
+	reason := strings.TrimSpace(r.FormValue("reason"))
+	if reason == "" {
+		s.renderAdminUserBans(w, r, "Give a reason. Banned users see it when they try to log in.")
+		return
+	}
+	var d time.Duration
+	if v := r.FormValue("duration"); v != "" {
+		var err error
+		if d, err = time.ParseDuration(v); err != nil || d <= 0 {
+			s.renderAdminUserBans(w, r, "Duration must look like 24h or 720h, or be left empty for a permanent ban.")
+			return
+		}
+	}
+	user, err := s.Queries.GetUserByEmail(r.Context(), email)
+	if err == sql.ErrNoRows {
+		s.renderAdminUserBans(w, r, "There is no account for "+email+".")
+		return
+	}
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if _, err := s.Bans.Ban(r.Context(), user.ID, kind, reason, d); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	// A hard ban logs the user out everywhere. Shadow-banned users
+	// mustn't notice anything.
+	if kind == bans.Hard {
+		if err := s.Queries.DeleteUserSessions(r.Context(), sql.NullInt64{Int64: user.ID, Valid: true}); err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+	}
+	length := "permanently"
+	if d > 0 {
+		length = "for " + d.String()
+	}
+	s.audit(r, "user.ban", user.Email, fmt.Sprintf("%s %s: %s", kind, length, reason))
+	http.Redirect(w, r, "/admin/bans", http.StatusSeeOther)
+}
+
+func (s *Server) liftUserBan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	lifted, err := s.Bans.Lift(r.Context(), id)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if lifted {
+		s.audit(r, "user.unban", "ban "+strconv.FormatInt(id, 10), "")
+	}
+	http.Redirect(w, r, "/admin/bans", http.StatusSeeOther)
+}
+
+func (s *Server) acceptAppeal(w http.ResponseWriter, r *http.Request) {
+	s.decideAppeal(w, r, true)
+}
+
+func (s *Server) rejectAppeal(w http.ResponseWriter, r *http.Request) {
+	s.decideAppeal(w, r, false)
+}
+
+// decideAppeal closes an appeal, lifting the ban if it's accepted, and
+// lets the user know by email.
+func (s *Server) decideAppeal(w http.ResponseWriter, r *http.Request, accept bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	pending, err := s.Queries.ListPendingBanAppeals(r.Context())
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	var appeal db.ListPendingBanAppealsRow
+	for _, a := range pending {
+		if a.ID == id {
+			appeal = a
+		}
+	}
+	if appeal.ID == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	if _, err := s.Queries.DecideBanAppeal(r.Context(), db.DecideBanAppealParams{
+		Accepted:  sql.NullBool{Bool: accept, Valid: true},
+		DecidedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:        id,
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	if accept {
+		if _, err := s.Bans.Lift(r.Context(), appeal.BanID); err != nil {
+			utils.ServerError(w, r, "Database error")
+			return
+		}
+		s.audit(r, "appeal.accept", appeal.Email, appeal.Message)
+		subject, body := s.Emails.Render(r.Context(), emails.AppealAccepted, map[string]string{"LoginURL": s.Config.BaseURL + "/login"})
+		s.Mailer.SendAsync(r.Context(), appeal.Email, subject, body)
+	} else {
+		s.audit(r, "appeal.reject", appeal.Email, appeal.Message)
+		subject, body := s.Emails.Render(r.Context(), emails.AppealRejected, nil)
+		s.Mailer.SendAsync(r.Context(), appeal.Email, subject, body)
+	}
+	http.Redirect(w, r, "/admin/bans", http.StatusSeeOther)
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gighub/db"
+	"gighub/utils"
+	"gighub/views"
+	"gighub/webhooks"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID := s.userID(r)
+	list, err := s.Queries.ListWebhooksByUser(r.Context(), userID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	inbound, err := s.Queries.ListInboundHooksByUser(r.Context(), userID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	views.Webhooks(list, inbound, webhooks.EventTypes, "").Render(r.Context(), w)
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	userID := s.userID(r)
+	url := r.FormValue("url")
+	events := r.Form["events"]
+	err := webhooks.ValidateURL(url)
+	if err == nil {
+		err = webhooks.ValidateEvents(events)
+	}
+	if err != nil {
+		list, _ := s.Queries.ListWebhooksByUser(r.Context(), userID)
+		inbound, _ := s.Queries.ListInboundHooksByUser(r.Context(), userID)
+		w.WriteHeader(http.StatusBadRequest)
+		views.Webhooks(list, inbound, webhooks.EventTypes, err.Error()).Render(r.Context(), w)
+		return
+	}
+	if _, err := s.Queries.CreateWebhook(r.Context(), db.CreateWebhookParams{
+		UserID: userID,
+		Url:    url,
+		Secret: webhooks.NewSecret(),
+		Events: strings.Join(events, ","),
+		Source: webhooks.SourceDashboard,
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/webhooks", http.StatusSeeOther)
+}
+
+func (s *Server) createInboundHook(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.Queries.CreateInboundHook(r.Context(), db.CreateInboundHookParams{
+		UserID: s.userID(r),
+		Token:  webhooks.NewInboundToken(),
+		Secret: webhooks.NewSecret(),
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/webhooks", http.StatusSeeOther)
+}
+
+func (s *Server) deleteInboundHook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.Queries.DeleteInboundHook(r.Context(), db.DeleteInboundHookParams{
+		ID:     id,
+		UserID: s.userID(r),
+	}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/webhooks", http.StatusSeeOther)
+}
+
+func (s *Server) getWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.userWebhook(w, r)
+	if !ok {
+		return
+	}
+	deliveries, err := s.Queries.ListWebhookDeliveries(r.Context(), hook.ID)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	total, err := s.Queries.CountWebhookDeliveries(r.Context(), hook.ID, nil)
+	if err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	views.Webhook(hook, deliveries, total).Render(r.Context(), w)
+}
+
+func (s *Server) pingWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.userWebhook(w, r)
+	if !ok {
+		return
+	}
+	if err := s.Webhooks.SendPing(r.Context(), hook); err != nil {
+		log.Printf("Error sending ping: %v", err)
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/webhooks/"+chi.URLParam(r, "id"), http.StatusSeeOther)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.userWebhook(w, r)
+	if !ok {
+		return
+	}
+	if err := s.Queries.DeleteWebhook(r.Context(), db.DeleteWebhookParams{ID: hook.ID, UserID: hook.UserID}); err != nil {
+		utils.ServerError(w, r, "Database error")
+		return
+	}
+	http.Redirect(w, r, "/webhooks", http.StatusSeeOther)
+}
+
+// userWebhook loads the webhook named in the URL if it belongs to the
+// logged-in user, writing a 404 otherwise.
+func (s *Server) userWebhook(w http.ResponseWriter, r *http.Request) (db.Webhook, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return db.Webhook{}, false
+	}
+	hook, err := s.Queries.GetWebhook(r.Context(), db.GetWebhookParams{
+		ID:     id,
+		UserID: s.userID(r),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.NotFound(w, r)
+		} else {
+			utils.ServerError(w, r, "Database error")
+		}
+		return db.Webhook{}, false
+	}
+	return hook, true
+}
@@ -0,0 +1,26 @@
+// Dismissing an announcement as a visitor: the IDs of dismissed banners
+// are kept in localStorage, and those banners are removed on each page.
+(function () {
+  const key = "dismissedAnnouncements";
+  let dismissed = [];
+  try {
+    dismissed = JSON.parse(localStorage.getItem(key)) || [];
+  } catch (e) {}
+
+  document.querySelectorAll("[data-announcement]").forEach(function (banner) {
+    const id = banner.dataset.announcement;
+    if (dismissed.includes(id)) {
+      banner.remove();
+      return;
+    }
+    const button = banner.querySelector("[data-dismiss]");
+    if (!button) return;
+    button.addEventListener("click", function () {
+      dismissed.push(id);
+      try {
+        localStorage.setItem(key, JSON.stringify(dismissed));
+      } catch (e) {}
+      banner.remove();
+    });
+  });
+})();
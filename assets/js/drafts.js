@@ -0,0 +1,35 @@
+// Autosaving drafts: a field with data-draft="<form>" is posted to
+// /drafts/<form> every few seconds while it changes, and once more when
+// the page is left. The server fills the field back in on return.
+(function () {
+  document.querySelectorAll("[data-draft]").forEach(function (field) {
+    const form = field.form;
+    const status = form.querySelector("[data-draft-status]");
+    let saved = field.value;
+
+    function save(keepalive) {
+      const value = field.value;
+      if (value === saved) return;
+      saved = value;
+      const body = new URLSearchParams({ csrf_token: form.elements.csrf_token.value, body: value });
+      fetch("/drafts/" + field.dataset.draft, { method: "POST", body: body, keepalive: keepalive })
+        .then(function (resp) {
+          if (!resp.ok) throw new Error(resp.statusText);
+          if (status) status.textContent = value.trim() ? "Draft saved." : "";
+        })
+        .catch(function () {
+          saved = null;
+          if (status) status.textContent = "The draft couldn't be saved.";
+        });
+    }
+
+    setInterval(save, 5000);
+    window.addEventListener("pagehide", function () {
+      save(true);
+    });
+    // The server drops the draft once the form is sent
+    form.addEventListener("submit", function () {
+      saved = field.value;
+    });
+  });
+})();
@@ -0,0 +1,42 @@
+// Live guestbook: changes to the message stream in over Server-Sent Events
+// and replace it without a reload. Posting shows the new message at once,
+// as plain text until the server's sanitized copy arrives, and puts the
+// old one back if the post fails. EventSource reconnects by itself, and
+// the server sends what was missed since the last event it got.
+(function () {
+  const box = document.querySelector("[data-guestbook-stream]");
+  if (!box || !box.dataset.guestbookStream) return;
+  const message = box.querySelector("[data-guestbook-message]");
+  const form = document.querySelector("[data-guestbook-form]");
+  const error = form.querySelector("[data-guestbook-error]");
+
+  const events = new EventSource(box.dataset.guestbookStream);
+  events.addEventListener("message", function (e) {
+    message.innerHTML = JSON.parse(e.data).html;
+  });
+
+  form.addEventListener("submit", function (e) {
+    e.preventDefault();
+    const field = form.elements.message;
+    const previous = message.innerHTML;
+    message.textContent = field.value;
+    error.textContent = "";
+    function undo(reason) {
+      message.innerHTML = previous;
+      error.textContent = reason;
+    }
+    fetch(form.action, { method: "POST", body: new URLSearchParams(new FormData(form)) })
+      .then(function (resp) {
+        if (resp.ok) {
+          field.value = "";
+        } else if (resp.status === 429) {
+          undo("You're posting too fast. Try again in a minute.");
+        } else {
+          undo("The message couldn't be saved.");
+        }
+      })
+      .catch(function () {
+        undo("The message couldn't be saved.");
+      });
+  });
+})();
@@ -0,0 +1,34 @@
+// Keyboard shortcuts for the moderation queue: j and k move between items,
+// and the keys in an item's data-key attributes act on the selected one.
+(function () {
+  const items = Array.from(document.querySelectorAll("[data-moderation-item]"));
+  if (items.length === 0) return;
+  let selected = 0;
+
+  function select(i) {
+    items[selected].classList.remove("bg-pink-50");
+    selected = Math.max(0, Math.min(items.length - 1, i));
+    items[selected].classList.add("bg-pink-50");
+    items[selected].scrollIntoView({ block: "nearest" });
+  }
+
+  document.addEventListener("keydown", function (e) {
+    if (e.ctrlKey || e.metaKey || e.altKey || e.target.closest("input, textarea, select")) return;
+    if (e.key === "j") {
+      select(selected + 1);
+    } else if (e.key === "k") {
+      select(selected - 1);
+    } else {
+      const target = items[selected].querySelector('[data-key="' + e.key + '"]');
+      if (!target) return;
+      if (target.tagName === "FORM") {
+        target.requestSubmit();
+      } else {
+        target.click();
+      }
+    }
+    e.preventDefault();
+  });
+
+  select(0);
+})();
@@ -0,0 +1,126 @@
+// Command palette: Ctrl/Cmd-K opens a list of the pages and actions open
+// to the visitor, fetched once from /commands (/admin/commands on admin
+// pages, for the admin pages too). Typing filters it, the arrow keys move
+// and Enter goes.
+(function () {
+  const dialog = document.querySelector("[data-palette]");
+  if (!dialog) return;
+  const input = dialog.querySelector("input");
+  const list = dialog.querySelector("ul");
+  const url = location.pathname.startsWith("/admin") ? "/admin/commands" : "/commands";
+  let loading = null;
+  let csrfToken = "";
+  let commands = [];
+  let shown = [];
+  let selected = 0;
+
+  function load() {
+    if (!loading) {
+      loading = fetch(url, { headers: { Accept: "application/json" } })
+        .then(function (resp) {
+          if (!resp.ok) throw new Error(resp.statusText);
+          return resp.json();
+        })
+        .then(function (data) {
+          csrfToken = data.csrf_token;
+          commands = data.commands;
+        })
+        .catch(function () {
+          loading = null;
+        });
+    }
+    return loading;
+  }
+
+  function render() {
+    const q = input.value.trim().toLowerCase();
+    shown = commands.filter(function (c) {
+      return (c.group + " " + c.title).toLowerCase().includes(q);
+    });
+    selected = Math.min(selected, Math.max(0, shown.length - 1));
+    list.replaceChildren();
+    shown.forEach(function (c, i) {
+      const item = document.createElement("li");
+      item.setAttribute("role", "option");
+      item.className = "px-3 py-2 flex justify-between gap-4 cursor-pointer" + (i === selected ? " bg-pink-50" : "");
+      const title = document.createElement("span");
+      title.className = "text-gray-900 truncate";
+      title.textContent = c.title;
+      const group = document.createElement("span");
+      group.className = "text-xs text-gray-500";
+      group.textContent = c.group;
+      item.append(title, group);
+      item.addEventListener("click", function () {
+        run(c);
+      });
+      list.append(item);
+      if (i === selected) item.scrollIntoView({ block: "nearest" });
+    });
+    if (shown.length === 0) {
+      const empty = document.createElement("li");
+      empty.className = "px-3 py-2 text-gray-500";
+      empty.textContent = "Nothing matches.";
+      list.append(empty);
+    }
+  }
+
+  function run(c) {
+    dialog.close();
+    if (c.method !== "post") {
+      location.href = c.url;
+      return;
+    }
+    const form = document.createElement("form");
+    form.method = "post";
+    form.action = c.url;
+    const token = document.createElement("input");
+    token.type = "hidden";
+    token.name = "csrf_token";
+    token.value = csrfToken;
+    form.append(token);
+    document.body.append(form);
+    form.submit();
+  }
+
+  function open() {
+    input.value = "";
+    selected = 0;
+    dialog.showModal();
+    load().then(render);
+  }
+
+  document.addEventListener("keydown", function (e) {
+    if ((e.ctrlKey || e.metaKey) && e.key === "k") {
+      e.preventDefault();
+      if (dialog.open) {
+        dialog.close();
+      } else {
+        open();
+      }
+    }
+  });
+
+  input.addEventListener("input", function () {
+    selected = 0;
+    render();
+  });
+
+  input.addEventListener("keydown", function (e) {
+    if (e.key === "ArrowDown") {
+      selected = Math.min(selected + 1, shown.length - 1);
+    } else if (e.key === "ArrowUp") {
+      selected = Math.max(selected - 1, 0);
+    } else if (e.key === "Enter") {
+      if (shown[selected]) run(shown[selected]);
+    } else {
+      return;
+    }
+    e.preventDefault();
+    render();
+  });
+
+  // A click on the backdrop lands on the dialog itself
+  dialog.addEventListener("click", function (e) {
+    if (e.target === dialog) dialog.close();
+  });
+})();
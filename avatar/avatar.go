@@ -0,0 +1,203 @@
+// Package avatar turns uploaded pictures into square profile images in a
+// few fixed sizes, and draws identicons for users who haven't uploaded one.
+package avatar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+
+	"gighub/imaging"
+)
+
+// Sizes are the square edge lengths, in pixels, every avatar is stored at.
+var Sizes = []int{32, 64, 256}
+
+// maxPixels bounds decoded images so a small file can't claim to be huge.
+const maxPixels = 4096 * 4096
+
+var ErrInvalid = errors.New("avatar must be a JPEG, PNG or GIF image")
+
+// Process decodes an uploaded image, turns it upright according to its EXIF
+// orientation, crops it to a centered square and returns it as PNG in each
+// of Sizes. Re-encoding drops EXIF and any other metadata.
+func Process(data []byte) (map[int][]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("avatar is too large, at most %d pixels", maxPixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	img = orient(img, orientation(data))
+	img = cropSquare(img)
+
+	out := map[int][]byte{}
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, imaging.Resize(img, size, size)); err != nil {
+			return nil, err
+		}
+		out[size] = buf.Bytes()
+	}
+	return out, nil
+}
+
+// ValidSize reports whether avatars are stored at size.
+func ValidSize(size int) bool {
+	for _, s := range Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// orientation reads the EXIF orientation tag from a JPEG, 1 (upright) when
+// there is none.
+func orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || i+2+length > len(data) {
+			break // start of scan, no more metadata
+		}
+		seg := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(seg) > 14 && string(seg[:6]) == "Exif\x00\x00" {
+			return exifOrientation(seg[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation finds tag 0x0112 in the first IFD of a TIFF block.
+func exifOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+		}
+	}
+	return 1
+}
+
+// orient applies an EXIF orientation so the image displays upright.
+func orient(img image.Image, o int) image.Image {
+	if o == 1 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if o >= 5 {
+		w, h = h, w
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Where the pixel at (x, y) of the upright image comes from
+			var sx, sy int
+			switch o {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, w-1-x
+			case 7:
+				sx, sy = h-1-y, w-1-x
+			case 8:
+				sx, sy = h-1-y, x
+			}
+			out.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return out
+}
+
+func cropSquare(img image.Image) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	out := image.NewNRGBA(image.Rect(0, 0, side, side))
+	for dy := 0; dy < side; dy++ {
+		for dx := 0; dx < side; dx++ {
+			out.Set(dx, dy, img.At(x+dx, y+dy))
+		}
+	}
+	return out
+}
+
+// Identicon draws a symmetric 5x5 pattern derived from seed, as PNG.
+func Identicon(seed string, size int) ([]byte, error) {
+	sum := sha256.Sum256([]byte(seed))
+	fg := color.NRGBA{R: sum[0]/2 + 64, G: sum[1]/2 + 64, B: sum[2]/2 + 64, A: 255}
+	bg := color.NRGBA{R: 240, G: 240, B: 240, A: 255}
+
+	const cells = 5
+	cell := size / (cells + 1)
+	margin := (size - cell*cells) / 2
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.SetNRGBA(x, y, bg)
+		}
+	}
+	for row := 0; row < cells; row++ {
+		for col := 0; col < (cells+1)/2; col++ {
+			if sum[3+row*3+col]%2 == 0 {
+				continue
+			}
+			for _, c := range []int{col, cells - 1 - col} {
+				for y := 0; y < cell; y++ {
+					for x := 0; x < cell; x++ {
+						img.SetNRGBA(margin+c*cell+x, margin+row*cell+y, fg)
+					}
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
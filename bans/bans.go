@@ -0,0 +1,141 @@
+// Package bans keeps misbehaving accounts in check. A hard ban stops the
+// user logging in, and a shadow ban lets them go on posting while only
+// they see what they post. Bans are permanent or expire on their own.
+// Addresses are blocked separately, by the abuse package.
+package bans
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gighub/db"
+)
+
+// Kinds of ban.
+const (
+	Hard   = "ban"
+	Shadow = "shadow"
+)
+
+// cacheTTL is how long the active bans are reused before they are read
+// again. Changes made through the Store apply immediately.
+const cacheTTL = 30 * time.Second
+
+// Store reads bans from the user_bans table.
+type Store struct {
+	Queries *db.Queries
+
+	mu     sync.Mutex
+	active map[int64][]db.UserBan
+	loaded time.Time
+}
+
+func New(queries *db.Queries) *Store {
+	return &Store{Queries: queries}
+}
+
+// Ban bans a user for d, or for good when d is 0. The reason is shown to
+// hard-banned users when they try to log in.
+func (s *Store) Ban(ctx context.Context, userID int64, kind, reason string, d time.Duration) (db.UserBan, error) {
+	p := db.CreateUserBanParams{UserID: userID, Kind: kind, Reason: reason}
+	if d > 0 {
+		p.ExpiresAt = sql.NullTime{Time: time.Now().Add(d).UTC(), Valid: true}
+	}
+	ban, err := s.Queries.CreateUserBan(ctx, p)
+	if err != nil {
+		return db.UserBan{}, fmt.Errorf("error banning user %d: %w", userID, err)
+	}
+	s.Invalidate()
+	return ban, nil
+}
+
+// Lift ends a ban early. It reports false if the ban had already ended.
+func (s *Store) Lift(ctx context.Context, id int64) (bool, error) {
+	n, err := s.Queries.LiftUserBan(ctx, db.LiftUserBanParams{
+		LiftedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:       id,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error lifting ban %d: %w", id, err)
+	}
+	s.Invalidate()
+	return n > 0, nil
+}
+
+// Active returns the user's ban of the given kind, if one is in force.
+// With several, it's the one that lasts longest.
+func (s *Store) Active(ctx context.Context, userID int64, kind string) (db.UserBan, bool, error) {
+	if userID == 0 {
+		return db.UserBan{}, false, nil
+	}
+	all, err := s.load(ctx)
+	if err != nil {
+		return db.UserBan{}, false, err
+	}
+	now := time.Now()
+	var found db.UserBan
+	ok := false
+	for _, b := range all[userID] {
+		if b.Kind != kind || (b.ExpiresAt.Valid && !b.ExpiresAt.Time.After(now)) {
+			continue
+		}
+		if !ok || !b.ExpiresAt.Valid || (found.ExpiresAt.Valid && b.ExpiresAt.Time.After(found.ExpiresAt.Time)) {
+			found, ok = b, true
+		}
+	}
+	return found, ok, nil
+}
+
+// Shadowed reports whether the user is shadow-banned. If that can't be
+// told, they are treated as not banned.
+func (s *Store) Shadowed(ctx context.Context, userID int64) bool {
+	_, ok, err := s.Active(ctx, userID, Shadow)
+	if err != nil {
+		log.Print(err)
+	}
+	return ok
+}
+
+// LiftExpired marks bans that ran out as lifted. They stopped applying
+// when they expired; this keeps the table's record of them straight.
+func (s *Store) LiftExpired(ctx context.Context) error {
+	n, err := s.Queries.LiftExpiredUserBans(ctx, sql.NullTime{Time: time.Now().UTC(), Valid: true})
+	if err != nil {
+		return fmt.Errorf("error lifting expired bans: %w", err)
+	}
+	if n > 0 {
+		log.Printf("Lifted %d expired bans", n)
+		s.Invalidate()
+	}
+	return nil
+}
+
+// Invalidate drops the cached bans after they were changed.
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	s.active = nil
+	s.mu.Unlock()
+}
+
+// load returns the bans in force, by user.
+func (s *Store) load(ctx context.Context) (map[int64][]db.UserBan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil && time.Since(s.loaded) < cacheTTL {
+		return s.active, nil
+	}
+	list, err := s.Queries.ListActiveUserBans(ctx, sql.NullTime{Time: time.Now().UTC(), Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("error loading bans: %w", err)
+	}
+	s.active = map[int64][]db.UserBan{}
+	for _, b := range list {
+		s.active[b.UserID] = append(s.active[b.UserID], b)
+	}
+	s.loaded = time.Now()
+	return s.active, nil
+}
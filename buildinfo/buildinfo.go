@@ -0,0 +1,53 @@
+// Package buildinfo describes the running binary: the commit it was built
+// from, when, and with which Go. The Go toolchain records the commit when
+// building inside a git checkout; builds without one, like the Docker
+// image, pass it with
+//
+//	go build -ldflags "-X gighub/buildinfo.commit=SHA -X gighub/buildinfo.built=2006-01-02T15:04:05Z"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set with -ldflags -X, and win over what the toolchain recorded.
+var commit, built string
+
+// Info is what's known about the build. Fields the build didn't record
+// are empty, except Commit, which is "local" then.
+type Info struct {
+	Commit     string `json:"commit"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified,omitempty"`
+	BuildTime  string `json:"build_time,omitempty"`
+	GoVersion  string `json:"go_version"`
+}
+
+// Get returns the build info, read once.
+var Get = sync.OnceValue(func() Info {
+	info := Info{GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Commit = s.Value
+			case "vcs.time":
+				info.CommitTime = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	if commit != "" {
+		info.Commit, info.CommitTime, info.Modified = commit, "", false
+	}
+	if built != "" {
+		info.BuildTime = built
+	}
+	if info.Commit == "" {
+		info.Commit = "local"
+	}
+	return info
+})
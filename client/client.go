@@ -0,0 +1,179 @@
+// Package client calls the gighub JSON API (/api/v1) for integrations and
+// tools. It only depends on the standard library.
+//
+//	c := client.New("https://gighub.example", token)
+//	me, err := c.Me(ctx)
+//
+// Calls that fail because of rate limiting, a server error or the network
+// are retried with backoff. POSTs are sent with an Idempotency-Key, so a
+// retry never does the work twice.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls one gighub instance as the owner of Token.
+type Client struct {
+	// BaseURL is where gighub is served, like "https://gighub.example".
+	BaseURL string
+	// Token is an API token, created on the account page.
+	Token      string
+	HTTPClient *http.Client
+	// MaxRetries is how many times a failed call is tried again.
+	MaxRetries int
+	// ChunkSize is how much of a file Upload sends per request. It must
+	// not be above the server's MAX_UPLOAD_SIZE.
+	ChunkSize int64
+}
+
+// New returns a client with the default timeout, retries and chunk size.
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: time.Minute},
+		MaxRetries: 3,
+		ChunkSize:  4 << 20,
+	}
+}
+
+// Error is an error response from the API. RequestID identifies the
+// request in the server's logs.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"error"`
+	RequestID  string `json:"request_id"`
+}
+
+func (e *Error) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("gighub: %d %s (request %s)", e.StatusCode, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("gighub: %d %s", e.StatusCode, e.Message)
+}
+
+// StatusCode returns the HTTP status of an API error, or 0 for any other
+// error, such as a network failure.
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// request is one API call. body is kept as bytes so the call can be sent
+// again.
+type request struct {
+	method      string
+	path        string
+	header      http.Header
+	contentType string
+	body        []byte
+}
+
+// call sends a JSON request and decodes the response into out, which may
+// be nil. in is encoded as the body unless it is nil.
+func (c *Client) call(ctx context.Context, method, path string, in, out any) (*http.Response, error) {
+	req := request{method: method, path: path}
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		req.body, req.contentType = b, "application/json"
+	}
+	return c.do(ctx, req, out)
+}
+
+// do sends req, retrying it while it fails in a way that may pass.
+func (c *Client) do(ctx context.Context, req request, out any) (*http.Response, error) {
+	if req.method == http.MethodPost {
+		if req.header == nil {
+			req.header = http.Header{}
+		}
+		req.header.Set("Idempotency-Key", rand.Text())
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req, out)
+		if err == nil || attempt >= c.MaxRetries || !retryable(err) {
+			return resp, err
+		}
+		select {
+		case <-time.After(backoff(attempt, resp)):
+		case <-ctx.Done():
+			return resp, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, out any) (*http.Response, error) {
+	r, err := http.NewRequestWithContext(ctx, req.method, c.BaseURL+"/api/v1"+req.path, bytes.NewReader(req.body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "Bearer "+c.Token)
+	r.Header.Set("Accept", "application/json")
+	if req.contentType != "" {
+		r.Header.Set("Content-Type", req.contentType)
+	}
+	resp, err := c.HTTPClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		if json.NewDecoder(resp.Body).Decode(apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return resp, apiErr
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("gighub: decoding %s %s: %w", req.method, req.path, err)
+		}
+	}
+	return resp, nil
+}
+
+// retryable reports whether a call that failed with err may succeed if
+// sent again: it was rate limited, the server failed, or the network did.
+func retryable(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff is how long to wait before retry attempt+1: what Retry-After
+// asks for, or an exponential delay with jitter.
+func backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	d := min(500*time.Millisecond<<min(attempt, 6), 30*time.Second)
+	return d/2 + mrand.N(d/2)
+}
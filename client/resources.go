@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// User is the owner of the token.
+type User struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email"`
+	Verified  bool      `json:"verified"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Guestbook is the shared message. ETag is its version, for PutGuestbook.
+type Guestbook struct {
+	Message string `json:"message"`
+	ETag    string `json:"-"`
+}
+
+// Webhook is an endpoint events are delivered to. Secret signs the
+// deliveries.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is one attempt at delivering an event to a webhook.
+// ResponseCode is nil until the endpoint has answered.
+type WebhookDelivery struct {
+	ID           int64     `json:"id"`
+	EventID      int64     `json:"event_id"`
+	EventType    string    `json:"event_type"`
+	Status       string    `json:"status"`
+	Attempts     int64     `json:"attempts"`
+	ResponseCode *int64    `json:"response_code"`
+	Error        string    `json:"error"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RestHook is a subscription of one event to a target URL, as Zapier and
+// similar tools make.
+type RestHook struct {
+	ID        int64  `json:"id"`
+	TargetURL string `json:"target_url"`
+	Event     string `json:"event"`
+	Secret    string `json:"secret"`
+}
+
+// Event is something that happened, as webhooks deliver it.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Upload is a resumable upload. Offset is how much of it arrived. URL is
+// set once the finished file has been scanned and found clean.
+type Upload struct {
+	ID          string    `json:"id"`
+	Filename    string    `json:"filename"`
+	Size        int64     `json:"size"`
+	Offset      int64     `json:"offset"`
+	Complete    bool      `json:"complete"`
+	ContentType string    `json:"content_type"`
+	Status      string    `json:"status"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ListOptions narrows and orders a list. Filter maps attributes to the
+// values to match, Sort is like "-created_at,id", and Fields limits the
+// attributes returned.
+type ListOptions struct {
+	Filter map[string][]string
+	Sort   string
+	Fields []string
+}
+
+// query encodes the options for a resource named kind, skipping offset
+// items.
+func (o ListOptions) query(kind string, offset int) string {
+	q := url.Values{}
+	for attr, values := range o.Filter {
+		q.Set("filter["+attr+"]", strings.Join(values, ","))
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	if len(o.Fields) > 0 {
+		q.Set("fields["+kind+"]", strings.Join(o.Fields, ","))
+	}
+	if offset > 0 {
+		q.Set("page[offset]", strconv.Itoa(offset))
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// Me returns the user the token belongs to.
+func (c *Client) Me(ctx context.Context) (User, error) {
+	var u User
+	_, err := c.call(ctx, http.MethodGet, "/me", nil, &u)
+	return u, err
+}
+
+// Guestbook returns the guestbook message.
+func (c *Client) Guestbook(ctx context.Context) (Guestbook, error) {
+	var gb Guestbook
+	resp, err := c.call(ctx, http.MethodGet, "/guestbook", nil, &gb)
+	if err == nil {
+		gb.ETag = resp.Header.Get("ETag")
+	}
+	return gb, err
+}
+
+// PutGuestbook replaces the guestbook message. With an ifMatch ETag from
+// Guestbook the write fails with a 412 if the message changed since.
+func (c *Client) PutGuestbook(ctx context.Context, message, ifMatch string) (Guestbook, error) {
+	req := request{method: http.MethodPut, path: "/guestbook", contentType: "application/json"}
+	req.body, _ = json.Marshal(Guestbook{Message: message})
+	if ifMatch != "" {
+		req.header = http.Header{"If-Match": {ifMatch}}
+	}
+	var gb Guestbook
+	resp, err := c.do(ctx, req, &gb)
+	if err == nil {
+		gb.ETag = resp.Header.Get("ETag")
+	}
+	return gb, err
+}
+
+// Webhooks lists the registered webhook endpoints.
+func (c *Client) Webhooks(ctx context.Context, opts ListOptions) ([]Webhook, error) {
+	var hooks []Webhook
+	_, err := c.call(ctx, http.MethodGet, "/webhooks"+opts.query("webhooks", 0), nil, &hooks)
+	return hooks, err
+}
+
+// CreateWebhook registers endpoint for the given event types. The
+// returned Secret is only shown here.
+func (c *Client) CreateWebhook(ctx context.Context, endpoint string, events []string) (Webhook, error) {
+	var hook Webhook
+	_, err := c.call(ctx, http.MethodPost, "/webhooks", map[string]any{"url": endpoint, "events": events}, &hook)
+	return hook, err
+}
+
+// DeleteWebhook removes an endpoint.
+func (c *Client) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := c.call(ctx, http.MethodDelete, "/webhooks/"+strconv.FormatInt(id, 10), nil, nil)
+	return err
+}
+
+// deliveriesPageSize is how many deliveries the server returns at most.
+const deliveriesPageSize = 50
+
+// Deliveries iterates over a webhook's deliveries, most recent first
+// unless opts sorts them otherwise. Pages are fetched as the loop needs
+// them; an error ends the iteration.
+func (c *Client) Deliveries(ctx context.Context, webhookID int64, opts ListOptions) iter.Seq2[WebhookDelivery, error] {
+	return func(yield func(WebhookDelivery, error) bool) {
+		for offset := 0; ; {
+			var page []WebhookDelivery
+			path := "/webhooks/" + strconv.FormatInt(webhookID, 10) + "/deliveries" + opts.query("deliveries", offset)
+			if _, err := c.call(ctx, http.MethodGet, path, nil, &page); err != nil {
+				yield(WebhookDelivery{}, err)
+				return
+			}
+			for _, d := range page {
+				if !yield(d, nil) {
+					return
+				}
+			}
+			if len(page) < deliveriesPageSize {
+				return
+			}
+			offset += len(page)
+		}
+	}
+}
+
+// SubscribeHook subscribes targetURL to one event type.
+func (c *Client) SubscribeHook(ctx context.Context, targetURL, event string) (RestHook, error) {
+	var hook RestHook
+	_, err := c.call(ctx, http.MethodPost, "/hooks", map[string]string{"target_url": targetURL, "event": event}, &hook)
+	return hook, err
+}
+
+// UnsubscribeHook removes a subscription.
+func (c *Client) UnsubscribeHook(ctx context.Context, id int64) error {
+	_, err := c.call(ctx, http.MethodDelete, "/hooks/"+strconv.FormatInt(id, 10), nil, nil)
+	return err
+}
+
+// TriggerEvents returns the latest 50 events of one type, newest first.
+func (c *Client) TriggerEvents(ctx context.Context, eventType string) ([]Event, error) {
+	var events []Event
+	_, err := c.call(ctx, http.MethodGet, "/triggers/"+url.PathEscape(eventType), nil, &events)
+	return events, err
+}
+
+// CreateUpload starts a resumable upload of size bytes.
+func (c *Client) CreateUpload(ctx context.Context, filename string, size int64) (Upload, error) {
+	var u Upload
+	_, err := c.call(ctx, http.MethodPost, "/uploads", map[string]any{"filename": filename, "size": size}, &u)
+	return u, err
+}
+
+// GetUpload returns an upload's progress.
+func (c *Client) GetUpload(ctx context.Context, id string) (Upload, error) {
+	var u Upload
+	_, err := c.call(ctx, http.MethodGet, "/uploads/"+url.PathEscape(id), nil, &u)
+	return u, err
+}
+
+// AppendUpload sends the chunk that starts at offset.
+func (c *Client) AppendUpload(ctx context.Context, id string, offset int64, chunk []byte) (Upload, error) {
+	req := request{
+		method:      http.MethodPatch,
+		path:        "/uploads/" + url.PathEscape(id),
+		header:      http.Header{"Upload-Offset": {strconv.FormatInt(offset, 10)}},
+		contentType: "application/offset+octet-stream",
+		body:        chunk,
+	}
+	var u Upload
+	_, err := c.do(ctx, req, &u)
+	return u, err
+}
+
+// DeleteUpload cancels an upload, or deletes the finished file.
+func (c *Client) DeleteUpload(ctx context.Context, id string) error {
+	_, err := c.call(ctx, http.MethodDelete, "/uploads/"+url.PathEscape(id), nil, nil)
+	return err
+}
+
+// Upload sends a file of size bytes from r in ChunkSize chunks. When a
+// chunk fails the upload resumes from what the server has, so only
+// errors that persist past the retries end it.
+func (c *Client) Upload(ctx context.Context, filename string, r io.ReaderAt, size int64) (Upload, error) {
+	u, err := c.CreateUpload(ctx, filename, size)
+	if err != nil {
+		return u, err
+	}
+	buf := make([]byte, c.ChunkSize)
+	for !u.Complete {
+		n, err := r.ReadAt(buf[:min(c.ChunkSize, size-u.Offset)], u.Offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return u, err
+		}
+		next, err := c.AppendUpload(ctx, u.ID, u.Offset, buf[:n])
+		if err == nil {
+			u = next
+			continue
+		}
+		// A chunk that was cut off or already arrived leaves the server
+		// at a different offset; pick up from there
+		if code := StatusCode(err); code != http.StatusConflict && code != http.StatusBadRequest {
+			return u, err
+		}
+		current, getErr := c.GetUpload(ctx, u.ID)
+		if getErr != nil || current.Offset == u.Offset {
+			return u, fmt.Errorf("gighub: uploading %s at offset %d: %w", filename, u.Offset, err)
+		}
+		u = current
+	}
+	return u, nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gighub/app"
+	"gighub/buildinfo"
+	"gighub/config"
+	"gighub/db"
+	"gighub/perf"
+)
+
+// loadConfig parses the command's flags along with the ones every command
+// shares, then loads settings from .env, the environment and those flags.
+func loadConfig(fs *flag.FlagSet, args []string) (*config.Config, error) {
+	load := config.Flags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+	return load()
+}
+
+// openDB opens and migrates the configured database.
+func openDB(cfg *config.Config) (*sql.DB, *db.Queries, error) {
+	db.Stats.SlowThreshold = cfg.Admin.SlowQuery
+	return db.Setup(cfg.DataDir, cfg.DBName)
+}
+
+func migrate(fs *flag.FlagSet, args []string) error {
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	dbConn, queries, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	defer queries.Close()
+	version, err := db.Version(dbConn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database is at version %d\n", version)
+	return nil
+}
+
+func seed(fs *flag.FlagSet, args []string) error {
+	email := fs.String("email", "demo@example.com", "email of the demo user")
+	password := fs.String("password", "demo", "password of the demo user")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	dbConn, queries, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	defer queries.Close()
+
+	// Seeding twice leaves what the first run added alone
+	ctx := context.Background()
+	if _, err := queries.GetUserByEmail(ctx, *email); err == sql.ErrNoRows {
+		if err := addVerifiedUser(ctx, queries, *email, *password); err != nil {
+			return err
+		}
+		fmt.Printf("Added %s with password %q\n", *email, *password)
+	} else if err != nil {
+		return err
+	}
+	if _, err := queries.GetMessage(ctx); err == sql.ErrNoRows {
+		if err := queries.UpsertMessage(ctx, "Welcome to gighub!"); err != nil {
+			return err
+		}
+		fmt.Println("Added a guestbook message")
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func sendTestEmail(fs *flag.FlagSet, args []string) error {
+	to := fs.String("to", "", "address to send the email to")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if *to == "" {
+		fmt.Fprintln(os.Stderr, "-to is required")
+		return errUsage
+	}
+	mailer := &app.Mailer{Config: cfg.SMTP}
+	body := fmt.Sprintf("This is a test email from gighub at %s, sent through %s.", cfg.BaseURL, cfg.SMTP.Host)
+	if err := mailer.Send(context.Background(), *to, "gighub test email", body); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+	fmt.Printf("Sent a test email to %s\n", *to)
+	return nil
+}
+
+// backup copies the database with VACUUM INTO, which is consistent even
+// while the server is writing to it.
+func backup(fs *flag.FlagSet, args []string) error {
+	out := fs.String("out", "", "file to write, by default a timestamped one in DATA_DIR/backups")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = filepath.Join(cfg.DataDir, "backups", "gighub-"+time.Now().UTC().Format("20060102-150405")+".db")
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0o755); err != nil {
+		return err
+	}
+	dbConn, queries, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	defer queries.Close()
+	if _, err := dbConn.Exec("VACUUM INTO ?", *out); err != nil {
+		return fmt.Errorf("error backing up to %s: %w", *out, err)
+	}
+	fmt.Printf("Backed up to %s\n", *out)
+	return nil
+}
+
+// runJobs lists the background jobs, or with "run NAME" runs one in the
+// foreground instead of waiting for its schedule.
+func runJobs(fs *flag.FlagSet, args []string) error {
+	run := len(args) > 0 && args[0] == "run"
+	if run {
+		args = args[1:]
+	}
+	var name string
+	if run && len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	payload := fs.String("payload", "{}", "JSON payload to pass to the job")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || (run && name == "") {
+		fmt.Fprintln(os.Stderr, "Usage: gighub jobs [run NAME [-payload JSON]]")
+		return errUsage
+	}
+	if !json.Valid([]byte(*payload)) {
+		fmt.Fprintln(os.Stderr, "-payload is not valid JSON")
+		return errUsage
+	}
+	dbConn, queries, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	defer queries.Close()
+
+	// Handlers are registered along with the rest of the app
+	server, err := app.New(cfg, dbConn, queries)
+	if err != nil {
+		return err
+	}
+	if !run {
+		for _, name := range server.Jobs.Names() {
+			fmt.Println(name)
+		}
+		return nil
+	}
+	start := time.Now()
+	err = server.Jobs.RunNow(context.Background(), name, json.RawMessage(*payload))
+	server.Mailer.Wait()
+	if err != nil {
+		return fmt.Errorf("job %s failed: %w", name, err)
+	}
+	fmt.Printf("Ran %s in %s\n", name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func version(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	info := buildinfo.Get()
+	commit := info.Commit
+	if info.Modified {
+		commit += " (modified)"
+	}
+	fmt.Println("commit:    ", commit)
+	if info.CommitTime != "" {
+		fmt.Println("committed: ", info.CommitTime)
+	}
+	if info.BuildTime != "" {
+		fmt.Println("built:     ", info.BuildTime)
+	}
+	fmt.Println("go:        ", info.GoVersion)
+	fmt.Println("migrations:", db.Latest())
+	return nil
+}
+
+// loadTest runs "gighub perf", which talks to a running instance over
+// HTTP and so shares no settings with the other commands.
+func loadTest(_ *flag.FlagSet, args []string) error {
+	if code := perf.Main(args); code != 0 {
+		os.Exit(code)
+	}
+	return nil
+}
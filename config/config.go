@@ -0,0 +1,542 @@
+// Package config loads every setting the server needs in one place, so a
+// misconfigured deployment fails at startup instead of on first use.
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/keys"
+	"gighub/ratelimit"
+	"gighub/utils"
+
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	// Env is "production" in deployments, which turns on secure cookies
+	// and makes SessionKeys and BaseURL required.
+	Env     string
+	Port    string
+	BaseURL string
+	// TrustedProxies are the reverse proxies in front of the server, whose
+	// X-Forwarded-For says who the visitor is. Without them every visitor
+	// behind a proxy has the proxy's address, and shares its rate limits
+	// and bans.
+	TrustedProxies utils.Proxies
+	// SessionKeys sign the OAuth state cookie. SESSION_SECRET holds one
+	// secret; SESSION_KEYS holds a ring to rotate with, see keys.Parse.
+	SessionKeys keys.Ring
+	DataDir     string
+	DBName      string
+	// SentryDSN sends errors and panics to Sentry. Without it they are
+	// only logged.
+	SentryDSN string
+
+	Limits    Limits
+	TLS       TLS
+	Google    OAuth
+	Apple     Apple
+	SMTP      SMTP
+	Admin     Admin
+	Signup    Signup
+	Storage   Storage
+	API       API
+	Throttles Throttles
+	Webhooks  Webhooks
+	Cache     Cache
+}
+
+// Limits bounds how long a request may take and how much it may send.
+// Routes can set tighter limits, or a larger body for uploads.
+type Limits struct {
+	RequestTimeout time.Duration
+	MaxBodySize    int64
+	// MaxUploadSize applies to multipart form uploads and resumable
+	// upload chunks instead.
+	MaxUploadSize int64
+	// MaxResumableSize bounds the total size of a resumable upload.
+	MaxResumableSize int64
+	// StorageQuota bounds the total size of each user's uploads.
+	StorageQuota int64
+	// ReferralBonus is the extra storage a user earns for each referred
+	// account that gets verified.
+	ReferralBonus int64
+}
+
+// TLS configures built-in HTTPS with certificates from Let's Encrypt. It is
+// off unless Domains is set; then Port only answers ACME challenges and
+// redirects to HTTPS on HTTPSPort.
+type TLS struct {
+	Domains   []string
+	Email     string
+	CacheDir  string
+	HTTPSPort string
+	// H2C serves HTTP/2 without TLS on Port, for a proxy in front that
+	// terminates TLS and talks HTTP/2 to the app. Over TLS HTTP/2 is
+	// always on.
+	H2C bool
+}
+
+// Enabled reports whether the server should terminate TLS itself.
+func (t TLS) Enabled() bool {
+	return len(t.Domains) > 0
+}
+
+// OAuth holds the credentials for a social login provider.
+type OAuth struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// Apple configures Sign in with Apple. Apple has no fixed client secret:
+// the server signs its own with PrivateKey, a key made for the team in the
+// Apple developer account.
+type Apple struct {
+	// ClientID is the Services ID, like "com.example.gighub.web".
+	ClientID string
+	TeamID   string
+	KeyID    string
+	// PrivateKey is the contents of the AuthKey_KEYID.p8 file.
+	PrivateKey string
+}
+
+// Enabled reports whether Sign in with Apple is configured.
+func (a Apple) Enabled() bool {
+	return a.ClientID != ""
+}
+
+// SMTP configures outgoing email. Email is disabled when Host is empty.
+type SMTP struct {
+	Host string
+	Port string
+	User string
+	Pass string
+	From string
+}
+
+// Admin configures the admin pages.
+type Admin struct {
+	Username string
+	Password string
+	// Debug serves pprof profiles and expvar at /debug to admins.
+	Debug bool
+	// AlertEmail receives an email when a health check keeps failing.
+	AlertEmail string
+	// SlowQuery is how long a query may take before it is logged with its
+	// query plan.
+	SlowQuery time.Duration
+	// JobFailureRate is the share of jobs, or of emails, that may fail in
+	// an hour before the jobs or email health check fails.
+	JobFailureRate float64
+}
+
+// Signup controls what happens to accounts whose email is never verified.
+type Signup struct {
+	// RemindAfter is when a second verification email is sent.
+	RemindAfter time.Duration
+	// DeleteAfter is when the account is deleted, freeing the address.
+	DeleteAfter time.Duration
+}
+
+// Storage configures where uploaded files are kept: in Dir, or in an
+// S3-compatible bucket when S3Bucket is set.
+type Storage struct {
+	Dir string
+	// SigningKeys sign links to files on local disk. They default to the
+	// session keys.
+	SigningKeys keys.Ring
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+
+	// CDNURL is a CDN origin in front of this server. When set, assets,
+	// avatars and links to files on local disk point at it instead of
+	// BaseURL. Presigned S3 links are bound to the bucket's host and don't
+	// go through it.
+	CDNURL string
+	// CDNPurgeURL is called with the URLs of replaced or deleted files,
+	// authenticated with CDNPurgeToken.
+	CDNPurgeURL   string
+	CDNPurgeToken string
+
+	// ImageProxyHosts lists the external hosts /img may fetch images from.
+	ImageProxyHosts []string
+
+	// ModerationURL is an external API finished uploads are sent to before
+	// they become visible, authenticated with ModerationToken. Without it
+	// uploads are only checked for duplicates.
+	ModerationURL   string
+	ModerationToken string
+}
+
+// S3 reports whether files are kept in a bucket.
+func (s Storage) S3() bool {
+	return s.S3Bucket != ""
+}
+
+// Cache configures the cache of public pages served to anonymous visitors.
+type Cache struct {
+	// TTL is how long a page is served from the cache at most. Writes that
+	// change a page invalidate it sooner.
+	TTL time.Duration
+	// RedisURL shares the cache between instances, e.g.
+	// "redis://:password@localhost:6379/0". Without it each process keeps
+	// its own.
+	RedisURL string
+}
+
+// Throttles overrides the default abuse limits, e.g.
+// "signup=5,email=10,message=10,global:email=100" (per minute).
+type Throttles struct {
+	Limits string
+}
+
+type API struct {
+	// RateLimits overrides the default quotas, e.g. "read=120,write=60,ip=120".
+	RateLimits      string
+	CORSOrigins     []string
+	CORSCredentials bool
+}
+
+// Webhooks holds signing keys for inbound webhook providers.
+type Webhooks struct {
+	StripeKeys   keys.Ring
+	CalendlyKeys keys.Ring
+}
+
+// Production reports whether the server runs in production.
+func (c *Config) Production() bool {
+	return c.Env == "production"
+}
+
+// Enabled reports whether all SMTP settings are present.
+func (s SMTP) Enabled() bool {
+	return s.Host != "" && s.Port != "" && s.User != "" && s.Pass != "" && s.From != ""
+}
+
+// Load reads settings from an env file (.env unless -env-file says
+// otherwise), the environment and command line flags, in increasing order of
+// precedence. All problems are reported together.
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("gighub", flag.ContinueOnError)
+	load := Flags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return load()
+}
+
+// Flags adds the flags Load understands to fs, for commands with flags of
+// their own. Once fs is parsed, the returned function loads the config.
+func Flags(fs *flag.FlagSet) func() (*Config, error) {
+	envFile := fs.String("env-file", ".env", "file to read environment variables from")
+	port := fs.String("port", "", "port to listen on (overrides PORT)")
+	dataDir := fs.String("data-dir", "", "directory holding the database (overrides DATA_DIR)")
+	return func() (*Config, error) {
+		return load(*envFile, *port, *dataDir)
+	}
+}
+
+func load(envFile, port, dataDir string) (*Config, error) {
+	// Variables already in the environment win over the file.
+	if err := godotenv.Load(envFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading %s: %w", envFile, err)
+	}
+
+	c := &Config{
+		Env:       os.Getenv("ENV"),
+		Port:      env("PORT", "3000"),
+		BaseURL:   strings.TrimSuffix(os.Getenv("BASE_URL"), "/"),
+		DataDir:   env("DATA_DIR", "data"),
+		DBName:    env("DB_NAME", "gighub.db"),
+		SentryDSN: os.Getenv("SENTRY_DSN"),
+		TLS: TLS{
+			Domains:   utils.SplitList(os.Getenv("TLS_DOMAINS")),
+			Email:     os.Getenv("TLS_EMAIL"),
+			CacheDir:  os.Getenv("TLS_CACHE_DIR"),
+			HTTPSPort: env("HTTPS_PORT", "443"),
+		},
+		Google: OAuth{
+			ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
+			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+		},
+		Apple: Apple{
+			ClientID:   os.Getenv("APPLE_CLIENT_ID"),
+			TeamID:     os.Getenv("APPLE_TEAM_ID"),
+			KeyID:      os.Getenv("APPLE_KEY_ID"),
+			PrivateKey: os.Getenv("APPLE_PRIVATE_KEY"),
+		},
+		SMTP: SMTP{
+			Host: os.Getenv("SMTP_HOST"),
+			Port: os.Getenv("SMTP_PORT"),
+			User: os.Getenv("SMTP_USER"),
+			Pass: os.Getenv("SMTP_PASS"),
+			From: os.Getenv("SMTP_FROM"),
+		},
+		Admin: Admin{
+			// SQLITEADMIN_* are the names from when the credentials guarded
+			// the sqliteadmin endpoint
+			Username:   env("ADMIN_USERNAME", os.Getenv("SQLITEADMIN_USERNAME")),
+			Password:   env("ADMIN_PASSWORD", os.Getenv("SQLITEADMIN_PASSWORD")),
+			AlertEmail: os.Getenv("ALERT_EMAIL"),
+		},
+		API: API{
+			RateLimits:  os.Getenv("API_RATE_LIMITS"),
+			CORSOrigins: utils.SplitList(os.Getenv("CORS_ALLOWED_ORIGINS")),
+		},
+		Storage: Storage{
+			Dir:         os.Getenv("STORAGE_DIR"),
+			S3Endpoint:  env("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			S3Region:    env("S3_REGION", "us-east-1"),
+			S3Bucket:    os.Getenv("S3_BUCKET"),
+			S3AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			S3SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+
+			CDNURL:        strings.TrimSuffix(os.Getenv("CDN_URL"), "/"),
+			CDNPurgeURL:   os.Getenv("CDN_PURGE_URL"),
+			CDNPurgeToken: os.Getenv("CDN_PURGE_TOKEN"),
+
+			ImageProxyHosts: utils.SplitList(os.Getenv("IMAGE_PROXY_HOSTS")),
+
+			ModerationURL:   os.Getenv("MODERATION_URL"),
+			ModerationToken: os.Getenv("MODERATION_TOKEN"),
+		},
+		Throttles: Throttles{
+			Limits: os.Getenv("THROTTLE_LIMITS"),
+		},
+		Cache: Cache{
+			RedisURL: os.Getenv("REDIS_URL"),
+		},
+	}
+	if port != "" {
+		c.Port = port
+	}
+	if dataDir != "" {
+		c.DataDir = dataDir
+	}
+
+	var errs []error
+	if n, err := strconv.Atoi(c.Port); err != nil || n <= 0 || n > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a port number, got %q", c.Port))
+	}
+	if c.TLS.Enabled() {
+		if n, err := strconv.Atoi(c.TLS.HTTPSPort); err != nil || n <= 0 || n > 65535 {
+			errs = append(errs, fmt.Errorf("HTTPS_PORT must be a port number, got %q", c.TLS.HTTPSPort))
+		}
+		if c.TLS.CacheDir == "" {
+			c.TLS.CacheDir = filepath.Join(c.DataDir, "certs")
+		}
+	}
+	if c.BaseURL == "" {
+		if c.Production() {
+			errs = append(errs, errors.New("BASE_URL is required in production"))
+		}
+		c.BaseURL = "http://localhost:" + c.Port
+	}
+	proxies, err := utils.ParseProxies(utils.SplitList(os.Getenv("TRUSTED_PROXIES")))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("TRUSTED_PROXIES: %w", err))
+	}
+	c.TrustedProxies = proxies
+	c.SessionKeys = keysEnv("SESSION_SECRET", "SESSION_KEYS", &errs)
+	c.Webhooks.StripeKeys = keysEnv("STRIPE_WEBHOOK_SECRET", "STRIPE_WEBHOOK_KEYS", &errs)
+	c.Webhooks.CalendlyKeys = keysEnv("CALENDLY_WEBHOOK_SIGNING_KEY", "CALENDLY_WEBHOOK_SIGNING_KEYS", &errs)
+	if len(c.SessionKeys) == 0 && c.Production() {
+		errs = append(errs, errors.New("SESSION_SECRET or SESSION_KEYS is required in production"))
+	}
+	c.Storage.SigningKeys = keysEnv("STORAGE_SIGNING_KEY", "STORAGE_SIGNING_KEYS", &errs)
+	if len(c.Storage.SigningKeys) == 0 {
+		c.Storage.SigningKeys = c.SessionKeys
+	}
+	if c.Storage.Dir == "" {
+		c.Storage.Dir = filepath.Join(c.DataDir, "files")
+	}
+	if u, err := url.Parse(c.Storage.CDNURL); c.Storage.CDNURL != "" && (err != nil || u.Scheme == "" || u.Host == "") {
+		errs = append(errs, errors.New("CDN_URL must be an absolute URL"))
+	}
+	if c.Storage.CDNPurgeURL != "" && c.Storage.CDNURL == "" {
+		errs = append(errs, errors.New("CDN_PURGE_URL needs CDN_URL"))
+	}
+	if c.Storage.S3() && (c.Storage.S3AccessKey == "" || c.Storage.S3SecretKey == "") {
+		errs = append(errs, errors.New("S3_BUCKET needs S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY"))
+	}
+	if (c.Google.ClientID == "") != (c.Google.ClientSecret == "") {
+		errs = append(errs, errors.New("GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET must be set together"))
+	}
+	if c.Apple != (Apple{}) {
+		if c.Apple.ClientID == "" || c.Apple.TeamID == "" || c.Apple.KeyID == "" || c.Apple.PrivateKey == "" {
+			errs = append(errs, errors.New("APPLE_CLIENT_ID, APPLE_TEAM_ID, APPLE_KEY_ID and APPLE_PRIVATE_KEY must be set together"))
+		} else if err := checkECKey(c.Apple.PrivateKey); err != nil {
+			errs = append(errs, fmt.Errorf("APPLE_PRIVATE_KEY: %w", err))
+		}
+	}
+	if c.SMTP != (SMTP{}) && !c.SMTP.Enabled() {
+		errs = append(errs, errors.New("SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASS and SMTP_FROM must be set together"))
+	}
+	if c.Admin.AlertEmail != "" && !c.SMTP.Enabled() {
+		errs = append(errs, errors.New("ALERT_EMAIL needs SMTP to be configured"))
+	}
+	if (c.Admin.Username == "") != (c.Admin.Password == "") {
+		errs = append(errs, errors.New("ADMIN_USERNAME and ADMIN_PASSWORD must be set together"))
+	}
+	if _, err := ratelimit.ParseLimits(c.API.RateLimits, nil); err != nil {
+		errs = append(errs, fmt.Errorf("API_RATE_LIMITS: %w", err))
+	}
+	if _, err := ratelimit.ParseLimits(c.Throttles.Limits, nil); err != nil {
+		errs = append(errs, fmt.Errorf("THROTTLE_LIMITS: %w", err))
+	}
+	if c.Cache.RedisURL != "" {
+		if err := checkRedisURL(c.Cache.RedisURL); err != nil {
+			errs = append(errs, fmt.Errorf("REDIS_URL: %w", err))
+		}
+	}
+	c.API.CORSCredentials = boolEnv("CORS_ALLOW_CREDENTIALS", &errs)
+	c.Admin.Debug = boolEnv("DEBUG_ENDPOINTS", &errs)
+	c.TLS.H2C = boolEnv("HTTP2_CLEARTEXT", &errs)
+	c.Limits.RequestTimeout = durationEnv("REQUEST_TIMEOUT", 30*time.Second, &errs)
+	c.Limits.MaxBodySize = sizeEnv("MAX_BODY_SIZE", 1<<20, &errs)
+	c.Limits.MaxUploadSize = sizeEnv("MAX_UPLOAD_SIZE", 10<<20, &errs)
+	c.Limits.MaxResumableSize = sizeEnv("MAX_RESUMABLE_UPLOAD_SIZE", 1<<30, &errs)
+	c.Limits.StorageQuota = sizeEnv("STORAGE_QUOTA", 5<<30, &errs)
+	c.Limits.ReferralBonus = sizeEnv("REFERRAL_STORAGE_BONUS", 1<<30, &errs)
+	c.Cache.TTL = durationEnv("PAGE_CACHE_TTL", time.Minute, &errs)
+	c.Admin.SlowQuery = durationEnv("SLOW_QUERY_THRESHOLD", 100*time.Millisecond, &errs)
+	c.Admin.JobFailureRate = rateEnv("JOB_FAILURE_ALERT_RATE", 0.2, &errs)
+	c.Signup.RemindAfter = durationEnv("UNVERIFIED_REMIND_AFTER", 24*time.Hour, &errs)
+	c.Signup.DeleteAfter = durationEnv("UNVERIFIED_DELETE_AFTER", 7*24*time.Hour, &errs)
+	if c.Signup.DeleteAfter <= c.Signup.RemindAfter {
+		errs = append(errs, errors.New("UNVERIFIED_DELETE_AFTER must be longer than UNVERIFIED_REMIND_AFTER"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return c, nil
+}
+
+// checkECKey checks that key is a PEM-encoded PKCS #8 ECDSA key, the
+// format of the .p8 files Apple hands out.
+func checkECKey(key string) error {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(key)))
+	if block == nil {
+		return errors.New("not a PEM-encoded key")
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+	if _, ok := k.(*ecdsa.PrivateKey); !ok {
+		return errors.New("not an ECDSA key")
+	}
+	return nil
+}
+
+// checkRedisURL checks that rawURL has the redis://[:password@]host[:port][/db]
+// form. The server is only reached once the app starts.
+func checkRedisURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "redis" || u.Hostname() == "" {
+		return errors.New("must look like redis://[:password@]host[:port][/db]")
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if n, err := strconv.Atoi(db); err != nil || n < 0 {
+			return fmt.Errorf("invalid database %q", db)
+		}
+	}
+	return nil
+}
+
+// boolEnv parses an optional true/false variable, recording bad values.
+func boolEnv(key string, errs *[]error) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be true or false, got %q", key, v))
+	}
+	return b
+}
+
+// keysEnv reads an optional key ring: a list like "new:secret,old:secret"
+// from the list variable, or else the single secret in the other one, read
+// whole as it always was.
+func keysEnv(single, list string, errs *[]error) keys.Ring {
+	spec := os.Getenv(list)
+	if spec == "" {
+		return keys.Single(os.Getenv(single))
+	}
+	if os.Getenv(single) != "" {
+		*errs = append(*errs, fmt.Errorf("%s and %s can't both be set", single, list))
+	}
+	ring, err := keys.Parse(spec)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", list, err))
+	}
+	return ring
+}
+
+// durationEnv parses an optional positive duration like "45s".
+func durationEnv(key string, fallback time.Duration, errs *[]error) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		*errs = append(*errs, fmt.Errorf("%s must be a positive duration like 30s, got %q", key, v))
+	}
+	return d
+}
+
+// rateEnv parses an optional fraction between 0 and 1.
+func rateEnv(key string, fallback float64, errs *[]error) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f > 1 {
+		*errs = append(*errs, fmt.Errorf("%s must be a fraction like 0.2, got %q", key, v))
+	}
+	return f
+}
+
+// sizeEnv parses an optional positive size in bytes.
+func sizeEnv(key string, fallback int64, errs *[]error) int64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		*errs = append(*errs, fmt.Errorf("%s must be a positive number of bytes, got %q", key, v))
+	}
+	return n
+}
+
+func env(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -7,6 +7,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 )
 
 type DBTX interface {
@@ -20,12 +21,1718 @@ func New(db DBTX) *Queries {
 	return &Queries{db: db}
 }
 
+func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
+	q := Queries{db: db}
+	var err error
+	if q.advanceJobScheduleStmt, err = db.PrepareContext(ctx, advanceJobSchedule); err != nil {
+		return nil, fmt.Errorf("error preparing query AdvanceJobSchedule: %w", err)
+	}
+	if q.advanceUploadStmt, err = db.PrepareContext(ctx, advanceUpload); err != nil {
+		return nil, fmt.Errorf("error preparing query AdvanceUpload: %w", err)
+	}
+	if q.cancelJobStmt, err = db.PrepareContext(ctx, cancelJob); err != nil {
+		return nil, fmt.Errorf("error preparing query CancelJob: %w", err)
+	}
+	if q.claimJobStmt, err = db.PrepareContext(ctx, claimJob); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimJob: %w", err)
+	}
+	if q.completeIdempotencyKeyStmt, err = db.PrepareContext(ctx, completeIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteIdempotencyKey: %w", err)
+	}
+	if q.completeUploadStmt, err = db.PrepareContext(ctx, completeUpload); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteUpload: %w", err)
+	}
+	if q.countActiveBansOnDeviceStmt, err = db.PrepareContext(ctx, countActiveBansOnDevice); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveBansOnDevice: %w", err)
+	}
+	if q.countActiveUsersStmt, err = db.PrepareContext(ctx, countActiveUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveUsers: %w", err)
+	}
+	if q.countAnalyticsEventsStmt, err = db.PrepareContext(ctx, countAnalyticsEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query CountAnalyticsEvents: %w", err)
+	}
+	if q.countJobsByDayStmt, err = db.PrepareContext(ctx, countJobsByDay); err != nil {
+		return nil, fmt.Errorf("error preparing query CountJobsByDay: %w", err)
+	}
+	if q.countPendingJobsByNameStmt, err = db.PrepareContext(ctx, countPendingJobsByName); err != nil {
+		return nil, fmt.Errorf("error preparing query CountPendingJobsByName: %w", err)
+	}
+	if q.countReferrerSightingsStmt, err = db.PrepareContext(ctx, countReferrerSightings); err != nil {
+		return nil, fmt.Errorf("error preparing query CountReferrerSightings: %w", err)
+	}
+	if q.countRewardedReferralsStmt, err = db.PrepareContext(ctx, countRewardedReferrals); err != nil {
+		return nil, fmt.Errorf("error preparing query CountRewardedReferrals: %w", err)
+	}
+	if q.countSignupsByDayStmt, err = db.PrepareContext(ctx, countSignupsByDay); err != nil {
+		return nil, fmt.Errorf("error preparing query CountSignupsByDay: %w", err)
+	}
+	if q.countUsersStmt, err = db.PrepareContext(ctx, countUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountUsers: %w", err)
+	}
+	if q.createAPITokenStmt, err = db.PrepareContext(ctx, createAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAPIToken: %w", err)
+	}
+	if q.createAnalyticsEventStmt, err = db.PrepareContext(ctx, createAnalyticsEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAnalyticsEvent: %w", err)
+	}
+	if q.createAnnouncementStmt, err = db.PrepareContext(ctx, createAnnouncement); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAnnouncement: %w", err)
+	}
+	if q.createAuditEntryStmt, err = db.PrepareContext(ctx, createAuditEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAuditEntry: %w", err)
+	}
+	if q.createBanStmt, err = db.PrepareContext(ctx, createBan); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBan: %w", err)
+	}
+	if q.createBanAppealStmt, err = db.PrepareContext(ctx, createBanAppeal); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBanAppeal: %w", err)
+	}
+	if q.createEventStmt, err = db.PrepareContext(ctx, createEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateEvent: %w", err)
+	}
+	if q.createFeatureFlagStmt, err = db.PrepareContext(ctx, createFeatureFlag); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateFeatureFlag: %w", err)
+	}
+	if q.createHealthCheckStmt, err = db.PrepareContext(ctx, createHealthCheck); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateHealthCheck: %w", err)
+	}
+	if q.createIdempotencyKeyStmt, err = db.PrepareContext(ctx, createIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateIdempotencyKey: %w", err)
+	}
+	if q.createInboundEventStmt, err = db.PrepareContext(ctx, createInboundEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateInboundEvent: %w", err)
+	}
+	if q.createInboundHookStmt, err = db.PrepareContext(ctx, createInboundHook); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateInboundHook: %w", err)
+	}
+	if q.createJobStmt, err = db.PrepareContext(ctx, createJob); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateJob: %w", err)
+	}
+	if q.createReferralStmt, err = db.PrepareContext(ctx, createReferral); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateReferral: %w", err)
+	}
+	if q.createUploadStmt, err = db.PrepareContext(ctx, createUpload); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUpload: %w", err)
+	}
+	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
+	}
+	if q.createUserBanStmt, err = db.PrepareContext(ctx, createUserBan); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUserBan: %w", err)
+	}
+	if q.createUserIdentityStmt, err = db.PrepareContext(ctx, createUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUserIdentity: %w", err)
+	}
+	if q.createWebhookStmt, err = db.PrepareContext(ctx, createWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhook: %w", err)
+	}
+	if q.createWebhookDeliveryStmt, err = db.PrepareContext(ctx, createWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDelivery: %w", err)
+	}
+	if q.decideBanAppealStmt, err = db.PrepareContext(ctx, decideBanAppeal); err != nil {
+		return nil, fmt.Errorf("error preparing query DecideBanAppeal: %w", err)
+	}
+	if q.deleteAPITokenStmt, err = db.PrepareContext(ctx, deleteAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAPIToken: %w", err)
+	}
+	if q.deleteAnalyticsEventsBeforeStmt, err = db.PrepareContext(ctx, deleteAnalyticsEventsBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAnalyticsEventsBefore: %w", err)
+	}
+	if q.deleteAnnouncementStmt, err = db.PrepareContext(ctx, deleteAnnouncement); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAnnouncement: %w", err)
+	}
+	if q.deleteBanStmt, err = db.PrepareContext(ctx, deleteBan); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBan: %w", err)
+	}
+	if q.deleteDraftStmt, err = db.PrepareContext(ctx, deleteDraft); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteDraft: %w", err)
+	}
+	if q.deleteDraftsBeforeStmt, err = db.PrepareContext(ctx, deleteDraftsBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteDraftsBefore: %w", err)
+	}
+	if q.deleteEmailTemplateStmt, err = db.PrepareContext(ctx, deleteEmailTemplate); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteEmailTemplate: %w", err)
+	}
+	if q.deleteExpiredBansStmt, err = db.PrepareContext(ctx, deleteExpiredBans); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredBans: %w", err)
+	}
+	if q.deleteExpiredSessionsStmt, err = db.PrepareContext(ctx, deleteExpiredSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredSessions: %w", err)
+	}
+	if q.deleteExpiredUploadsStmt, err = db.PrepareContext(ctx, deleteExpiredUploads); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredUploads: %w", err)
+	}
+	if q.deleteFeatureFlagStmt, err = db.PrepareContext(ctx, deleteFeatureFlag); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFeatureFlag: %w", err)
+	}
+	if q.deleteFeatureFlagOverrideStmt, err = db.PrepareContext(ctx, deleteFeatureFlagOverride); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFeatureFlagOverride: %w", err)
+	}
+	if q.deleteFinishedJobsBeforeStmt, err = db.PrepareContext(ctx, deleteFinishedJobsBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFinishedJobsBefore: %w", err)
+	}
+	if q.deleteHealthChecksBeforeStmt, err = db.PrepareContext(ctx, deleteHealthChecksBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteHealthChecksBefore: %w", err)
+	}
+	if q.deleteIdempotencyKeyStmt, err = db.PrepareContext(ctx, deleteIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteIdempotencyKey: %w", err)
+	}
+	if q.deleteIdempotencyKeysBeforeStmt, err = db.PrepareContext(ctx, deleteIdempotencyKeysBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteIdempotencyKeysBefore: %w", err)
+	}
+	if q.deleteInboundHookStmt, err = db.PrepareContext(ctx, deleteInboundHook); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteInboundHook: %w", err)
+	}
+	if q.deleteOtherUserSessionsStmt, err = db.PrepareContext(ctx, deleteOtherUserSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteOtherUserSessions: %w", err)
+	}
+	if q.deleteRateLimitsBeforeStmt, err = db.PrepareContext(ctx, deleteRateLimitsBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRateLimitsBefore: %w", err)
+	}
+	if q.deleteRestHookStmt, err = db.PrepareContext(ctx, deleteRestHook); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRestHook: %w", err)
+	}
+	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
+	}
+	if q.deleteSettingsStmt, err = db.PrepareContext(ctx, deleteSettings); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSettings: %w", err)
+	}
+	if q.deleteUnverifiedUserStmt, err = db.PrepareContext(ctx, deleteUnverifiedUser); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUnverifiedUser: %w", err)
+	}
+	if q.deleteUploadStmt, err = db.PrepareContext(ctx, deleteUpload); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUpload: %w", err)
+	}
+	if q.deleteUserSessionStmt, err = db.PrepareContext(ctx, deleteUserSession); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserSession: %w", err)
+	}
+	if q.deleteUserSessionsStmt, err = db.PrepareContext(ctx, deleteUserSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserSessions: %w", err)
+	}
+	if q.deleteWebhookStmt, err = db.PrepareContext(ctx, deleteWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhook: %w", err)
+	}
+	if q.dismissAnnouncementStmt, err = db.PrepareContext(ctx, dismissAnnouncement); err != nil {
+		return nil, fmt.Errorf("error preparing query DismissAnnouncement: %w", err)
+	}
+	if q.ensureReferralCodeStmt, err = db.PrepareContext(ctx, ensureReferralCode); err != nil {
+		return nil, fmt.Errorf("error preparing query EnsureReferralCode: %w", err)
+	}
+	if q.finishJobStmt, err = db.PrepareContext(ctx, finishJob); err != nil {
+		return nil, fmt.Errorf("error preparing query FinishJob: %w", err)
+	}
+	if q.getAPITokenByHashStmt, err = db.PrepareContext(ctx, getAPITokenByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAPITokenByHash: %w", err)
+	}
+	if q.getBanAppealStmt, err = db.PrepareContext(ctx, getBanAppeal); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBanAppeal: %w", err)
+	}
+	if q.getDraftStmt, err = db.PrepareContext(ctx, getDraft); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDraft: %w", err)
+	}
+	if q.getEmailTemplateStmt, err = db.PrepareContext(ctx, getEmailTemplate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEmailTemplate: %w", err)
+	}
+	if q.getGuestbookStmt, err = db.PrepareContext(ctx, getGuestbook); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGuestbook: %w", err)
+	}
+	if q.getIdempotencyKeyStmt, err = db.PrepareContext(ctx, getIdempotencyKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetIdempotencyKey: %w", err)
+	}
+	if q.getInboundHookByTokenStmt, err = db.PrepareContext(ctx, getInboundHookByToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetInboundHookByToken: %w", err)
+	}
+	if q.getMessageStmt, err = db.PrepareContext(ctx, getMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMessage: %w", err)
+	}
+	if q.getPendingReferralStmt, err = db.PrepareContext(ctx, getPendingReferral); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPendingReferral: %w", err)
+	}
+	if q.getReferralStorageBonusStmt, err = db.PrepareContext(ctx, getReferralStorageBonus); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReferralStorageBonus: %w", err)
+	}
+	if q.getReferrerByCodeStmt, err = db.PrepareContext(ctx, getReferrerByCode); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReferrerByCode: %w", err)
+	}
+	if q.getSessionStmt, err = db.PrepareContext(ctx, getSession); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSession: %w", err)
+	}
+	if q.getShadowMessageStmt, err = db.PrepareContext(ctx, getShadowMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetShadowMessage: %w", err)
+	}
+	if q.getStorageUsageStmt, err = db.PrepareContext(ctx, getStorageUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetStorageUsage: %w", err)
+	}
+	if q.getUploadStmt, err = db.PrepareContext(ctx, getUpload); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUpload: %w", err)
+	}
+	if q.getUploadByIDStmt, err = db.PrepareContext(ctx, getUploadByID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUploadByID: %w", err)
+	}
+	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
+	}
+	if q.getUserByEmailStmt, err = db.PrepareContext(ctx, getUserByEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserByEmail: %w", err)
+	}
+	if q.getUserIdentityStmt, err = db.PrepareContext(ctx, getUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserIdentity: %w", err)
+	}
+	if q.getWebhookStmt, err = db.PrepareContext(ctx, getWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhook: %w", err)
+	}
+	if q.latestEventIDStmt, err = db.PrepareContext(ctx, latestEventID); err != nil {
+		return nil, fmt.Errorf("error preparing query LatestEventID: %w", err)
+	}
+	if q.liftExpiredUserBansStmt, err = db.PrepareContext(ctx, liftExpiredUserBans); err != nil {
+		return nil, fmt.Errorf("error preparing query LiftExpiredUserBans: %w", err)
+	}
+	if q.liftUserBanStmt, err = db.PrepareContext(ctx, liftUserBan); err != nil {
+		return nil, fmt.Errorf("error preparing query LiftUserBan: %w", err)
+	}
+	if q.listAPITokensByUserStmt, err = db.PrepareContext(ctx, listAPITokensByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAPITokensByUser: %w", err)
+	}
+	if q.listActiveBansStmt, err = db.PrepareContext(ctx, listActiveBans); err != nil {
+		return nil, fmt.Errorf("error preparing query ListActiveBans: %w", err)
+	}
+	if q.listActiveUserBansStmt, err = db.PrepareContext(ctx, listActiveUserBans); err != nil {
+		return nil, fmt.Errorf("error preparing query ListActiveUserBans: %w", err)
+	}
+	if q.listAnalyticsDaysStmt, err = db.PrepareContext(ctx, listAnalyticsDays); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAnalyticsDays: %w", err)
+	}
+	if q.listAnnouncementsStmt, err = db.PrepareContext(ctx, listAnnouncements); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAnnouncements: %w", err)
+	}
+	if q.listAssociatedUsersStmt, err = db.PrepareContext(ctx, listAssociatedUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAssociatedUsers: %w", err)
+	}
+	if q.listAuditLogStmt, err = db.PrepareContext(ctx, listAuditLog); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditLog: %w", err)
+	}
+	if q.listDismissedAnnouncementsStmt, err = db.PrepareContext(ctx, listDismissedAnnouncements); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDismissedAnnouncements: %w", err)
+	}
+	if q.listDueWebhookDeliveriesStmt, err = db.PrepareContext(ctx, listDueWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDueWebhookDeliveries: %w", err)
+	}
+	if q.listEmailTemplatesStmt, err = db.PrepareContext(ctx, listEmailTemplates); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEmailTemplates: %w", err)
+	}
+	if q.listEventsForUserSinceStmt, err = db.PrepareContext(ctx, listEventsForUserSince); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEventsForUserSince: %w", err)
+	}
+	if q.listEventsOfTypeSinceStmt, err = db.PrepareContext(ctx, listEventsOfTypeSince); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEventsOfTypeSince: %w", err)
+	}
+	if q.listExperimentResultsStmt, err = db.PrepareContext(ctx, listExperimentResults); err != nil {
+		return nil, fmt.Errorf("error preparing query ListExperimentResults: %w", err)
+	}
+	if q.listExpiredUploadsStmt, err = db.PrepareContext(ctx, listExpiredUploads); err != nil {
+		return nil, fmt.Errorf("error preparing query ListExpiredUploads: %w", err)
+	}
+	if q.listFailedJobsStmt, err = db.PrepareContext(ctx, listFailedJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFailedJobs: %w", err)
+	}
+	if q.listFailedJobsByNameStmt, err = db.PrepareContext(ctx, listFailedJobsByName); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFailedJobsByName: %w", err)
+	}
+	if q.listFeatureFlagOverridesStmt, err = db.PrepareContext(ctx, listFeatureFlagOverrides); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFeatureFlagOverrides: %w", err)
+	}
+	if q.listFeatureFlagOverridesForUserStmt, err = db.PrepareContext(ctx, listFeatureFlagOverridesForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFeatureFlagOverridesForUser: %w", err)
+	}
+	if q.listFeatureFlagsStmt, err = db.PrepareContext(ctx, listFeatureFlags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFeatureFlags: %w", err)
+	}
+	if q.listHealthCheckHistoryStmt, err = db.PrepareContext(ctx, listHealthCheckHistory); err != nil {
+		return nil, fmt.Errorf("error preparing query ListHealthCheckHistory: %w", err)
+	}
+	if q.listHealthCheckUptimeStmt, err = db.PrepareContext(ctx, listHealthCheckUptime); err != nil {
+		return nil, fmt.Errorf("error preparing query ListHealthCheckUptime: %w", err)
+	}
+	if q.listInboundHooksByUserStmt, err = db.PrepareContext(ctx, listInboundHooksByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListInboundHooksByUser: %w", err)
+	}
+	if q.listJobOutcomesStmt, err = db.PrepareContext(ctx, listJobOutcomes); err != nil {
+		return nil, fmt.Errorf("error preparing query ListJobOutcomes: %w", err)
+	}
+	if q.listJobSchedulesStmt, err = db.PrepareContext(ctx, listJobSchedules); err != nil {
+		return nil, fmt.Errorf("error preparing query ListJobSchedules: %w", err)
+	}
+	if q.listLargestUploadsStmt, err = db.PrepareContext(ctx, listLargestUploads); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLargestUploads: %w", err)
+	}
+	if q.listLatestHealthChecksStmt, err = db.PrepareContext(ctx, listLatestHealthChecks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLatestHealthChecks: %w", err)
+	}
+	if q.listPendingBanAppealsStmt, err = db.PrepareContext(ctx, listPendingBanAppeals); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPendingBanAppeals: %w", err)
+	}
+	if q.listPendingInboundEventsStmt, err = db.PrepareContext(ctx, listPendingInboundEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPendingInboundEvents: %w", err)
+	}
+	if q.listPendingJobsStmt, err = db.PrepareContext(ctx, listPendingJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPendingJobs: %w", err)
+	}
+	if q.listPendingJobsByNameStmt, err = db.PrepareContext(ctx, listPendingJobsByName); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPendingJobsByName: %w", err)
+	}
+	if q.listQuarantinedUploadsStmt, err = db.PrepareContext(ctx, listQuarantinedUploads); err != nil {
+		return nil, fmt.Errorf("error preparing query ListQuarantinedUploads: %w", err)
+	}
+	if q.listRateLimitsStmt, err = db.PrepareContext(ctx, listRateLimits); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRateLimits: %w", err)
+	}
+	if q.listRecentEventsForUserStmt, err = db.PrepareContext(ctx, listRecentEventsForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecentEventsForUser: %w", err)
+	}
+	if q.listRecentJobsStmt, err = db.PrepareContext(ctx, listRecentJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecentJobs: %w", err)
+	}
+	if q.listRecentJobsByNameStmt, err = db.PrepareContext(ctx, listRecentJobsByName); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecentJobsByName: %w", err)
+	}
+	if q.listReferralsStmt, err = db.PrepareContext(ctx, listReferrals); err != nil {
+		return nil, fmt.Errorf("error preparing query ListReferrals: %w", err)
+	}
+	if q.listSettingsStmt, err = db.PrepareContext(ctx, listSettings); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSettings: %w", err)
+	}
+	if q.listTopPagesStmt, err = db.PrepareContext(ctx, listTopPages); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTopPages: %w", err)
+	}
+	if q.listTopReferrersStmt, err = db.PrepareContext(ctx, listTopReferrers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTopReferrers: %w", err)
+	}
+	if q.listUnendedAnnouncementsStmt, err = db.PrepareContext(ctx, listUnendedAnnouncements); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUnendedAnnouncements: %w", err)
+	}
+	if q.listUnverifiedUsersBeforeStmt, err = db.PrepareContext(ctx, listUnverifiedUsersBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUnverifiedUsersBefore: %w", err)
+	}
+	if q.listUnverifiedUsersToRemindStmt, err = db.PrepareContext(ctx, listUnverifiedUsersToRemind); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUnverifiedUsersToRemind: %w", err)
+	}
+	if q.listUploadStorageKeysStmt, err = db.PrepareContext(ctx, listUploadStorageKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUploadStorageKeys: %w", err)
+	}
+	if q.listUploadsBySHA256Stmt, err = db.PrepareContext(ctx, listUploadsBySHA256); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUploadsBySHA256: %w", err)
+	}
+	if q.listUserBansStmt, err = db.PrepareContext(ctx, listUserBans); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserBans: %w", err)
+	}
+	if q.listUserSessionsStmt, err = db.PrepareContext(ctx, listUserSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserSessions: %w", err)
+	}
+	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
+	}
+	if q.listWebhookDeliveriesStmt, err = db.PrepareContext(ctx, listWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveries: %w", err)
+	}
+	if q.listWebhooksStmt, err = db.PrepareContext(ctx, listWebhooks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhooks: %w", err)
+	}
+	if q.listWebhooksByUserStmt, err = db.PrepareContext(ctx, listWebhooksByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhooksByUser: %w", err)
+	}
+	if q.markUserVerifiedStmt, err = db.PrepareContext(ctx, markUserVerified); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkUserVerified: %w", err)
+	}
+	if q.markVerificationRemindedStmt, err = db.PrepareContext(ctx, markVerificationReminded); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkVerificationReminded: %w", err)
+	}
+	if q.qualifyReferralStmt, err = db.PrepareContext(ctx, qualifyReferral); err != nil {
+		return nil, fmt.Errorf("error preparing query QualifyReferral: %w", err)
+	}
+	if q.recordUserSightingStmt, err = db.PrepareContext(ctx, recordUserSighting); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordUserSighting: %w", err)
+	}
+	if q.retryJobStmt, err = db.PrepareContext(ctx, retryJob); err != nil {
+		return nil, fmt.Errorf("error preparing query RetryJob: %w", err)
+	}
+	if q.rollUpAnalyticsCountsStmt, err = db.PrepareContext(ctx, rollUpAnalyticsCounts); err != nil {
+		return nil, fmt.Errorf("error preparing query RollUpAnalyticsCounts: %w", err)
+	}
+	if q.rollUpAnalyticsDaysStmt, err = db.PrepareContext(ctx, rollUpAnalyticsDays); err != nil {
+		return nil, fmt.Errorf("error preparing query RollUpAnalyticsDays: %w", err)
+	}
+	if q.rollUpExperimentStmt, err = db.PrepareContext(ctx, rollUpExperiment); err != nil {
+		return nil, fmt.Errorf("error preparing query RollUpExperiment: %w", err)
+	}
+	if q.saveDraftStmt, err = db.PrepareContext(ctx, saveDraft); err != nil {
+		return nil, fmt.Errorf("error preparing query SaveDraft: %w", err)
+	}
+	if q.setUploadStatusStmt, err = db.PrepareContext(ctx, setUploadStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUploadStatus: %w", err)
+	}
+	if q.setUserAvatarStmt, err = db.PrepareContext(ctx, setUserAvatar); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserAvatar: %w", err)
+	}
+	if q.setUserLocaleStmt, err = db.PrepareContext(ctx, setUserLocale); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserLocale: %w", err)
+	}
+	if q.setUserPasswordStmt, err = db.PrepareContext(ctx, setUserPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserPassword: %w", err)
+	}
+	if q.touchAPITokenStmt, err = db.PrepareContext(ctx, touchAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchAPIToken: %w", err)
+	}
+	if q.updateFeatureFlagStmt, err = db.PrepareContext(ctx, updateFeatureFlag); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateFeatureFlag: %w", err)
+	}
+	if q.updateInboundEventStmt, err = db.PrepareContext(ctx, updateInboundEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateInboundEvent: %w", err)
+	}
+	if q.updateMessageIfVersionStmt, err = db.PrepareContext(ctx, updateMessageIfVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateMessageIfVersion: %w", err)
+	}
+	if q.updateWebhookDeliveryStmt, err = db.PrepareContext(ctx, updateWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateWebhookDelivery: %w", err)
+	}
+	if q.upsertEmailTemplateStmt, err = db.PrepareContext(ctx, upsertEmailTemplate); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertEmailTemplate: %w", err)
+	}
+	if q.upsertFeatureFlagOverrideStmt, err = db.PrepareContext(ctx, upsertFeatureFlagOverride); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertFeatureFlagOverride: %w", err)
+	}
+	if q.upsertJobScheduleStmt, err = db.PrepareContext(ctx, upsertJobSchedule); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertJobSchedule: %w", err)
+	}
+	if q.upsertMessageStmt, err = db.PrepareContext(ctx, upsertMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertMessage: %w", err)
+	}
+	if q.upsertRateLimitStmt, err = db.PrepareContext(ctx, upsertRateLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertRateLimit: %w", err)
+	}
+	if q.upsertSessionStmt, err = db.PrepareContext(ctx, upsertSession); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertSession: %w", err)
+	}
+	if q.upsertSettingStmt, err = db.PrepareContext(ctx, upsertSetting); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertSetting: %w", err)
+	}
+	if q.upsertShadowMessageStmt, err = db.PrepareContext(ctx, upsertShadowMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertShadowMessage: %w", err)
+	}
+	if q.verifyUserStmt, err = db.PrepareContext(ctx, verifyUser); err != nil {
+		return nil, fmt.Errorf("error preparing query VerifyUser: %w", err)
+	}
+	return &q, nil
+}
+
+func (q *Queries) Close() error {
+	var err error
+	if q.advanceJobScheduleStmt != nil {
+		if cerr := q.advanceJobScheduleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing advanceJobScheduleStmt: %w", cerr)
+		}
+	}
+	if q.advanceUploadStmt != nil {
+		if cerr := q.advanceUploadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing advanceUploadStmt: %w", cerr)
+		}
+	}
+	if q.cancelJobStmt != nil {
+		if cerr := q.cancelJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing cancelJobStmt: %w", cerr)
+		}
+	}
+	if q.claimJobStmt != nil {
+		if cerr := q.claimJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimJobStmt: %w", cerr)
+		}
+	}
+	if q.completeIdempotencyKeyStmt != nil {
+		if cerr := q.completeIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.completeUploadStmt != nil {
+		if cerr := q.completeUploadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeUploadStmt: %w", cerr)
+		}
+	}
+	if q.countActiveBansOnDeviceStmt != nil {
+		if cerr := q.countActiveBansOnDeviceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveBansOnDeviceStmt: %w", cerr)
+		}
+	}
+	if q.countActiveUsersStmt != nil {
+		if cerr := q.countActiveUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveUsersStmt: %w", cerr)
+		}
+	}
+	if q.countAnalyticsEventsStmt != nil {
+		if cerr := q.countAnalyticsEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countAnalyticsEventsStmt: %w", cerr)
+		}
+	}
+	if q.countJobsByDayStmt != nil {
+		if cerr := q.countJobsByDayStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countJobsByDayStmt: %w", cerr)
+		}
+	}
+	if q.countPendingJobsByNameStmt != nil {
+		if cerr := q.countPendingJobsByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countPendingJobsByNameStmt: %w", cerr)
+		}
+	}
+	if q.countReferrerSightingsStmt != nil {
+		if cerr := q.countReferrerSightingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countReferrerSightingsStmt: %w", cerr)
+		}
+	}
+	if q.countRewardedReferralsStmt != nil {
+		if cerr := q.countRewardedReferralsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countRewardedReferralsStmt: %w", cerr)
+		}
+	}
+	if q.countSignupsByDayStmt != nil {
+		if cerr := q.countSignupsByDayStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countSignupsByDayStmt: %w", cerr)
+		}
+	}
+	if q.countUsersStmt != nil {
+		if cerr := q.countUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countUsersStmt: %w", cerr)
+		}
+	}
+	if q.createAPITokenStmt != nil {
+		if cerr := q.createAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.createAnalyticsEventStmt != nil {
+		if cerr := q.createAnalyticsEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAnalyticsEventStmt: %w", cerr)
+		}
+	}
+	if q.createAnnouncementStmt != nil {
+		if cerr := q.createAnnouncementStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAnnouncementStmt: %w", cerr)
+		}
+	}
+	if q.createAuditEntryStmt != nil {
+		if cerr := q.createAuditEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAuditEntryStmt: %w", cerr)
+		}
+	}
+	if q.createBanStmt != nil {
+		if cerr := q.createBanStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBanStmt: %w", cerr)
+		}
+	}
+	if q.createBanAppealStmt != nil {
+		if cerr := q.createBanAppealStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBanAppealStmt: %w", cerr)
+		}
+	}
+	if q.createEventStmt != nil {
+		if cerr := q.createEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createEventStmt: %w", cerr)
+		}
+	}
+	if q.createFeatureFlagStmt != nil {
+		if cerr := q.createFeatureFlagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createFeatureFlagStmt: %w", cerr)
+		}
+	}
+	if q.createHealthCheckStmt != nil {
+		if cerr := q.createHealthCheckStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createHealthCheckStmt: %w", cerr)
+		}
+	}
+	if q.createIdempotencyKeyStmt != nil {
+		if cerr := q.createIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.createInboundEventStmt != nil {
+		if cerr := q.createInboundEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createInboundEventStmt: %w", cerr)
+		}
+	}
+	if q.createInboundHookStmt != nil {
+		if cerr := q.createInboundHookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createInboundHookStmt: %w", cerr)
+		}
+	}
+	if q.createJobStmt != nil {
+		if cerr := q.createJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createJobStmt: %w", cerr)
+		}
+	}
+	if q.createReferralStmt != nil {
+		if cerr := q.createReferralStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createReferralStmt: %w", cerr)
+		}
+	}
+	if q.createUploadStmt != nil {
+		if cerr := q.createUploadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUploadStmt: %w", cerr)
+		}
+	}
+	if q.createUserStmt != nil {
+		if cerr := q.createUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
+		}
+	}
+	if q.createUserBanStmt != nil {
+		if cerr := q.createUserBanStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUserBanStmt: %w", cerr)
+		}
+	}
+	if q.createUserIdentityStmt != nil {
+		if cerr := q.createUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookStmt != nil {
+		if cerr := q.createWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookDeliveryStmt != nil {
+		if cerr := q.createWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.decideBanAppealStmt != nil {
+		if cerr := q.decideBanAppealStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing decideBanAppealStmt: %w", cerr)
+		}
+	}
+	if q.deleteAPITokenStmt != nil {
+		if cerr := q.deleteAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.deleteAnalyticsEventsBeforeStmt != nil {
+		if cerr := q.deleteAnalyticsEventsBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAnalyticsEventsBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteAnnouncementStmt != nil {
+		if cerr := q.deleteAnnouncementStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAnnouncementStmt: %w", cerr)
+		}
+	}
+	if q.deleteBanStmt != nil {
+		if cerr := q.deleteBanStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBanStmt: %w", cerr)
+		}
+	}
+	if q.deleteDraftStmt != nil {
+		if cerr := q.deleteDraftStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteDraftStmt: %w", cerr)
+		}
+	}
+	if q.deleteDraftsBeforeStmt != nil {
+		if cerr := q.deleteDraftsBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteDraftsBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteEmailTemplateStmt != nil {
+		if cerr := q.deleteEmailTemplateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteEmailTemplateStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredBansStmt != nil {
+		if cerr := q.deleteExpiredBansStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredBansStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredSessionsStmt != nil {
+		if cerr := q.deleteExpiredSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredUploadsStmt != nil {
+		if cerr := q.deleteExpiredUploadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredUploadsStmt: %w", cerr)
+		}
+	}
+	if q.deleteFeatureFlagStmt != nil {
+		if cerr := q.deleteFeatureFlagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFeatureFlagStmt: %w", cerr)
+		}
+	}
+	if q.deleteFeatureFlagOverrideStmt != nil {
+		if cerr := q.deleteFeatureFlagOverrideStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFeatureFlagOverrideStmt: %w", cerr)
+		}
+	}
+	if q.deleteFinishedJobsBeforeStmt != nil {
+		if cerr := q.deleteFinishedJobsBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFinishedJobsBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteHealthChecksBeforeStmt != nil {
+		if cerr := q.deleteHealthChecksBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteHealthChecksBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteIdempotencyKeyStmt != nil {
+		if cerr := q.deleteIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.deleteIdempotencyKeysBeforeStmt != nil {
+		if cerr := q.deleteIdempotencyKeysBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteIdempotencyKeysBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteInboundHookStmt != nil {
+		if cerr := q.deleteInboundHookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteInboundHookStmt: %w", cerr)
+		}
+	}
+	if q.deleteOtherUserSessionsStmt != nil {
+		if cerr := q.deleteOtherUserSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteOtherUserSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteRateLimitsBeforeStmt != nil {
+		if cerr := q.deleteRateLimitsBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRateLimitsBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteRestHookStmt != nil {
+		if cerr := q.deleteRestHookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRestHookStmt: %w", cerr)
+		}
+	}
+	if q.deleteSessionStmt != nil {
+		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
+		}
+	}
+	if q.deleteSettingsStmt != nil {
+		if cerr := q.deleteSettingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSettingsStmt: %w", cerr)
+		}
+	}
+	if q.deleteUnverifiedUserStmt != nil {
+		if cerr := q.deleteUnverifiedUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUnverifiedUserStmt: %w", cerr)
+		}
+	}
+	if q.deleteUploadStmt != nil {
+		if cerr := q.deleteUploadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUploadStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserSessionStmt != nil {
+		if cerr := q.deleteUserSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserSessionStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserSessionsStmt != nil {
+		if cerr := q.deleteUserSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookStmt != nil {
+		if cerr := q.deleteWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookStmt: %w", cerr)
+		}
+	}
+	if q.dismissAnnouncementStmt != nil {
+		if cerr := q.dismissAnnouncementStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing dismissAnnouncementStmt: %w", cerr)
+		}
+	}
+	if q.ensureReferralCodeStmt != nil {
+		if cerr := q.ensureReferralCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing ensureReferralCodeStmt: %w", cerr)
+		}
+	}
+	if q.finishJobStmt != nil {
+		if cerr := q.finishJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing finishJobStmt: %w", cerr)
+		}
+	}
+	if q.getAPITokenByHashStmt != nil {
+		if cerr := q.getAPITokenByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAPITokenByHashStmt: %w", cerr)
+		}
+	}
+	if q.getBanAppealStmt != nil {
+		if cerr := q.getBanAppealStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBanAppealStmt: %w", cerr)
+		}
+	}
+	if q.getDraftStmt != nil {
+		if cerr := q.getDraftStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDraftStmt: %w", cerr)
+		}
+	}
+	if q.getEmailTemplateStmt != nil {
+		if cerr := q.getEmailTemplateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEmailTemplateStmt: %w", cerr)
+		}
+	}
+	if q.getGuestbookStmt != nil {
+		if cerr := q.getGuestbookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGuestbookStmt: %w", cerr)
+		}
+	}
+	if q.getIdempotencyKeyStmt != nil {
+		if cerr := q.getIdempotencyKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getIdempotencyKeyStmt: %w", cerr)
+		}
+	}
+	if q.getInboundHookByTokenStmt != nil {
+		if cerr := q.getInboundHookByTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getInboundHookByTokenStmt: %w", cerr)
+		}
+	}
+	if q.getMessageStmt != nil {
+		if cerr := q.getMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMessageStmt: %w", cerr)
+		}
+	}
+	if q.getPendingReferralStmt != nil {
+		if cerr := q.getPendingReferralStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPendingReferralStmt: %w", cerr)
+		}
+	}
+	if q.getReferralStorageBonusStmt != nil {
+		if cerr := q.getReferralStorageBonusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getReferralStorageBonusStmt: %w", cerr)
+		}
+	}
+	if q.getReferrerByCodeStmt != nil {
+		if cerr := q.getReferrerByCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getReferrerByCodeStmt: %w", cerr)
+		}
+	}
+	if q.getSessionStmt != nil {
+		if cerr := q.getSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionStmt: %w", cerr)
+		}
+	}
+	if q.getShadowMessageStmt != nil {
+		if cerr := q.getShadowMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getShadowMessageStmt: %w", cerr)
+		}
+	}
+	if q.getStorageUsageStmt != nil {
+		if cerr := q.getStorageUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getStorageUsageStmt: %w", cerr)
+		}
+	}
+	if q.getUploadStmt != nil {
+		if cerr := q.getUploadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUploadStmt: %w", cerr)
+		}
+	}
+	if q.getUploadByIDStmt != nil {
+		if cerr := q.getUploadByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUploadByIDStmt: %w", cerr)
+		}
+	}
+	if q.getUserStmt != nil {
+		if cerr := q.getUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
+		}
+	}
+	if q.getUserByEmailStmt != nil {
+		if cerr := q.getUserByEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserByEmailStmt: %w", cerr)
+		}
+	}
+	if q.getUserIdentityStmt != nil {
+		if cerr := q.getUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookStmt != nil {
+		if cerr := q.getWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookStmt: %w", cerr)
+		}
+	}
+	if q.latestEventIDStmt != nil {
+		if cerr := q.latestEventIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing latestEventIDStmt: %w", cerr)
+		}
+	}
+	if q.liftExpiredUserBansStmt != nil {
+		if cerr := q.liftExpiredUserBansStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing liftExpiredUserBansStmt: %w", cerr)
+		}
+	}
+	if q.liftUserBanStmt != nil {
+		if cerr := q.liftUserBanStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing liftUserBanStmt: %w", cerr)
+		}
+	}
+	if q.listAPITokensByUserStmt != nil {
+		if cerr := q.listAPITokensByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAPITokensByUserStmt: %w", cerr)
+		}
+	}
+	if q.listActiveBansStmt != nil {
+		if cerr := q.listActiveBansStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listActiveBansStmt: %w", cerr)
+		}
+	}
+	if q.listActiveUserBansStmt != nil {
+		if cerr := q.listActiveUserBansStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listActiveUserBansStmt: %w", cerr)
+		}
+	}
+	if q.listAnalyticsDaysStmt != nil {
+		if cerr := q.listAnalyticsDaysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAnalyticsDaysStmt: %w", cerr)
+		}
+	}
+	if q.listAnnouncementsStmt != nil {
+		if cerr := q.listAnnouncementsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAnnouncementsStmt: %w", cerr)
+		}
+	}
+	if q.listAssociatedUsersStmt != nil {
+		if cerr := q.listAssociatedUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAssociatedUsersStmt: %w", cerr)
+		}
+	}
+	if q.listAuditLogStmt != nil {
+		if cerr := q.listAuditLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditLogStmt: %w", cerr)
+		}
+	}
+	if q.listDismissedAnnouncementsStmt != nil {
+		if cerr := q.listDismissedAnnouncementsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDismissedAnnouncementsStmt: %w", cerr)
+		}
+	}
+	if q.listDueWebhookDeliveriesStmt != nil {
+		if cerr := q.listDueWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDueWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.listEmailTemplatesStmt != nil {
+		if cerr := q.listEmailTemplatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEmailTemplatesStmt: %w", cerr)
+		}
+	}
+	if q.listEventsForUserSinceStmt != nil {
+		if cerr := q.listEventsForUserSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEventsForUserSinceStmt: %w", cerr)
+		}
+	}
+	if q.listEventsOfTypeSinceStmt != nil {
+		if cerr := q.listEventsOfTypeSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEventsOfTypeSinceStmt: %w", cerr)
+		}
+	}
+	if q.listExperimentResultsStmt != nil {
+		if cerr := q.listExperimentResultsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listExperimentResultsStmt: %w", cerr)
+		}
+	}
+	if q.listExpiredUploadsStmt != nil {
+		if cerr := q.listExpiredUploadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listExpiredUploadsStmt: %w", cerr)
+		}
+	}
+	if q.listFailedJobsStmt != nil {
+		if cerr := q.listFailedJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFailedJobsStmt: %w", cerr)
+		}
+	}
+	if q.listFailedJobsByNameStmt != nil {
+		if cerr := q.listFailedJobsByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFailedJobsByNameStmt: %w", cerr)
+		}
+	}
+	if q.listFeatureFlagOverridesStmt != nil {
+		if cerr := q.listFeatureFlagOverridesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFeatureFlagOverridesStmt: %w", cerr)
+		}
+	}
+	if q.listFeatureFlagOverridesForUserStmt != nil {
+		if cerr := q.listFeatureFlagOverridesForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFeatureFlagOverridesForUserStmt: %w", cerr)
+		}
+	}
+	if q.listFeatureFlagsStmt != nil {
+		if cerr := q.listFeatureFlagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFeatureFlagsStmt: %w", cerr)
+		}
+	}
+	if q.listHealthCheckHistoryStmt != nil {
+		if cerr := q.listHealthCheckHistoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listHealthCheckHistoryStmt: %w", cerr)
+		}
+	}
+	if q.listHealthCheckUptimeStmt != nil {
+		if cerr := q.listHealthCheckUptimeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listHealthCheckUptimeStmt: %w", cerr)
+		}
+	}
+	if q.listInboundHooksByUserStmt != nil {
+		if cerr := q.listInboundHooksByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listInboundHooksByUserStmt: %w", cerr)
+		}
+	}
+	if q.listJobOutcomesStmt != nil {
+		if cerr := q.listJobOutcomesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listJobOutcomesStmt: %w", cerr)
+		}
+	}
+	if q.listJobSchedulesStmt != nil {
+		if cerr := q.listJobSchedulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listJobSchedulesStmt: %w", cerr)
+		}
+	}
+	if q.listLargestUploadsStmt != nil {
+		if cerr := q.listLargestUploadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLargestUploadsStmt: %w", cerr)
+		}
+	}
+	if q.listLatestHealthChecksStmt != nil {
+		if cerr := q.listLatestHealthChecksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLatestHealthChecksStmt: %w", cerr)
+		}
+	}
+	if q.listPendingBanAppealsStmt != nil {
+		if cerr := q.listPendingBanAppealsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPendingBanAppealsStmt: %w", cerr)
+		}
+	}
+	if q.listPendingInboundEventsStmt != nil {
+		if cerr := q.listPendingInboundEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPendingInboundEventsStmt: %w", cerr)
+		}
+	}
+	if q.listPendingJobsStmt != nil {
+		if cerr := q.listPendingJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPendingJobsStmt: %w", cerr)
+		}
+	}
+	if q.listPendingJobsByNameStmt != nil {
+		if cerr := q.listPendingJobsByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPendingJobsByNameStmt: %w", cerr)
+		}
+	}
+	if q.listQuarantinedUploadsStmt != nil {
+		if cerr := q.listQuarantinedUploadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listQuarantinedUploadsStmt: %w", cerr)
+		}
+	}
+	if q.listRateLimitsStmt != nil {
+		if cerr := q.listRateLimitsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRateLimitsStmt: %w", cerr)
+		}
+	}
+	if q.listRecentEventsForUserStmt != nil {
+		if cerr := q.listRecentEventsForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecentEventsForUserStmt: %w", cerr)
+		}
+	}
+	if q.listRecentJobsStmt != nil {
+		if cerr := q.listRecentJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecentJobsStmt: %w", cerr)
+		}
+	}
+	if q.listRecentJobsByNameStmt != nil {
+		if cerr := q.listRecentJobsByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecentJobsByNameStmt: %w", cerr)
+		}
+	}
+	if q.listReferralsStmt != nil {
+		if cerr := q.listReferralsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listReferralsStmt: %w", cerr)
+		}
+	}
+	if q.listSettingsStmt != nil {
+		if cerr := q.listSettingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSettingsStmt: %w", cerr)
+		}
+	}
+	if q.listTopPagesStmt != nil {
+		if cerr := q.listTopPagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTopPagesStmt: %w", cerr)
+		}
+	}
+	if q.listTopReferrersStmt != nil {
+		if cerr := q.listTopReferrersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTopReferrersStmt: %w", cerr)
+		}
+	}
+	if q.listUnendedAnnouncementsStmt != nil {
+		if cerr := q.listUnendedAnnouncementsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUnendedAnnouncementsStmt: %w", cerr)
+		}
+	}
+	if q.listUnverifiedUsersBeforeStmt != nil {
+		if cerr := q.listUnverifiedUsersBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUnverifiedUsersBeforeStmt: %w", cerr)
+		}
+	}
+	if q.listUnverifiedUsersToRemindStmt != nil {
+		if cerr := q.listUnverifiedUsersToRemindStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUnverifiedUsersToRemindStmt: %w", cerr)
+		}
+	}
+	if q.listUploadStorageKeysStmt != nil {
+		if cerr := q.listUploadStorageKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUploadStorageKeysStmt: %w", cerr)
+		}
+	}
+	if q.listUploadsBySHA256Stmt != nil {
+		if cerr := q.listUploadsBySHA256Stmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUploadsBySHA256Stmt: %w", cerr)
+		}
+	}
+	if q.listUserBansStmt != nil {
+		if cerr := q.listUserBansStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserBansStmt: %w", cerr)
+		}
+	}
+	if q.listUserSessionsStmt != nil {
+		if cerr := q.listUserSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserSessionsStmt: %w", cerr)
+		}
+	}
+	if q.listUsersStmt != nil {
+		if cerr := q.listUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookDeliveriesStmt != nil {
+		if cerr := q.listWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.listWebhooksStmt != nil {
+		if cerr := q.listWebhooksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhooksStmt: %w", cerr)
+		}
+	}
+	if q.listWebhooksByUserStmt != nil {
+		if cerr := q.listWebhooksByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhooksByUserStmt: %w", cerr)
+		}
+	}
+	if q.markUserVerifiedStmt != nil {
+		if cerr := q.markUserVerifiedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markUserVerifiedStmt: %w", cerr)
+		}
+	}
+	if q.markVerificationRemindedStmt != nil {
+		if cerr := q.markVerificationRemindedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markVerificationRemindedStmt: %w", cerr)
+		}
+	}
+	if q.qualifyReferralStmt != nil {
+		if cerr := q.qualifyReferralStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing qualifyReferralStmt: %w", cerr)
+		}
+	}
+	if q.recordUserSightingStmt != nil {
+		if cerr := q.recordUserSightingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordUserSightingStmt: %w", cerr)
+		}
+	}
+	if q.retryJobStmt != nil {
+		if cerr := q.retryJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing retryJobStmt: %w", cerr)
+		}
+	}
+	if q.rollUpAnalyticsCountsStmt != nil {
+		if cerr := q.rollUpAnalyticsCountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rollUpAnalyticsCountsStmt: %w", cerr)
+		}
+	}
+	if q.rollUpAnalyticsDaysStmt != nil {
+		if cerr := q.rollUpAnalyticsDaysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rollUpAnalyticsDaysStmt: %w", cerr)
+		}
+	}
+	if q.rollUpExperimentStmt != nil {
+		if cerr := q.rollUpExperimentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rollUpExperimentStmt: %w", cerr)
+		}
+	}
+	if q.saveDraftStmt != nil {
+		if cerr := q.saveDraftStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing saveDraftStmt: %w", cerr)
+		}
+	}
+	if q.setUploadStatusStmt != nil {
+		if cerr := q.setUploadStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUploadStatusStmt: %w", cerr)
+		}
+	}
+	if q.setUserAvatarStmt != nil {
+		if cerr := q.setUserAvatarStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserAvatarStmt: %w", cerr)
+		}
+	}
+	if q.setUserLocaleStmt != nil {
+		if cerr := q.setUserLocaleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserLocaleStmt: %w", cerr)
+		}
+	}
+	if q.setUserPasswordStmt != nil {
+		if cerr := q.setUserPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserPasswordStmt: %w", cerr)
+		}
+	}
+	if q.touchAPITokenStmt != nil {
+		if cerr := q.touchAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.updateFeatureFlagStmt != nil {
+		if cerr := q.updateFeatureFlagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateFeatureFlagStmt: %w", cerr)
+		}
+	}
+	if q.updateInboundEventStmt != nil {
+		if cerr := q.updateInboundEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateInboundEventStmt: %w", cerr)
+		}
+	}
+	if q.updateMessageIfVersionStmt != nil {
+		if cerr := q.updateMessageIfVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateMessageIfVersionStmt: %w", cerr)
+		}
+	}
+	if q.updateWebhookDeliveryStmt != nil {
+		if cerr := q.updateWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.upsertEmailTemplateStmt != nil {
+		if cerr := q.upsertEmailTemplateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertEmailTemplateStmt: %w", cerr)
+		}
+	}
+	if q.upsertFeatureFlagOverrideStmt != nil {
+		if cerr := q.upsertFeatureFlagOverrideStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertFeatureFlagOverrideStmt: %w", cerr)
+		}
+	}
+	if q.upsertJobScheduleStmt != nil {
+		if cerr := q.upsertJobScheduleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertJobScheduleStmt: %w", cerr)
+		}
+	}
+	if q.upsertMessageStmt != nil {
+		if cerr := q.upsertMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertMessageStmt: %w", cerr)
+		}
+	}
+	if q.upsertRateLimitStmt != nil {
+		if cerr := q.upsertRateLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertRateLimitStmt: %w", cerr)
+		}
+	}
+	if q.upsertSessionStmt != nil {
+		if cerr := q.upsertSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertSessionStmt: %w", cerr)
+		}
+	}
+	if q.upsertSettingStmt != nil {
+		if cerr := q.upsertSettingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertSettingStmt: %w", cerr)
+		}
+	}
+	if q.upsertShadowMessageStmt != nil {
+		if cerr := q.upsertShadowMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertShadowMessageStmt: %w", cerr)
+		}
+	}
+	if q.verifyUserStmt != nil {
+		if cerr := q.verifyUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing verifyUserStmt: %w", cerr)
+		}
+	}
+	return err
+}
+
+func (q *Queries) exec(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (sql.Result, error) {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	case stmt != nil:
+		return stmt.ExecContext(ctx, args...)
+	default:
+		return q.db.ExecContext(ctx, query, args...)
+	}
+}
+
+func (q *Queries) query(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (*sql.Rows, error) {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+	case stmt != nil:
+		return stmt.QueryContext(ctx, args...)
+	default:
+		return q.db.QueryContext(ctx, query, args...)
+	}
+}
+
+func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) *sql.Row {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).QueryRowContext(ctx, args...)
+	case stmt != nil:
+		return stmt.QueryRowContext(ctx, args...)
+	default:
+		return q.db.QueryRowContext(ctx, query, args...)
+	}
+}
+
 type Queries struct {
-	db DBTX
+	db                                  DBTX
+	tx                                  *sql.Tx
+	advanceJobScheduleStmt              *sql.Stmt
+	advanceUploadStmt                   *sql.Stmt
+	cancelJobStmt                       *sql.Stmt
+	claimJobStmt                        *sql.Stmt
+	completeIdempotencyKeyStmt          *sql.Stmt
+	completeUploadStmt                  *sql.Stmt
+	countActiveBansOnDeviceStmt         *sql.Stmt
+	countActiveUsersStmt                *sql.Stmt
+	countAnalyticsEventsStmt            *sql.Stmt
+	countJobsByDayStmt                  *sql.Stmt
+	countPendingJobsByNameStmt          *sql.Stmt
+	countReferrerSightingsStmt          *sql.Stmt
+	countRewardedReferralsStmt          *sql.Stmt
+	countSignupsByDayStmt               *sql.Stmt
+	countUsersStmt                      *sql.Stmt
+	createAPITokenStmt                  *sql.Stmt
+	createAnalyticsEventStmt            *sql.Stmt
+	createAnnouncementStmt              *sql.Stmt
+	createAuditEntryStmt                *sql.Stmt
+	createBanStmt                       *sql.Stmt
+	createBanAppealStmt                 *sql.Stmt
+	createEventStmt                     *sql.Stmt
+	createFeatureFlagStmt               *sql.Stmt
+	createHealthCheckStmt               *sql.Stmt
+	createIdempotencyKeyStmt            *sql.Stmt
+	createInboundEventStmt              *sql.Stmt
+	createInboundHookStmt               *sql.Stmt
+	createJobStmt                       *sql.Stmt
+	createReferralStmt                  *sql.Stmt
+	createUploadStmt                    *sql.Stmt
+	createUserStmt                      *sql.Stmt
+	createUserBanStmt                   *sql.Stmt
+	createUserIdentityStmt              *sql.Stmt
+	createWebhookStmt                   *sql.Stmt
+	createWebhookDeliveryStmt           *sql.Stmt
+	decideBanAppealStmt                 *sql.Stmt
+	deleteAPITokenStmt                  *sql.Stmt
+	deleteAnalyticsEventsBeforeStmt     *sql.Stmt
+	deleteAnnouncementStmt              *sql.Stmt
+	deleteBanStmt                       *sql.Stmt
+	deleteDraftStmt                     *sql.Stmt
+	deleteDraftsBeforeStmt              *sql.Stmt
+	deleteEmailTemplateStmt             *sql.Stmt
+	deleteExpiredBansStmt               *sql.Stmt
+	deleteExpiredSessionsStmt           *sql.Stmt
+	deleteExpiredUploadsStmt            *sql.Stmt
+	deleteFeatureFlagStmt               *sql.Stmt
+	deleteFeatureFlagOverrideStmt       *sql.Stmt
+	deleteFinishedJobsBeforeStmt        *sql.Stmt
+	deleteHealthChecksBeforeStmt        *sql.Stmt
+	deleteIdempotencyKeyStmt            *sql.Stmt
+	deleteIdempotencyKeysBeforeStmt     *sql.Stmt
+	deleteInboundHookStmt               *sql.Stmt
+	deleteOtherUserSessionsStmt         *sql.Stmt
+	deleteRateLimitsBeforeStmt          *sql.Stmt
+	deleteRestHookStmt                  *sql.Stmt
+	deleteSessionStmt                   *sql.Stmt
+	deleteSettingsStmt                  *sql.Stmt
+	deleteUnverifiedUserStmt            *sql.Stmt
+	deleteUploadStmt                    *sql.Stmt
+	deleteUserSessionStmt               *sql.Stmt
+	deleteUserSessionsStmt              *sql.Stmt
+	deleteWebhookStmt                   *sql.Stmt
+	dismissAnnouncementStmt             *sql.Stmt
+	ensureReferralCodeStmt              *sql.Stmt
+	finishJobStmt                       *sql.Stmt
+	getAPITokenByHashStmt               *sql.Stmt
+	getBanAppealStmt                    *sql.Stmt
+	getDraftStmt                        *sql.Stmt
+	getEmailTemplateStmt                *sql.Stmt
+	getGuestbookStmt                    *sql.Stmt
+	getIdempotencyKeyStmt               *sql.Stmt
+	getInboundHookByTokenStmt           *sql.Stmt
+	getMessageStmt                      *sql.Stmt
+	getPendingReferralStmt              *sql.Stmt
+	getReferralStorageBonusStmt         *sql.Stmt
+	getReferrerByCodeStmt               *sql.Stmt
+	getSessionStmt                      *sql.Stmt
+	getShadowMessageStmt                *sql.Stmt
+	getStorageUsageStmt                 *sql.Stmt
+	getUploadStmt                       *sql.Stmt
+	getUploadByIDStmt                   *sql.Stmt
+	getUserStmt                         *sql.Stmt
+	getUserByEmailStmt                  *sql.Stmt
+	getUserIdentityStmt                 *sql.Stmt
+	getWebhookStmt                      *sql.Stmt
+	latestEventIDStmt                   *sql.Stmt
+	liftExpiredUserBansStmt             *sql.Stmt
+	liftUserBanStmt                     *sql.Stmt
+	listAPITokensByUserStmt             *sql.Stmt
+	listActiveBansStmt                  *sql.Stmt
+	listActiveUserBansStmt              *sql.Stmt
+	listAnalyticsDaysStmt               *sql.Stmt
+	listAnnouncementsStmt               *sql.Stmt
+	listAssociatedUsersStmt             *sql.Stmt
+	listAuditLogStmt                    *sql.Stmt
+	listDismissedAnnouncementsStmt      *sql.Stmt
+	listDueWebhookDeliveriesStmt        *sql.Stmt
+	listEmailTemplatesStmt              *sql.Stmt
+	listEventsForUserSinceStmt          *sql.Stmt
+	listEventsOfTypeSinceStmt           *sql.Stmt
+	listExperimentResultsStmt           *sql.Stmt
+	listExpiredUploadsStmt              *sql.Stmt
+	listFailedJobsStmt                  *sql.Stmt
+	listFailedJobsByNameStmt            *sql.Stmt
+	listFeatureFlagOverridesStmt        *sql.Stmt
+	listFeatureFlagOverridesForUserStmt *sql.Stmt
+	listFeatureFlagsStmt                *sql.Stmt
+	listHealthCheckHistoryStmt          *sql.Stmt
+	listHealthCheckUptimeStmt           *sql.Stmt
+	listInboundHooksByUserStmt          *sql.Stmt
+	listJobOutcomesStmt                 *sql.Stmt
+	listJobSchedulesStmt                *sql.Stmt
+	listLargestUploadsStmt              *sql.Stmt
+	listLatestHealthChecksStmt          *sql.Stmt
+	listPendingBanAppealsStmt           *sql.Stmt
+	listPendingInboundEventsStmt        *sql.Stmt
+	listPendingJobsStmt                 *sql.Stmt
+	listPendingJobsByNameStmt           *sql.Stmt
+	listQuarantinedUploadsStmt          *sql.Stmt
+	listRateLimitsStmt                  *sql.Stmt
+	listRecentEventsForUserStmt         *sql.Stmt
+	listRecentJobsStmt                  *sql.Stmt
+	listRecentJobsByNameStmt            *sql.Stmt
+	listReferralsStmt                   *sql.Stmt
+	listSettingsStmt                    *sql.Stmt
+	listTopPagesStmt                    *sql.Stmt
+	listTopReferrersStmt                *sql.Stmt
+	listUnendedAnnouncementsStmt        *sql.Stmt
+	listUnverifiedUsersBeforeStmt       *sql.Stmt
+	listUnverifiedUsersToRemindStmt     *sql.Stmt
+	listUploadStorageKeysStmt           *sql.Stmt
+	listUploadsBySHA256Stmt             *sql.Stmt
+	listUserBansStmt                    *sql.Stmt
+	listUserSessionsStmt                *sql.Stmt
+	listUsersStmt                       *sql.Stmt
+	listWebhookDeliveriesStmt           *sql.Stmt
+	listWebhooksStmt                    *sql.Stmt
+	listWebhooksByUserStmt              *sql.Stmt
+	markUserVerifiedStmt                *sql.Stmt
+	markVerificationRemindedStmt        *sql.Stmt
+	qualifyReferralStmt                 *sql.Stmt
+	recordUserSightingStmt              *sql.Stmt
+	retryJobStmt                        *sql.Stmt
+	rollUpAnalyticsCountsStmt           *sql.Stmt
+	rollUpAnalyticsDaysStmt             *sql.Stmt
+	rollUpExperimentStmt                *sql.Stmt
+	saveDraftStmt                       *sql.Stmt
+	setUploadStatusStmt                 *sql.Stmt
+	setUserAvatarStmt                   *sql.Stmt
+	setUserLocaleStmt                   *sql.Stmt
+	setUserPasswordStmt                 *sql.Stmt
+	touchAPITokenStmt                   *sql.Stmt
+	updateFeatureFlagStmt               *sql.Stmt
+	updateInboundEventStmt              *sql.Stmt
+	updateMessageIfVersionStmt          *sql.Stmt
+	updateWebhookDeliveryStmt           *sql.Stmt
+	upsertEmailTemplateStmt             *sql.Stmt
+	upsertFeatureFlagOverrideStmt       *sql.Stmt
+	upsertJobScheduleStmt               *sql.Stmt
+	upsertMessageStmt                   *sql.Stmt
+	upsertRateLimitStmt                 *sql.Stmt
+	upsertSessionStmt                   *sql.Stmt
+	upsertSettingStmt                   *sql.Stmt
+	upsertShadowMessageStmt             *sql.Stmt
+	verifyUserStmt                      *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db: tx,
+		db:                                  tx,
+		tx:                                  tx,
+		advanceJobScheduleStmt:              q.advanceJobScheduleStmt,
+		advanceUploadStmt:                   q.advanceUploadStmt,
+		cancelJobStmt:                       q.cancelJobStmt,
+		claimJobStmt:                        q.claimJobStmt,
+		completeIdempotencyKeyStmt:          q.completeIdempotencyKeyStmt,
+		completeUploadStmt:                  q.completeUploadStmt,
+		countActiveBansOnDeviceStmt:         q.countActiveBansOnDeviceStmt,
+		countActiveUsersStmt:                q.countActiveUsersStmt,
+		countAnalyticsEventsStmt:            q.countAnalyticsEventsStmt,
+		countJobsByDayStmt:                  q.countJobsByDayStmt,
+		countPendingJobsByNameStmt:          q.countPendingJobsByNameStmt,
+		countReferrerSightingsStmt:          q.countReferrerSightingsStmt,
+		countRewardedReferralsStmt:          q.countRewardedReferralsStmt,
+		countSignupsByDayStmt:               q.countSignupsByDayStmt,
+		countUsersStmt:                      q.countUsersStmt,
+		createAPITokenStmt:                  q.createAPITokenStmt,
+		createAnalyticsEventStmt:            q.createAnalyticsEventStmt,
+		createAnnouncementStmt:              q.createAnnouncementStmt,
+		createAuditEntryStmt:                q.createAuditEntryStmt,
+		createBanStmt:                       q.createBanStmt,
+		createBanAppealStmt:                 q.createBanAppealStmt,
+		createEventStmt:                     q.createEventStmt,
+		createFeatureFlagStmt:               q.createFeatureFlagStmt,
+		createHealthCheckStmt:               q.createHealthCheckStmt,
+		createIdempotencyKeyStmt:            q.createIdempotencyKeyStmt,
+		createInboundEventStmt:              q.createInboundEventStmt,
+		createInboundHookStmt:               q.createInboundHookStmt,
+		createJobStmt:                       q.createJobStmt,
+		createReferralStmt:                  q.createReferralStmt,
+		createUploadStmt:                    q.createUploadStmt,
+		createUserStmt:                      q.createUserStmt,
+		createUserBanStmt:                   q.createUserBanStmt,
+		createUserIdentityStmt:              q.createUserIdentityStmt,
+		createWebhookStmt:                   q.createWebhookStmt,
+		createWebhookDeliveryStmt:           q.createWebhookDeliveryStmt,
+		decideBanAppealStmt:                 q.decideBanAppealStmt,
+		deleteAPITokenStmt:                  q.deleteAPITokenStmt,
+		deleteAnalyticsEventsBeforeStmt:     q.deleteAnalyticsEventsBeforeStmt,
+		deleteAnnouncementStmt:              q.deleteAnnouncementStmt,
+		deleteBanStmt:                       q.deleteBanStmt,
+		deleteDraftStmt:                     q.deleteDraftStmt,
+		deleteDraftsBeforeStmt:              q.deleteDraftsBeforeStmt,
+		deleteEmailTemplateStmt:             q.deleteEmailTemplateStmt,
+		deleteExpiredBansStmt:               q.deleteExpiredBansStmt,
+		deleteExpiredSessionsStmt:           q.deleteExpiredSessionsStmt,
+		deleteExpiredUploadsStmt:            q.deleteExpiredUploadsStmt,
+		deleteFeatureFlagStmt:               q.deleteFeatureFlagStmt,
+		deleteFeatureFlagOverrideStmt:       q.deleteFeatureFlagOverrideStmt,
+		deleteFinishedJobsBeforeStmt:        q.deleteFinishedJobsBeforeStmt,
+		deleteHealthChecksBeforeStmt:        q.deleteHealthChecksBeforeStmt,
+		deleteIdempotencyKeyStmt:            q.deleteIdempotencyKeyStmt,
+		deleteIdempotencyKeysBeforeStmt:     q.deleteIdempotencyKeysBeforeStmt,
+		deleteInboundHookStmt:               q.deleteInboundHookStmt,
+		deleteOtherUserSessionsStmt:         q.deleteOtherUserSessionsStmt,
+		deleteRateLimitsBeforeStmt:          q.deleteRateLimitsBeforeStmt,
+		deleteRestHookStmt:                  q.deleteRestHookStmt,
+		deleteSessionStmt:                   q.deleteSessionStmt,
+		deleteSettingsStmt:                  q.deleteSettingsStmt,
+		deleteUnverifiedUserStmt:            q.deleteUnverifiedUserStmt,
+		deleteUploadStmt:                    q.deleteUploadStmt,
+		deleteUserSessionStmt:               q.deleteUserSessionStmt,
+		deleteUserSessionsStmt:              q.deleteUserSessionsStmt,
+		deleteWebhookStmt:                   q.deleteWebhookStmt,
+		dismissAnnouncementStmt:             q.dismissAnnouncementStmt,
+		ensureReferralCodeStmt:              q.ensureReferralCodeStmt,
+		finishJobStmt:                       q.finishJobStmt,
+		getAPITokenByHashStmt:               q.getAPITokenByHashStmt,
+		getBanAppealStmt:                    q.getBanAppealStmt,
+		getDraftStmt:                        q.getDraftStmt,
+		getEmailTemplateStmt:                q.getEmailTemplateStmt,
+		getGuestbookStmt:                    q.getGuestbookStmt,
+		getIdempotencyKeyStmt:               q.getIdempotencyKeyStmt,
+		getInboundHookByTokenStmt:           q.getInboundHookByTokenStmt,
+		getMessageStmt:                      q.getMessageStmt,
+		getPendingReferralStmt:              q.getPendingReferralStmt,
+		getReferralStorageBonusStmt:         q.getReferralStorageBonusStmt,
+		getReferrerByCodeStmt:               q.getReferrerByCodeStmt,
+		getSessionStmt:                      q.getSessionStmt,
+		getShadowMessageStmt:                q.getShadowMessageStmt,
+		getStorageUsageStmt:                 q.getStorageUsageStmt,
+		getUploadStmt:                       q.getUploadStmt,
+		getUploadByIDStmt:                   q.getUploadByIDStmt,
+		getUserStmt:                         q.getUserStmt,
+		getUserByEmailStmt:                  q.getUserByEmailStmt,
+		getUserIdentityStmt:                 q.getUserIdentityStmt,
+		getWebhookStmt:                      q.getWebhookStmt,
+		latestEventIDStmt:                   q.latestEventIDStmt,
+		liftExpiredUserBansStmt:             q.liftExpiredUserBansStmt,
+		liftUserBanStmt:                     q.liftUserBanStmt,
+		listAPITokensByUserStmt:             q.listAPITokensByUserStmt,
+		listActiveBansStmt:                  q.listActiveBansStmt,
+		listActiveUserBansStmt:              q.listActiveUserBansStmt,
+		listAnalyticsDaysStmt:               q.listAnalyticsDaysStmt,
+		listAnnouncementsStmt:               q.listAnnouncementsStmt,
+		listAssociatedUsersStmt:             q.listAssociatedUsersStmt,
+		listAuditLogStmt:                    q.listAuditLogStmt,
+		listDismissedAnnouncementsStmt:      q.listDismissedAnnouncementsStmt,
+		listDueWebhookDeliveriesStmt:        q.listDueWebhookDeliveriesStmt,
+		listEmailTemplatesStmt:              q.listEmailTemplatesStmt,
+		listEventsForUserSinceStmt:          q.listEventsForUserSinceStmt,
+		listEventsOfTypeSinceStmt:           q.listEventsOfTypeSinceStmt,
+		listExperimentResultsStmt:           q.listExperimentResultsStmt,
+		listExpiredUploadsStmt:              q.listExpiredUploadsStmt,
+		listFailedJobsStmt:                  q.listFailedJobsStmt,
+		listFailedJobsByNameStmt:            q.listFailedJobsByNameStmt,
+		listFeatureFlagOverridesStmt:        q.listFeatureFlagOverridesStmt,
+		listFeatureFlagOverridesForUserStmt: q.listFeatureFlagOverridesForUserStmt,
+		listFeatureFlagsStmt:                q.listFeatureFlagsStmt,
+		listHealthCheckHistoryStmt:          q.listHealthCheckHistoryStmt,
+		listHealthCheckUptimeStmt:           q.listHealthCheckUptimeStmt,
+		listInboundHooksByUserStmt:          q.listInboundHooksByUserStmt,
+		listJobOutcomesStmt:                 q.listJobOutcomesStmt,
+		listJobSchedulesStmt:                q.listJobSchedulesStmt,
+		listLargestUploadsStmt:              q.listLargestUploadsStmt,
+		listLatestHealthChecksStmt:          q.listLatestHealthChecksStmt,
+		listPendingBanAppealsStmt:           q.listPendingBanAppealsStmt,
+		listPendingInboundEventsStmt:        q.listPendingInboundEventsStmt,
+		listPendingJobsStmt:                 q.listPendingJobsStmt,
+		listPendingJobsByNameStmt:           q.listPendingJobsByNameStmt,
+		listQuarantinedUploadsStmt:          q.listQuarantinedUploadsStmt,
+		listRateLimitsStmt:                  q.listRateLimitsStmt,
+		listRecentEventsForUserStmt:         q.listRecentEventsForUserStmt,
+		listRecentJobsStmt:                  q.listRecentJobsStmt,
+		listRecentJobsByNameStmt:            q.listRecentJobsByNameStmt,
+		listReferralsStmt:                   q.listReferralsStmt,
+		listSettingsStmt:                    q.listSettingsStmt,
+		listTopPagesStmt:                    q.listTopPagesStmt,
+		listTopReferrersStmt:                q.listTopReferrersStmt,
+		listUnendedAnnouncementsStmt:        q.listUnendedAnnouncementsStmt,
+		listUnverifiedUsersBeforeStmt:       q.listUnverifiedUsersBeforeStmt,
+		listUnverifiedUsersToRemindStmt:     q.listUnverifiedUsersToRemindStmt,
+		listUploadStorageKeysStmt:           q.listUploadStorageKeysStmt,
+		listUploadsBySHA256Stmt:             q.listUploadsBySHA256Stmt,
+		listUserBansStmt:                    q.listUserBansStmt,
+		listUserSessionsStmt:                q.listUserSessionsStmt,
+		listUsersStmt:                       q.listUsersStmt,
+		listWebhookDeliveriesStmt:           q.listWebhookDeliveriesStmt,
+		listWebhooksStmt:                    q.listWebhooksStmt,
+		listWebhooksByUserStmt:              q.listWebhooksByUserStmt,
+		markUserVerifiedStmt:                q.markUserVerifiedStmt,
+		markVerificationRemindedStmt:        q.markVerificationRemindedStmt,
+		qualifyReferralStmt:                 q.qualifyReferralStmt,
+		recordUserSightingStmt:              q.recordUserSightingStmt,
+		retryJobStmt:                        q.retryJobStmt,
+		rollUpAnalyticsCountsStmt:           q.rollUpAnalyticsCountsStmt,
+		rollUpAnalyticsDaysStmt:             q.rollUpAnalyticsDaysStmt,
+		rollUpExperimentStmt:                q.rollUpExperimentStmt,
+		saveDraftStmt:                       q.saveDraftStmt,
+		setUploadStatusStmt:                 q.setUploadStatusStmt,
+		setUserAvatarStmt:                   q.setUserAvatarStmt,
+		setUserLocaleStmt:                   q.setUserLocaleStmt,
+		setUserPasswordStmt:                 q.setUserPasswordStmt,
+		touchAPITokenStmt:                   q.touchAPITokenStmt,
+		updateFeatureFlagStmt:               q.updateFeatureFlagStmt,
+		updateInboundEventStmt:              q.updateInboundEventStmt,
+		updateMessageIfVersionStmt:          q.updateMessageIfVersionStmt,
+		updateWebhookDeliveryStmt:           q.updateWebhookDeliveryStmt,
+		upsertEmailTemplateStmt:             q.upsertEmailTemplateStmt,
+		upsertFeatureFlagOverrideStmt:       q.upsertFeatureFlagOverrideStmt,
+		upsertJobScheduleStmt:               q.upsertJobScheduleStmt,
+		upsertMessageStmt:                   q.upsertMessageStmt,
+		upsertRateLimitStmt:                 q.upsertRateLimitStmt,
+		upsertSessionStmt:                   q.upsertSessionStmt,
+		upsertSettingStmt:                   q.upsertSettingStmt,
+		upsertShadowMessageStmt:             q.upsertShadowMessageStmt,
+		verifyUserStmt:                      q.verifyUserStmt,
 	}
 }
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// QueryStats times every statement run through the connector Setup opens
+// the database with. Statements slower than SlowThreshold are logged with
+// their query plan, and the totals are shown at /admin/queries.
+type QueryStats struct {
+	SlowThreshold time.Duration
+
+	mu      sync.Mutex
+	queries map[string]*QueryStat
+	db      *sql.DB // for EXPLAIN QUERY PLAN
+}
+
+// QueryStat aggregates the runs of one query since startup. Plan is the
+// query plan from its first slow run.
+type QueryStat struct {
+	Name   string
+	Count  int64
+	Errors int64
+	Total  time.Duration
+	Max    time.Duration
+	Slow   int64
+	Plan   string
+}
+
+// Stats is the process-wide set of query timings.
+var Stats = &QueryStats{SlowThreshold: 100 * time.Millisecond, queries: map[string]*QueryStat{}}
+
+// Snapshot returns the queries by total time spent, most first.
+func (s *QueryStats) Snapshot() []QueryStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]QueryStat, 0, len(s.queries))
+	for _, q := range s.queries {
+		stats = append(stats, *q)
+	}
+	slices.SortFunc(stats, func(a, b QueryStat) int {
+		return int(b.Total - a.Total)
+	})
+	return stats
+}
+
+func (s *QueryStats) record(query string, args []driver.NamedValue, start time.Time, err error) {
+	elapsed := time.Since(start)
+	if strings.HasPrefix(query, explainPrefix) {
+		return // run by explain
+	}
+	name := queryName(query)
+	s.mu.Lock()
+	q, ok := s.queries[name]
+	if !ok {
+		q = &QueryStat{Name: name}
+		s.queries[name] = q
+	}
+	q.Count++
+	q.Total += elapsed
+	q.Max = max(q.Max, elapsed)
+	if err != nil {
+		q.Errors++
+	}
+	slow := elapsed >= s.SlowThreshold
+	explain := slow && q.Slow == 0 && s.db != nil
+	if slow {
+		q.Slow++
+	}
+	s.mu.Unlock()
+
+	if !slow {
+		return
+	}
+	log.Printf("Slow query %s took %s", name, elapsed.Round(time.Millisecond))
+	if explain {
+		// Plans don't change between runs, so only the first slow one is explained
+		values := make([]any, len(args))
+		for i, a := range args {
+			values[i] = a.Value
+		}
+		go s.explain(name, query, values)
+	}
+}
+
+const explainPrefix = "EXPLAIN QUERY PLAN "
+
+func (s *QueryStats) explain(name, query string, args []any) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, explainPrefix+query, args...)
+	if err != nil {
+		log.Printf("Error explaining %s: %v", name, err)
+		return
+	}
+	defer rows.Close()
+	var steps []string
+	for rows.Next() {
+		var id, parent, unused int64
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			log.Printf("Error explaining %s: %v", name, err)
+			return
+		}
+		steps = append(steps, detail)
+	}
+	if len(steps) == 0 {
+		return
+	}
+	plan := strings.Join(steps, "; ")
+	log.Printf("Plan of %s: %s", name, plan)
+	s.mu.Lock()
+	s.queries[name].Plan = plan
+	s.mu.Unlock()
+}
+
+// queryName names a query by its sqlc name, or by the start of its SQL
+// for the few built at runtime.
+func queryName(query string) string {
+	if rest, ok := strings.CutPrefix(query, "-- name: "); ok {
+		if name, _, ok := strings.Cut(rest, " "); ok {
+			return name
+		}
+	}
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > 80 {
+		query = query[:80] + "…"
+	}
+	return query
+}
+
+// connector opens SQLite connections that report to Stats.
+type connector struct {
+	dsn string
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{conn.(sqliteConn)}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// sqliteConn is what the sqlite driver's connections implement, and so
+// what timedConn passes through.
+type sqliteConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+type timedConn struct {
+	sqliteConn
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.sqliteConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{stmt.(sqliteStmt), query}, nil
+}
+
+func (c *timedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := c.sqliteConn.ExecContext(ctx, query, args)
+	Stats.record(query, args, start, err)
+	return res, err
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.sqliteConn.QueryContext(ctx, query, args)
+	Stats.record(query, args, start, err)
+	return rows, err
+}
+
+type sqliteStmt interface {
+	driver.Stmt
+	driver.StmtExecContext
+	driver.StmtQueryContext
+}
+
+// timedStmt times prepared statements, which is how the Queries run.
+type timedStmt struct {
+	sqliteStmt
+	query string
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := s.sqliteStmt.ExecContext(ctx, args)
+	Stats.record(s.query, args, start, err)
+	return res, err
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.sqliteStmt.QueryContext(ctx, args)
+	Stats.record(s.query, args, start, err)
+	return rows, err
+}
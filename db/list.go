@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Condition restricts a list to rows whose column equals one of Values.
+type Condition struct {
+	Column string
+	Values []any
+}
+
+// Order sorts a list by a column.
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// buildList appends conditions, ordering and a page to a base query that
+// already has a WHERE clause. sqlc can't express these dynamic lists, so
+// column names must come from the caller's allow-list; only values are
+// bound. A limit of 0 means no limit.
+func buildList(base string, args []any, conds []Condition, order []Order, limit, offset int64) (string, []any) {
+	var b strings.Builder
+	b.WriteString(base)
+	for _, c := range conds {
+		b.WriteString(" AND ")
+		b.WriteString(c.Column)
+		if len(c.Values) == 1 {
+			b.WriteString(" = ?")
+		} else {
+			b.WriteString(" IN (?" + strings.Repeat(", ?", len(c.Values)-1) + ")")
+		}
+		args = append(args, c.Values...)
+	}
+	if len(order) > 0 {
+		b.WriteString(" ORDER BY ")
+		for i, o := range order {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(o.Column)
+			if o.Desc {
+				b.WriteString(" DESC")
+			}
+		}
+	}
+	if limit > 0 || offset > 0 {
+		// SQLite only takes OFFSET after a LIMIT, where -1 is none
+		if limit <= 0 {
+			limit = -1
+		}
+		b.WriteString(" LIMIT ?")
+		args = append(args, limit)
+	}
+	if offset > 0 {
+		b.WriteString(" OFFSET ?")
+		args = append(args, offset)
+	}
+	return b.String(), args
+}
+
+// CountLimit is how far counts of list rows go before they stop being exact.
+const CountLimit = 10000
+
+// Count is the number of rows a list would have without its page limit.
+// Past CountLimit counting stops, and N is only a lower bound.
+type Count struct {
+	N     int64
+	Exact bool
+}
+
+func (c Count) String() string {
+	if !c.Exact {
+		return "more than " + strconv.FormatInt(c.N, 10)
+	}
+	return strconv.FormatInt(c.N, 10)
+}
+
+// count counts the rows of a list query, stopping after CountLimit so a
+// big table isn't scanned to the end on every page load.
+func (q *Queries) count(ctx context.Context, base string, args []any, conds []Condition) (Count, error) {
+	query, args := buildList(base, args, conds, nil, CountLimit+1, 0)
+	var n int64
+	if err := q.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+query+")", args...).Scan(&n); err != nil {
+		return Count{}, err
+	}
+	if n > CountLimit {
+		return Count{N: CountLimit}, nil
+	}
+	return Count{N: n, Exact: true}, nil
+}
+
+func (q *Queries) FilterWebhooks(ctx context.Context, userID int64, conds []Condition, order []Order, offset int64) ([]Webhook, error) {
+	query, args := buildList(
+		"SELECT id, user_id, url, secret, events, created_at, source FROM webhooks WHERE user_id = ?",
+		[]any{userID}, conds, order, 0, offset)
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.CreatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const filterWebhookDeliveries = `SELECT webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event_id, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.response_code, webhook_deliveries.error, webhook_deliveries.next_attempt_at, webhook_deliveries.created_at, events.type AS event_type FROM webhook_deliveries
+JOIN events ON webhook_deliveries.event_id = events.id
+WHERE webhook_deliveries.webhook_id = ?`
+
+func (q *Queries) FilterWebhookDeliveries(ctx context.Context, webhookID int64, conds []Condition, order []Order, limit, offset int64) ([]ListWebhookDeliveriesRow, error) {
+	query, args := buildList(filterWebhookDeliveries, []any{webhookID}, conds, order, limit, offset)
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWebhookDeliveriesRow
+	for rows.Next() {
+		var i ListWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.Error,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.EventType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+func (q *Queries) CountWebhookDeliveries(ctx context.Context, webhookID int64, conds []Condition) (Count, error) {
+	return q.count(ctx, filterWebhookDeliveries, []any{webhookID}, conds)
+}
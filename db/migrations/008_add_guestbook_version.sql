@@ -0,0 +1 @@
+ALTER TABLE guestbook ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
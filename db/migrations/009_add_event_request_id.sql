@@ -0,0 +1 @@
+ALTER TABLE events ADD COLUMN request_id TEXT;
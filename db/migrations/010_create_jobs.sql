@@ -0,0 +1,22 @@
+CREATE TABLE jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at DATETIME NOT NULL,
+    locked_until DATETIME,
+    error TEXT,
+    started_at DATETIME,
+    finished_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_jobs_due ON jobs (status, run_at);
+
+CREATE TABLE job_schedules (
+    name TEXT PRIMARY KEY,
+    spec TEXT NOT NULL,
+    next_run_at DATETIME NOT NULL
+);
@@ -0,0 +1,16 @@
+CREATE TABLE feature_flags (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT 0,
+    rollout_percent INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE feature_flag_overrides (
+    flag_name TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (flag_name, user_id),
+    FOREIGN KEY (flag_name) REFERENCES feature_flags(name) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
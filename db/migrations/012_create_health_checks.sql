@@ -0,0 +1,10 @@
+CREATE TABLE health_checks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    ok BOOLEAN NOT NULL,
+    error TEXT,
+    duration_ms INTEGER NOT NULL,
+    checked_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_health_checks_name ON health_checks (name, id);
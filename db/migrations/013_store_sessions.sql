@@ -0,0 +1,17 @@
+-- The original sessions table was never used. Sessions are now kept here
+-- by the session manager, with the token hashed.
+DROP TABLE sessions;
+
+CREATE TABLE sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    user_id INTEGER,
+    data BLOB NOT NULL,
+    expiry DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sessions_expiry ON sessions (expiry);
+CREATE INDEX idx_sessions_user ON sessions (user_id);
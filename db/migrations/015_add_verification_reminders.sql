@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN verification_reminded_at DATETIME;
@@ -0,0 +1,7 @@
+ALTER TABLE uploads ADD COLUMN sha256 TEXT;
+ALTER TABLE uploads ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+ALTER TABLE uploads ADD COLUMN status_reason TEXT;
+ALTER TABLE uploads ADD COLUMN reviewed_at DATETIME;
+
+CREATE INDEX idx_uploads_sha256 ON uploads (sha256);
+CREATE INDEX idx_uploads_status ON uploads (status);
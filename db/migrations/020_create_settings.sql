@@ -0,0 +1,7 @@
+-- Values admins change at runtime from /admin/settings. Keys without a
+-- row use the default from the environment.
+CREATE TABLE settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
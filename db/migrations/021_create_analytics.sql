@@ -0,0 +1,29 @@
+-- First-party analytics. Raw events are rolled up into daily counts, and
+-- kept for a day or two. Visitors are a hash that changes every day, and
+-- no cookies or full IP addresses are stored.
+CREATE TABLE analytics_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    path TEXT NOT NULL DEFAULT '',
+    referrer TEXT NOT NULL DEFAULT '',
+    visitor TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_analytics_events_created_at ON analytics_events(created_at);
+
+-- Page views and unique visitors per day.
+CREATE TABLE analytics_days (
+    day TEXT PRIMARY KEY,
+    views INTEGER NOT NULL,
+    visitors INTEGER NOT NULL
+);
+
+-- How many times each event happened per day, by page and referrer.
+CREATE TABLE analytics_counts (
+    day TEXT NOT NULL,
+    name TEXT NOT NULL,
+    path TEXT NOT NULL,
+    referrer TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (day, name, path, referrer)
+);
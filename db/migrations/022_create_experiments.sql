@@ -0,0 +1,15 @@
+-- Experiment exposures are analytics events naming the experiment and
+-- the variant shown.
+ALTER TABLE analytics_events ADD COLUMN experiment TEXT NOT NULL DEFAULT '';
+ALTER TABLE analytics_events ADD COLUMN variant TEXT NOT NULL DEFAULT '';
+
+-- Visitors shown each variant per day, and how many of them reached the
+-- experiment's goal that day.
+CREATE TABLE analytics_experiments (
+    day TEXT NOT NULL,
+    experiment TEXT NOT NULL,
+    variant TEXT NOT NULL,
+    visitors INTEGER NOT NULL,
+    conversions INTEGER NOT NULL,
+    PRIMARY KEY (day, experiment, variant)
+);
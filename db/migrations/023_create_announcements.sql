@@ -0,0 +1,22 @@
+-- Banners shown across the site between starts_at and ends_at.
+CREATE TABLE announcements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message TEXT NOT NULL,
+    level TEXT NOT NULL DEFAULT 'info',
+    audience TEXT NOT NULL DEFAULT 'everyone',
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME,
+    dismissible BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Announcements logged-in users closed. Visitors' dismissals stay in
+-- their browser.
+CREATE TABLE announcement_dismissals (
+    announcement_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (announcement_id, user_id),
+    FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,51 @@
+-- Bans on accounts, as opposed to the IP bans in bans. A hard ban stops
+-- the user logging in; a shadow ban lets them go on posting, but only they
+-- see what they post. expires_at is NULL for permanent bans.
+CREATE TABLE user_bans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    expires_at DATETIME,
+    lifted_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_bans_user ON user_bans (user_id);
+
+-- Appeals of hard bans, from the page shown when logging in is refused.
+-- accepted is NULL until an admin decides.
+CREATE TABLE ban_appeals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ban_id INTEGER NOT NULL,
+    message TEXT NOT NULL,
+    accepted BOOLEAN,
+    decided_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (ban_id) REFERENCES user_bans(id) ON DELETE CASCADE
+);
+
+-- The addresses and devices each account logged in from, to find other
+-- accounts run by the same person. device is a random ID kept in a
+-- long-lived cookie.
+CREATE TABLE user_sightings (
+    user_id INTEGER NOT NULL,
+    ip TEXT NOT NULL,
+    device TEXT NOT NULL,
+    first_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, ip, device),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_sightings_ip ON user_sightings (ip);
+CREATE INDEX idx_user_sightings_device ON user_sightings (device);
+
+-- Guestbook messages from shadow-banned users, shown only to them.
+CREATE TABLE shadow_messages (
+    user_id INTEGER PRIMARY KEY,
+    message TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,26 @@
+-- Each user's referral code, made the first time they open the referrals
+-- page.
+CREATE TABLE referral_codes (
+    user_id INTEGER PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Signups through a referral link. status is 'pending' until the new
+-- account is verified, then 'qualified'; 'rejected' ones came from an
+-- address or device the referrer used, and reason says which.
+-- reward_bytes is the extra storage the referral earned the referrer.
+CREATE TABLE referrals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    referrer_id INTEGER NOT NULL,
+    referred_id INTEGER NOT NULL UNIQUE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    reason TEXT NOT NULL DEFAULT '',
+    reward_bytes INTEGER NOT NULL DEFAULT 0,
+    qualified_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (referrer_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (referred_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_referrals_referrer ON referrals (referrer_id);
@@ -0,0 +1,3 @@
+-- The locale dates and numbers are shown in, like "de" or "pt-BR". Empty
+-- means the browser's Accept-Language decides.
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Unsent text of long forms, autosaved as it's written so a closed tab
+-- doesn't lose it. form names the form, like 'guestbook' or 'appeal'.
+CREATE TABLE drafts (
+    user_id INTEGER NOT NULL,
+    form TEXT NOT NULL,
+    body TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, form),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+-- Wording admins gave transactional emails, by template name. Templates
+-- without a row use their built-in wording.
+CREATE TABLE email_templates (
+    name TEXT PRIMARY KEY,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    updated_at DATETIME NOT NULL
+);
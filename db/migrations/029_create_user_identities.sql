@@ -0,0 +1,11 @@
+-- Accounts at social login providers, by the provider's stable ID for the
+-- person (the ID token's sub). Logins find the user by it before trying
+-- the email address, which can change at the provider.
+CREATE TABLE user_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
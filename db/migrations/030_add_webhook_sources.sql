@@ -0,0 +1,4 @@
+-- Where a webhook was created: "dashboard", "api", or "rest_hook" for
+-- subscriptions made through POST /hooks, which are the only ones an
+-- integration may unsubscribe through DELETE /hooks/{id}.
+ALTER TABLE webhooks ADD COLUMN source TEXT NOT NULL DEFAULT 'dashboard';
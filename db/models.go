@@ -9,9 +9,197 @@ import (
 	"time"
 )
 
+type AnalyticsCount struct {
+	Day      string
+	Name     string
+	Path     string
+	Referrer string
+	Count    int64
+}
+
+type AnalyticsDay struct {
+	Day      string
+	Views    int64
+	Visitors int64
+}
+
+type AnalyticsEvent struct {
+	ID         int64
+	Name       string
+	Path       string
+	Referrer   string
+	Visitor    string
+	CreatedAt  time.Time
+	Experiment string
+	Variant    string
+}
+
+type AnalyticsExperiment struct {
+	Day         string
+	Experiment  string
+	Variant     string
+	Visitors    int64
+	Conversions int64
+}
+
+type Announcement struct {
+	ID          int64
+	Message     string
+	Level       string
+	Audience    string
+	StartsAt    time.Time
+	EndsAt      sql.NullTime
+	Dismissible bool
+	CreatedAt   sql.NullTime
+}
+
+type AnnouncementDismissal struct {
+	AnnouncementID int64
+	UserID         int64
+	CreatedAt      sql.NullTime
+}
+
+type ApiToken struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	TokenHash  string
+	Scope      string
+	CreatedAt  sql.NullTime
+	LastUsedAt sql.NullTime
+}
+
+type AuditLog struct {
+	ID        int64
+	Actor     string
+	Action    string
+	Target    string
+	Detail    string
+	Ip        string
+	CreatedAt sql.NullTime
+}
+
+type BanAppeal struct {
+	ID        int64
+	BanID     int64
+	Message   string
+	Accepted  sql.NullBool
+	DecidedAt sql.NullTime
+	CreatedAt sql.NullTime
+}
+
+type Ban struct {
+	ID        int64
+	Ip        string
+	Reason    string
+	ExpiresAt time.Time
+	CreatedAt sql.NullTime
+}
+
+type Draft struct {
+	UserID    int64
+	Form      string
+	Body      string
+	UpdatedAt time.Time
+}
+
+type EmailTemplate struct {
+	Name      string
+	Subject   string
+	Body      string
+	UpdatedAt time.Time
+}
+
+type Event struct {
+	ID        int64
+	UserID    sql.NullInt64
+	Type      string
+	Payload   string
+	CreatedAt sql.NullTime
+	RequestID sql.NullString
+}
+
 type Guestbook struct {
 	ID      int64
 	Message string
+	Version int64
+}
+
+type FeatureFlag struct {
+	Name           string
+	Description    string
+	Enabled        bool
+	RolloutPercent int64
+	UpdatedAt      sql.NullTime
+}
+
+type FeatureFlagOverride struct {
+	FlagName string
+	UserID   int64
+	Enabled  bool
+}
+
+type HealthCheck struct {
+	ID         int64
+	Name       string
+	Ok         bool
+	Error      sql.NullString
+	DurationMs int64
+	CheckedAt  time.Time
+}
+
+type IdempotencyKey struct {
+	UserID       int64
+	Key          string
+	Method       string
+	Path         string
+	RequestHash  string
+	StatusCode   sql.NullInt64
+	ResponseBody []byte
+	CreatedAt    sql.NullTime
+}
+
+type InboundEvent struct {
+	ID             int64
+	Source         string
+	IdempotencyKey string
+	HookID         sql.NullInt64
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int64
+	Error          sql.NullString
+	CreatedAt      sql.NullTime
+	ProcessedAt    sql.NullTime
+}
+
+type InboundHook struct {
+	ID        int64
+	UserID    int64
+	Token     string
+	Secret    string
+	CreatedAt sql.NullTime
+}
+
+type Job struct {
+	ID          int64
+	Name        string
+	Payload     string
+	Status      string
+	Attempts    int64
+	MaxAttempts int64
+	RunAt       time.Time
+	LockedUntil sql.NullTime
+	Error       sql.NullString
+	StartedAt   sql.NullTime
+	FinishedAt  sql.NullTime
+	CreatedAt   sql.NullTime
+}
+
+type JobSchedule struct {
+	Name      string
+	Spec      string
+	NextRunAt time.Time
 }
 
 type PasswordResetToken struct {
@@ -20,17 +208,122 @@ type PasswordResetToken struct {
 	Expiry    time.Time
 }
 
+type RateLimit struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+type Referral struct {
+	ID          int64
+	ReferrerID  int64
+	ReferredID  int64
+	Status      string
+	Reason      string
+	RewardBytes int64
+	QualifiedAt sql.NullTime
+	CreatedAt   sql.NullTime
+}
+
+type ReferralCode struct {
+	UserID int64
+	Code   string
+}
+
 type Session struct {
+	ID        int64
 	TokenHash string
-	UserID    int64
+	UserID    sql.NullInt64
+	Data      []byte
 	Expiry    time.Time
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+}
+
+type Setting struct {
+	Key       string
+	Value     string
+	UpdatedAt sql.NullTime
+}
+
+type ShadowMessage struct {
+	UserID    int64
+	Message   string
+	UpdatedAt sql.NullTime
+}
+
+type Upload struct {
+	ID           string
+	UserID       int64
+	Filename     string
+	Size         int64
+	Received     int64
+	ContentType  sql.NullString
+	StorageKey   sql.NullString
+	ExpiresAt    time.Time
+	CompletedAt  sql.NullTime
+	CreatedAt    sql.NullTime
+	Sha256       sql.NullString
+	Status       string
+	StatusReason sql.NullString
+	ReviewedAt   sql.NullTime
 }
 
 type User struct {
-	ID                int64
-	Email             string
-	PasswordHash      string
-	CreatedAt         sql.NullTime
-	VerificationToken sql.NullString
-	VerifiedAt        sql.NullTime
+	ID                     int64
+	Email                  string
+	PasswordHash           string
+	CreatedAt              sql.NullTime
+	VerificationToken      sql.NullString
+	VerifiedAt             sql.NullTime
+	VerificationRemindedAt sql.NullTime
+	Avatar                 sql.NullString
+	Locale                 string
+}
+
+type UserBan struct {
+	ID        int64
+	UserID    int64
+	Kind      string
+	Reason    string
+	ExpiresAt sql.NullTime
+	LiftedAt  sql.NullTime
+	CreatedAt sql.NullTime
+}
+
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserID    int64
+	CreatedAt time.Time
+}
+
+type UserSighting struct {
+	UserID      int64
+	Ip          string
+	Device      string
+	FirstSeenAt sql.NullTime
+	LastSeenAt  sql.NullTime
+}
+
+type Webhook struct {
+	ID        int64
+	UserID    int64
+	Url       string
+	Secret    string
+	Events    string
+	CreatedAt sql.NullTime
+	Source    string
+}
+
+type WebhookDelivery struct {
+	ID            int64
+	WebhookID     int64
+	EventID       int64
+	Status        string
+	Attempts      int64
+	ResponseCode  sql.NullInt64
+	Error         sql.NullString
+	NextAttemptAt time.Time
+	CreatedAt     sql.NullTime
 }
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+// Compares looking up a user by email through statements prepared once, as
+// Setup returns them, with preparing the query on every call:
+//
+//	go test ./db -run '^$' -bench GetUserByEmail
+func BenchmarkGetUserByEmail(b *testing.B) {
+	ctx := context.Background()
+	conn, prepared, err := Setup(b.TempDir(), "bench.db")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := prepared.CreateUser(ctx, CreateUserParams{Email: "bench@example.com", PasswordHash: "x"}); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bb := range []struct {
+		name    string
+		queries *Queries
+	}{
+		{"Prepared", prepared},
+		{"Unprepared", New(conn)},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := bb.queries.GetUserByEmail(ctx, "bench@example.com"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
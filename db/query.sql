@@ -4,7 +4,16 @@ SELECT message FROM guestbook WHERE id = 1 LIMIT 1;
 -- name: UpsertMessage :exec
 INSERT INTO guestbook (id, message) 
 VALUES (1, ?)
-ON CONFLICT(id) DO UPDATE SET message = excluded.message;
+ON CONFLICT(id) DO UPDATE SET message = excluded.message, version = guestbook.version + 1;
+
+-- name: GetGuestbook :one
+SELECT * FROM guestbook WHERE id = 1 LIMIT 1;
+
+-- name: UpdateMessageIfVersion :execrows
+INSERT INTO guestbook (id, message)
+VALUES (1, ?)
+ON CONFLICT(id) DO UPDATE SET message = excluded.message, version = guestbook.version + 1
+WHERE guestbook.version = ?;
 
 -- name: CreateUser :one
 INSERT INTO users (email, password_hash, verification_token)
@@ -17,20 +26,646 @@ SELECT * FROM users WHERE email = ?;
 -- name: GetUser :one
 SELECT * FROM users WHERE id = ?;
 
--- name: CreateSession :exec
-INSERT INTO sessions (token_hash, user_id, expiry)
-VALUES (?, ?, ?);
-
 -- name: GetSession :one
-SELECT sessions.*, users.email FROM sessions
-JOIN users ON sessions.user_id = users.id
-WHERE token_hash = ? AND expiry > CURRENT_TIMESTAMP LIMIT 1;
+SELECT data FROM sessions WHERE token_hash = ? AND expiry > ?;
+
+-- name: UpsertSession :exec
+INSERT INTO sessions (token_hash, user_id, data, expiry)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(token_hash) DO UPDATE SET
+    user_id = excluded.user_id, data = excluded.data, expiry = excluded.expiry, updated_at = CURRENT_TIMESTAMP;
 
 -- name: DeleteSession :exec
 DELETE FROM sessions WHERE token_hash = ?;
 
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expiry <= ?;
+
+-- name: ListUserSessions :many
+SELECT id, token_hash, created_at, updated_at, expiry FROM sessions
+WHERE user_id = ? AND expiry > ?
+ORDER BY updated_at DESC;
+
+-- name: DeleteUserSession :exec
+DELETE FROM sessions WHERE id = ? AND user_id = ?;
+
+-- name: DeleteOtherUserSessions :exec
+DELETE FROM sessions WHERE user_id = ? AND token_hash != ?;
+
+-- name: ListUnverifiedUsersToRemind :many
+SELECT id, email, verification_token FROM users
+WHERE verified_at IS NULL AND verification_reminded_at IS NULL AND created_at <= ?;
+
+-- name: MarkVerificationReminded :exec
+UPDATE users SET verification_reminded_at = ? WHERE id = ?;
+
+-- name: ListUnverifiedUsersBefore :many
+SELECT id, avatar FROM users WHERE verified_at IS NULL AND created_at <= ?;
+
+-- name: DeleteUnverifiedUser :execrows
+DELETE FROM users WHERE id = ? AND verified_at IS NULL;
+
+-- name: SetUserAvatar :exec
+UPDATE users SET avatar = ? WHERE id = ?;
+
+-- name: SetUserLocale :exec
+UPDATE users SET locale = ? WHERE id = ?;
+
 -- name: VerifyUser :one
 UPDATE users 
 SET verified_at = CURRENT_TIMESTAMP, verification_token = NULL
 WHERE verification_token = ? AND verified_at IS NULL
-RETURNING id;
\ No newline at end of file
+RETURNING id;
+
+-- name: ListUsers :many
+SELECT * FROM users ORDER BY id;
+
+-- name: MarkUserVerified :execrows
+UPDATE users
+SET verified_at = CURRENT_TIMESTAMP, verification_token = NULL
+WHERE id = ? AND verified_at IS NULL;
+
+-- name: SetUserPassword :exec
+UPDATE users SET password_hash = ? WHERE id = ?;
+
+-- name: DeleteUserSessions :exec
+DELETE FROM sessions WHERE user_id = ?;
+
+-- name: CountUsers :one
+SELECT COUNT(*) AS total, COUNT(verified_at) AS verified FROM users;
+
+-- name: CountSignupsByDay :many
+SELECT CAST(date(created_at) AS TEXT) AS day, COUNT(*) AS signups FROM users
+WHERE created_at >= ? AND created_at < ?
+GROUP BY day
+ORDER BY day;
+
+-- name: CountActiveUsers :one
+SELECT COUNT(DISTINCT user_id) FROM sessions WHERE expiry > ?;
+
+-- name: CreateEvent :one
+INSERT INTO events (user_id, type, payload, request_id)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, events, source)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListWebhooksByUser :many
+SELECT * FROM webhooks WHERE user_id = ? ORDER BY id;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks ORDER BY id;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks WHERE id = ? AND user_id = ?;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = ? AND user_id = ?;
+
+-- name: DeleteRestHook :execrows
+DELETE FROM webhooks WHERE id = ? AND user_id = ? AND source = 'rest_hook';
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, event_id, next_attempt_at)
+VALUES (?, ?, ?);
+
+-- name: ListDueWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.attempts, webhooks.id AS webhook_id, webhooks.user_id, webhooks.url, webhooks.secret, events.id AS event_id, events.type, events.payload, events.created_at, events.request_id
+FROM webhook_deliveries
+JOIN webhooks ON webhook_deliveries.webhook_id = webhooks.id
+JOIN events ON webhook_deliveries.event_id = events.id
+WHERE webhook_deliveries.status = 'pending' AND webhook_deliveries.next_attempt_at <= ?
+ORDER BY webhook_deliveries.next_attempt_at
+LIMIT ?;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = ?, response_code = ?, error = ?, next_attempt_at = ?
+WHERE id = ?;
+
+-- name: ListWebhookDeliveries :many
+SELECT webhook_deliveries.*, events.type AS event_type FROM webhook_deliveries
+JOIN events ON webhook_deliveries.event_id = events.id
+WHERE webhook_deliveries.webhook_id = ?
+ORDER BY webhook_deliveries.id DESC
+LIMIT 50;
+
+-- name: CreateInboundHook :one
+INSERT INTO inbound_hooks (user_id, token, secret)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetInboundHookByToken :one
+SELECT * FROM inbound_hooks WHERE token = ?;
+
+-- name: ListInboundHooksByUser :many
+SELECT * FROM inbound_hooks WHERE user_id = ? ORDER BY id;
+
+-- name: DeleteInboundHook :exec
+DELETE FROM inbound_hooks WHERE id = ? AND user_id = ?;
+
+-- name: CreateInboundEvent :one
+INSERT INTO inbound_events (source, idempotency_key, hook_id, event_type, payload)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (source, idempotency_key) DO NOTHING
+RETURNING id;
+
+-- name: ListPendingInboundEvents :many
+SELECT inbound_events.*, inbound_hooks.user_id FROM inbound_events
+LEFT JOIN inbound_hooks ON inbound_events.hook_id = inbound_hooks.id
+WHERE inbound_events.status = 'pending'
+ORDER BY inbound_events.id
+LIMIT ?;
+
+-- name: UpdateInboundEvent :exec
+UPDATE inbound_events
+SET status = ?, attempts = ?, error = ?, processed_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (user_id, name, token_hash, scope)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetAPITokenByHash :one
+SELECT * FROM api_tokens WHERE token_hash = ?;
+
+-- name: ListAPITokensByUser :many
+SELECT * FROM api_tokens WHERE user_id = ? ORDER BY id;
+
+-- name: DeleteAPIToken :exec
+DELETE FROM api_tokens WHERE id = ? AND user_id = ?;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: ListRateLimits :many
+SELECT * FROM rate_limits;
+
+-- name: UpsertRateLimit :exec
+INSERT INTO rate_limits (key, tokens, updated_at)
+VALUES (?, ?, ?)
+ON CONFLICT(key) DO UPDATE SET tokens = excluded.tokens, updated_at = excluded.updated_at;
+
+-- name: DeleteRateLimitsBefore :exec
+DELETE FROM rate_limits WHERE updated_at < ?;
+
+-- name: CreateIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, key, method, path, request_hash)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (user_id, key) DO NOTHING;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys WHERE user_id = ? AND key = ?;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys SET status_code = ?, response_body = ?
+WHERE user_id = ? AND key = ?;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE user_id = ? AND key = ?;
+
+-- name: DeleteIdempotencyKeysBefore :exec
+DELETE FROM idempotency_keys WHERE created_at < ?;
+
+-- name: ListEventsForUserSince :many
+SELECT * FROM events
+WHERE id > ? AND (user_id = ? OR user_id IS NULL)
+ORDER BY id
+LIMIT 100;
+
+-- name: ListEventsOfTypeSince :many
+SELECT * FROM events
+WHERE type = ? AND id > ?
+ORDER BY id
+LIMIT 100;
+
+-- name: ListRecentEventsForUser :many
+SELECT * FROM events
+WHERE type = ? AND (user_id = ? OR user_id IS NULL)
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: LatestEventID :one
+SELECT CAST(COALESCE(MAX(id), 0) AS INTEGER) FROM events;
+
+-- name: CreateJob :exec
+INSERT INTO jobs (name, payload, max_attempts, run_at)
+VALUES (?, ?, ?, ?);
+
+-- name: ClaimJob :one
+UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_until = ?, started_at = ?
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE (status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ?)
+    ORDER BY run_at
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: FinishJob :exec
+UPDATE jobs SET status = ?, error = ?, run_at = ?, locked_until = NULL, finished_at = ?
+WHERE id = ?;
+
+-- name: RetryJob :execrows
+UPDATE jobs SET status = 'pending', attempts = 0, error = NULL, run_at = ?
+WHERE id = ? AND status = 'failed';
+
+-- name: ListRecentJobs :many
+SELECT * FROM jobs ORDER BY id DESC LIMIT ?;
+
+-- name: ListFailedJobs :many
+SELECT * FROM jobs WHERE status = 'failed' ORDER BY finished_at DESC LIMIT ?;
+
+-- name: ListPendingJobs :many
+SELECT * FROM jobs WHERE status IN ('pending', 'running') ORDER BY run_at LIMIT ?;
+
+-- name: ListPendingJobsByName :many
+SELECT * FROM jobs WHERE name = ? AND status IN ('pending', 'running') ORDER BY run_at LIMIT ?;
+
+-- name: ListFailedJobsByName :many
+SELECT * FROM jobs WHERE name = ? AND status = 'failed' ORDER BY finished_at DESC LIMIT ?;
+
+-- name: ListRecentJobsByName :many
+SELECT * FROM jobs WHERE name = ? ORDER BY id DESC LIMIT ?;
+
+-- name: CountPendingJobsByName :one
+SELECT COUNT(*) FROM jobs WHERE name = ? AND status IN ('pending', 'running');
+
+-- name: CancelJob :execrows
+UPDATE jobs SET status = 'cancelled', finished_at = ?
+WHERE id = ? AND status IN ('pending', 'failed');
+
+-- name: ListJobOutcomes :many
+SELECT name, COUNT(*) AS finished, CAST(SUM(error IS NOT NULL) AS INTEGER) AS failing FROM jobs
+WHERE finished_at >= ? AND status != 'cancelled'
+GROUP BY name
+ORDER BY name;
+
+-- name: CountJobsByDay :many
+SELECT CAST(date(finished_at) AS TEXT) AS day, COUNT(*) AS runs, CAST(SUM(status = 'failed') AS INTEGER) AS failures FROM jobs
+WHERE finished_at >= ? AND finished_at < ? AND status != 'cancelled'
+GROUP BY day
+ORDER BY day;
+
+-- name: DeleteFinishedJobsBefore :exec
+DELETE FROM jobs WHERE status IN ('succeeded', 'failed', 'cancelled') AND finished_at < ?;
+
+-- name: UpsertJobSchedule :exec
+INSERT INTO job_schedules (name, spec, next_run_at)
+VALUES (?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET spec = excluded.spec, next_run_at = excluded.next_run_at
+WHERE job_schedules.spec != excluded.spec;
+
+-- name: AdvanceJobSchedule :execrows
+UPDATE job_schedules SET next_run_at = ?
+WHERE name = ? AND next_run_at <= ?;
+
+-- name: ListJobSchedules :many
+SELECT * FROM job_schedules ORDER BY name;
+
+-- name: ListFeatureFlags :many
+SELECT * FROM feature_flags ORDER BY name;
+
+-- name: CreateFeatureFlag :execrows
+INSERT INTO feature_flags (name, description) VALUES (?, ?)
+ON CONFLICT(name) DO NOTHING;
+
+-- name: UpdateFeatureFlag :execrows
+UPDATE feature_flags SET enabled = ?, rollout_percent = ?, updated_at = CURRENT_TIMESTAMP
+WHERE name = ?;
+
+-- name: DeleteFeatureFlag :exec
+DELETE FROM feature_flags WHERE name = ?;
+
+-- name: ListFeatureFlagOverrides :many
+SELECT feature_flag_overrides.*, users.email FROM feature_flag_overrides
+JOIN users ON feature_flag_overrides.user_id = users.id
+ORDER BY feature_flag_overrides.flag_name, users.email;
+
+-- name: ListFeatureFlagOverridesForUser :many
+SELECT * FROM feature_flag_overrides WHERE user_id = ?;
+
+-- name: UpsertFeatureFlagOverride :exec
+INSERT INTO feature_flag_overrides (flag_name, user_id, enabled)
+VALUES (?, ?, ?)
+ON CONFLICT(flag_name, user_id) DO UPDATE SET enabled = excluded.enabled;
+
+-- name: DeleteFeatureFlagOverride :exec
+DELETE FROM feature_flag_overrides WHERE flag_name = ? AND user_id = ?;
+
+-- name: CreateHealthCheck :exec
+INSERT INTO health_checks (name, ok, error, duration_ms, checked_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: ListHealthCheckHistory :many
+SELECT * FROM health_checks WHERE name = ? ORDER BY id DESC LIMIT ?;
+
+-- name: ListLatestHealthChecks :many
+SELECT * FROM health_checks
+WHERE id IN (SELECT MAX(id) FROM health_checks GROUP BY name)
+ORDER BY name;
+
+-- name: ListHealthCheckUptime :many
+SELECT name, CAST(SUM(ok) AS INTEGER) AS passed, COUNT(*) AS total FROM health_checks
+WHERE checked_at >= ?
+GROUP BY name
+ORDER BY name;
+
+-- name: DeleteHealthChecksBefore :exec
+DELETE FROM health_checks WHERE checked_at < ?;
+
+-- name: CreateBan :exec
+INSERT INTO bans (ip, reason, expires_at) VALUES (?, ?, ?);
+
+-- name: ListActiveBans :many
+SELECT * FROM bans WHERE expires_at > ? ORDER BY id DESC;
+
+-- name: DeleteBan :exec
+DELETE FROM bans WHERE id = ?;
+
+-- name: DeleteExpiredBans :exec
+DELETE FROM bans WHERE expires_at <= ?;
+
+-- name: CreateUpload :one
+INSERT INTO uploads (id, user_id, filename, size, expires_at)
+SELECT @id, @user_id, @filename, @size, @expires_at
+WHERE (SELECT COALESCE(SUM(size), 0) FROM uploads WHERE user_id = @user_id AND status != 'rejected') + @size <= @quota
+RETURNING *;
+
+-- name: GetUpload :one
+SELECT * FROM uploads WHERE id = ? AND user_id = ?;
+
+-- name: AdvanceUpload :execrows
+UPDATE uploads SET received = ? WHERE id = ? AND received = ?;
+
+-- name: CompleteUpload :exec
+UPDATE uploads SET content_type = ?, storage_key = ?, sha256 = ?, completed_at = ? WHERE id = ?;
+
+-- name: DeleteUpload :exec
+DELETE FROM uploads WHERE id = ? AND user_id = ?;
+
+-- name: ListExpiredUploads :many
+SELECT id FROM uploads WHERE completed_at IS NULL AND expires_at <= ?;
+
+-- name: DeleteExpiredUploads :exec
+DELETE FROM uploads WHERE completed_at IS NULL AND expires_at <= ?;
+
+-- name: GetUploadByID :one
+SELECT * FROM uploads WHERE id = ?;
+
+-- name: SetUploadStatus :exec
+UPDATE uploads SET status = ?, status_reason = ?, reviewed_at = ? WHERE id = ?;
+
+-- name: ListUploadsBySHA256 :many
+SELECT id, user_id, status FROM uploads WHERE sha256 = ? AND id != ?;
+
+-- name: ListUploadStorageKeys :many
+SELECT storage_key FROM uploads WHERE user_id = ? AND storage_key IS NOT NULL;
+
+-- name: GetStorageUsage :one
+SELECT CAST(COALESCE(SUM(size), 0) AS INTEGER) AS used FROM uploads
+WHERE user_id = ? AND status != 'rejected';
+
+-- name: ListLargestUploads :many
+SELECT id, filename, size, status, completed_at FROM uploads
+WHERE user_id = ? AND status != 'rejected'
+ORDER BY size DESC
+LIMIT ?;
+
+-- name: ListQuarantinedUploads :many
+SELECT uploads.id, uploads.user_id, uploads.filename, uploads.size, uploads.content_type, uploads.status_reason, uploads.completed_at, users.email FROM uploads
+JOIN users ON uploads.user_id = users.id
+WHERE uploads.status = 'quarantined'
+ORDER BY uploads.completed_at;
+
+-- name: CreateAuditEntry :exec
+INSERT INTO audit_log (actor, action, target, detail, ip) VALUES (?, ?, ?, ?, ?);
+
+-- name: ListAuditLog :many
+SELECT * FROM audit_log ORDER BY id DESC LIMIT ?;
+
+-- name: ListSettings :many
+SELECT * FROM settings;
+
+-- name: UpsertSetting :exec
+INSERT INTO settings (key, value) VALUES (?, ?)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteSettings :exec
+DELETE FROM settings;
+
+-- name: CreateAnalyticsEvent :exec
+INSERT INTO analytics_events (name, path, referrer, visitor, experiment, variant) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: RollUpAnalyticsDays :exec
+INSERT INTO analytics_days (day, views, visitors)
+SELECT CAST(date(created_at) AS TEXT) AS day, COUNT(*), COUNT(DISTINCT visitor) FROM analytics_events
+WHERE name = 'pageview'
+GROUP BY day
+ON CONFLICT(day) DO UPDATE SET views = excluded.views, visitors = excluded.visitors;
+
+-- name: RollUpAnalyticsCounts :exec
+INSERT INTO analytics_counts (day, name, path, referrer, count)
+SELECT CAST(date(created_at) AS TEXT) AS day, name, path, referrer, COUNT(*) FROM analytics_events
+WHERE true
+GROUP BY day, name, path, referrer
+ON CONFLICT(day, name, path, referrer) DO UPDATE SET count = excluded.count;
+
+-- name: DeleteAnalyticsEventsBefore :exec
+DELETE FROM analytics_events WHERE created_at < ?;
+
+-- name: ListAnalyticsDays :many
+SELECT * FROM analytics_days WHERE day >= ? AND day <= ? ORDER BY day;
+
+-- name: ListTopPages :many
+SELECT path, CAST(SUM(count) AS INTEGER) AS views FROM analytics_counts
+WHERE name = 'pageview' AND day >= ? AND day <= ?
+GROUP BY path
+ORDER BY views DESC, path
+LIMIT ?;
+
+-- name: ListTopReferrers :many
+SELECT referrer, CAST(SUM(count) AS INTEGER) AS views FROM analytics_counts
+WHERE name = 'pageview' AND referrer != '' AND day >= ? AND day <= ?
+GROUP BY referrer
+ORDER BY views DESC, referrer
+LIMIT ?;
+
+-- name: CountAnalyticsEvents :one
+SELECT CAST(COALESCE(SUM(count), 0) AS INTEGER) FROM analytics_counts
+WHERE name = ? AND path = ? AND day >= ? AND day <= ?;
+
+-- name: RollUpExperiment :exec
+INSERT INTO analytics_experiments (day, experiment, variant, visitors, conversions)
+SELECT CAST(date(e.created_at) AS TEXT) AS day, e.experiment, e.variant, COUNT(DISTINCT e.visitor), COUNT(DISTINCT g.visitor) FROM analytics_events e
+LEFT JOIN analytics_events g ON g.visitor = e.visitor AND g.name = ? AND date(g.created_at) = date(e.created_at) AND g.created_at >= e.created_at
+WHERE e.name = 'exposure' AND e.experiment = ?
+GROUP BY day, e.experiment, e.variant
+ON CONFLICT(day, experiment, variant) DO UPDATE SET visitors = excluded.visitors, conversions = excluded.conversions;
+
+-- name: ListExperimentResults :many
+SELECT variant, CAST(SUM(visitors) AS INTEGER) AS visitors, CAST(SUM(conversions) AS INTEGER) AS conversions FROM analytics_experiments
+WHERE experiment = ? AND day >= ? AND day <= ?
+GROUP BY variant;
+
+-- name: CreateAnnouncement :exec
+INSERT INTO announcements (message, level, audience, starts_at, ends_at, dismissible) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListAnnouncements :many
+SELECT announcements.*, (SELECT COUNT(*) FROM announcement_dismissals WHERE announcement_id = announcements.id) AS dismissals FROM announcements
+ORDER BY starts_at DESC, id DESC;
+
+-- name: ListUnendedAnnouncements :many
+SELECT * FROM announcements
+WHERE ends_at IS NULL OR ends_at > ?
+ORDER BY starts_at, id;
+
+-- name: DeleteAnnouncement :exec
+DELETE FROM announcements WHERE id = ?;
+
+-- name: DismissAnnouncement :exec
+INSERT OR IGNORE INTO announcement_dismissals (announcement_id, user_id) VALUES (?, ?);
+
+-- name: ListDismissedAnnouncements :many
+SELECT announcement_id FROM announcement_dismissals WHERE user_id = ?;
+
+-- name: CreateUserBan :one
+INSERT INTO user_bans (user_id, kind, reason, expires_at) VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListActiveUserBans :many
+SELECT * FROM user_bans
+WHERE lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?);
+
+-- name: ListUserBans :many
+SELECT user_bans.*, users.email FROM user_bans
+JOIN users ON users.id = user_bans.user_id
+ORDER BY user_bans.id DESC
+LIMIT ?;
+
+-- name: LiftUserBan :execrows
+UPDATE user_bans SET lifted_at = ? WHERE id = ? AND lifted_at IS NULL;
+
+-- name: LiftExpiredUserBans :execrows
+UPDATE user_bans SET lifted_at = expires_at
+WHERE lifted_at IS NULL AND expires_at <= ?;
+
+-- name: CountActiveBansOnDevice :one
+SELECT COUNT(*) FROM user_sightings
+JOIN user_bans ON user_bans.user_id = user_sightings.user_id
+WHERE user_sightings.device = ? AND user_bans.kind = 'ban' AND user_bans.lifted_at IS NULL
+  AND (user_bans.expires_at IS NULL OR user_bans.expires_at > ?);
+
+-- name: CreateBanAppeal :exec
+INSERT INTO ban_appeals (ban_id, message) VALUES (?, ?);
+
+-- name: GetBanAppeal :one
+SELECT * FROM ban_appeals WHERE ban_id = ? ORDER BY id DESC LIMIT 1;
+
+-- name: ListPendingBanAppeals :many
+SELECT ban_appeals.id, ban_appeals.message, ban_appeals.created_at,
+    user_bans.id AS ban_id, user_bans.reason, user_bans.created_at AS banned_at, users.id AS user_id, users.email
+FROM ban_appeals
+JOIN user_bans ON user_bans.id = ban_appeals.ban_id
+JOIN users ON users.id = user_bans.user_id
+WHERE ban_appeals.accepted IS NULL
+ORDER BY ban_appeals.id;
+
+-- name: DecideBanAppeal :one
+UPDATE ban_appeals SET accepted = ?, decided_at = ?
+WHERE id = ? AND accepted IS NULL
+RETURNING ban_id;
+
+-- name: RecordUserSighting :exec
+INSERT INTO user_sightings (user_id, ip, device) VALUES (?, ?, ?)
+ON CONFLICT(user_id, ip, device) DO UPDATE SET last_seen_at = CURRENT_TIMESTAMP;
+
+-- name: ListAssociatedUsers :many
+SELECT users.id, users.email,
+    COUNT(DISTINCT CASE WHEN theirs.ip = mine.ip THEN theirs.ip END) AS shared_ips,
+    COUNT(DISTINCT CASE WHEN theirs.device = mine.device THEN theirs.device END) AS shared_devices
+FROM user_sightings mine
+JOIN user_sightings theirs ON (theirs.ip = mine.ip OR theirs.device = mine.device) AND theirs.user_id != mine.user_id
+JOIN users ON users.id = theirs.user_id
+WHERE mine.user_id = ?
+GROUP BY users.id
+ORDER BY users.id;
+
+-- name: UpsertShadowMessage :exec
+INSERT INTO shadow_messages (user_id, message) VALUES (?, ?)
+ON CONFLICT(user_id) DO UPDATE SET message = excluded.message, updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetShadowMessage :one
+SELECT message FROM shadow_messages WHERE user_id = ?;
+
+-- name: EnsureReferralCode :one
+INSERT INTO referral_codes (user_id, code) VALUES (?, ?)
+ON CONFLICT(user_id) DO UPDATE SET code = referral_codes.code
+RETURNING code;
+
+-- name: GetReferrerByCode :one
+SELECT user_id FROM referral_codes WHERE code = ?;
+
+-- name: CountReferrerSightings :one
+SELECT CAST(COALESCE(SUM(ip = ?), 0) AS INTEGER) AS shared_ips,
+    CAST(COALESCE(SUM(device = ?), 0) AS INTEGER) AS shared_devices
+FROM user_sightings WHERE user_id = ?;
+
+-- name: CreateReferral :exec
+INSERT INTO referrals (referrer_id, referred_id, status, reason) VALUES (?, ?, ?, ?);
+
+-- name: GetPendingReferral :one
+SELECT * FROM referrals WHERE referred_id = ? AND status = 'pending';
+
+-- name: CountRewardedReferrals :one
+SELECT COUNT(*) FROM referrals WHERE referrer_id = ? AND reward_bytes > 0;
+
+-- name: QualifyReferral :exec
+UPDATE referrals SET status = 'qualified', reward_bytes = ?, qualified_at = ?
+WHERE id = ? AND status = 'pending';
+
+-- name: GetReferralStorageBonus :one
+SELECT CAST(COALESCE(SUM(reward_bytes), 0) AS INTEGER) AS bonus FROM referrals WHERE referrer_id = ?;
+
+-- name: ListReferrals :many
+SELECT referrals.*, substr(users.email, 1, 1) || '•••@' || substr(users.email, instr(users.email, '@') + 1, 1) || '•••' AS masked_email FROM referrals
+JOIN users ON users.id = referrals.referred_id
+WHERE referrals.referrer_id = ?
+ORDER BY referrals.id DESC;
+
+-- name: SaveDraft :exec
+INSERT INTO drafts (user_id, form, body, updated_at) VALUES (?, ?, ?, ?)
+ON CONFLICT(user_id, form) DO UPDATE SET body = excluded.body, updated_at = excluded.updated_at;
+
+-- name: GetDraft :one
+SELECT body FROM drafts WHERE user_id = ? AND form = ?;
+
+-- name: DeleteDraft :exec
+DELETE FROM drafts WHERE user_id = ? AND form = ?;
+
+-- name: DeleteDraftsBefore :exec
+DELETE FROM drafts WHERE updated_at < ?;
+
+-- name: ListEmailTemplates :many
+SELECT * FROM email_templates ORDER BY name;
+
+-- name: GetEmailTemplate :one
+SELECT * FROM email_templates WHERE name = ?;
+
+-- name: UpsertEmailTemplate :exec
+INSERT INTO email_templates (name, subject, body, updated_at) VALUES (?, ?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET subject = excluded.subject, body = excluded.body, updated_at = excluded.updated_at;
+
+-- name: DeleteEmailTemplate :exec
+DELETE FROM email_templates WHERE name = ?;
+
+-- name: GetUserIdentity :one
+SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (provider, subject, user_id) VALUES (?, ?, ?)
+ON CONFLICT(provider, subject) DO NOTHING;
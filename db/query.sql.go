@@ -11,117 +11,738 @@ import (
 	"time"
 )
 
-const createSession = `-- name: CreateSession :exec
-INSERT INTO sessions (token_hash, user_id, expiry)
-VALUES (?, ?, ?)
+const advanceJobSchedule = `-- name: AdvanceJobSchedule :execrows
+UPDATE job_schedules SET next_run_at = ?
+WHERE name = ? AND next_run_at <= ?
 `
 
-type CreateSessionParams struct {
-	TokenHash string
-	UserID    int64
-	Expiry    time.Time
+type AdvanceJobScheduleParams struct {
+	NextRunAt   time.Time
+	Name        string
+	NextRunAt_2 time.Time
 }
 
-func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
-	_, err := q.db.ExecContext(ctx, createSession, arg.TokenHash, arg.UserID, arg.Expiry)
-	return err
+func (q *Queries) AdvanceJobSchedule(ctx context.Context, arg AdvanceJobScheduleParams) (int64, error) {
+	result, err := q.exec(ctx, q.advanceJobScheduleStmt, advanceJobSchedule, arg.NextRunAt, arg.Name, arg.NextRunAt_2)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const createUser = `-- name: CreateUser :one
-INSERT INTO users (email, password_hash, verification_token)
-VALUES (?, ?, ?)
-RETURNING id, email, password_hash, created_at, verification_token, verified_at
+const advanceUpload = `-- name: AdvanceUpload :execrows
+UPDATE uploads SET received = ? WHERE id = ? AND received = ?
 `
 
-type CreateUserParams struct {
-	Email             string
-	PasswordHash      string
-	VerificationToken sql.NullString
+type AdvanceUploadParams struct {
+	Received   int64
+	ID         string
+	Received_2 int64
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.PasswordHash, arg.VerificationToken)
-	var i User
+func (q *Queries) AdvanceUpload(ctx context.Context, arg AdvanceUploadParams) (int64, error) {
+	result, err := q.exec(ctx, q.advanceUploadStmt, advanceUpload, arg.Received, arg.ID, arg.Received_2)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const cancelJob = `-- name: CancelJob :execrows
+UPDATE jobs SET status = 'cancelled', finished_at = ?
+WHERE id = ? AND status IN ('pending', 'failed')
+`
+
+type CancelJobParams struct {
+	FinishedAt sql.NullTime
+	ID         int64
+}
+
+func (q *Queries) CancelJob(ctx context.Context, arg CancelJobParams) (int64, error) {
+	result, err := q.exec(ctx, q.cancelJobStmt, cancelJob, arg.FinishedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimJob = `-- name: ClaimJob :one
+UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_until = ?, started_at = ?
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE (status = 'pending' AND run_at <= ?) OR (status = 'running' AND locked_until < ?)
+    ORDER BY run_at
+    LIMIT 1
+)
+RETURNING id, name, payload, status, attempts, max_attempts, run_at, locked_until, error, started_at, finished_at, created_at
+`
+
+type ClaimJobParams struct {
+	LockedUntil   sql.NullTime
+	StartedAt     sql.NullTime
+	RunAt         time.Time
+	LockedUntil_2 sql.NullTime
+}
+
+func (q *Queries) ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error) {
+	row := q.queryRow(ctx, q.claimJobStmt, claimJob,
+		arg.LockedUntil,
+		arg.StartedAt,
+		arg.RunAt,
+		arg.LockedUntil_2,
+	)
+	var i Job
 	err := row.Scan(
 		&i.ID,
-		&i.Email,
-		&i.PasswordHash,
+		&i.Name,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.Error,
+		&i.StartedAt,
+		&i.FinishedAt,
 		&i.CreatedAt,
-		&i.VerificationToken,
-		&i.VerifiedAt,
 	)
 	return i, err
 }
 
-const deleteSession = `-- name: DeleteSession :exec
-DELETE FROM sessions WHERE token_hash = ?
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys SET status_code = ?, response_body = ?
+WHERE user_id = ? AND key = ?
 `
 
-func (q *Queries) DeleteSession(ctx context.Context, tokenHash string) error {
-	_, err := q.db.ExecContext(ctx, deleteSession, tokenHash)
+type CompleteIdempotencyKeyParams struct {
+	StatusCode   sql.NullInt64
+	ResponseBody []byte
+	UserID       int64
+	Key          string
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.exec(ctx, q.completeIdempotencyKeyStmt, completeIdempotencyKey,
+		arg.StatusCode,
+		arg.ResponseBody,
+		arg.UserID,
+		arg.Key,
+	)
 	return err
 }
 
-const getMessage = `-- name: GetMessage :one
-SELECT message FROM guestbook WHERE id = 1 LIMIT 1
+const completeUpload = `-- name: CompleteUpload :exec
+UPDATE uploads SET content_type = ?, storage_key = ?, sha256 = ?, completed_at = ? WHERE id = ?
 `
 
-func (q *Queries) GetMessage(ctx context.Context) (string, error) {
-	row := q.db.QueryRowContext(ctx, getMessage)
-	var message string
-	err := row.Scan(&message)
-	return message, err
+type CompleteUploadParams struct {
+	ContentType sql.NullString
+	StorageKey  sql.NullString
+	Sha256      sql.NullString
+	CompletedAt sql.NullTime
+	ID          string
 }
 
-const getSession = `-- name: GetSession :one
-SELECT sessions.token_hash, sessions.user_id, sessions.expiry, users.email FROM sessions
-JOIN users ON sessions.user_id = users.id
-WHERE token_hash = ? AND expiry > CURRENT_TIMESTAMP LIMIT 1
+func (q *Queries) CompleteUpload(ctx context.Context, arg CompleteUploadParams) error {
+	_, err := q.exec(ctx, q.completeUploadStmt, completeUpload,
+		arg.ContentType,
+		arg.StorageKey,
+		arg.Sha256,
+		arg.CompletedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const countActiveBansOnDevice = `-- name: CountActiveBansOnDevice :one
+SELECT COUNT(*) FROM user_sightings
+JOIN user_bans ON user_bans.user_id = user_sightings.user_id
+WHERE user_sightings.device = ? AND user_bans.kind = 'ban' AND user_bans.lifted_at IS NULL
+  AND (user_bans.expires_at IS NULL OR user_bans.expires_at > ?)
 `
 
-type GetSessionRow struct {
-	TokenHash string
+type CountActiveBansOnDeviceParams struct {
+	Device    string
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) CountActiveBansOnDevice(ctx context.Context, arg CountActiveBansOnDeviceParams) (int64, error) {
+	row := q.queryRow(ctx, q.countActiveBansOnDeviceStmt, countActiveBansOnDevice, arg.Device, arg.ExpiresAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countActiveUsers = `-- name: CountActiveUsers :one
+SELECT COUNT(DISTINCT user_id) FROM sessions WHERE expiry > ?
+`
+
+func (q *Queries) CountActiveUsers(ctx context.Context, expiry time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.countActiveUsersStmt, countActiveUsers, expiry)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countAnalyticsEvents = `-- name: CountAnalyticsEvents :one
+SELECT CAST(COALESCE(SUM(count), 0) AS INTEGER) FROM analytics_counts
+WHERE name = ? AND path = ? AND day >= ? AND day <= ?
+`
+
+type CountAnalyticsEventsParams struct {
+	Name  string
+	Path  string
+	Day   string
+	Day_2 string
+}
+
+func (q *Queries) CountAnalyticsEvents(ctx context.Context, arg CountAnalyticsEventsParams) (int64, error) {
+	row := q.queryRow(ctx, q.countAnalyticsEventsStmt, countAnalyticsEvents,
+		arg.Name,
+		arg.Path,
+		arg.Day,
+		arg.Day_2,
+	)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countJobsByDay = `-- name: CountJobsByDay :many
+SELECT CAST(date(finished_at) AS TEXT) AS day, COUNT(*) AS runs, CAST(SUM(status = 'failed') AS INTEGER) AS failures FROM jobs
+WHERE finished_at >= ? AND finished_at < ? AND status != 'cancelled'
+GROUP BY day
+ORDER BY day
+`
+
+type CountJobsByDayParams struct {
+	FinishedAt   sql.NullTime
+	FinishedAt_2 sql.NullTime
+}
+
+type CountJobsByDayRow struct {
+	Day      string
+	Runs     int64
+	Failures int64
+}
+
+func (q *Queries) CountJobsByDay(ctx context.Context, arg CountJobsByDayParams) ([]CountJobsByDayRow, error) {
+	rows, err := q.query(ctx, q.countJobsByDayStmt, countJobsByDay, arg.FinishedAt, arg.FinishedAt_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountJobsByDayRow
+	for rows.Next() {
+		var i CountJobsByDayRow
+		if err := rows.Scan(&i.Day, &i.Runs, &i.Failures); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countPendingJobsByName = `-- name: CountPendingJobsByName :one
+SELECT COUNT(*) FROM jobs WHERE name = ? AND status IN ('pending', 'running')
+`
+
+func (q *Queries) CountPendingJobsByName(ctx context.Context, name string) (int64, error) {
+	row := q.queryRow(ctx, q.countPendingJobsByNameStmt, countPendingJobsByName, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countReferrerSightings = `-- name: CountReferrerSightings :one
+SELECT CAST(COALESCE(SUM(ip = ?), 0) AS INTEGER) AS shared_ips,
+    CAST(COALESCE(SUM(device = ?), 0) AS INTEGER) AS shared_devices
+FROM user_sightings WHERE user_id = ?
+`
+
+type CountReferrerSightingsParams struct {
+	Ip     string
+	Device string
+	UserID int64
+}
+
+type CountReferrerSightingsRow struct {
+	SharedIps     int64
+	SharedDevices int64
+}
+
+func (q *Queries) CountReferrerSightings(ctx context.Context, arg CountReferrerSightingsParams) (CountReferrerSightingsRow, error) {
+	row := q.queryRow(ctx, q.countReferrerSightingsStmt, countReferrerSightings, arg.Ip, arg.Device, arg.UserID)
+	var i CountReferrerSightingsRow
+	err := row.Scan(&i.SharedIps, &i.SharedDevices)
+	return i, err
+}
+
+const countRewardedReferrals = `-- name: CountRewardedReferrals :one
+SELECT COUNT(*) FROM referrals WHERE referrer_id = ? AND reward_bytes > 0
+`
+
+func (q *Queries) CountRewardedReferrals(ctx context.Context, referrerID int64) (int64, error) {
+	row := q.queryRow(ctx, q.countRewardedReferralsStmt, countRewardedReferrals, referrerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSignupsByDay = `-- name: CountSignupsByDay :many
+SELECT CAST(date(created_at) AS TEXT) AS day, COUNT(*) AS signups FROM users
+WHERE created_at >= ? AND created_at < ?
+GROUP BY day
+ORDER BY day
+`
+
+type CountSignupsByDayParams struct {
+	CreatedAt   sql.NullTime
+	CreatedAt_2 sql.NullTime
+}
+
+type CountSignupsByDayRow struct {
+	Day     string
+	Signups int64
+}
+
+func (q *Queries) CountSignupsByDay(ctx context.Context, arg CountSignupsByDayParams) ([]CountSignupsByDayRow, error) {
+	rows, err := q.query(ctx, q.countSignupsByDayStmt, countSignupsByDay, arg.CreatedAt, arg.CreatedAt_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountSignupsByDayRow
+	for rows.Next() {
+		var i CountSignupsByDayRow
+		if err := rows.Scan(&i.Day, &i.Signups); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) AS total, COUNT(verified_at) AS verified FROM users
+`
+
+type CountUsersRow struct {
+	Total    int64
+	Verified int64
+}
+
+func (q *Queries) CountUsers(ctx context.Context) (CountUsersRow, error) {
+	row := q.queryRow(ctx, q.countUsersStmt, countUsers)
+	var i CountUsersRow
+	err := row.Scan(&i.Total, &i.Verified)
+	return i, err
+}
+
+const createAPIToken = `-- name: CreateAPIToken :one
+INSERT INTO api_tokens (user_id, name, token_hash, scope)
+VALUES (?, ?, ?, ?)
+RETURNING id, user_id, name, token_hash, scope, created_at, last_used_at
+`
+
+type CreateAPITokenParams struct {
 	UserID    int64
-	Expiry    time.Time
-	Email     string
+	Name      string
+	TokenHash string
+	Scope     string
 }
 
-func (q *Queries) GetSession(ctx context.Context, tokenHash string) (GetSessionRow, error) {
-	row := q.db.QueryRowContext(ctx, getSession, tokenHash)
-	var i GetSessionRow
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
+	row := q.queryRow(ctx, q.createAPITokenStmt, createAPIToken,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+		arg.Scope,
+	)
+	var i ApiToken
 	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
 		&i.TokenHash,
+		&i.Scope,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const createAnalyticsEvent = `-- name: CreateAnalyticsEvent :exec
+INSERT INTO analytics_events (name, path, referrer, visitor, experiment, variant) VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateAnalyticsEventParams struct {
+	Name       string
+	Path       string
+	Referrer   string
+	Visitor    string
+	Experiment string
+	Variant    string
+}
+
+func (q *Queries) CreateAnalyticsEvent(ctx context.Context, arg CreateAnalyticsEventParams) error {
+	_, err := q.exec(ctx, q.createAnalyticsEventStmt, createAnalyticsEvent,
+		arg.Name,
+		arg.Path,
+		arg.Referrer,
+		arg.Visitor,
+		arg.Experiment,
+		arg.Variant,
+	)
+	return err
+}
+
+const createAnnouncement = `-- name: CreateAnnouncement :exec
+INSERT INTO announcements (message, level, audience, starts_at, ends_at, dismissible) VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateAnnouncementParams struct {
+	Message     string
+	Level       string
+	Audience    string
+	StartsAt    time.Time
+	EndsAt      sql.NullTime
+	Dismissible bool
+}
+
+func (q *Queries) CreateAnnouncement(ctx context.Context, arg CreateAnnouncementParams) error {
+	_, err := q.exec(ctx, q.createAnnouncementStmt, createAnnouncement,
+		arg.Message,
+		arg.Level,
+		arg.Audience,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Dismissible,
+	)
+	return err
+}
+
+const createAuditEntry = `-- name: CreateAuditEntry :exec
+INSERT INTO audit_log (actor, action, target, detail, ip) VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateAuditEntryParams struct {
+	Actor  string
+	Action string
+	Target string
+	Detail string
+	Ip     string
+}
+
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) error {
+	_, err := q.exec(ctx, q.createAuditEntryStmt, createAuditEntry,
+		arg.Actor,
+		arg.Action,
+		arg.Target,
+		arg.Detail,
+		arg.Ip,
+	)
+	return err
+}
+
+const createBan = `-- name: CreateBan :exec
+INSERT INTO bans (ip, reason, expires_at) VALUES (?, ?, ?)
+`
+
+type CreateBanParams struct {
+	Ip        string
+	Reason    string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateBan(ctx context.Context, arg CreateBanParams) error {
+	_, err := q.exec(ctx, q.createBanStmt, createBan, arg.Ip, arg.Reason, arg.ExpiresAt)
+	return err
+}
+
+const createBanAppeal = `-- name: CreateBanAppeal :exec
+INSERT INTO ban_appeals (ban_id, message) VALUES (?, ?)
+`
+
+type CreateBanAppealParams struct {
+	BanID   int64
+	Message string
+}
+
+func (q *Queries) CreateBanAppeal(ctx context.Context, arg CreateBanAppealParams) error {
+	_, err := q.exec(ctx, q.createBanAppealStmt, createBanAppeal, arg.BanID, arg.Message)
+	return err
+}
+
+const createEvent = `-- name: CreateEvent :one
+INSERT INTO events (user_id, type, payload, request_id)
+VALUES (?, ?, ?, ?)
+RETURNING id, user_id, type, payload, created_at, request_id
+`
+
+type CreateEventParams struct {
+	UserID    sql.NullInt64
+	Type      string
+	Payload   string
+	RequestID sql.NullString
+}
+
+func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
+	row := q.queryRow(ctx, q.createEventStmt, createEvent,
+		arg.UserID,
+		arg.Type,
+		arg.Payload,
+		arg.RequestID,
+	)
+	var i Event
+	err := row.Scan(
+		&i.ID,
 		&i.UserID,
-		&i.Expiry,
-		&i.Email,
+		&i.Type,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.RequestID,
 	)
 	return i, err
 }
 
-const getUser = `-- name: GetUser :one
-SELECT id, email, password_hash, created_at, verification_token, verified_at FROM users WHERE id = ?
+const createFeatureFlag = `-- name: CreateFeatureFlag :execrows
+INSERT INTO feature_flags (name, description) VALUES (?, ?)
+ON CONFLICT(name) DO NOTHING
 `
 
-func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUser, id)
-	var i User
+type CreateFeatureFlagParams struct {
+	Name        string
+	Description string
+}
+
+func (q *Queries) CreateFeatureFlag(ctx context.Context, arg CreateFeatureFlagParams) (int64, error) {
+	result, err := q.exec(ctx, q.createFeatureFlagStmt, createFeatureFlag, arg.Name, arg.Description)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createHealthCheck = `-- name: CreateHealthCheck :exec
+INSERT INTO health_checks (name, ok, error, duration_ms, checked_at)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateHealthCheckParams struct {
+	Name       string
+	Ok         bool
+	Error      sql.NullString
+	DurationMs int64
+	CheckedAt  time.Time
+}
+
+func (q *Queries) CreateHealthCheck(ctx context.Context, arg CreateHealthCheckParams) error {
+	_, err := q.exec(ctx, q.createHealthCheckStmt, createHealthCheck,
+		arg.Name,
+		arg.Ok,
+		arg.Error,
+		arg.DurationMs,
+		arg.CheckedAt,
+	)
+	return err
+}
+
+const createIdempotencyKey = `-- name: CreateIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, key, method, path, request_hash)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (user_id, key) DO NOTHING
+`
+
+type CreateIdempotencyKeyParams struct {
+	UserID      int64
+	Key         string
+	Method      string
+	Path        string
+	RequestHash string
+}
+
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.createIdempotencyKeyStmt, createIdempotencyKey,
+		arg.UserID,
+		arg.Key,
+		arg.Method,
+		arg.Path,
+		arg.RequestHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createInboundEvent = `-- name: CreateInboundEvent :one
+INSERT INTO inbound_events (source, idempotency_key, hook_id, event_type, payload)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (source, idempotency_key) DO NOTHING
+RETURNING id
+`
+
+type CreateInboundEventParams struct {
+	Source         string
+	IdempotencyKey string
+	HookID         sql.NullInt64
+	EventType      string
+	Payload        string
+}
+
+func (q *Queries) CreateInboundEvent(ctx context.Context, arg CreateInboundEventParams) (int64, error) {
+	row := q.queryRow(ctx, q.createInboundEventStmt, createInboundEvent,
+		arg.Source,
+		arg.IdempotencyKey,
+		arg.HookID,
+		arg.EventType,
+		arg.Payload,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createInboundHook = `-- name: CreateInboundHook :one
+INSERT INTO inbound_hooks (user_id, token, secret)
+VALUES (?, ?, ?)
+RETURNING id, user_id, token, secret, created_at
+`
+
+type CreateInboundHookParams struct {
+	UserID int64
+	Token  string
+	Secret string
+}
+
+func (q *Queries) CreateInboundHook(ctx context.Context, arg CreateInboundHookParams) (InboundHook, error) {
+	row := q.queryRow(ctx, q.createInboundHookStmt, createInboundHook, arg.UserID, arg.Token, arg.Secret)
+	var i InboundHook
 	err := row.Scan(
 		&i.ID,
-		&i.Email,
-		&i.PasswordHash,
+		&i.UserID,
+		&i.Token,
+		&i.Secret,
 		&i.CreatedAt,
-		&i.VerificationToken,
-		&i.VerifiedAt,
 	)
 	return i, err
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, created_at, verification_token, verified_at FROM users WHERE email = ?
+const createJob = `-- name: CreateJob :exec
+INSERT INTO jobs (name, payload, max_attempts, run_at)
+VALUES (?, ?, ?, ?)
 `
 
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+type CreateJobParams struct {
+	Name        string
+	Payload     string
+	MaxAttempts int64
+	RunAt       time.Time
+}
+
+func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) error {
+	_, err := q.exec(ctx, q.createJobStmt, createJob,
+		arg.Name,
+		arg.Payload,
+		arg.MaxAttempts,
+		arg.RunAt,
+	)
+	return err
+}
+
+const createReferral = `-- name: CreateReferral :exec
+INSERT INTO referrals (referrer_id, referred_id, status, reason) VALUES (?, ?, ?, ?)
+`
+
+type CreateReferralParams struct {
+	ReferrerID int64
+	ReferredID int64
+	Status     string
+	Reason     string
+}
+
+func (q *Queries) CreateReferral(ctx context.Context, arg CreateReferralParams) error {
+	_, err := q.exec(ctx, q.createReferralStmt, createReferral,
+		arg.ReferrerID,
+		arg.ReferredID,
+		arg.Status,
+		arg.Reason,
+	)
+	return err
+}
+
+const createUpload = `-- name: CreateUpload :one
+INSERT INTO uploads (id, user_id, filename, size, expires_at)
+SELECT ?1, ?2, ?3, ?4, ?5
+WHERE (SELECT COALESCE(SUM(size), 0) FROM uploads WHERE user_id = ?2 AND status != 'rejected') + ?4 <= ?6
+RETURNING id, user_id, filename, size, received, content_type, storage_key, expires_at, completed_at, created_at, sha256, status, status_reason, reviewed_at
+`
+
+type CreateUploadParams struct {
+	ID        string
+	UserID    int64
+	Filename  string
+	Size      int64
+	ExpiresAt time.Time
+	Quota     int64
+}
+
+func (q *Queries) CreateUpload(ctx context.Context, arg CreateUploadParams) (Upload, error) {
+	row := q.queryRow(ctx, q.createUploadStmt, createUpload,
+		arg.ID,
+		arg.UserID,
+		arg.Filename,
+		arg.Size,
+		arg.ExpiresAt,
+		arg.Quota,
+	)
+	var i Upload
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Filename,
+		&i.Size,
+		&i.Received,
+		&i.ContentType,
+		&i.StorageKey,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.Sha256,
+		&i.Status,
+		&i.StatusReason,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (email, password_hash, verification_token)
+VALUES (?, ?, ?)
+RETURNING id, email, password_hash, created_at, verification_token, verified_at, verification_reminded_at, avatar, locale
+`
+
+type CreateUserParams struct {
+	Email             string
+	PasswordHash      string
+	VerificationToken sql.NullString
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.queryRow(ctx, q.createUserStmt, createUser, arg.Email, arg.PasswordHash, arg.VerificationToken)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -130,18 +751,3305 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.VerificationToken,
 		&i.VerifiedAt,
+		&i.VerificationRemindedAt,
+		&i.Avatar,
+		&i.Locale,
 	)
 	return i, err
 }
 
-const upsertMessage = `-- name: UpsertMessage :exec
-INSERT INTO guestbook (id, message) 
-VALUES (1, ?)
-ON CONFLICT(id) DO UPDATE SET message = excluded.message
+const createUserBan = `-- name: CreateUserBan :one
+INSERT INTO user_bans (user_id, kind, reason, expires_at) VALUES (?, ?, ?, ?)
+RETURNING id, user_id, kind, reason, expires_at, lifted_at, created_at
 `
 
-func (q *Queries) UpsertMessage(ctx context.Context, message string) error {
-	_, err := q.db.ExecContext(ctx, upsertMessage, message)
+type CreateUserBanParams struct {
+	UserID    int64
+	Kind      string
+	Reason    string
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) CreateUserBan(ctx context.Context, arg CreateUserBanParams) (UserBan, error) {
+	row := q.queryRow(ctx, q.createUserBanStmt, createUserBan,
+		arg.UserID,
+		arg.Kind,
+		arg.Reason,
+		arg.ExpiresAt,
+	)
+	var i UserBan
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Kind,
+		&i.Reason,
+		&i.ExpiresAt,
+		&i.LiftedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (provider, subject, user_id) VALUES (?, ?, ?)
+ON CONFLICT(provider, subject) DO NOTHING
+`
+
+type CreateUserIdentityParams struct {
+	Provider string
+	Subject  string
+	UserID   int64
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error {
+	_, err := q.exec(ctx, q.createUserIdentityStmt, createUserIdentity, arg.Provider, arg.Subject, arg.UserID)
+	return err
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, events, source)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, user_id, url, secret, events, created_at, source
+`
+
+type CreateWebhookParams struct {
+	UserID int64
+	Url    string
+	Secret string
+	Events string
+	Source string
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.queryRow(ctx, q.createWebhookStmt, createWebhook,
+		arg.UserID,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+		arg.Source,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.CreatedAt,
+		&i.Source,
+	)
+	return i, err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, event_id, next_attempt_at)
+VALUES (?, ?, ?)
+`
+
+type CreateWebhookDeliveryParams struct {
+	WebhookID     int64
+	EventID       int64
+	NextAttemptAt time.Time
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error {
+	_, err := q.exec(ctx, q.createWebhookDeliveryStmt, createWebhookDelivery, arg.WebhookID, arg.EventID, arg.NextAttemptAt)
+	return err
+}
+
+const decideBanAppeal = `-- name: DecideBanAppeal :one
+UPDATE ban_appeals SET accepted = ?, decided_at = ?
+WHERE id = ? AND accepted IS NULL
+RETURNING ban_id
+`
+
+type DecideBanAppealParams struct {
+	Accepted  sql.NullBool
+	DecidedAt sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) DecideBanAppeal(ctx context.Context, arg DecideBanAppealParams) (int64, error) {
+	row := q.queryRow(ctx, q.decideBanAppealStmt, decideBanAppeal, arg.Accepted, arg.DecidedAt, arg.ID)
+	var ban_id int64
+	err := row.Scan(&ban_id)
+	return ban_id, err
+}
+
+const deleteAPIToken = `-- name: DeleteAPIToken :exec
+DELETE FROM api_tokens WHERE id = ? AND user_id = ?
+`
+
+type DeleteAPITokenParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) DeleteAPIToken(ctx context.Context, arg DeleteAPITokenParams) error {
+	_, err := q.exec(ctx, q.deleteAPITokenStmt, deleteAPIToken, arg.ID, arg.UserID)
+	return err
+}
+
+const deleteAnalyticsEventsBefore = `-- name: DeleteAnalyticsEventsBefore :exec
+DELETE FROM analytics_events WHERE created_at < ?
+`
+
+func (q *Queries) DeleteAnalyticsEventsBefore(ctx context.Context, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.deleteAnalyticsEventsBeforeStmt, deleteAnalyticsEventsBefore, createdAt)
+	return err
+}
+
+const deleteAnnouncement = `-- name: DeleteAnnouncement :exec
+DELETE FROM announcements WHERE id = ?
+`
+
+func (q *Queries) DeleteAnnouncement(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteAnnouncementStmt, deleteAnnouncement, id)
+	return err
+}
+
+const deleteBan = `-- name: DeleteBan :exec
+DELETE FROM bans WHERE id = ?
+`
+
+func (q *Queries) DeleteBan(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteBanStmt, deleteBan, id)
+	return err
+}
+
+const deleteDraft = `-- name: DeleteDraft :exec
+DELETE FROM drafts WHERE user_id = ? AND form = ?
+`
+
+type DeleteDraftParams struct {
+	UserID int64
+	Form   string
+}
+
+func (q *Queries) DeleteDraft(ctx context.Context, arg DeleteDraftParams) error {
+	_, err := q.exec(ctx, q.deleteDraftStmt, deleteDraft, arg.UserID, arg.Form)
+	return err
+}
+
+const deleteDraftsBefore = `-- name: DeleteDraftsBefore :exec
+DELETE FROM drafts WHERE updated_at < ?
+`
+
+func (q *Queries) DeleteDraftsBefore(ctx context.Context, updatedAt time.Time) error {
+	_, err := q.exec(ctx, q.deleteDraftsBeforeStmt, deleteDraftsBefore, updatedAt)
+	return err
+}
+
+const deleteEmailTemplate = `-- name: DeleteEmailTemplate :exec
+DELETE FROM email_templates WHERE name = ?
+`
+
+func (q *Queries) DeleteEmailTemplate(ctx context.Context, name string) error {
+	_, err := q.exec(ctx, q.deleteEmailTemplateStmt, deleteEmailTemplate, name)
+	return err
+}
+
+const deleteExpiredBans = `-- name: DeleteExpiredBans :exec
+DELETE FROM bans WHERE expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredBans(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.exec(ctx, q.deleteExpiredBansStmt, deleteExpiredBans, expiresAt)
+	return err
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expiry <= ?
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, expiry time.Time) error {
+	_, err := q.exec(ctx, q.deleteExpiredSessionsStmt, deleteExpiredSessions, expiry)
+	return err
+}
+
+const deleteExpiredUploads = `-- name: DeleteExpiredUploads :exec
+DELETE FROM uploads WHERE completed_at IS NULL AND expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredUploads(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.exec(ctx, q.deleteExpiredUploadsStmt, deleteExpiredUploads, expiresAt)
+	return err
+}
+
+const deleteFeatureFlag = `-- name: DeleteFeatureFlag :exec
+DELETE FROM feature_flags WHERE name = ?
+`
+
+func (q *Queries) DeleteFeatureFlag(ctx context.Context, name string) error {
+	_, err := q.exec(ctx, q.deleteFeatureFlagStmt, deleteFeatureFlag, name)
+	return err
+}
+
+const deleteFeatureFlagOverride = `-- name: DeleteFeatureFlagOverride :exec
+DELETE FROM feature_flag_overrides WHERE flag_name = ? AND user_id = ?
+`
+
+type DeleteFeatureFlagOverrideParams struct {
+	FlagName string
+	UserID   int64
+}
+
+func (q *Queries) DeleteFeatureFlagOverride(ctx context.Context, arg DeleteFeatureFlagOverrideParams) error {
+	_, err := q.exec(ctx, q.deleteFeatureFlagOverrideStmt, deleteFeatureFlagOverride, arg.FlagName, arg.UserID)
+	return err
+}
+
+const deleteFinishedJobsBefore = `-- name: DeleteFinishedJobsBefore :exec
+DELETE FROM jobs WHERE status IN ('succeeded', 'failed', 'cancelled') AND finished_at < ?
+`
+
+func (q *Queries) DeleteFinishedJobsBefore(ctx context.Context, finishedAt sql.NullTime) error {
+	_, err := q.exec(ctx, q.deleteFinishedJobsBeforeStmt, deleteFinishedJobsBefore, finishedAt)
+	return err
+}
+
+const deleteHealthChecksBefore = `-- name: DeleteHealthChecksBefore :exec
+DELETE FROM health_checks WHERE checked_at < ?
+`
+
+func (q *Queries) DeleteHealthChecksBefore(ctx context.Context, checkedAt time.Time) error {
+	_, err := q.exec(ctx, q.deleteHealthChecksBeforeStmt, deleteHealthChecksBefore, checkedAt)
+	return err
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE user_id = ? AND key = ?
+`
+
+type DeleteIdempotencyKeyParams struct {
+	UserID int64
+	Key    string
+}
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error {
+	_, err := q.exec(ctx, q.deleteIdempotencyKeyStmt, deleteIdempotencyKey, arg.UserID, arg.Key)
+	return err
+}
+
+const deleteIdempotencyKeysBefore = `-- name: DeleteIdempotencyKeysBefore :exec
+DELETE FROM idempotency_keys WHERE created_at < ?
+`
+
+func (q *Queries) DeleteIdempotencyKeysBefore(ctx context.Context, createdAt sql.NullTime) error {
+	_, err := q.exec(ctx, q.deleteIdempotencyKeysBeforeStmt, deleteIdempotencyKeysBefore, createdAt)
+	return err
+}
+
+const deleteInboundHook = `-- name: DeleteInboundHook :exec
+DELETE FROM inbound_hooks WHERE id = ? AND user_id = ?
+`
+
+type DeleteInboundHookParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) DeleteInboundHook(ctx context.Context, arg DeleteInboundHookParams) error {
+	_, err := q.exec(ctx, q.deleteInboundHookStmt, deleteInboundHook, arg.ID, arg.UserID)
+	return err
+}
+
+const deleteOtherUserSessions = `-- name: DeleteOtherUserSessions :exec
+DELETE FROM sessions WHERE user_id = ? AND token_hash != ?
+`
+
+type DeleteOtherUserSessionsParams struct {
+	UserID    sql.NullInt64
+	TokenHash string
+}
+
+func (q *Queries) DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) error {
+	_, err := q.exec(ctx, q.deleteOtherUserSessionsStmt, deleteOtherUserSessions, arg.UserID, arg.TokenHash)
+	return err
+}
+
+const deleteRateLimitsBefore = `-- name: DeleteRateLimitsBefore :exec
+DELETE FROM rate_limits WHERE updated_at < ?
+`
+
+func (q *Queries) DeleteRateLimitsBefore(ctx context.Context, updatedAt time.Time) error {
+	_, err := q.exec(ctx, q.deleteRateLimitsBeforeStmt, deleteRateLimitsBefore, updatedAt)
+	return err
+}
+
+const deleteRestHook = `-- name: DeleteRestHook :execrows
+DELETE FROM webhooks WHERE id = ? AND user_id = ? AND source = 'rest_hook'
+`
+
+type DeleteRestHookParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) DeleteRestHook(ctx context.Context, arg DeleteRestHookParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteRestHookStmt, deleteRestHook, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSession = `-- name: DeleteSession :exec
+DELETE FROM sessions WHERE token_hash = ?
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := q.exec(ctx, q.deleteSessionStmt, deleteSession, tokenHash)
+	return err
+}
+
+const deleteSettings = `-- name: DeleteSettings :exec
+DELETE FROM settings
+`
+
+func (q *Queries) DeleteSettings(ctx context.Context) error {
+	_, err := q.exec(ctx, q.deleteSettingsStmt, deleteSettings)
+	return err
+}
+
+const deleteUnverifiedUser = `-- name: DeleteUnverifiedUser :execrows
+DELETE FROM users WHERE id = ? AND verified_at IS NULL
+`
+
+func (q *Queries) DeleteUnverifiedUser(ctx context.Context, id int64) (int64, error) {
+	result, err := q.exec(ctx, q.deleteUnverifiedUserStmt, deleteUnverifiedUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUpload = `-- name: DeleteUpload :exec
+DELETE FROM uploads WHERE id = ? AND user_id = ?
+`
+
+type DeleteUploadParams struct {
+	ID     string
+	UserID int64
+}
+
+func (q *Queries) DeleteUpload(ctx context.Context, arg DeleteUploadParams) error {
+	_, err := q.exec(ctx, q.deleteUploadStmt, deleteUpload, arg.ID, arg.UserID)
+	return err
+}
+
+const deleteUserSession = `-- name: DeleteUserSession :exec
+DELETE FROM sessions WHERE id = ? AND user_id = ?
+`
+
+type DeleteUserSessionParams struct {
+	ID     int64
+	UserID sql.NullInt64
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) error {
+	_, err := q.exec(ctx, q.deleteUserSessionStmt, deleteUserSession, arg.ID, arg.UserID)
+	return err
+}
+
+const deleteUserSessions = `-- name: DeleteUserSessions :exec
+DELETE FROM sessions WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserSessions(ctx context.Context, userID sql.NullInt64) error {
+	_, err := q.exec(ctx, q.deleteUserSessionsStmt, deleteUserSessions, userID)
+	return err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = ? AND user_id = ?
+`
+
+type DeleteWebhookParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) error {
+	_, err := q.exec(ctx, q.deleteWebhookStmt, deleteWebhook, arg.ID, arg.UserID)
+	return err
+}
+
+const dismissAnnouncement = `-- name: DismissAnnouncement :exec
+INSERT OR IGNORE INTO announcement_dismissals (announcement_id, user_id) VALUES (?, ?)
+`
+
+type DismissAnnouncementParams struct {
+	AnnouncementID int64
+	UserID         int64
+}
+
+func (q *Queries) DismissAnnouncement(ctx context.Context, arg DismissAnnouncementParams) error {
+	_, err := q.exec(ctx, q.dismissAnnouncementStmt, dismissAnnouncement, arg.AnnouncementID, arg.UserID)
+	return err
+}
+
+const ensureReferralCode = `-- name: EnsureReferralCode :one
+INSERT INTO referral_codes (user_id, code) VALUES (?, ?)
+ON CONFLICT(user_id) DO UPDATE SET code = referral_codes.code
+RETURNING code
+`
+
+type EnsureReferralCodeParams struct {
+	UserID int64
+	Code   string
+}
+
+func (q *Queries) EnsureReferralCode(ctx context.Context, arg EnsureReferralCodeParams) (string, error) {
+	row := q.queryRow(ctx, q.ensureReferralCodeStmt, ensureReferralCode, arg.UserID, arg.Code)
+	var code string
+	err := row.Scan(&code)
+	return code, err
+}
+
+const finishJob = `-- name: FinishJob :exec
+UPDATE jobs SET status = ?, error = ?, run_at = ?, locked_until = NULL, finished_at = ?
+WHERE id = ?
+`
+
+type FinishJobParams struct {
+	Status     string
+	Error      sql.NullString
+	RunAt      time.Time
+	FinishedAt sql.NullTime
+	ID         int64
+}
+
+func (q *Queries) FinishJob(ctx context.Context, arg FinishJobParams) error {
+	_, err := q.exec(ctx, q.finishJobStmt, finishJob,
+		arg.Status,
+		arg.Error,
+		arg.RunAt,
+		arg.FinishedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const getAPITokenByHash = `-- name: GetAPITokenByHash :one
+SELECT id, user_id, name, token_hash, scope, created_at, last_used_at FROM api_tokens WHERE token_hash = ?
+`
+
+func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error) {
+	row := q.queryRow(ctx, q.getAPITokenByHashStmt, getAPITokenByHash, tokenHash)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scope,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getBanAppeal = `-- name: GetBanAppeal :one
+SELECT id, ban_id, message, accepted, decided_at, created_at FROM ban_appeals WHERE ban_id = ? ORDER BY id DESC LIMIT 1
+`
+
+func (q *Queries) GetBanAppeal(ctx context.Context, banID int64) (BanAppeal, error) {
+	row := q.queryRow(ctx, q.getBanAppealStmt, getBanAppeal, banID)
+	var i BanAppeal
+	err := row.Scan(
+		&i.ID,
+		&i.BanID,
+		&i.Message,
+		&i.Accepted,
+		&i.DecidedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDraft = `-- name: GetDraft :one
+SELECT body FROM drafts WHERE user_id = ? AND form = ?
+`
+
+type GetDraftParams struct {
+	UserID int64
+	Form   string
+}
+
+func (q *Queries) GetDraft(ctx context.Context, arg GetDraftParams) (string, error) {
+	row := q.queryRow(ctx, q.getDraftStmt, getDraft, arg.UserID, arg.Form)
+	var body string
+	err := row.Scan(&body)
+	return body, err
+}
+
+const getEmailTemplate = `-- name: GetEmailTemplate :one
+SELECT name, subject, body, updated_at FROM email_templates WHERE name = ?
+`
+
+func (q *Queries) GetEmailTemplate(ctx context.Context, name string) (EmailTemplate, error) {
+	row := q.queryRow(ctx, q.getEmailTemplateStmt, getEmailTemplate, name)
+	var i EmailTemplate
+	err := row.Scan(
+		&i.Name,
+		&i.Subject,
+		&i.Body,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getGuestbook = `-- name: GetGuestbook :one
+SELECT id, message, version FROM guestbook WHERE id = 1 LIMIT 1
+`
+
+func (q *Queries) GetGuestbook(ctx context.Context) (Guestbook, error) {
+	row := q.queryRow(ctx, q.getGuestbookStmt, getGuestbook)
+	var i Guestbook
+	err := row.Scan(&i.ID, &i.Message, &i.Version)
+	return i, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT user_id, key, method, path, request_hash, status_code, response_body, created_at FROM idempotency_keys WHERE user_id = ? AND key = ?
+`
+
+type GetIdempotencyKeyParams struct {
+	UserID int64
+	Key    string
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.queryRow(ctx, q.getIdempotencyKeyStmt, getIdempotencyKey, arg.UserID, arg.Key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.UserID,
+		&i.Key,
+		&i.Method,
+		&i.Path,
+		&i.RequestHash,
+		&i.StatusCode,
+		&i.ResponseBody,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInboundHookByToken = `-- name: GetInboundHookByToken :one
+SELECT id, user_id, token, secret, created_at FROM inbound_hooks WHERE token = ?
+`
+
+func (q *Queries) GetInboundHookByToken(ctx context.Context, token string) (InboundHook, error) {
+	row := q.queryRow(ctx, q.getInboundHookByTokenStmt, getInboundHookByToken, token)
+	var i InboundHook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Token,
+		&i.Secret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getMessage = `-- name: GetMessage :one
+SELECT message FROM guestbook WHERE id = 1 LIMIT 1
+`
+
+func (q *Queries) GetMessage(ctx context.Context) (string, error) {
+	row := q.queryRow(ctx, q.getMessageStmt, getMessage)
+	var message string
+	err := row.Scan(&message)
+	return message, err
+}
+
+const getPendingReferral = `-- name: GetPendingReferral :one
+SELECT id, referrer_id, referred_id, status, reason, reward_bytes, qualified_at, created_at FROM referrals WHERE referred_id = ? AND status = 'pending'
+`
+
+func (q *Queries) GetPendingReferral(ctx context.Context, referredID int64) (Referral, error) {
+	row := q.queryRow(ctx, q.getPendingReferralStmt, getPendingReferral, referredID)
+	var i Referral
+	err := row.Scan(
+		&i.ID,
+		&i.ReferrerID,
+		&i.ReferredID,
+		&i.Status,
+		&i.Reason,
+		&i.RewardBytes,
+		&i.QualifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReferralStorageBonus = `-- name: GetReferralStorageBonus :one
+SELECT CAST(COALESCE(SUM(reward_bytes), 0) AS INTEGER) AS bonus FROM referrals WHERE referrer_id = ?
+`
+
+func (q *Queries) GetReferralStorageBonus(ctx context.Context, referrerID int64) (int64, error) {
+	row := q.queryRow(ctx, q.getReferralStorageBonusStmt, getReferralStorageBonus, referrerID)
+	var bonus int64
+	err := row.Scan(&bonus)
+	return bonus, err
+}
+
+const getReferrerByCode = `-- name: GetReferrerByCode :one
+SELECT user_id FROM referral_codes WHERE code = ?
+`
+
+func (q *Queries) GetReferrerByCode(ctx context.Context, code string) (int64, error) {
+	row := q.queryRow(ctx, q.getReferrerByCodeStmt, getReferrerByCode, code)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getSession = `-- name: GetSession :one
+SELECT data FROM sessions WHERE token_hash = ? AND expiry > ?
+`
+
+type GetSessionParams struct {
+	TokenHash string
+	Expiry    time.Time
+}
+
+func (q *Queries) GetSession(ctx context.Context, arg GetSessionParams) ([]byte, error) {
+	row := q.queryRow(ctx, q.getSessionStmt, getSession, arg.TokenHash, arg.Expiry)
+	var data []byte
+	err := row.Scan(&data)
+	return data, err
+}
+
+const getShadowMessage = `-- name: GetShadowMessage :one
+SELECT message FROM shadow_messages WHERE user_id = ?
+`
+
+func (q *Queries) GetShadowMessage(ctx context.Context, userID int64) (string, error) {
+	row := q.queryRow(ctx, q.getShadowMessageStmt, getShadowMessage, userID)
+	var message string
+	err := row.Scan(&message)
+	return message, err
+}
+
+const getStorageUsage = `-- name: GetStorageUsage :one
+SELECT CAST(COALESCE(SUM(size), 0) AS INTEGER) AS used FROM uploads
+WHERE user_id = ? AND status != 'rejected'
+`
+
+func (q *Queries) GetStorageUsage(ctx context.Context, userID int64) (int64, error) {
+	row := q.queryRow(ctx, q.getStorageUsageStmt, getStorageUsage, userID)
+	var used int64
+	err := row.Scan(&used)
+	return used, err
+}
+
+const getUpload = `-- name: GetUpload :one
+SELECT id, user_id, filename, size, received, content_type, storage_key, expires_at, completed_at, created_at, sha256, status, status_reason, reviewed_at FROM uploads WHERE id = ? AND user_id = ?
+`
+
+type GetUploadParams struct {
+	ID     string
+	UserID int64
+}
+
+func (q *Queries) GetUpload(ctx context.Context, arg GetUploadParams) (Upload, error) {
+	row := q.queryRow(ctx, q.getUploadStmt, getUpload, arg.ID, arg.UserID)
+	var i Upload
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Filename,
+		&i.Size,
+		&i.Received,
+		&i.ContentType,
+		&i.StorageKey,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.Sha256,
+		&i.Status,
+		&i.StatusReason,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
+
+const getUploadByID = `-- name: GetUploadByID :one
+SELECT id, user_id, filename, size, received, content_type, storage_key, expires_at, completed_at, created_at, sha256, status, status_reason, reviewed_at FROM uploads WHERE id = ?
+`
+
+func (q *Queries) GetUploadByID(ctx context.Context, id string) (Upload, error) {
+	row := q.queryRow(ctx, q.getUploadByIDStmt, getUploadByID, id)
+	var i Upload
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Filename,
+		&i.Size,
+		&i.Received,
+		&i.ContentType,
+		&i.StorageKey,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+		&i.CreatedAt,
+		&i.Sha256,
+		&i.Status,
+		&i.StatusReason,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, email, password_hash, created_at, verification_token, verified_at, verification_reminded_at, avatar, locale FROM users WHERE id = ?
+`
+
+func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
+	row := q.queryRow(ctx, q.getUserStmt, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.VerificationToken,
+		&i.VerifiedAt,
+		&i.VerificationRemindedAt,
+		&i.Avatar,
+		&i.Locale,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, email, password_hash, created_at, verification_token, verified_at, verification_reminded_at, avatar, locale FROM users WHERE email = ?
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.queryRow(ctx, q.getUserByEmailStmt, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.VerificationToken,
+		&i.VerifiedAt,
+		&i.VerificationRemindedAt,
+		&i.Avatar,
+		&i.Locale,
+	)
+	return i, err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?
+`
+
+type GetUserIdentityParams struct {
+	Provider string
+	Subject  string
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (int64, error) {
+	row := q.queryRow(ctx, q.getUserIdentityStmt, getUserIdentity, arg.Provider, arg.Subject)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, user_id, url, secret, events, created_at, source FROM webhooks WHERE id = ? AND user_id = ?
+`
+
+type GetWebhookParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) GetWebhook(ctx context.Context, arg GetWebhookParams) (Webhook, error) {
+	row := q.queryRow(ctx, q.getWebhookStmt, getWebhook, arg.ID, arg.UserID)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.CreatedAt,
+		&i.Source,
+	)
+	return i, err
+}
+
+const latestEventID = `-- name: LatestEventID :one
+SELECT CAST(COALESCE(MAX(id), 0) AS INTEGER) FROM events
+`
+
+func (q *Queries) LatestEventID(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.latestEventIDStmt, latestEventID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const liftExpiredUserBans = `-- name: LiftExpiredUserBans :execrows
+UPDATE user_bans SET lifted_at = expires_at
+WHERE lifted_at IS NULL AND expires_at <= ?
+`
+
+func (q *Queries) LiftExpiredUserBans(ctx context.Context, expiresAt sql.NullTime) (int64, error) {
+	result, err := q.exec(ctx, q.liftExpiredUserBansStmt, liftExpiredUserBans, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const liftUserBan = `-- name: LiftUserBan :execrows
+UPDATE user_bans SET lifted_at = ? WHERE id = ? AND lifted_at IS NULL
+`
+
+type LiftUserBanParams struct {
+	LiftedAt sql.NullTime
+	ID       int64
+}
+
+func (q *Queries) LiftUserBan(ctx context.Context, arg LiftUserBanParams) (int64, error) {
+	result, err := q.exec(ctx, q.liftUserBanStmt, liftUserBan, arg.LiftedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listAPITokensByUser = `-- name: ListAPITokensByUser :many
+SELECT id, user_id, name, token_hash, scope, created_at, last_used_at FROM api_tokens WHERE user_id = ? ORDER BY id
+`
+
+func (q *Queries) ListAPITokensByUser(ctx context.Context, userID int64) ([]ApiToken, error) {
+	rows, err := q.query(ctx, q.listAPITokensByUserStmt, listAPITokensByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiToken
+	for rows.Next() {
+		var i ApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+			&i.Scope,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveBans = `-- name: ListActiveBans :many
+SELECT id, ip, reason, expires_at, created_at FROM bans WHERE expires_at > ? ORDER BY id DESC
+`
+
+func (q *Queries) ListActiveBans(ctx context.Context, expiresAt time.Time) ([]Ban, error) {
+	rows, err := q.query(ctx, q.listActiveBansStmt, listActiveBans, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Ban
+	for rows.Next() {
+		var i Ban
+		if err := rows.Scan(
+			&i.ID,
+			&i.Ip,
+			&i.Reason,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveUserBans = `-- name: ListActiveUserBans :many
+SELECT id, user_id, kind, reason, expires_at, lifted_at, created_at FROM user_bans
+WHERE lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+`
+
+func (q *Queries) ListActiveUserBans(ctx context.Context, expiresAt sql.NullTime) ([]UserBan, error) {
+	rows, err := q.query(ctx, q.listActiveUserBansStmt, listActiveUserBans, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserBan
+	for rows.Next() {
+		var i UserBan
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Kind,
+			&i.Reason,
+			&i.ExpiresAt,
+			&i.LiftedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAnalyticsDays = `-- name: ListAnalyticsDays :many
+SELECT day, views, visitors FROM analytics_days WHERE day >= ? AND day <= ? ORDER BY day
+`
+
+type ListAnalyticsDaysParams struct {
+	Day   string
+	Day_2 string
+}
+
+func (q *Queries) ListAnalyticsDays(ctx context.Context, arg ListAnalyticsDaysParams) ([]AnalyticsDay, error) {
+	rows, err := q.query(ctx, q.listAnalyticsDaysStmt, listAnalyticsDays, arg.Day, arg.Day_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AnalyticsDay
+	for rows.Next() {
+		var i AnalyticsDay
+		if err := rows.Scan(&i.Day, &i.Views, &i.Visitors); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAnnouncements = `-- name: ListAnnouncements :many
+SELECT announcements.id, announcements.message, announcements.level, announcements.audience, announcements.starts_at, announcements.ends_at, announcements.dismissible, announcements.created_at, (SELECT COUNT(*) FROM announcement_dismissals WHERE announcement_id = announcements.id) AS dismissals FROM announcements
+ORDER BY starts_at DESC, id DESC
+`
+
+type ListAnnouncementsRow struct {
+	ID          int64
+	Message     string
+	Level       string
+	Audience    string
+	StartsAt    time.Time
+	EndsAt      sql.NullTime
+	Dismissible bool
+	CreatedAt   sql.NullTime
+	Dismissals  int64
+}
+
+func (q *Queries) ListAnnouncements(ctx context.Context) ([]ListAnnouncementsRow, error) {
+	rows, err := q.query(ctx, q.listAnnouncementsStmt, listAnnouncements)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAnnouncementsRow
+	for rows.Next() {
+		var i ListAnnouncementsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Level,
+			&i.Audience,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Dismissible,
+			&i.CreatedAt,
+			&i.Dismissals,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAssociatedUsers = `-- name: ListAssociatedUsers :many
+SELECT users.id, users.email,
+    COUNT(DISTINCT CASE WHEN theirs.ip = mine.ip THEN theirs.ip END) AS shared_ips,
+    COUNT(DISTINCT CASE WHEN theirs.device = mine.device THEN theirs.device END) AS shared_devices
+FROM user_sightings mine
+JOIN user_sightings theirs ON (theirs.ip = mine.ip OR theirs.device = mine.device) AND theirs.user_id != mine.user_id
+JOIN users ON users.id = theirs.user_id
+WHERE mine.user_id = ?
+GROUP BY users.id
+ORDER BY users.id
+`
+
+type ListAssociatedUsersRow struct {
+	ID            int64
+	Email         string
+	SharedIps     int64
+	SharedDevices int64
+}
+
+func (q *Queries) ListAssociatedUsers(ctx context.Context, userID int64) ([]ListAssociatedUsersRow, error) {
+	rows, err := q.query(ctx, q.listAssociatedUsersStmt, listAssociatedUsers, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAssociatedUsersRow
+	for rows.Next() {
+		var i ListAssociatedUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.SharedIps,
+			&i.SharedDevices,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLog = `-- name: ListAuditLog :many
+SELECT id, actor, action, target, detail, ip, created_at FROM audit_log ORDER BY id DESC LIMIT ?
+`
+
+func (q *Queries) ListAuditLog(ctx context.Context, limit int64) ([]AuditLog, error) {
+	rows, err := q.query(ctx, q.listAuditLogStmt, listAuditLog, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Actor,
+			&i.Action,
+			&i.Target,
+			&i.Detail,
+			&i.Ip,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDismissedAnnouncements = `-- name: ListDismissedAnnouncements :many
+SELECT announcement_id FROM announcement_dismissals WHERE user_id = ?
+`
+
+func (q *Queries) ListDismissedAnnouncements(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := q.query(ctx, q.listDismissedAnnouncementsStmt, listDismissedAnnouncements, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var announcement_id int64
+		if err := rows.Scan(&announcement_id); err != nil {
+			return nil, err
+		}
+		items = append(items, announcement_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueWebhookDeliveries = `-- name: ListDueWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.attempts, webhooks.id AS webhook_id, webhooks.user_id, webhooks.url, webhooks.secret, events.id AS event_id, events.type, events.payload, events.created_at, events.request_id
+FROM webhook_deliveries
+JOIN webhooks ON webhook_deliveries.webhook_id = webhooks.id
+JOIN events ON webhook_deliveries.event_id = events.id
+WHERE webhook_deliveries.status = 'pending' AND webhook_deliveries.next_attempt_at <= ?
+ORDER BY webhook_deliveries.next_attempt_at
+LIMIT ?
+`
+
+type ListDueWebhookDeliveriesParams struct {
+	NextAttemptAt time.Time
+	Limit         int64
+}
+
+type ListDueWebhookDeliveriesRow struct {
+	ID        int64
+	Attempts  int64
+	WebhookID int64
+	UserID    int64
+	Url       string
+	Secret    string
+	EventID   int64
+	Type      string
+	Payload   string
+	CreatedAt sql.NullTime
+	RequestID sql.NullString
+}
+
+func (q *Queries) ListDueWebhookDeliveries(ctx context.Context, arg ListDueWebhookDeliveriesParams) ([]ListDueWebhookDeliveriesRow, error) {
+	rows, err := q.query(ctx, q.listDueWebhookDeliveriesStmt, listDueWebhookDeliveries, arg.NextAttemptAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDueWebhookDeliveriesRow
+	for rows.Next() {
+		var i ListDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Attempts,
+			&i.WebhookID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.EventID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmailTemplates = `-- name: ListEmailTemplates :many
+SELECT name, subject, body, updated_at FROM email_templates ORDER BY name
+`
+
+func (q *Queries) ListEmailTemplates(ctx context.Context) ([]EmailTemplate, error) {
+	rows, err := q.query(ctx, q.listEmailTemplatesStmt, listEmailTemplates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EmailTemplate
+	for rows.Next() {
+		var i EmailTemplate
+		if err := rows.Scan(
+			&i.Name,
+			&i.Subject,
+			&i.Body,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsForUserSince = `-- name: ListEventsForUserSince :many
+SELECT id, user_id, type, payload, created_at, request_id FROM events
+WHERE id > ? AND (user_id = ? OR user_id IS NULL)
+ORDER BY id
+LIMIT 100
+`
+
+type ListEventsForUserSinceParams struct {
+	ID     int64
+	UserID sql.NullInt64
+}
+
+func (q *Queries) ListEventsForUserSince(ctx context.Context, arg ListEventsForUserSinceParams) ([]Event, error) {
+	rows, err := q.query(ctx, q.listEventsForUserSinceStmt, listEventsForUserSince, arg.ID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsOfTypeSince = `-- name: ListEventsOfTypeSince :many
+SELECT id, user_id, type, payload, created_at, request_id FROM events
+WHERE type = ? AND id > ?
+ORDER BY id
+LIMIT 100
+`
+
+type ListEventsOfTypeSinceParams struct {
+	Type string
+	ID   int64
+}
+
+func (q *Queries) ListEventsOfTypeSince(ctx context.Context, arg ListEventsOfTypeSinceParams) ([]Event, error) {
+	rows, err := q.query(ctx, q.listEventsOfTypeSinceStmt, listEventsOfTypeSince, arg.Type, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExperimentResults = `-- name: ListExperimentResults :many
+SELECT variant, CAST(SUM(visitors) AS INTEGER) AS visitors, CAST(SUM(conversions) AS INTEGER) AS conversions FROM analytics_experiments
+WHERE experiment = ? AND day >= ? AND day <= ?
+GROUP BY variant
+`
+
+type ListExperimentResultsParams struct {
+	Experiment string
+	Day        string
+	Day_2      string
+}
+
+type ListExperimentResultsRow struct {
+	Variant     string
+	Visitors    int64
+	Conversions int64
+}
+
+func (q *Queries) ListExperimentResults(ctx context.Context, arg ListExperimentResultsParams) ([]ListExperimentResultsRow, error) {
+	rows, err := q.query(ctx, q.listExperimentResultsStmt, listExperimentResults, arg.Experiment, arg.Day, arg.Day_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExperimentResultsRow
+	for rows.Next() {
+		var i ListExperimentResultsRow
+		if err := rows.Scan(&i.Variant, &i.Visitors, &i.Conversions); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredUploads = `-- name: ListExpiredUploads :many
+SELECT id FROM uploads WHERE completed_at IS NULL AND expires_at <= ?
+`
+
+func (q *Queries) ListExpiredUploads(ctx context.Context, expiresAt time.Time) ([]string, error) {
+	rows, err := q.query(ctx, q.listExpiredUploadsStmt, listExpiredUploads, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFailedJobs = `-- name: ListFailedJobs :many
+SELECT id, name, payload, status, attempts, max_attempts, run_at, locked_until, error, started_at, finished_at, created_at FROM jobs WHERE status = 'failed' ORDER BY finished_at DESC LIMIT ?
+`
+
+func (q *Queries) ListFailedJobs(ctx context.Context, limit int64) ([]Job, error) {
+	rows, err := q.query(ctx, q.listFailedJobsStmt, listFailedJobs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.Error,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFailedJobsByName = `-- name: ListFailedJobsByName :many
+SELECT id, name, payload, status, attempts, max_attempts, run_at, locked_until, error, started_at, finished_at, created_at FROM jobs WHERE name = ? AND status = 'failed' ORDER BY finished_at DESC LIMIT ?
+`
+
+type ListFailedJobsByNameParams struct {
+	Name  string
+	Limit int64
+}
+
+func (q *Queries) ListFailedJobsByName(ctx context.Context, arg ListFailedJobsByNameParams) ([]Job, error) {
+	rows, err := q.query(ctx, q.listFailedJobsByNameStmt, listFailedJobsByName, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.Error,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeatureFlagOverrides = `-- name: ListFeatureFlagOverrides :many
+SELECT feature_flag_overrides.flag_name, feature_flag_overrides.user_id, feature_flag_overrides.enabled, users.email FROM feature_flag_overrides
+JOIN users ON feature_flag_overrides.user_id = users.id
+ORDER BY feature_flag_overrides.flag_name, users.email
+`
+
+type ListFeatureFlagOverridesRow struct {
+	FlagName string
+	UserID   int64
+	Enabled  bool
+	Email    string
+}
+
+func (q *Queries) ListFeatureFlagOverrides(ctx context.Context) ([]ListFeatureFlagOverridesRow, error) {
+	rows, err := q.query(ctx, q.listFeatureFlagOverridesStmt, listFeatureFlagOverrides)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFeatureFlagOverridesRow
+	for rows.Next() {
+		var i ListFeatureFlagOverridesRow
+		if err := rows.Scan(
+			&i.FlagName,
+			&i.UserID,
+			&i.Enabled,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeatureFlagOverridesForUser = `-- name: ListFeatureFlagOverridesForUser :many
+SELECT flag_name, user_id, enabled FROM feature_flag_overrides WHERE user_id = ?
+`
+
+func (q *Queries) ListFeatureFlagOverridesForUser(ctx context.Context, userID int64) ([]FeatureFlagOverride, error) {
+	rows, err := q.query(ctx, q.listFeatureFlagOverridesForUserStmt, listFeatureFlagOverridesForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeatureFlagOverride
+	for rows.Next() {
+		var i FeatureFlagOverride
+		if err := rows.Scan(&i.FlagName, &i.UserID, &i.Enabled); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeatureFlags = `-- name: ListFeatureFlags :many
+SELECT name, description, enabled, rollout_percent, updated_at FROM feature_flags ORDER BY name
+`
+
+func (q *Queries) ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error) {
+	rows, err := q.query(ctx, q.listFeatureFlagsStmt, listFeatureFlags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeatureFlag
+	for rows.Next() {
+		var i FeatureFlag
+		if err := rows.Scan(
+			&i.Name,
+			&i.Description,
+			&i.Enabled,
+			&i.RolloutPercent,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHealthCheckHistory = `-- name: ListHealthCheckHistory :many
+SELECT id, name, ok, error, duration_ms, checked_at FROM health_checks WHERE name = ? ORDER BY id DESC LIMIT ?
+`
+
+type ListHealthCheckHistoryParams struct {
+	Name  string
+	Limit int64
+}
+
+func (q *Queries) ListHealthCheckHistory(ctx context.Context, arg ListHealthCheckHistoryParams) ([]HealthCheck, error) {
+	rows, err := q.query(ctx, q.listHealthCheckHistoryStmt, listHealthCheckHistory, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HealthCheck
+	for rows.Next() {
+		var i HealthCheck
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Ok,
+			&i.Error,
+			&i.DurationMs,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHealthCheckUptime = `-- name: ListHealthCheckUptime :many
+SELECT name, CAST(SUM(ok) AS INTEGER) AS passed, COUNT(*) AS total FROM health_checks
+WHERE checked_at >= ?
+GROUP BY name
+ORDER BY name
+`
+
+type ListHealthCheckUptimeRow struct {
+	Name   string
+	Passed int64
+	Total  int64
+}
+
+func (q *Queries) ListHealthCheckUptime(ctx context.Context, checkedAt time.Time) ([]ListHealthCheckUptimeRow, error) {
+	rows, err := q.query(ctx, q.listHealthCheckUptimeStmt, listHealthCheckUptime, checkedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListHealthCheckUptimeRow
+	for rows.Next() {
+		var i ListHealthCheckUptimeRow
+		if err := rows.Scan(&i.Name, &i.Passed, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInboundHooksByUser = `-- name: ListInboundHooksByUser :many
+SELECT id, user_id, token, secret, created_at FROM inbound_hooks WHERE user_id = ? ORDER BY id
+`
+
+func (q *Queries) ListInboundHooksByUser(ctx context.Context, userID int64) ([]InboundHook, error) {
+	rows, err := q.query(ctx, q.listInboundHooksByUserStmt, listInboundHooksByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []InboundHook
+	for rows.Next() {
+		var i InboundHook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Token,
+			&i.Secret,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobOutcomes = `-- name: ListJobOutcomes :many
+SELECT name, COUNT(*) AS finished, CAST(SUM(error IS NOT NULL) AS INTEGER) AS failing FROM jobs
+WHERE finished_at >= ? AND status != 'cancelled'
+GROUP BY name
+ORDER BY name
+`
+
+type ListJobOutcomesRow struct {
+	Name     string
+	Finished int64
+	Failing  int64
+}
+
+func (q *Queries) ListJobOutcomes(ctx context.Context, finishedAt sql.NullTime) ([]ListJobOutcomesRow, error) {
+	rows, err := q.query(ctx, q.listJobOutcomesStmt, listJobOutcomes, finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListJobOutcomesRow
+	for rows.Next() {
+		var i ListJobOutcomesRow
+		if err := rows.Scan(&i.Name, &i.Finished, &i.Failing); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobSchedules = `-- name: ListJobSchedules :many
+SELECT name, spec, next_run_at FROM job_schedules ORDER BY name
+`
+
+func (q *Queries) ListJobSchedules(ctx context.Context) ([]JobSchedule, error) {
+	rows, err := q.query(ctx, q.listJobSchedulesStmt, listJobSchedules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []JobSchedule
+	for rows.Next() {
+		var i JobSchedule
+		if err := rows.Scan(
+			&i.Name,
+			&i.Spec,
+			&i.NextRunAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLargestUploads = `-- name: ListLargestUploads :many
+SELECT id, filename, size, status, completed_at FROM uploads
+WHERE user_id = ? AND status != 'rejected'
+ORDER BY size DESC
+LIMIT ?
+`
+
+type ListLargestUploadsParams struct {
+	UserID int64
+	Limit  int64
+}
+
+type ListLargestUploadsRow struct {
+	ID          string
+	Filename    string
+	Size        int64
+	Status      string
+	CompletedAt sql.NullTime
+}
+
+func (q *Queries) ListLargestUploads(ctx context.Context, arg ListLargestUploadsParams) ([]ListLargestUploadsRow, error) {
+	rows, err := q.query(ctx, q.listLargestUploadsStmt, listLargestUploads, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLargestUploadsRow
+	for rows.Next() {
+		var i ListLargestUploadsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Filename,
+			&i.Size,
+			&i.Status,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLatestHealthChecks = `-- name: ListLatestHealthChecks :many
+SELECT id, name, ok, error, duration_ms, checked_at FROM health_checks
+WHERE id IN (SELECT MAX(id) FROM health_checks GROUP BY name)
+ORDER BY name
+`
+
+func (q *Queries) ListLatestHealthChecks(ctx context.Context) ([]HealthCheck, error) {
+	rows, err := q.query(ctx, q.listLatestHealthChecksStmt, listLatestHealthChecks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HealthCheck
+	for rows.Next() {
+		var i HealthCheck
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Ok,
+			&i.Error,
+			&i.DurationMs,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingBanAppeals = `-- name: ListPendingBanAppeals :many
+SELECT ban_appeals.id, ban_appeals.message, ban_appeals.created_at,
+    user_bans.id AS ban_id, user_bans.reason, user_bans.created_at AS banned_at, users.id AS user_id, users.email
+FROM ban_appeals
+JOIN user_bans ON user_bans.id = ban_appeals.ban_id
+JOIN users ON users.id = user_bans.user_id
+WHERE ban_appeals.accepted IS NULL
+ORDER BY ban_appeals.id
+`
+
+type ListPendingBanAppealsRow struct {
+	ID        int64
+	Message   string
+	CreatedAt sql.NullTime
+	BanID     int64
+	Reason    string
+	BannedAt  sql.NullTime
+	UserID    int64
+	Email     string
+}
+
+func (q *Queries) ListPendingBanAppeals(ctx context.Context) ([]ListPendingBanAppealsRow, error) {
+	rows, err := q.query(ctx, q.listPendingBanAppealsStmt, listPendingBanAppeals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPendingBanAppealsRow
+	for rows.Next() {
+		var i ListPendingBanAppealsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.CreatedAt,
+			&i.BanID,
+			&i.Reason,
+			&i.BannedAt,
+			&i.UserID,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingInboundEvents = `-- name: ListPendingInboundEvents :many
+SELECT inbound_events.id, inbound_events.source, inbound_events.idempotency_key, inbound_events.hook_id, inbound_events.event_type, inbound_events.payload, inbound_events.status, inbound_events.attempts, inbound_events.error, inbound_events.created_at, inbound_events.processed_at, inbound_hooks.user_id FROM inbound_events
+LEFT JOIN inbound_hooks ON inbound_events.hook_id = inbound_hooks.id
+WHERE inbound_events.status = 'pending'
+ORDER BY inbound_events.id
+LIMIT ?
+`
+
+type ListPendingInboundEventsRow struct {
+	ID             int64
+	Source         string
+	IdempotencyKey string
+	HookID         sql.NullInt64
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int64
+	Error          sql.NullString
+	CreatedAt      sql.NullTime
+	ProcessedAt    sql.NullTime
+	UserID         sql.NullInt64
+}
+
+func (q *Queries) ListPendingInboundEvents(ctx context.Context, limit int64) ([]ListPendingInboundEventsRow, error) {
+	rows, err := q.query(ctx, q.listPendingInboundEventsStmt, listPendingInboundEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPendingInboundEventsRow
+	for rows.Next() {
+		var i ListPendingInboundEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Source,
+			&i.IdempotencyKey,
+			&i.HookID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.Error,
+			&i.CreatedAt,
+			&i.ProcessedAt,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingJobs = `-- name: ListPendingJobs :many
+SELECT id, name, payload, status, attempts, max_attempts, run_at, locked_until, error, started_at, finished_at, created_at FROM jobs WHERE status IN ('pending', 'running') ORDER BY run_at LIMIT ?
+`
+
+func (q *Queries) ListPendingJobs(ctx context.Context, limit int64) ([]Job, error) {
+	rows, err := q.query(ctx, q.listPendingJobsStmt, listPendingJobs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.Error,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingJobsByName = `-- name: ListPendingJobsByName :many
+SELECT id, name, payload, status, attempts, max_attempts, run_at, locked_until, error, started_at, finished_at, created_at FROM jobs WHERE name = ? AND status IN ('pending', 'running') ORDER BY run_at LIMIT ?
+`
+
+type ListPendingJobsByNameParams struct {
+	Name  string
+	Limit int64
+}
+
+func (q *Queries) ListPendingJobsByName(ctx context.Context, arg ListPendingJobsByNameParams) ([]Job, error) {
+	rows, err := q.query(ctx, q.listPendingJobsByNameStmt, listPendingJobsByName, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.Error,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQuarantinedUploads = `-- name: ListQuarantinedUploads :many
+SELECT uploads.id, uploads.user_id, uploads.filename, uploads.size, uploads.content_type, uploads.status_reason, uploads.completed_at, users.email FROM uploads
+JOIN users ON uploads.user_id = users.id
+WHERE uploads.status = 'quarantined'
+ORDER BY uploads.completed_at
+`
+
+type ListQuarantinedUploadsRow struct {
+	ID           string
+	UserID       int64
+	Filename     string
+	Size         int64
+	ContentType  sql.NullString
+	StatusReason sql.NullString
+	CompletedAt  sql.NullTime
+	Email        string
+}
+
+func (q *Queries) ListQuarantinedUploads(ctx context.Context) ([]ListQuarantinedUploadsRow, error) {
+	rows, err := q.query(ctx, q.listQuarantinedUploadsStmt, listQuarantinedUploads)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListQuarantinedUploadsRow
+	for rows.Next() {
+		var i ListQuarantinedUploadsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Filename,
+			&i.Size,
+			&i.ContentType,
+			&i.StatusReason,
+			&i.CompletedAt,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRateLimits = `-- name: ListRateLimits :many
+SELECT key, tokens, updated_at FROM rate_limits
+`
+
+func (q *Queries) ListRateLimits(ctx context.Context) ([]RateLimit, error) {
+	rows, err := q.query(ctx, q.listRateLimitsStmt, listRateLimits)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RateLimit
+	for rows.Next() {
+		var i RateLimit
+		if err := rows.Scan(&i.Key, &i.Tokens, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentEventsForUser = `-- name: ListRecentEventsForUser :many
+SELECT id, user_id, type, payload, created_at, request_id FROM events
+WHERE type = ? AND (user_id = ? OR user_id IS NULL)
+ORDER BY id DESC
+LIMIT ?
+`
+
+type ListRecentEventsForUserParams struct {
+	Type   string
+	UserID sql.NullInt64
+	Limit  int64
+}
+
+func (q *Queries) ListRecentEventsForUser(ctx context.Context, arg ListRecentEventsForUserParams) ([]Event, error) {
+	rows, err := q.query(ctx, q.listRecentEventsForUserStmt, listRecentEventsForUser, arg.Type, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.RequestID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentJobs = `-- name: ListRecentJobs :many
+SELECT id, name, payload, status, attempts, max_attempts, run_at, locked_until, error, started_at, finished_at, created_at FROM jobs ORDER BY id DESC LIMIT ?
+`
+
+func (q *Queries) ListRecentJobs(ctx context.Context, limit int64) ([]Job, error) {
+	rows, err := q.query(ctx, q.listRecentJobsStmt, listRecentJobs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.Error,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentJobsByName = `-- name: ListRecentJobsByName :many
+SELECT id, name, payload, status, attempts, max_attempts, run_at, locked_until, error, started_at, finished_at, created_at FROM jobs WHERE name = ? ORDER BY id DESC LIMIT ?
+`
+
+type ListRecentJobsByNameParams struct {
+	Name  string
+	Limit int64
+}
+
+func (q *Queries) ListRecentJobsByName(ctx context.Context, arg ListRecentJobsByNameParams) ([]Job, error) {
+	rows, err := q.query(ctx, q.listRecentJobsByNameStmt, listRecentJobsByName, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.Error,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReferrals = `-- name: ListReferrals :many
+SELECT referrals.id, referrals.referrer_id, referrals.referred_id, referrals.status, referrals.reason, referrals.reward_bytes, referrals.qualified_at, referrals.created_at, substr(users.email, 1, 1) || '•••@' || substr(users.email, instr(users.email, '@') + 1, 1) || '•••' AS masked_email FROM referrals
+JOIN users ON users.id = referrals.referred_id
+WHERE referrals.referrer_id = ?
+ORDER BY referrals.id DESC
+`
+
+type ListReferralsRow struct {
+	ID          int64
+	ReferrerID  int64
+	ReferredID  int64
+	Status      string
+	Reason      string
+	RewardBytes int64
+	QualifiedAt sql.NullTime
+	CreatedAt   sql.NullTime
+	MaskedEmail string
+}
+
+func (q *Queries) ListReferrals(ctx context.Context, referrerID int64) ([]ListReferralsRow, error) {
+	rows, err := q.query(ctx, q.listReferralsStmt, listReferrals, referrerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReferralsRow
+	for rows.Next() {
+		var i ListReferralsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReferrerID,
+			&i.ReferredID,
+			&i.Status,
+			&i.Reason,
+			&i.RewardBytes,
+			&i.QualifiedAt,
+			&i.CreatedAt,
+			&i.MaskedEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSettings = `-- name: ListSettings :many
+SELECT key, value, updated_at FROM settings
+`
+
+func (q *Queries) ListSettings(ctx context.Context) ([]Setting, error) {
+	rows, err := q.query(ctx, q.listSettingsStmt, listSettings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Setting
+	for rows.Next() {
+		var i Setting
+		if err := rows.Scan(&i.Key, &i.Value, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopPages = `-- name: ListTopPages :many
+SELECT path, CAST(SUM(count) AS INTEGER) AS views FROM analytics_counts
+WHERE name = 'pageview' AND day >= ? AND day <= ?
+GROUP BY path
+ORDER BY views DESC, path
+LIMIT ?
+`
+
+type ListTopPagesParams struct {
+	Day   string
+	Day_2 string
+	Limit int64
+}
+
+type ListTopPagesRow struct {
+	Path  string
+	Views int64
+}
+
+func (q *Queries) ListTopPages(ctx context.Context, arg ListTopPagesParams) ([]ListTopPagesRow, error) {
+	rows, err := q.query(ctx, q.listTopPagesStmt, listTopPages, arg.Day, arg.Day_2, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopPagesRow
+	for rows.Next() {
+		var i ListTopPagesRow
+		if err := rows.Scan(&i.Path, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopReferrers = `-- name: ListTopReferrers :many
+SELECT referrer, CAST(SUM(count) AS INTEGER) AS views FROM analytics_counts
+WHERE name = 'pageview' AND referrer != '' AND day >= ? AND day <= ?
+GROUP BY referrer
+ORDER BY views DESC, referrer
+LIMIT ?
+`
+
+type ListTopReferrersParams struct {
+	Day   string
+	Day_2 string
+	Limit int64
+}
+
+type ListTopReferrersRow struct {
+	Referrer string
+	Views    int64
+}
+
+func (q *Queries) ListTopReferrers(ctx context.Context, arg ListTopReferrersParams) ([]ListTopReferrersRow, error) {
+	rows, err := q.query(ctx, q.listTopReferrersStmt, listTopReferrers, arg.Day, arg.Day_2, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopReferrersRow
+	for rows.Next() {
+		var i ListTopReferrersRow
+		if err := rows.Scan(&i.Referrer, &i.Views); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnendedAnnouncements = `-- name: ListUnendedAnnouncements :many
+SELECT id, message, level, audience, starts_at, ends_at, dismissible, created_at FROM announcements
+WHERE ends_at IS NULL OR ends_at > ?
+ORDER BY starts_at, id
+`
+
+func (q *Queries) ListUnendedAnnouncements(ctx context.Context, endsAt sql.NullTime) ([]Announcement, error) {
+	rows, err := q.query(ctx, q.listUnendedAnnouncementsStmt, listUnendedAnnouncements, endsAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Announcement
+	for rows.Next() {
+		var i Announcement
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Level,
+			&i.Audience,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Dismissible,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnverifiedUsersBefore = `-- name: ListUnverifiedUsersBefore :many
+SELECT id, avatar FROM users WHERE verified_at IS NULL AND created_at <= ?
+`
+
+type ListUnverifiedUsersBeforeRow struct {
+	ID     int64
+	Avatar sql.NullString
+}
+
+func (q *Queries) ListUnverifiedUsersBefore(ctx context.Context, createdAt sql.NullTime) ([]ListUnverifiedUsersBeforeRow, error) {
+	rows, err := q.query(ctx, q.listUnverifiedUsersBeforeStmt, listUnverifiedUsersBefore, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnverifiedUsersBeforeRow
+	for rows.Next() {
+		var i ListUnverifiedUsersBeforeRow
+		if err := rows.Scan(&i.ID, &i.Avatar); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnverifiedUsersToRemind = `-- name: ListUnverifiedUsersToRemind :many
+SELECT id, email, verification_token FROM users
+WHERE verified_at IS NULL AND verification_reminded_at IS NULL AND created_at <= ?
+`
+
+type ListUnverifiedUsersToRemindRow struct {
+	ID                int64
+	Email             string
+	VerificationToken sql.NullString
+}
+
+func (q *Queries) ListUnverifiedUsersToRemind(ctx context.Context, createdAt sql.NullTime) ([]ListUnverifiedUsersToRemindRow, error) {
+	rows, err := q.query(ctx, q.listUnverifiedUsersToRemindStmt, listUnverifiedUsersToRemind, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnverifiedUsersToRemindRow
+	for rows.Next() {
+		var i ListUnverifiedUsersToRemindRow
+		if err := rows.Scan(&i.ID, &i.Email, &i.VerificationToken); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUploadStorageKeys = `-- name: ListUploadStorageKeys :many
+SELECT storage_key FROM uploads WHERE user_id = ? AND storage_key IS NOT NULL
+`
+
+func (q *Queries) ListUploadStorageKeys(ctx context.Context, userID int64) ([]sql.NullString, error) {
+	rows, err := q.query(ctx, q.listUploadStorageKeysStmt, listUploadStorageKeys, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var storage_key sql.NullString
+		if err := rows.Scan(&storage_key); err != nil {
+			return nil, err
+		}
+		items = append(items, storage_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUploadsBySHA256 = `-- name: ListUploadsBySHA256 :many
+SELECT id, user_id, status FROM uploads WHERE sha256 = ? AND id != ?
+`
+
+type ListUploadsBySHA256Params struct {
+	Sha256 sql.NullString
+	ID     string
+}
+
+type ListUploadsBySHA256Row struct {
+	ID     string
+	UserID int64
+	Status string
+}
+
+func (q *Queries) ListUploadsBySHA256(ctx context.Context, arg ListUploadsBySHA256Params) ([]ListUploadsBySHA256Row, error) {
+	rows, err := q.query(ctx, q.listUploadsBySHA256Stmt, listUploadsBySHA256, arg.Sha256, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUploadsBySHA256Row
+	for rows.Next() {
+		var i ListUploadsBySHA256Row
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Status); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserBans = `-- name: ListUserBans :many
+SELECT user_bans.id, user_bans.user_id, user_bans.kind, user_bans.reason, user_bans.expires_at, user_bans.lifted_at, user_bans.created_at, users.email FROM user_bans
+JOIN users ON users.id = user_bans.user_id
+ORDER BY user_bans.id DESC
+LIMIT ?
+`
+
+type ListUserBansRow struct {
+	ID        int64
+	UserID    int64
+	Kind      string
+	Reason    string
+	ExpiresAt sql.NullTime
+	LiftedAt  sql.NullTime
+	CreatedAt sql.NullTime
+	Email     string
+}
+
+func (q *Queries) ListUserBans(ctx context.Context, limit int64) ([]ListUserBansRow, error) {
+	rows, err := q.query(ctx, q.listUserBansStmt, listUserBans, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserBansRow
+	for rows.Next() {
+		var i ListUserBansRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Kind,
+			&i.Reason,
+			&i.ExpiresAt,
+			&i.LiftedAt,
+			&i.CreatedAt,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, token_hash, created_at, updated_at, expiry FROM sessions
+WHERE user_id = ? AND expiry > ?
+ORDER BY updated_at DESC
+`
+
+type ListUserSessionsParams struct {
+	UserID sql.NullInt64
+	Expiry time.Time
+}
+
+type ListUserSessionsRow struct {
+	ID        int64
+	TokenHash string
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+	Expiry    time.Time
+}
+
+func (q *Queries) ListUserSessions(ctx context.Context, arg ListUserSessionsParams) ([]ListUserSessionsRow, error) {
+	rows, err := q.query(ctx, q.listUserSessionsStmt, listUserSessions, arg.UserID, arg.Expiry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionsRow
+	for rows.Next() {
+		var i ListUserSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Expiry,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, email, password_hash, created_at, verification_token, verified_at, verification_reminded_at, avatar, locale FROM users ORDER BY id
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.query(ctx, q.listUsersStmt, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.VerificationToken,
+			&i.VerifiedAt,
+			&i.VerificationRemindedAt,
+			&i.Avatar,
+			&i.Locale,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event_id, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.response_code, webhook_deliveries.error, webhook_deliveries.next_attempt_at, webhook_deliveries.created_at, events.type AS event_type FROM webhook_deliveries
+JOIN events ON webhook_deliveries.event_id = events.id
+WHERE webhook_deliveries.webhook_id = ?
+ORDER BY webhook_deliveries.id DESC
+LIMIT 50
+`
+
+type ListWebhookDeliveriesRow struct {
+	ID            int64
+	WebhookID     int64
+	EventID       int64
+	Status        string
+	Attempts      int64
+	ResponseCode  sql.NullInt64
+	Error         sql.NullString
+	NextAttemptAt time.Time
+	CreatedAt     sql.NullTime
+	EventType     string
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, webhookID int64) ([]ListWebhookDeliveriesRow, error) {
+	rows, err := q.query(ctx, q.listWebhookDeliveriesStmt, listWebhookDeliveries, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWebhookDeliveriesRow
+	for rows.Next() {
+		var i ListWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.Error,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.EventType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, user_id, url, secret, events, created_at, source FROM webhooks ORDER BY id
+`
+
+func (q *Queries) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	rows, err := q.query(ctx, q.listWebhooksStmt, listWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.CreatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooksByUser = `-- name: ListWebhooksByUser :many
+SELECT id, user_id, url, secret, events, created_at, source FROM webhooks WHERE user_id = ? ORDER BY id
+`
+
+func (q *Queries) ListWebhooksByUser(ctx context.Context, userID int64) ([]Webhook, error) {
+	rows, err := q.query(ctx, q.listWebhooksByUserStmt, listWebhooksByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.CreatedAt,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markUserVerified = `-- name: MarkUserVerified :execrows
+UPDATE users
+SET verified_at = CURRENT_TIMESTAMP, verification_token = NULL
+WHERE id = ? AND verified_at IS NULL
+`
+
+func (q *Queries) MarkUserVerified(ctx context.Context, id int64) (int64, error) {
+	result, err := q.exec(ctx, q.markUserVerifiedStmt, markUserVerified, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markVerificationReminded = `-- name: MarkVerificationReminded :exec
+UPDATE users SET verification_reminded_at = ? WHERE id = ?
+`
+
+type MarkVerificationRemindedParams struct {
+	VerificationRemindedAt sql.NullTime
+	ID                     int64
+}
+
+func (q *Queries) MarkVerificationReminded(ctx context.Context, arg MarkVerificationRemindedParams) error {
+	_, err := q.exec(ctx, q.markVerificationRemindedStmt, markVerificationReminded, arg.VerificationRemindedAt, arg.ID)
+	return err
+}
+
+const qualifyReferral = `-- name: QualifyReferral :exec
+UPDATE referrals SET status = 'qualified', reward_bytes = ?, qualified_at = ?
+WHERE id = ? AND status = 'pending'
+`
+
+type QualifyReferralParams struct {
+	RewardBytes int64
+	QualifiedAt sql.NullTime
+	ID          int64
+}
+
+func (q *Queries) QualifyReferral(ctx context.Context, arg QualifyReferralParams) error {
+	_, err := q.exec(ctx, q.qualifyReferralStmt, qualifyReferral, arg.RewardBytes, arg.QualifiedAt, arg.ID)
+	return err
+}
+
+const recordUserSighting = `-- name: RecordUserSighting :exec
+INSERT INTO user_sightings (user_id, ip, device) VALUES (?, ?, ?)
+ON CONFLICT(user_id, ip, device) DO UPDATE SET last_seen_at = CURRENT_TIMESTAMP
+`
+
+type RecordUserSightingParams struct {
+	UserID int64
+	Ip     string
+	Device string
+}
+
+func (q *Queries) RecordUserSighting(ctx context.Context, arg RecordUserSightingParams) error {
+	_, err := q.exec(ctx, q.recordUserSightingStmt, recordUserSighting, arg.UserID, arg.Ip, arg.Device)
+	return err
+}
+
+const retryJob = `-- name: RetryJob :execrows
+UPDATE jobs SET status = 'pending', attempts = 0, error = NULL, run_at = ?
+WHERE id = ? AND status = 'failed'
+`
+
+type RetryJobParams struct {
+	RunAt time.Time
+	ID    int64
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) (int64, error) {
+	result, err := q.exec(ctx, q.retryJobStmt, retryJob, arg.RunAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rollUpAnalyticsCounts = `-- name: RollUpAnalyticsCounts :exec
+INSERT INTO analytics_counts (day, name, path, referrer, count)
+SELECT CAST(date(created_at) AS TEXT) AS day, name, path, referrer, COUNT(*) FROM analytics_events
+WHERE true
+GROUP BY day, name, path, referrer
+ON CONFLICT(day, name, path, referrer) DO UPDATE SET count = excluded.count
+`
+
+func (q *Queries) RollUpAnalyticsCounts(ctx context.Context) error {
+	_, err := q.exec(ctx, q.rollUpAnalyticsCountsStmt, rollUpAnalyticsCounts)
+	return err
+}
+
+const rollUpAnalyticsDays = `-- name: RollUpAnalyticsDays :exec
+INSERT INTO analytics_days (day, views, visitors)
+SELECT CAST(date(created_at) AS TEXT) AS day, COUNT(*), COUNT(DISTINCT visitor) FROM analytics_events
+WHERE name = 'pageview'
+GROUP BY day
+ON CONFLICT(day) DO UPDATE SET views = excluded.views, visitors = excluded.visitors
+`
+
+func (q *Queries) RollUpAnalyticsDays(ctx context.Context) error {
+	_, err := q.exec(ctx, q.rollUpAnalyticsDaysStmt, rollUpAnalyticsDays)
+	return err
+}
+
+const rollUpExperiment = `-- name: RollUpExperiment :exec
+INSERT INTO analytics_experiments (day, experiment, variant, visitors, conversions)
+SELECT CAST(date(e.created_at) AS TEXT) AS day, e.experiment, e.variant, COUNT(DISTINCT e.visitor), COUNT(DISTINCT g.visitor) FROM analytics_events e
+LEFT JOIN analytics_events g ON g.visitor = e.visitor AND g.name = ? AND date(g.created_at) = date(e.created_at) AND g.created_at >= e.created_at
+WHERE e.name = 'exposure' AND e.experiment = ?
+GROUP BY day, e.experiment, e.variant
+ON CONFLICT(day, experiment, variant) DO UPDATE SET visitors = excluded.visitors, conversions = excluded.conversions
+`
+
+type RollUpExperimentParams struct {
+	Name       string
+	Experiment string
+}
+
+func (q *Queries) RollUpExperiment(ctx context.Context, arg RollUpExperimentParams) error {
+	_, err := q.exec(ctx, q.rollUpExperimentStmt, rollUpExperiment, arg.Name, arg.Experiment)
+	return err
+}
+
+const saveDraft = `-- name: SaveDraft :exec
+INSERT INTO drafts (user_id, form, body, updated_at) VALUES (?, ?, ?, ?)
+ON CONFLICT(user_id, form) DO UPDATE SET body = excluded.body, updated_at = excluded.updated_at
+`
+
+type SaveDraftParams struct {
+	UserID    int64
+	Form      string
+	Body      string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SaveDraft(ctx context.Context, arg SaveDraftParams) error {
+	_, err := q.exec(ctx, q.saveDraftStmt, saveDraft,
+		arg.UserID,
+		arg.Form,
+		arg.Body,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const setUploadStatus = `-- name: SetUploadStatus :exec
+UPDATE uploads SET status = ?, status_reason = ?, reviewed_at = ? WHERE id = ?
+`
+
+type SetUploadStatusParams struct {
+	Status       string
+	StatusReason sql.NullString
+	ReviewedAt   sql.NullTime
+	ID           string
+}
+
+func (q *Queries) SetUploadStatus(ctx context.Context, arg SetUploadStatusParams) error {
+	_, err := q.exec(ctx, q.setUploadStatusStmt, setUploadStatus,
+		arg.Status,
+		arg.StatusReason,
+		arg.ReviewedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const setUserAvatar = `-- name: SetUserAvatar :exec
+UPDATE users SET avatar = ? WHERE id = ?
+`
+
+type SetUserAvatarParams struct {
+	Avatar sql.NullString
+	ID     int64
+}
+
+func (q *Queries) SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) error {
+	_, err := q.exec(ctx, q.setUserAvatarStmt, setUserAvatar, arg.Avatar, arg.ID)
+	return err
+}
+
+const setUserLocale = `-- name: SetUserLocale :exec
+UPDATE users SET locale = ? WHERE id = ?
+`
+
+type SetUserLocaleParams struct {
+	Locale string
+	ID     int64
+}
+
+func (q *Queries) SetUserLocale(ctx context.Context, arg SetUserLocaleParams) error {
+	_, err := q.exec(ctx, q.setUserLocaleStmt, setUserLocale, arg.Locale, arg.ID)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users SET password_hash = ? WHERE id = ?
+`
+
+type SetUserPasswordParams struct {
+	PasswordHash string
+	ID           int64
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.exec(ctx, q.setUserPasswordStmt, setUserPassword, arg.PasswordHash, arg.ID)
+	return err
+}
+
+const touchAPIToken = `-- name: TouchAPIToken :exec
+UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+func (q *Queries) TouchAPIToken(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.touchAPITokenStmt, touchAPIToken, id)
+	return err
+}
+
+const updateFeatureFlag = `-- name: UpdateFeatureFlag :execrows
+UPDATE feature_flags SET enabled = ?, rollout_percent = ?, updated_at = CURRENT_TIMESTAMP
+WHERE name = ?
+`
+
+type UpdateFeatureFlagParams struct {
+	Enabled        bool
+	RolloutPercent int64
+	Name           string
+}
+
+func (q *Queries) UpdateFeatureFlag(ctx context.Context, arg UpdateFeatureFlagParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateFeatureFlagStmt, updateFeatureFlag, arg.Enabled, arg.RolloutPercent, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateInboundEvent = `-- name: UpdateInboundEvent :exec
+UPDATE inbound_events
+SET status = ?, attempts = ?, error = ?, processed_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateInboundEventParams struct {
+	Status   string
+	Attempts int64
+	Error    sql.NullString
+	ID       int64
+}
+
+func (q *Queries) UpdateInboundEvent(ctx context.Context, arg UpdateInboundEventParams) error {
+	_, err := q.exec(ctx, q.updateInboundEventStmt, updateInboundEvent,
+		arg.Status,
+		arg.Attempts,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const updateMessageIfVersion = `-- name: UpdateMessageIfVersion :execrows
+INSERT INTO guestbook (id, message)
+VALUES (1, ?)
+ON CONFLICT(id) DO UPDATE SET message = excluded.message, version = guestbook.version + 1
+WHERE guestbook.version = ?
+`
+
+type UpdateMessageIfVersionParams struct {
+	Message string
+	Version int64
+}
+
+func (q *Queries) UpdateMessageIfVersion(ctx context.Context, arg UpdateMessageIfVersionParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateMessageIfVersionStmt, updateMessageIfVersion, arg.Message, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateWebhookDelivery = `-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = ?, response_code = ?, error = ?, next_attempt_at = ?
+WHERE id = ?
+`
+
+type UpdateWebhookDeliveryParams struct {
+	Status        string
+	Attempts      int64
+	ResponseCode  sql.NullInt64
+	Error         sql.NullString
+	NextAttemptAt time.Time
+	ID            int64
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error {
+	_, err := q.exec(ctx, q.updateWebhookDeliveryStmt, updateWebhookDelivery,
+		arg.Status,
+		arg.Attempts,
+		arg.ResponseCode,
+		arg.Error,
+		arg.NextAttemptAt,
+		arg.ID,
+	)
+	return err
+}
+
+const upsertEmailTemplate = `-- name: UpsertEmailTemplate :exec
+INSERT INTO email_templates (name, subject, body, updated_at) VALUES (?, ?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET subject = excluded.subject, body = excluded.body, updated_at = excluded.updated_at
+`
+
+type UpsertEmailTemplateParams struct {
+	Name      string
+	Subject   string
+	Body      string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpsertEmailTemplate(ctx context.Context, arg UpsertEmailTemplateParams) error {
+	_, err := q.exec(ctx, q.upsertEmailTemplateStmt, upsertEmailTemplate,
+		arg.Name,
+		arg.Subject,
+		arg.Body,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const upsertFeatureFlagOverride = `-- name: UpsertFeatureFlagOverride :exec
+INSERT INTO feature_flag_overrides (flag_name, user_id, enabled)
+VALUES (?, ?, ?)
+ON CONFLICT(flag_name, user_id) DO UPDATE SET enabled = excluded.enabled
+`
+
+type UpsertFeatureFlagOverrideParams struct {
+	FlagName string
+	UserID   int64
+	Enabled  bool
+}
+
+func (q *Queries) UpsertFeatureFlagOverride(ctx context.Context, arg UpsertFeatureFlagOverrideParams) error {
+	_, err := q.exec(ctx, q.upsertFeatureFlagOverrideStmt, upsertFeatureFlagOverride, arg.FlagName, arg.UserID, arg.Enabled)
+	return err
+}
+
+const upsertJobSchedule = `-- name: UpsertJobSchedule :exec
+INSERT INTO job_schedules (name, spec, next_run_at)
+VALUES (?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET spec = excluded.spec, next_run_at = excluded.next_run_at
+WHERE job_schedules.spec != excluded.spec
+`
+
+type UpsertJobScheduleParams struct {
+	Name      string
+	Spec      string
+	NextRunAt time.Time
+}
+
+func (q *Queries) UpsertJobSchedule(ctx context.Context, arg UpsertJobScheduleParams) error {
+	_, err := q.exec(ctx, q.upsertJobScheduleStmt, upsertJobSchedule, arg.Name, arg.Spec, arg.NextRunAt)
+	return err
+}
+
+const upsertMessage = `-- name: UpsertMessage :exec
+INSERT INTO guestbook (id, message) 
+VALUES (1, ?)
+ON CONFLICT(id) DO UPDATE SET message = excluded.message, version = guestbook.version + 1
+`
+
+func (q *Queries) UpsertMessage(ctx context.Context, message string) error {
+	_, err := q.exec(ctx, q.upsertMessageStmt, upsertMessage, message)
+	return err
+}
+
+const upsertRateLimit = `-- name: UpsertRateLimit :exec
+INSERT INTO rate_limits (key, tokens, updated_at)
+VALUES (?, ?, ?)
+ON CONFLICT(key) DO UPDATE SET tokens = excluded.tokens, updated_at = excluded.updated_at
+`
+
+type UpsertRateLimitParams struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpsertRateLimit(ctx context.Context, arg UpsertRateLimitParams) error {
+	_, err := q.exec(ctx, q.upsertRateLimitStmt, upsertRateLimit, arg.Key, arg.Tokens, arg.UpdatedAt)
+	return err
+}
+
+const upsertSession = `-- name: UpsertSession :exec
+INSERT INTO sessions (token_hash, user_id, data, expiry)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(token_hash) DO UPDATE SET
+    user_id = excluded.user_id, data = excluded.data, expiry = excluded.expiry, updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertSessionParams struct {
+	TokenHash string
+	UserID    sql.NullInt64
+	Data      []byte
+	Expiry    time.Time
+}
+
+func (q *Queries) UpsertSession(ctx context.Context, arg UpsertSessionParams) error {
+	_, err := q.exec(ctx, q.upsertSessionStmt, upsertSession,
+		arg.TokenHash,
+		arg.UserID,
+		arg.Data,
+		arg.Expiry,
+	)
+	return err
+}
+
+const upsertSetting = `-- name: UpsertSetting :exec
+INSERT INTO settings (key, value) VALUES (?, ?)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertSettingParams struct {
+	Key   string
+	Value string
+}
+
+func (q *Queries) UpsertSetting(ctx context.Context, arg UpsertSettingParams) error {
+	_, err := q.exec(ctx, q.upsertSettingStmt, upsertSetting, arg.Key, arg.Value)
+	return err
+}
+
+const upsertShadowMessage = `-- name: UpsertShadowMessage :exec
+INSERT INTO shadow_messages (user_id, message) VALUES (?, ?)
+ON CONFLICT(user_id) DO UPDATE SET message = excluded.message, updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertShadowMessageParams struct {
+	UserID  int64
+	Message string
+}
+
+func (q *Queries) UpsertShadowMessage(ctx context.Context, arg UpsertShadowMessageParams) error {
+	_, err := q.exec(ctx, q.upsertShadowMessageStmt, upsertShadowMessage, arg.UserID, arg.Message)
 	return err
 }
 
@@ -153,7 +4061,7 @@ RETURNING id
 `
 
 func (q *Queries) VerifyUser(ctx context.Context, verificationToken sql.NullString) (int64, error) {
-	row := q.db.QueryRowContext(ctx, verifyUser, verificationToken)
+	row := q.queryRow(ctx, q.verifyUserStmt, verifyUser, verificationToken)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
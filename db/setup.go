@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
@@ -8,35 +9,50 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-
-	_ "modernc.org/sqlite"
 )
 
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
 func Setup(dataDir, dbName string) (*sql.DB, *Queries, error) {
-	// Check if the data directory is writable by creating a temporary file.
-	// This provides a clearer error message than the cryptic SQLite one.
-	tmpFile, err := os.Create(filepath.Join(dataDir, ".writable"))
+	dbConn, err := Open(dataDir, dbName)
 	if err != nil {
-		return nil, nil, fmt.Errorf("the data directory ('%s') is not writable. Please check permissions. Original error: %w", dataDir, err)
+		return nil, nil, err
 	}
-	tmpFile.Close()
-	os.Remove(tmpFile.Name())
 
-	// Initialize Database
-	dbConn, err := sql.Open("sqlite", filepath.Join(dataDir, dbName))
-	if err != nil {
-		return nil, nil, fmt.Errorf("error opening database: %w", err)
+	if err := runMigrations(dbConn); err != nil {
+		dbConn.Close()
+		return nil, nil, err
 	}
 
-	if err := runMigrations(dbConn); err != nil {
+	// Hot queries are parsed once instead of on every request
+	queries, err := Prepare(context.Background(), dbConn)
+	if err != nil {
 		dbConn.Close()
 		return nil, nil, err
 	}
 
-	return dbConn, New(dbConn), nil
+	return dbConn, queries, nil
+}
+
+// Open opens the database in dataDir without migrating it.
+func Open(dataDir, dbName string) (*sql.DB, error) {
+	// Check if the data directory is writable by creating a temporary file.
+	// This provides a clearer error message than the cryptic SQLite one.
+	tmpFile, err := os.Create(filepath.Join(dataDir, ".writable"))
+	if err != nil {
+		return nil, fmt.Errorf("the data directory ('%s') is not writable. Please check permissions. Original error: %w", dataDir, err)
+	}
+	tmpFile.Close()
+	os.Remove(tmpFile.Name())
+
+	// Initialize Database. Background workers write concurrently with
+	// requests, so wait for locks instead of failing with SQLITE_BUSY.
+	// Foreign keys are on for every connection, so deleting a user deletes
+	// everything that belongs to them. Queries are timed, see Stats.
+	dbConn := sql.OpenDB(connector{dsn: filepath.Join(dataDir, dbName) + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"})
+	Stats.db = dbConn
+	return dbConn, nil
 }
 
 func runMigrations(dbConn *sql.DB) error {
@@ -48,29 +64,19 @@ func runMigrations(dbConn *sql.DB) error {
 		return fmt.Errorf("error creating schema_migrations: %w", err)
 	}
 
-	var currentVersion int
-	if err := dbConn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&currentVersion); err != nil {
-		return fmt.Errorf("error getting current version: %w", err)
+	currentVersion, err := Version(dbConn)
+	if err != nil {
+		return err
 	}
 
-	// Run migrations
-	entries, err := migrationsFS.ReadDir("migrations")
+	migrations, err := listMigrations()
 	if err != nil {
-		return fmt.Errorf("error reading migrations: %w", err)
+		return err
 	}
-	for _, entry := range entries {
-		parts := strings.Split(entry.Name(), "_")
-		if len(parts) == 0 {
-			continue
-		}
-		version, err := strconv.Atoi(parts[0])
-		if err != nil {
-			continue
-		}
-
-		if version > currentVersion {
-			fmt.Printf("Running migration %s...\n", entry.Name())
-			content, _ := migrationsFS.ReadFile("migrations/" + entry.Name())
+	for _, m := range migrations {
+		if m.version > currentVersion {
+			fmt.Printf("Running migration %s...\n", m.name)
+			content, _ := migrationsFS.ReadFile("migrations/" + m.name)
 
 			tx, err := dbConn.Begin()
 			if err != nil {
@@ -78,9 +84,9 @@ func runMigrations(dbConn *sql.DB) error {
 			}
 			if _, err := tx.Exec(string(content)); err != nil {
 				tx.Rollback()
-				return fmt.Errorf("error running migration %s: %w", entry.Name(), err)
+				return fmt.Errorf("error running migration %s: %w", m.name, err)
 			}
-			if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+			if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", m.version); err != nil {
 				tx.Rollback()
 				return fmt.Errorf("error updating schema_migrations: %w", err)
 			}
@@ -91,3 +97,73 @@ func runMigrations(dbConn *sql.DB) error {
 	}
 	return nil
 }
+
+type migration struct {
+	name    string
+	version int
+}
+
+// listMigrations returns the embedded migrations in order.
+func listMigrations() ([]migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+	var migrations []migration
+	for _, entry := range entries {
+		parts := strings.Split(entry.Name(), "_")
+		if len(parts) == 0 {
+			continue
+		}
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		migrations = append(migrations, migration{entry.Name(), version})
+	}
+	return migrations, nil
+}
+
+// Latest returns the version of the newest migration in this binary.
+func Latest() int {
+	migrations, err := listMigrations()
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].version
+}
+
+// Pending returns the names of the migrations Setup would apply to dbConn.
+func Pending(dbConn *sql.DB) ([]string, error) {
+	current := 0
+	var exists bool
+	if err := dbConn.QueryRow("SELECT COUNT(*) > 0 FROM sqlite_schema WHERE name = 'schema_migrations'").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists {
+		var err error
+		if current, err = Version(dbConn); err != nil {
+			return nil, err
+		}
+	}
+	migrations, err := listMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, m := range migrations {
+		if m.version > current {
+			pending = append(pending, m.name)
+		}
+	}
+	return pending, nil
+}
+
+// Version returns the latest migration applied to dbConn.
+func Version(dbConn *sql.DB) (int, error) {
+	var version int
+	if err := dbConn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("error getting current version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"gighub/db"
+)
+
+const dbUsage = `Usage: gighub db shell
+       gighub db query "SQL"`
+
+// foreignKeys is run when the sqlite3 shell opens, so rows deleted by hand
+// take their dependent rows with them as they do in the app.
+const foreignKeys = "PRAGMA foreign_keys = ON"
+
+// dbCommand opens the configured database for poking at by hand: a shell,
+// or one statement for scripts.
+func dbCommand(fs *flag.FlagSet, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, dbUsage)
+		return errUsage
+	}
+	action, args := args[0], args[1:]
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	switch {
+	case action == "shell" && fs.NArg() == 0:
+	case action == "query" && fs.NArg() == 1:
+	default:
+		fmt.Fprintln(os.Stderr, dbUsage)
+		return errUsage
+	}
+
+	// Opening a missing file would create an empty database
+	path := filepath.Join(cfg.DataDir, cfg.DBName)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("no database at %s, run gighub migrate to create it: %w", path, err)
+	}
+	dbConn, err := db.Open(cfg.DataDir, cfg.DBName)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	// The sqlite3 shell has line editing and every dot command, so use it
+	// when it's installed
+	if bin, err := exec.LookPath("sqlite3"); err == nil && action == "shell" {
+		return sqliteShell(bin, path)
+	}
+
+	ctx := context.Background()
+	conn, err := dbConn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if action == "query" {
+		return runQuery(ctx, conn, fs.Arg(0), os.Stdout)
+	}
+	fmt.Printf("Connected to %s. Enter .help for help.\n", path)
+	return shell(ctx, conn, os.Stdin, os.Stdout)
+}
+
+func sqliteShell(bin, path string) error {
+	// Wait for the server's writes like the app does, instead of failing
+	cmd := exec.Command(bin, "-header", "-column", "-cmd", ".timeout 5000", "-cmd", foreignKeys, path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// shell is a minimal stand-in for the sqlite3 shell: statements end with
+// a semicolon, and .tables and .schema list what's there.
+func shell(ctx context.Context, conn *sql.Conn, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	var stmt strings.Builder
+	prompt := func() {
+		if stmt.Len() == 0 {
+			fmt.Fprint(out, "gighub> ")
+		} else {
+			fmt.Fprint(out, "   ...> ")
+		}
+	}
+	for prompt(); scanner.Scan(); prompt() {
+		line := strings.TrimSpace(scanner.Text())
+		if stmt.Len() == 0 && strings.HasPrefix(line, ".") {
+			if !dotCommand(ctx, conn, line, out) {
+				return nil
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		stmt.WriteString(line + "\n")
+		if !strings.HasSuffix(line, ";") {
+			continue
+		}
+		if err := runQuery(ctx, conn, stmt.String(), out); err != nil {
+			fmt.Fprintln(out, "Error:", err)
+		}
+		stmt.Reset()
+	}
+	fmt.Fprintln(out)
+	return scanner.Err()
+}
+
+// dotCommand runs one of the shell's own commands, and reports whether
+// the shell should go on.
+func dotCommand(ctx context.Context, conn *sql.Conn, line string, out io.Writer) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	var err error
+	switch name {
+	case ".quit", ".exit":
+		return false
+	case ".tables":
+		err = runQuery(ctx, conn, "SELECT name FROM sqlite_schema WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name", out)
+	case ".schema":
+		err = printSchema(ctx, conn, arg, out)
+	case ".help":
+		fmt.Fprintln(out, ".tables          list tables")
+		fmt.Fprintln(out, ".schema [TABLE]  show CREATE statements")
+		fmt.Fprintln(out, ".quit            exit")
+		fmt.Fprintln(out, "Statements run once they end with a semicolon.")
+	default:
+		fmt.Fprintf(out, "Unknown command %s, enter .help for help\n", name)
+	}
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+	}
+	return true
+}
+
+func printSchema(ctx context.Context, conn *sql.Conn, table string, out io.Writer) error {
+	rows, err := conn.QueryContext(ctx, `SELECT sql FROM sqlite_schema
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND (?1 = '' OR tbl_name = ?1)
+		ORDER BY tbl_name, type DESC, name`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, stmt+";")
+	}
+	return rows.Err()
+}
+
+// runQuery runs one statement and prints the rows it returns as a table,
+// or how many rows it changed.
+func runQuery(ctx context.Context, conn *sql.Conn, query string, out io.Writer) error {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(cols) == 0 {
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		var changed int64
+		if err := conn.QueryRowContext(ctx, "SELECT changes()").Scan(&changed); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%d rows changed\n", changed)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(cols, "\t")+"\t")
+	values := make([]sql.NullString, len(cols))
+	dest := make([]any, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			fields[i] = "NULL"
+			if v.Valid {
+				fields[i] = v.String
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(fields, "\t")+"\t")
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	tw.Flush()
+	fmt.Fprintf(out, "(%d rows)\n", n)
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gighub/config"
+	"gighub/db"
+	"gighub/health"
+)
+
+// finding is one line of the doctor's report. Hint says what to do about
+// a warning or failure.
+type finding struct {
+	status string // "ok", "warn" or "fail"
+	check  string
+	detail string
+	hint   string
+}
+
+// doctor checks the configuration and what it points at, so a deploy
+// doesn't find out about a typo in SMTP_PASS from the first signup.
+func doctor(fs *flag.FlagSet, args []string) error {
+	load := config.Flags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	var report []finding
+	cfg, err := load()
+	if err != nil {
+		report = append(report, finding{"fail", "configuration", err.Error(), "fix the variables above in the environment or .env"})
+	} else {
+		mode := "development"
+		if cfg.Production() {
+			mode = "production"
+		}
+		report = append(report, finding{"ok", "configuration", "loaded for " + mode, ""})
+		report = append(report, checkDatabase(cfg)...)
+		report = append(report, checkSMTP(cfg))
+		report = append(report, checkOAuth(cfg))
+		report = append(report, checkApple(cfg))
+	}
+
+	failed := false
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range report {
+		// Multi-line details, like configuration errors, are indented under
+		// their check
+		detail := strings.ReplaceAll(f.detail, "\n", "\n\t\t")
+		fmt.Fprintf(out, "%s\t%s\t%s\n", f.status, f.check, detail)
+		if f.hint != "" {
+			fmt.Fprintf(out, "\t\t-> %s\n", f.hint)
+		}
+		failed = failed || f.status == "fail"
+	}
+	out.Flush()
+	if failed {
+		return errors.New("some checks failed")
+	}
+	return nil
+}
+
+// checkDatabase checks the data directory and which migrations the next
+// start will apply, without creating or migrating the database.
+func checkDatabase(cfg *config.Config) []finding {
+	path := filepath.Join(cfg.DataDir, cfg.DBName)
+	dbConn, err := db.Open(cfg.DataDir, cfg.DBName)
+	if err != nil {
+		return []finding{{"fail", "data directory", err.Error(), "create DATA_DIR and make it writable by the user gighub runs as"}}
+	}
+	defer dbConn.Close()
+	report := []finding{{"ok", "data directory", cfg.DataDir + " is writable", ""}}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return append(report, finding{"warn", "migrations", "no database at " + path + " yet", "gighub serve or gighub migrate will create it"})
+	}
+	pending, err := db.Pending(dbConn)
+	switch {
+	case err != nil:
+		report = append(report, finding{"fail", "migrations", err.Error(), "check that " + path + " is a gighub database"})
+	case len(pending) > 0:
+		report = append(report, finding{"warn", "migrations", fmt.Sprintf("%d pending: %s", len(pending), strings.Join(pending, ", ")),
+			"they run on the next start; back up first with gighub backup"})
+	default:
+		report = append(report, finding{"ok", "migrations", "the database is up to date", ""})
+	}
+	return report
+}
+
+// checkSMTP logs in to the mail server without sending anything.
+func checkSMTP(cfg *config.Config) finding {
+	if !cfg.SMTP.Enabled() {
+		return finding{"warn", "smtp", "not configured, so no verification emails are sent", "set the SMTP_* variables"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server := cfg.SMTP.Host + ":" + cfg.SMTP.Port
+	check := health.SMTPLogin(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.User, cfg.SMTP.Pass)
+	if err := check.Run(ctx); err != nil {
+		return finding{"fail", "smtp", server + ": " + err.Error(), "check SMTP_HOST, SMTP_PORT, SMTP_USER and SMTP_PASS, and that outgoing connections to the port are allowed"}
+	}
+	return finding{"ok", "smtp", "logged in to " + server, ""}
+}
+
+// checkOAuth checks that Google can redirect back to the callback URL
+// built from BASE_URL.
+func checkOAuth(cfg *config.Config) finding {
+	if cfg.Google.ClientID == "" {
+		return finding{"ok", "google login", "disabled", ""}
+	}
+	callback := cfg.BaseURL + "/auth/google/callback"
+	u, err := url.Parse(callback)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return finding{"fail", "google login", "BASE_URL " + cfg.BaseURL + " is not an http(s) URL", "set BASE_URL to the site's public URL, like https://gighub.example"}
+	}
+	if cfg.Production() {
+		if u.Scheme != "https" {
+			return finding{"fail", "google login", "the callback " + callback + " is not HTTPS", "Google only redirects to HTTPS outside localhost; fix BASE_URL"}
+		}
+		if host := u.Hostname(); host == "localhost" || host == "127.0.0.1" {
+			return finding{"fail", "google login", "the callback " + callback + " is on localhost", "set BASE_URL to the site's public URL"}
+		}
+	}
+	return finding{"ok", "google login", "callback " + callback, "make sure it's listed under Authorized redirect URIs in the Google Cloud console"}
+}
+
+// checkApple checks the Sign in with Apple callback, which Apple only
+// accepts over HTTPS on a public domain, in development too.
+func checkApple(cfg *config.Config) finding {
+	if !cfg.Apple.Enabled() {
+		return finding{"ok", "apple login", "disabled", ""}
+	}
+	callback := cfg.BaseURL + "/auth/apple/callback"
+	u, err := url.Parse(callback)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return finding{"fail", "apple login", "the callback " + callback + " is not HTTPS", "set BASE_URL to the site's public HTTPS URL"}
+	}
+	if host := u.Hostname(); host == "localhost" || net.ParseIP(host) != nil {
+		return finding{"fail", "apple login", "the callback " + callback + " is not on a domain", "Apple doesn't redirect to localhost or IP addresses; use a tunnel with a domain for development"}
+	}
+	return finding{"ok", "apple login", "callback " + callback, "make sure it's listed under Return URLs for the Services ID " + cfg.Apple.ClientID}
+}
@@ -0,0 +1,257 @@
+// Package emails holds the wording of the transactional emails. Admins can
+// rewrite a template at /admin/emails/templates; until they do, or after
+// they reset it, the built-in wording is sent.
+package emails
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"gighub/db"
+	"gighub/report"
+	"gighub/settings"
+)
+
+// Names of the templates.
+const (
+	Verify         = "verify"
+	VerifyReminder = "verify-reminder"
+	AppealAccepted = "appeal-accepted"
+	AppealRejected = "appeal-rejected"
+)
+
+// Var is a placeholder a template can use, written like {{.Link}}.
+// Example is what previews and test sends fill it with.
+type Var struct {
+	Name        string
+	Description string
+	Example     string
+}
+
+// Template is the wording of an email. Subject and Body are text/template
+// sources over the template's Vars.
+type Template struct {
+	Name        string
+	Description string
+	Vars        []Var
+	Subject     string
+	Body        string
+}
+
+// siteName can be used in every template.
+var siteName = Var{"SiteName", "The site name from the settings", "gighub"}
+
+// Builtin are the templates with their default wording.
+var Builtin = []Template{
+	{
+		Name:        Verify,
+		Description: "Sent on signup, to confirm the address.",
+		Vars:        []Var{{"Link", "The link that verifies the address", "https://gighub.example/verify?token=abc123"}},
+		Subject:     "Verify your email",
+		Body:        "Please verify your email by clicking here: {{.Link}}",
+	},
+	{
+		Name:        VerifyReminder,
+		Description: "Sent once to accounts still unverified after UNVERIFIED_REMIND_AFTER.",
+		Vars: []Var{
+			{"Link", "The link that verifies the address", "https://gighub.example/verify?token=abc123"},
+			{"Deadline", "The day the account is deleted unless it's verified", "March 14"},
+		},
+		Subject: "Reminder: verify your email",
+		Body:    "You signed up but haven't verified your email yet. Verify it here: {{.Link}}\n\nIf you don't, the account will be deleted after {{.Deadline}}.",
+	},
+	{
+		Name:        AppealAccepted,
+		Description: "Sent when an admin accepts the appeal of a ban.",
+		Vars:        []Var{{"LoginURL", "The login page", "https://gighub.example/login"}},
+		Subject:     "Your appeal was accepted",
+		Body:        "Your account is no longer banned, and you can log in again: {{.LoginURL}}",
+	},
+	{
+		Name:        AppealRejected,
+		Description: "Sent when an admin rejects the appeal of a ban.",
+		Subject:     "Your appeal was rejected",
+		Body:        "We looked at your appeal, and the ban on your account stays in place.",
+	},
+}
+
+func init() {
+	for _, t := range Builtin {
+		if err := t.Validate(); err != nil {
+			panic(fmt.Sprintf("emails: built-in template %s: %v", t.Name, err))
+		}
+	}
+}
+
+// Lookup returns the built-in template with the given name.
+func Lookup(name string) (Template, bool) {
+	for _, t := range Builtin {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// AllVars returns the template's placeholders, SiteName included.
+func (t Template) AllVars() []Var {
+	return append([]Var{siteName}, t.Vars...)
+}
+
+// Examples maps each placeholder to its example value.
+func (t Template) Examples() map[string]string {
+	vars := map[string]string{}
+	for _, v := range t.AllVars() {
+		vars[v.Name] = v.Example
+	}
+	return vars
+}
+
+// Validate checks that the wording can be sent: there is a subject and a
+// body, and they only use the template's placeholders.
+func (t Template) Validate() error {
+	if strings.TrimSpace(t.Subject) == "" || strings.TrimSpace(t.Body) == "" {
+		return errors.New("the subject and body can't be empty")
+	}
+	if strings.ContainsAny(t.Subject, "\r\n") {
+		return errors.New("the subject must be one line")
+	}
+	_, _, err := t.Render(t.Examples())
+	return err
+}
+
+// Render fills in the placeholders. The subject is collapsed to one line,
+// since it goes in a header.
+func (t Template) Render(vars map[string]string) (subject, body string, err error) {
+	if subject, err = execute(t.Subject, vars); err != nil {
+		return "", "", fmt.Errorf("subject: %w", err)
+	}
+	if body, err = execute(t.Body, vars); err != nil {
+		return "", "", fmt.Errorf("body: %w", err)
+	}
+	return strings.Join(strings.Fields(subject), " "), body, nil
+}
+
+func execute(src string, vars map[string]string) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Store keeps the wording admins gave templates in the email_templates
+// table.
+type Store struct {
+	Queries *db.Queries
+	// Settings provides SiteName, when set.
+	Settings *settings.Store
+	Reporter report.Reporter
+}
+
+func New(queries *db.Queries, set *settings.Store) *Store {
+	return &Store{Queries: queries, Settings: set, Reporter: report.Log{}}
+}
+
+// Get returns the named template with the wording in use, and when admins
+// last changed it; the time is zero for the built-in wording.
+func (s *Store) Get(ctx context.Context, name string) (Template, time.Time, error) {
+	t, ok := Lookup(name)
+	if !ok {
+		return Template{}, time.Time{}, fmt.Errorf("unknown email template %q", name)
+	}
+	row, err := s.Queries.GetEmailTemplate(ctx, name)
+	if err == sql.ErrNoRows {
+		return t, time.Time{}, nil
+	} else if err != nil {
+		return t, time.Time{}, fmt.Errorf("error loading email template %s: %w", name, err)
+	}
+	t.Subject, t.Body = row.Subject, row.Body
+	return t, row.UpdatedAt, nil
+}
+
+// List returns every template with the wording in use, and when admins
+// changed those they did, by name.
+func (s *Store) List(ctx context.Context) ([]Template, map[string]time.Time, error) {
+	rows, err := s.Queries.ListEmailTemplates(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading email templates: %w", err)
+	}
+	custom := map[string]db.EmailTemplate{}
+	for _, row := range rows {
+		custom[row.Name] = row
+	}
+	list := make([]Template, len(Builtin))
+	changed := map[string]time.Time{}
+	for i, t := range Builtin {
+		if row, ok := custom[t.Name]; ok {
+			t.Subject, t.Body = row.Subject, row.Body
+			changed[t.Name] = row.UpdatedAt
+		}
+		list[i] = t
+	}
+	return list, changed, nil
+}
+
+// Save validates and stores new wording for a template.
+func (s *Store) Save(ctx context.Context, t Template) error {
+	if _, ok := Lookup(t.Name); !ok {
+		return fmt.Errorf("unknown email template %q", t.Name)
+	}
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	return s.Queries.UpsertEmailTemplate(ctx, db.UpsertEmailTemplateParams{
+		Name:      t.Name,
+		Subject:   t.Subject,
+		Body:      t.Body,
+		UpdatedAt: time.Now().UTC(),
+	})
+}
+
+// Reset goes back to the built-in wording.
+func (s *Store) Reset(ctx context.Context, name string) error {
+	return s.Queries.DeleteEmailTemplate(ctx, name)
+}
+
+// Render fills in the named template, with SiteName added to vars. When
+// the admins' wording can't be loaded or no longer renders, say because a
+// placeholder was dropped since, the error is reported and the built-in
+// wording is sent instead.
+func (s *Store) Render(ctx context.Context, name string, vars map[string]string) (subject, body string) {
+	builtin, ok := Lookup(name)
+	if !ok {
+		panic("emails: unknown template " + name)
+	}
+	all := map[string]string{siteName.Name: siteName.Example}
+	if s.Settings != nil {
+		if set, err := s.Settings.Get(ctx); err == nil {
+			all[siteName.Name] = set.SiteName
+		}
+	}
+	for k, v := range vars {
+		all[k] = v
+	}
+	t, _, err := s.Get(ctx, name)
+	if err == nil {
+		if subject, body, err = t.Render(all); err == nil {
+			return subject, body
+		}
+		err = fmt.Errorf("rendering email template %s: %w", name, err)
+	}
+	s.Reporter.Error(ctx, err)
+	subject, body, err = builtin.Render(all)
+	if err != nil {
+		panic(fmt.Sprintf("emails: built-in template %s: %v", name, err))
+	}
+	return subject, body
+}
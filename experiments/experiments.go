@@ -0,0 +1,128 @@
+// Package experiments runs A/B tests. Each request is shown one variant
+// of an experiment, picked from the user or visitor ID so it stays the
+// same, and the analytics compare how many of the visitors shown each
+// variant go on to reach the experiment's goal.
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"gighub/analytics"
+	"gighub/db"
+)
+
+// Experiment compares variants of something by its goal.
+type Experiment struct {
+	Name string
+	// Variants are what's compared. The first is the control, what was
+	// shown before the experiment.
+	Variants []string
+	// Goal is the analytics event that counts as a conversion, when it
+	// happens the same day the visitor was shown a variant.
+	Goal string
+}
+
+// All are the experiments running. They are defined here, next to the
+// code that shows their variants. Removing one ends it, and its results
+// stay in the analytics.
+var All = []Experiment{
+	// Whether a heading that says signing up is free gets more signups
+	{Name: "signup-heading", Variants: []string{"control", "free"}, Goal: analytics.Signup},
+}
+
+// Assign picks id's variant of e. The same id always gets the same one,
+// and hashing the experiment's name in spreads ids differently for each.
+func Assign(e Experiment, id string) string {
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + ":" + id))
+	return e.Variants[h.Sum32()%uint32(len(e.Variants))]
+}
+
+type contextKey struct{}
+
+type assignment struct {
+	id     string
+	expose func(experiment, variant string)
+
+	mu      sync.Mutex
+	exposed map[string]bool
+}
+
+// NewContext returns a context whose variants are assigned by id, like
+// "user:42". expose is called the first time each experiment's variant
+// is read.
+func NewContext(ctx context.Context, id string, expose func(experiment, variant string)) context.Context {
+	return context.WithValue(ctx, contextKey{}, &assignment{id: id, expose: expose, exposed: map[string]bool{}})
+}
+
+// Variant returns the variant of the named experiment for the current
+// request, and records that it was shown. Unknown experiments and
+// contexts without an ID get "". Pages that show a variant aren't put in
+// the page cache, see Exposed, so every showing is recorded.
+func Variant(ctx context.Context, name string) string {
+	a, _ := ctx.Value(contextKey{}).(*assignment)
+	if a == nil {
+		return ""
+	}
+	for _, e := range All {
+		if e.Name != name {
+			continue
+		}
+		variant := Assign(e, a.id)
+		a.mu.Lock()
+		first := !a.exposed[name]
+		a.exposed[name] = true
+		a.mu.Unlock()
+		if first {
+			a.expose(name, variant)
+		}
+		return variant
+	}
+	return ""
+}
+
+// Variants lists the request's variant of every experiment, like
+// "signup-heading=free", without recording them as shown. The page cache
+// keeps pages apart by it.
+func Variants(ctx context.Context) string {
+	a, _ := ctx.Value(contextKey{}).(*assignment)
+	if a == nil {
+		return ""
+	}
+	var b strings.Builder
+	for i, e := range All {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(e.Name + "=" + Assign(e, a.id))
+	}
+	return b.String()
+}
+
+// Exposed reports whether the request was shown a variant of any
+// experiment.
+func Exposed(ctx context.Context) bool {
+	a, _ := ctx.Value(contextKey{}).(*assignment)
+	if a == nil {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.exposed) > 0
+}
+
+// RollUp counts the visitors shown each variant, and those that reached
+// the goal, from the raw analytics events. It runs before the analytics
+// roll-up deletes them.
+func RollUp(ctx context.Context, queries *db.Queries) error {
+	for _, e := range All {
+		if err := queries.RollUpExperiment(ctx, db.RollUpExperimentParams{Name: e.Goal, Experiment: e.Name}); err != nil {
+			return fmt.Errorf("error rolling up experiment %s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Package flags decides which features are on for a request, so risky
+// changes can ship dark and be rolled out gradually.
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"gighub/db"
+)
+
+// cacheTTL is how long flag definitions are reused before they are read
+// again. Changes made through the Store show up immediately.
+const cacheTTL = 30 * time.Second
+
+// Set holds the flags that are on for one request.
+type Set map[string]bool
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the evaluated flags.
+func NewContext(ctx context.Context, set Set) context.Context {
+	return context.WithValue(ctx, contextKey{}, set)
+}
+
+// Enabled reports whether the named flag is on for the current request.
+// Unknown flags and contexts without flags are off.
+func Enabled(ctx context.Context, name string) bool {
+	set, _ := ctx.Value(contextKey{}).(Set)
+	return set[name]
+}
+
+// Store evaluates flags from the feature_flags table.
+type Store struct {
+	Queries *db.Queries
+
+	mu     sync.Mutex
+	flags  []db.FeatureFlag
+	loaded time.Time
+}
+
+func New(queries *db.Queries) *Store {
+	return &Store{Queries: queries}
+}
+
+// Evaluate works out which flags are on for a user. A per-user override
+// wins; otherwise an enabled flag is on for RolloutPercent of users,
+// picked by a stable hash so each user keeps the same answer as the
+// percentage grows. Logged-out visitors (userID 0) only see flags rolled
+// out to everyone.
+func (s *Store) Evaluate(ctx context.Context, userID int64) (Set, error) {
+	all, err := s.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	set := Set{}
+	for _, f := range all {
+		if f.Enabled && (f.RolloutPercent >= 100 || (userID != 0 && bucket(f.Name, userID) < f.RolloutPercent)) {
+			set[f.Name] = true
+		}
+	}
+	if userID == 0 || len(all) == 0 {
+		return set, nil
+	}
+	overrides, err := s.Queries.ListFeatureFlagOverridesForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading flag overrides: %w", err)
+	}
+	for _, o := range overrides {
+		set[o.FlagName] = o.Enabled
+	}
+	return set, nil
+}
+
+// Invalidate drops the cached definitions after they were changed.
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	s.flags = nil
+	s.mu.Unlock()
+}
+
+func (s *Store) list(ctx context.Context) ([]db.FeatureFlag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.flags != nil && time.Since(s.loaded) < cacheTTL {
+		return s.flags, nil
+	}
+	all, err := s.Queries.ListFeatureFlags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading feature flags: %w", err)
+	}
+	if all == nil {
+		all = []db.FeatureFlag{}
+	}
+	s.flags, s.loaded = all, time.Now()
+	return all, nil
+}
+
+// bucket places a user in 0-99 for a flag. Hashing the flag name in means
+// the same users aren't always first in line for every rollout.
+func bucket(name string, userID int64) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + strconv.FormatInt(userID, 10)))
+	return int64(h.Sum32() % 100)
+}
@@ -0,0 +1,223 @@
+// Package health probes the services the site depends on, keeps a history
+// of the results for the status page and raises an alert when a check keeps
+// failing.
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"time"
+
+	"gighub/db"
+)
+
+// checkTimeout bounds a single probe.
+const checkTimeout = 10 * time.Second
+
+// Check probes one dependency. Run returns nil when it is healthy.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Monitor runs the checks and records the results.
+type Monitor struct {
+	Queries *db.Queries
+	Checks  []Check
+
+	// AlertAfter is how many failures in a row trigger an alert.
+	AlertAfter int
+	// Alert is called once when a check reaches AlertAfter failures in a
+	// row, and again with a nil error when it passes after that.
+	Alert func(ctx context.Context, name string, err error)
+}
+
+// RunChecks probes every dependency once and stores the results.
+func (m *Monitor) RunChecks(ctx context.Context) error {
+	for _, c := range m.Checks {
+		start := time.Now()
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := c.Run(checkCtx)
+		cancel()
+
+		if err := m.Queries.CreateHealthCheck(ctx, db.CreateHealthCheckParams{
+			Name:       c.Name,
+			Ok:         err == nil,
+			Error:      sql.NullString{String: errString(err), Valid: err != nil},
+			DurationMs: time.Since(start).Milliseconds(),
+			CheckedAt:  start.UTC(),
+		}); err != nil {
+			return fmt.Errorf("error recording %s check: %w", c.Name, err)
+		}
+		if err := m.maybeAlert(ctx, c.Name, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maybeAlert looks at the run just recorded and the ones before it to see
+// whether the check has just crossed the alert threshold or recovered
+// after crossing it.
+func (m *Monitor) maybeAlert(ctx context.Context, name string, err error) error {
+	if m.Alert == nil || m.AlertAfter <= 0 {
+		return nil
+	}
+	history, qerr := m.Queries.ListHealthCheckHistory(ctx, db.ListHealthCheckHistoryParams{
+		Name:  name,
+		Limit: int64(m.AlertAfter) + 1,
+	})
+	if qerr != nil {
+		return fmt.Errorf("error loading %s check history: %w", name, qerr)
+	}
+	if len(history) == 0 {
+		return nil
+	}
+	// history[0] is the run just recorded. Count the failures in a row
+	// before it, up to AlertAfter.
+	failing := 0
+	for _, h := range history[1:] {
+		if h.Ok {
+			break
+		}
+		failing++
+	}
+	switch {
+	case err != nil && failing == m.AlertAfter-1:
+		m.Alert(ctx, name, err)
+	case err == nil && failing >= m.AlertAfter:
+		m.Alert(ctx, name, nil)
+	}
+	return nil
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Database checks that the database answers a query.
+func Database(conn *sql.DB) Check {
+	return Check{Name: "database", Run: func(ctx context.Context) error {
+		var one int
+		return conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	}}
+}
+
+// SMTP checks that the mail server accepts a connection and greets us.
+func SMTP(host, port string) Check {
+	return Check{Name: "smtp", Run: func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		c, err := smtp.NewClient(conn, host)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		defer c.Close()
+		if err := c.Hello("localhost"); err != nil {
+			return err
+		}
+		return c.Quit()
+	}}
+}
+
+// SMTPLogin goes further than SMTP and logs in, over STARTTLS when the
+// server offers it as sending mail does, to catch wrong credentials.
+func SMTPLogin(host, port, user, pass string) Check {
+	return Check{Name: "smtp", Run: func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		c, err := smtp.NewClient(conn, host)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		defer c.Close()
+		if err := c.Hello("localhost"); err != nil {
+			return err
+		}
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return fmt.Errorf("STARTTLS: %w", err)
+			}
+		}
+		if err := c.Auth(smtp.PlainAuth("", user, pass, host)); err != nil {
+			return fmt.Errorf("login: %w", err)
+		}
+		return c.Quit()
+	}}
+}
+
+// HTTP checks that a URL answers with a 2xx or 3xx status.
+func HTTP(name, url string) Check {
+	client := &http.Client{
+		// A redirect is an answer; don't follow it
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", "gighub-health")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("%s responded with %s", url, resp.Status)
+		}
+		return nil
+	}}
+}
+
+// minJobRuns is how many jobs must have finished before their failure
+// rate is judged. One failure out of two says little.
+const minJobRuns = 5
+
+// JobFailures checks that no more than maxRate of the jobs include picks
+// by name failed within window. Failed attempts that will be retried
+// count too, so trouble shows before the jobs give up.
+func JobFailures(name string, queries *db.Queries, include func(job string) bool, window time.Duration, maxRate float64) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		outcomes, err := queries.ListJobOutcomes(ctx, sql.NullTime{Time: time.Now().Add(-window).UTC(), Valid: true})
+		if err != nil {
+			return err
+		}
+		var finished, failing int64
+		for _, o := range outcomes {
+			if include(o.Name) {
+				finished += o.Finished
+				failing += o.Failing
+			}
+		}
+		if finished < minJobRuns {
+			return nil
+		}
+		if rate := float64(failing) / float64(finished); rate > maxRate {
+			return fmt.Errorf("%d of %d failed in the last %s (%.0f%%, more than %.0f%%)", failing, finished, window, rate*100, maxRate*100)
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,234 @@
+// Package imageproxy fetches images from allow-listed external hosts,
+// scales them down and serves them from our own origin, so pages never
+// hotlink third-party content and visitors' addresses aren't leaked to it.
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/imaging"
+	"gighub/keys"
+	"gighub/storage"
+	"gighub/utils"
+)
+
+// Widths are the sizes images are scaled to. URL rounds up to one of them,
+// which bounds how many copies of an image end up cached.
+var Widths = []int{160, 320, 640, 1280}
+
+const (
+	// maxSize bounds the fetched file.
+	maxSize = 10 << 20
+	// maxPixels bounds decoded images so a small file can't claim to be huge.
+	maxPixels = 6000 * 6000
+)
+
+var errNotAllowed = errors.New("host not allowed")
+
+// Proxy serves images by signed URL. Only URLs made by URL are served, and
+// only for Hosts, so it can't be used as an open proxy.
+type Proxy struct {
+	// Hosts lists the hosts images may come from. "*.example.com" allows
+	// every subdomain of example.com.
+	// Connections to addresses that aren't public are refused, so an
+	// allowed host can't point the proxy at internal services.
+	Hosts []string
+	// BaseURL is where Proxy is mounted.
+	BaseURL string
+	Keys    keys.Ring
+	// Store caches scaled images.
+	Store  storage.Store
+	Client *http.Client
+}
+
+func New(hosts []string, baseURL string, ring keys.Ring, store storage.Store) *Proxy {
+	p := &Proxy{Hosts: hosts, BaseURL: baseURL, Keys: ring, Store: store}
+	p.Client = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{Timeout: 5 * time.Second, Control: utils.PublicOnly}).DialContext,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("too many redirects")
+			}
+			if !p.allowed(req.URL) {
+				return errNotAllowed
+			}
+			return nil
+		},
+	}
+	return p
+}
+
+func (p *Proxy) allowed(u *url.URL) bool {
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range p.Hosts {
+		h = strings.ToLower(h)
+		if host == h {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(h, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether src is an image URL on one of Hosts, which URL
+// can link to.
+func (p *Proxy) Allowed(src string) bool {
+	u, err := url.Parse(src)
+	return err == nil && p.allowed(u)
+}
+
+// URL links to src scaled to at least width pixels across.
+func (p *Proxy) URL(src string, width int) string {
+	w := Widths[len(Widths)-1]
+	for _, candidate := range Widths {
+		if candidate >= width {
+			w = candidate
+			break
+		}
+	}
+	q := url.Values{
+		"url": {src},
+		"w":   {strconv.Itoa(w)},
+		"sig": {p.Keys.Sign([]byte(src + "\n" + strconv.Itoa(w)))},
+	}
+	return p.BaseURL + "?" + q.Encode()
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	src := r.URL.Query().Get("url")
+	width, err := strconv.Atoi(r.URL.Query().Get("w"))
+	if err != nil || !validWidth(width) || !p.Keys.Verify([]byte(src+"\n"+strconv.Itoa(width)), r.URL.Query().Get("sig")) {
+		http.Error(w, "Invalid image link", http.StatusForbidden)
+		return
+	}
+	u, err := url.Parse(src)
+	if err != nil || !p.allowed(u) {
+		http.Error(w, "Image host not allowed", http.StatusForbidden)
+		return
+	}
+
+	sum := sha256.Sum256([]byte(src))
+	prefix := "imageproxy/" + hex.EncodeToString(sum[:16]) + "/" + strconv.Itoa(width)
+	for _, ext := range []string{".jpg", ".png"} {
+		if f, obj, err := p.Store.Open(r.Context(), prefix+ext); err == nil {
+			defer f.Close()
+			serve(w, obj.ContentType, f)
+			return
+		}
+	}
+
+	data, contentType, err := p.fetch(r.Context(), u.String(), width)
+	if err != nil {
+		log.Printf("Error proxying image %s: %v", src, err)
+		http.Error(w, "Image unavailable", http.StatusBadGateway)
+		return
+	}
+	ext := ".jpg"
+	if contentType == "image/png" {
+		ext = ".png"
+	}
+	if err := p.Store.Put(r.Context(), prefix+ext, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		log.Printf("Error caching proxied image: %v", err)
+	}
+	serve(w, contentType, bytes.NewReader(data))
+}
+
+func serve(w http.ResponseWriter, contentType string, r io.Reader) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, r)
+}
+
+func validWidth(width int) bool {
+	for _, w := range Widths {
+		if w == width {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch downloads src and scales it to width. Images with transparency come
+// back as PNG, everything else as JPEG.
+func (p *Proxy) fetch(ctx context.Context, src string, width int) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "image/*")
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	if ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); !strings.HasPrefix(ct, "image/") {
+		return nil, "", fmt.Errorf("upstream sent %q", ct)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxSize {
+		return nil, "", fmt.Errorf("image is over %d bytes", maxSize)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", fmt.Errorf("image is %dx%d", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if b := img.Bounds(); b.Dx() > width {
+		img = imaging.Resize(img, width, max(b.Dy()*width/b.Dx(), 1))
+	}
+
+	var buf bytes.Buffer
+	if opaque(img) {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", err
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
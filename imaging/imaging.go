@@ -0,0 +1,42 @@
+// Package imaging holds the image operations shared by avatars and the
+// image proxy.
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+// Resize scales img to w by h by averaging the source pixels behind each
+// output pixel, which keeps downscaled photos smooth.
+func Resize(img image.Image, w, h int) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := span(y, h, b.Dy())
+		for x := 0; x < w; x++ {
+			x0, x1 := span(x, w, b.Dx())
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(img.At(b.Min.X+sx, b.Min.Y+sy)).(color.NRGBA)
+					r, g, bl, a = r+uint64(c.R), g+uint64(c.G), bl+uint64(c.B), a+uint64(c.A)
+					n++
+				}
+			}
+			out.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: uint8(a / n)})
+		}
+	}
+	return out
+}
+
+// span returns the source pixel range behind output pixel i, at least one
+// pixel wide.
+func span(i, size, src int) (int, int) {
+	start := i * src / size
+	end := (i + 1) * src / size
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, tt := range []struct {
+		spec string
+		ok   bool
+	}{
+		{"* * * * *", true},
+		{"0 0 * * *", true},
+		{"*/15 9-17 * * 1-5", true},
+		{"0,30 * 1,15 * *", true},
+		{"5-55/10 * * * *", true},
+		{"10/20 * * * *", true},
+		{"0 0 * * 7", true},
+		{"59 23 31 12 6", true},
+		{"0 0 31 2 *", true}, // parses; Cron refuses it since it never matches
+		{"@hourly", true},
+		{"@daily", true},
+		{"@weekly", true},
+		{"@monthly", true},
+		{"@yearly", true},
+
+		{"", false},
+		{"* * * *", false},
+		{"* * * * * *", false},
+		{"@reboot", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * 32 * *", false},
+		{"* * * 0 *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"-1 * * * *", false},
+		{"5-1 * * * *", false},
+		{"*/0 * * * *", false},
+		{"*/x * * * *", false},
+		{"a * * * *", false},
+		{"1-x * * * *", false},
+		{"1,,2 * * * *", false},
+		{"MON * * * *", false},
+	} {
+		if _, err := ParseCron(tt.spec); (err == nil) != tt.ok {
+			t.Errorf("ParseCron(%q) = %v, want ok %v", tt.spec, err, tt.ok)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2025, 1, 15, 10, 30, 45, 0, time.UTC)
+	for _, tt := range []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", from, time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"@hourly", from, time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", from, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", from, time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", from, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", from, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", from, time.Date(2025, 1, 16, 10, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", from, time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2025, 1, 17, 18, 0, 0, 0, time.UTC), time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC)},
+		// 7 is Sunday too
+		{"0 0 * * 7", from, time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matching is enough
+		{"0 0 20 * 5", from, time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 16 * 0", from, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		// Only day of month restricted, not the week
+		{"0 0 31 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"59 23 31 12 *", time.Date(2025, 12, 31, 23, 59, 0, 0, time.UTC), time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC)},
+		// Times in other zones are compared in UTC
+		{"0 12 * * *", time.Date(2025, 1, 15, 11, 0, 0, 0, time.FixedZone("CET", 3600)), time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)},
+
+		// Never
+		{"0 0 31 2 *", from, time.Time{}},
+		{"0 0 30 2 *", from, time.Time{}},
+		{"0 0 31 4,6,9,11 *", from, time.Time{}},
+	} {
+		s, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q after %v: got %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+	s := New(nil)
+	if err := s.Cron("never", "0 0 31 2 *"); err == nil {
+		t.Error("Cron accepted a spec that never matches")
+	}
+	if err := s.Cron("leap-day", "0 0 29 2 *"); err != nil {
+		t.Errorf("Cron refused Feb 29: %v", err)
+	}
+	if err := s.Cron("bad", "0 0 * *"); err == nil {
+		t.Error("Cron accepted a spec that doesn't parse")
+	}
+	if len(s.schedules) != 1 {
+		t.Errorf("got %d schedules, want 1", len(s.schedules))
+	}
+}
@@ -0,0 +1,260 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"gighub/db"
+	"gighub/report"
+)
+
+// lockTimeout is how long a worker holds a job. A job still marked running
+// after that is assumed abandoned (say, the process died) and runs again, so
+// handlers must be safe to repeat.
+const lockTimeout = 10 * time.Minute
+
+// defaultMaxAttempts is how many times a failing job is tried.
+const defaultMaxAttempts = 5
+
+// Handler runs one job.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type schedule struct {
+	name     string
+	spec     string
+	schedule Schedule
+}
+
+// Scheduler runs jobs stored in the jobs table with a pool of workers and
+// enqueues jobs on cron schedules. Several processes can share a database:
+// claiming a job and advancing a schedule are single conditional updates,
+// so each run is picked up by one worker.
+type Scheduler struct {
+	Queries  *db.Queries
+	Workers  int
+	Reporter report.Reporter
+
+	handlers  map[string]Handler
+	schedules []schedule
+	wake      chan struct{}
+}
+
+func New(queries *db.Queries) *Scheduler {
+	return &Scheduler{
+		Queries:  queries,
+		Workers:  2,
+		Reporter: report.Log{},
+		handlers: map[string]Handler{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler for jobs with the given name.
+func (s *Scheduler) Handle(name string, h Handler) {
+	s.handlers[name] = h
+}
+
+// RunNow runs the handler for name right away in the calling goroutine,
+// skipping the queue, for running a job by hand.
+func (s *Scheduler) RunNow(ctx context.Context, name string, payload json.RawMessage) error {
+	h, ok := s.handlers[name]
+	if !ok {
+		return fmt.Errorf("no handler for job %q", name)
+	}
+	ctx = report.NewContext(ctx, nil)
+	report.SetTag(ctx, "job", name)
+	return h(ctx, payload)
+}
+
+// Names returns the names of the registered handlers, sorted.
+func (s *Scheduler) Names() []string {
+	return slices.Sorted(maps.Keys(s.handlers))
+}
+
+// Cron enqueues a job with the given name whenever spec matches. The
+// job's handler receives an empty payload. Specs that never match, like
+// "0 0 31 2 *", are refused.
+func (s *Scheduler) Cron(name, spec string) error {
+	sched, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	if sched.Next(time.Now()).IsZero() {
+		return fmt.Errorf("cron expression %q never matches", spec)
+	}
+	s.schedules = append(s.schedules, schedule{name: name, spec: spec, schedule: sched})
+	return nil
+}
+
+// Enqueue stores a job to run at runAt, or as soon as possible if runAt
+// is zero.
+func (s *Scheduler) Enqueue(ctx context.Context, name string, payload any, runAt time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding job payload: %w", err)
+	}
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	if err := s.Queries.CreateJob(ctx, db.CreateJobParams{
+		Name:        name,
+		Payload:     string(data),
+		MaxAttempts: defaultMaxAttempts,
+		RunAt:       runAt.UTC(),
+	}); err != nil {
+		return fmt.Errorf("error enqueueing job: %w", err)
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run starts the workers and the cron loop and returns once ctx is
+// cancelled and running jobs have returned.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range s.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	s.runCron(ctx)
+	wg.Wait()
+}
+
+func (s *Scheduler) work(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		// Drain everything due before waiting again.
+		for ctx.Err() == nil && s.runNext(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// runNext claims and runs one due job, reporting whether there was one.
+func (s *Scheduler) runNext(ctx context.Context) bool {
+	now := time.Now().UTC()
+	job, err := s.Queries.ClaimJob(ctx, db.ClaimJobParams{
+		LockedUntil:   sql.NullTime{Time: now.Add(lockTimeout), Valid: true},
+		StartedAt:     sql.NullTime{Time: now, Valid: true},
+		RunAt:         now,
+		LockedUntil_2: sql.NullTime{Time: now, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error claiming job: %v", err)
+		return false
+	}
+
+	jobCtx, cancel := context.WithTimeout(report.NewContext(ctx, nil), lockTimeout)
+	defer cancel()
+	report.SetTag(jobCtx, "job", job.Name)
+	report.SetTag(jobCtx, "job_id", strconv.FormatInt(job.ID, 10))
+
+	err = fmt.Errorf("no handler for job %q", job.Name)
+	if h, ok := s.handlers[job.Name]; ok {
+		err = h(jobCtx, json.RawMessage(job.Payload))
+	}
+
+	finished := time.Now().UTC()
+	update := db.FinishJobParams{
+		ID:         job.ID,
+		Status:     "succeeded",
+		RunAt:      job.RunAt,
+		FinishedAt: sql.NullTime{Time: finished, Valid: true},
+	}
+	if err != nil {
+		update.Error = sql.NullString{String: err.Error(), Valid: true}
+		if job.Attempts >= job.MaxAttempts {
+			update.Status = "failed"
+			s.Reporter.Error(jobCtx, err)
+		} else {
+			update.Status = "pending"
+			update.RunAt = finished.Add(backoff(job.Attempts))
+		}
+	}
+	// Record the outcome even if shutdown cancelled ctx meanwhile.
+	if err := s.Queries.FinishJob(context.WithoutCancel(ctx), update); err != nil {
+		log.Printf("Error updating job %d: %v", job.ID, err)
+	}
+	return true
+}
+
+// runCron enqueues scheduled jobs as they come due until ctx is cancelled.
+func (s *Scheduler) runCron(ctx context.Context) {
+	if len(s.schedules) == 0 {
+		<-ctx.Done()
+		return
+	}
+	now := time.Now()
+	for _, sc := range s.schedules {
+		// Keeps the stored next run unless the expression changed.
+		if err := s.Queries.UpsertJobSchedule(ctx, db.UpsertJobScheduleParams{
+			Name:      sc.name,
+			Spec:      sc.spec,
+			NextRunAt: sc.schedule.Next(now),
+		}); err != nil {
+			log.Printf("Error registering schedule %s: %v", sc.name, err)
+		}
+	}
+
+	for {
+		s.enqueueDue(ctx)
+		// Wake just after the start of each minute.
+		wait := time.Until(time.Now().Truncate(time.Minute).Add(time.Minute + time.Second))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (s *Scheduler) enqueueDue(ctx context.Context) {
+	now := time.Now().UTC()
+	for _, sc := range s.schedules {
+		// Only the process that moves next_run_at forward enqueues the run.
+		// Runs missed while nothing was running collapse into one.
+		advanced, err := s.Queries.AdvanceJobSchedule(ctx, db.AdvanceJobScheduleParams{
+			NextRunAt:   sc.schedule.Next(now),
+			Name:        sc.name,
+			NextRunAt_2: now,
+		})
+		if err != nil {
+			log.Printf("Error advancing schedule %s: %v", sc.name, err)
+			continue
+		}
+		if advanced == 0 {
+			continue
+		}
+		if err := s.Enqueue(ctx, sc.name, struct{}{}, now); err != nil {
+			log.Printf("Error enqueueing scheduled job %s: %v", sc.name, err)
+		}
+	}
+}
+
+// backoff waits 1m, 4m, 16m, ... between attempts.
+func backoff(attempts int64) time.Duration {
+	return time.Minute << (2 * (attempts - 1))
+}
@@ -0,0 +1,107 @@
+// Package keys holds secrets that can be rotated. A ring setting lists
+// several keys with IDs, newest first: the first one is used for new
+// signatures and cookies, and the others are still accepted until they are
+// removed, so rotating a key doesn't invalidate everything issued under the
+// old one.
+package keys
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultID names a single secret, from a setting that holds one key
+// without an ID.
+const DefaultID = "default"
+
+var keyID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Key is one secret and the ID it is known by.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Ring is a list of keys, the current one first.
+type Ring []Key
+
+// Single makes a ring of one secret, taken whole: it may contain ':' and ','.
+// An empty secret makes an empty ring.
+func Single(secret string) Ring {
+	if secret == "" {
+		return nil
+	}
+	return Ring{{ID: DefaultID, Secret: []byte(secret)}}
+}
+
+// Parse reads a key list like "2024-06:newsecret,2024-01:oldsecret". Every
+// key needs an ID; to rotate away from a single secret, list it as
+// "default:oldsecret" so what it signed stays valid.
+func Parse(spec string) (Ring, error) {
+	var ring Ring
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(part, ":")
+		if !ok || !keyID.MatchString(id) {
+			return nil, fmt.Errorf("key %d needs an ID like \"2024-06:secret\"", len(ring)+1)
+		}
+		k := Key{ID: id, Secret: []byte(secret)}
+		if len(k.Secret) == 0 {
+			return nil, fmt.Errorf("key %q has no secret", k.ID)
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("key ID %q is used twice", k.ID)
+		}
+		seen[k.ID] = true
+		ring = append(ring, k)
+	}
+	return ring, nil
+}
+
+// Match returns the first key for which ok returns true, e.g. the one a
+// signature verifies under.
+func (r Ring) Match(ok func(secret []byte) bool) (Key, bool) {
+	for _, k := range r {
+		if ok(k.Secret) {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// Sign returns an HMAC of msg under the current key, prefixed with the
+// key's ID so Verify knows which key to check it with.
+func (r Ring) Sign(msg []byte) string {
+	if len(r) == 0 {
+		return ""
+	}
+	return r[0].ID + "." + mac(r[0].Secret, msg)
+}
+
+// Verify checks a signature made by Sign with any key still in the ring.
+func (r Ring) Verify(msg []byte, sig string) bool {
+	id, sum, ok := strings.Cut(sig, ".")
+	if !ok {
+		return false
+	}
+	for _, k := range r {
+		if k.ID == id {
+			return hmac.Equal([]byte(sum), []byte(mac(k.Secret, msg)))
+		}
+	}
+	return false
+}
+
+func mac(secret, msg []byte) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write(msg)
+	return hex.EncodeToString(h.Sum(nil))
+}
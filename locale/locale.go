@@ -0,0 +1,234 @@
+// Package locale formats dates, relative times, numbers and money for the
+// views in the reader's language. The locale comes from the user's setting,
+// or from Accept-Language when they haven't picked one.
+package locale
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Locale formats values for one language and region.
+type Locale struct {
+	// Code is the BCP 47 tag users pick, like "pt-BR".
+	Code string
+	// Name is the locale in its own language, for the picker.
+	Name string
+
+	tag     language.Tag
+	printer *message.Printer
+	format  format
+}
+
+// format holds what x/text doesn't cover: month names, the order of date
+// parts and the phrases of relative times.
+type format struct {
+	months [12]string
+	// date is a fmt pattern of the day (%[1]d), month (%[2]s) and year
+	// (%[3]d).
+	date    string
+	clock12 bool
+	// justNow, future and past phrase a relative time; the last two wrap
+	// an amount like "3 days".
+	justNow, future, past string
+	// units are the singular and plural of minute, hour, day, month and
+	// year.
+	units [5][2]string
+	// money is a fmt pattern of the symbol (%[1]s) and the amount (%[2]s).
+	money string
+}
+
+var formats = []struct {
+	code, name string
+	format
+}{
+	{"en-US", "English (US)", format{
+		months:  [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		date:    "%[2]s %[1]d, %[3]d",
+		clock12: true,
+		justNow: "just now", future: "in %s", past: "%s ago",
+		units: [5][2]string{{"minute", "minutes"}, {"hour", "hours"}, {"day", "days"}, {"month", "months"}, {"year", "years"}},
+		money: "%[1]s%[2]s",
+	}},
+	{"en-GB", "English (UK)", format{
+		months:  [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sept", "Oct", "Nov", "Dec"},
+		date:    "%[1]d %[2]s %[3]d",
+		justNow: "just now", future: "in %s", past: "%s ago",
+		units: [5][2]string{{"minute", "minutes"}, {"hour", "hours"}, {"day", "days"}, {"month", "months"}, {"year", "years"}},
+		money: "%[1]s%[2]s",
+	}},
+	{"es", "Español", format{
+		months:  [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		date:    "%[1]d %[2]s %[3]d",
+		justNow: "ahora", future: "dentro de %s", past: "hace %s",
+		units: [5][2]string{{"minuto", "minutos"}, {"hora", "horas"}, {"día", "días"}, {"mes", "meses"}, {"año", "años"}},
+		money: "%[2]s %[1]s",
+	}},
+	{"de", "Deutsch", format{
+		months:  [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		date:    "%[1]d. %[2]s %[3]d",
+		justNow: "gerade eben", future: "in %s", past: "vor %s",
+		units: [5][2]string{{"Minute", "Minuten"}, {"Stunde", "Stunden"}, {"Tag", "Tagen"}, {"Monat", "Monaten"}, {"Jahr", "Jahren"}},
+		money: "%[2]s %[1]s",
+	}},
+	{"fr", "Français", format{
+		months:  [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		date:    "%[1]d %[2]s %[3]d",
+		justNow: "à l’instant", future: "dans %s", past: "il y a %s",
+		units: [5][2]string{{"minute", "minutes"}, {"heure", "heures"}, {"jour", "jours"}, {"mois", "mois"}, {"an", "ans"}},
+		money: "%[2]s %[1]s",
+	}},
+	{"pt-BR", "Português (Brasil)", format{
+		months:  [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		date:    "%[1]d de %[2]s de %[3]d",
+		justNow: "agora", future: "em %s", past: "há %s",
+		units: [5][2]string{{"minuto", "minutos"}, {"hora", "horas"}, {"dia", "dias"}, {"mês", "meses"}, {"ano", "anos"}},
+		money: "%[1]s %[2]s",
+	}},
+}
+
+// Supported lists the locales users can pick, the default first.
+var Supported []Locale
+
+var matcher language.Matcher
+
+func init() {
+	tags := make([]language.Tag, len(formats))
+	for i, f := range formats {
+		tags[i] = language.MustParse(f.code)
+		Supported = append(Supported, Locale{
+			Code:    f.code,
+			Name:    f.name,
+			tag:     tags[i],
+			printer: message.NewPrinter(tags[i]),
+			format:  f.format,
+		})
+	}
+	matcher = language.NewMatcher(tags)
+}
+
+// Default is the locale used when nothing else matches.
+func Default() Locale {
+	return Supported[0]
+}
+
+// Parse returns the supported locale with the given code. ok is false for
+// codes that aren't supported, including "".
+func Parse(code string) (l Locale, ok bool) {
+	for _, l := range Supported {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return Default(), false
+}
+
+// Match picks the supported locale closest to an Accept-Language header,
+// so a "es-AR" reader gets "es" and a "pt-PT" one "pt-BR".
+func Match(acceptLanguage string) Locale {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default()
+	}
+	_, i, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default()
+	}
+	return Supported[i]
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the locale of the request.
+func NewContext(ctx context.Context, l Locale) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the locale of the request, or Default if the context
+// has none.
+func FromContext(ctx context.Context) Locale {
+	if l, ok := ctx.Value(contextKey{}).(Locale); ok {
+		return l
+	}
+	return Default()
+}
+
+// Tag is the language tag, for the lang attribute.
+func (l Locale) Tag() language.Tag {
+	return l.tag
+}
+
+// Date formats the day of t, like "Mar 4, 2026" or "4. März 2026".
+func (l Locale) Date(t time.Time) string {
+	return fmt.Sprintf(l.format.date, t.Day(), l.format.months[t.Month()-1], t.Year())
+}
+
+// DateTime formats t to the minute, like "Mar 4, 2026, 3:04 PM".
+func (l Locale) DateTime(t time.Time) string {
+	if l.format.clock12 {
+		return l.Date(t) + ", " + t.Format("3:04 PM")
+	}
+	return l.Date(t) + ", " + t.Format("15:04")
+}
+
+// Relative describes t from now, like "in 3 days" or "2 hours ago". Under
+// a minute away is "just now"; amounts are rounded down to the largest
+// whole unit.
+func (l Locale) Relative(t, now time.Time) string {
+	d := t.Sub(now)
+	phrase := l.format.future
+	if d < 0 {
+		d, phrase = -d, l.format.past
+	}
+	var n int64
+	var unit int
+	switch day := 24 * time.Hour; {
+	case d < time.Minute:
+		return l.format.justNow
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), 0
+	case d < day:
+		n, unit = int64(d/time.Hour), 1
+	case d < 30*day:
+		n, unit = int64(d/day), 2
+	case d < 365*day:
+		n, unit = int64(d/(30*day)), 3
+	default:
+		n, unit = int64(d/(365*day)), 4
+	}
+	name := l.format.units[unit][1]
+	if n == 1 {
+		name = l.format.units[unit][0]
+	}
+	return fmt.Sprintf(phrase, l.Number(n)+" "+name)
+}
+
+// Number formats a whole number with the locale's digit grouping.
+func (l Locale) Number(n int64) string {
+	return l.printer.Sprint(number.Decimal(n))
+}
+
+// Decimal formats f with the given number of fraction digits.
+func (l Locale) Decimal(f float64, digits int) string {
+	return l.printer.Sprint(number.Decimal(f, number.Scale(digits)))
+}
+
+// Money formats an amount given in the currency's minor unit (cents for
+// "USD", yen for "JPY"), like "$12.50" or "12,50 €". Unknown currency codes
+// are shown as they are.
+func (l Locale) Money(minor int64, code string) string {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return fmt.Sprintf(l.format.money, code, l.Number(minor))
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	amount := l.Decimal(float64(minor)/math.Pow10(scale), scale)
+	return fmt.Sprintf(l.format.money, l.printer.Sprint(currency.Symbol(unit)), amount)
+}
@@ -1,407 +1,82 @@
 package main
 
 import (
-	"context"
-	"crypto/rand"
-	"database/sql"
-	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
-	"net/smtp"
 	"os"
-	"time"
-
-	"gighub/db"
-	"gighub/utils"
-	"gighub/views"
-
-	"github.com/alexedwards/scs/v2"
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/gorilla/sessions"
-	"github.com/joelseq/sqliteadmin-go"
-	"github.com/joho/godotenv"
-	"github.com/justinas/nosurf"
-	"github.com/markbates/goth"
-	"github.com/markbates/goth/gothic"
-	"github.com/markbates/goth/providers/google"
-	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"text/tabwriter"
 )
 
-var sessionManager *scs.SessionManager
-
-func requireAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !sessionManager.Exists(r.Context(), "userID") {
-			http.Redirect(w, r, "/login", http.StatusSeeOther)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// command is a gighub subcommand. run gets the arguments after its name.
+type command struct {
+	name    string
+	summary string
+	run     func(fs *flag.FlagSet, args []string) error
 }
 
-func main() {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
-	}
-
-	// Initialize session manager
-	sessionManager = scs.New()
-	sessionManager.Lifetime = 24 * time.Hour
-	sessionManager.Cookie.Persist = true
-	sessionManager.Cookie.SameSite = http.SameSiteLaxMode
-	sessionManager.Cookie.Secure = os.Getenv("ENV") == "production"
-
-	// Configure Goth for Social Login
-	goth.UseProviders(
-		google.New(os.Getenv("GOOGLE_CLIENT_ID"), os.Getenv("GOOGLE_CLIENT_SECRET"), os.Getenv("BASE_URL")+"/auth/google/callback"),
-	)
-	gothic.GetProviderName = func(req *http.Request) (string, error) {
-		provider := chi.URLParam(req, "provider")
-		if provider == "" {
-			return "", fmt.Errorf("provider not found")
-		}
-		return provider, nil
-	}
-
-	// Configure Gothic session store
-	store := sessions.NewCookieStore([]byte(os.Getenv("SESSION_SECRET")))
-	store.MaxAge(86400 * 30)
-	store.Options.Path = "/"
-	store.Options.HttpOnly = true
-	store.Options.Secure = os.Getenv("ENV") == "production"
-	gothic.Store = store
-
-	// Initialize the router
-	r := chi.NewRouter()
-
-	// Use default middleware
-	// Logger: Logs the start and end of each request
-	// Recoverer: Recovers from panics and returns a 500 error instead of crashing
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(sessionManager.LoadAndSave)
-	r.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := context.WithValue(r.Context(), "isLoggedIn", sessionManager.Exists(r.Context(), "userID"))
-			ctx = context.WithValue(ctx, "csrf", nosurf.Token(r))
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
-	})
-
-	dbConn, queries, err := db.Setup("data", "gighub.db")
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer dbConn.Close()
-
-	// Define the route
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		views.Home().Render(r.Context(), w)
-	})
-
-	// Static pages
-	r.Get("/privacy-policy", func(w http.ResponseWriter, r *http.Request) {
-		views.PrivacyPolicy().Render(r.Context(), w)
-	})
+var commands = []command{
+	{"serve", "run the web server and background jobs (the default)", serve},
+	{"migrate", "apply pending database migrations and exit", migrate},
+	{"doctor", "check the configuration and what it points at before a deploy", doctor},
+	{"seed", "add a demo user and guestbook message for development", seed},
+	{"users", "list, create, verify and reset the passwords of users", users},
+	{"send-test-email", "send an email to check the SMTP settings", sendTestEmail},
+	{"db", "open a SQL shell on the database, or run one statement", dbCommand},
+	{"export", "write a table's rows as CSV or JSON", exportTable},
+	{"import", "insert rows from a CSV or JSON export, or check them with -dry-run", importTable},
+	{"backup", "copy the database to a file while it's in use", backup},
+	{"jobs", "list background jobs, or run one now with \"jobs run NAME\"", runJobs},
+	{"version", "print the commit, build time and Go version of this binary", version},
+	{"perf", "load-test a running instance", loadTest},
+}
 
-	r.Get("/terms", func(w http.ResponseWriter, r *http.Request) {
-		views.Terms().Render(r.Context(), w)
-	})
+// errUsage is returned by commands called with bad arguments, after
+// saying what was wrong.
+var errUsage = errors.New("usage error")
 
-	// Admin dashboard
-	adminConfig := sqliteadmin.Config{
-		DB:       dbConn,
-		Username: os.Getenv("SQLITEADMIN_USERNAME"),
-		Password: os.Getenv("SQLITEADMIN_PASSWORD"),
+func main() {
+	// Without a command gighub serves, so "gighub -port 8080" still works
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-	admin := sqliteadmin.New(adminConfig)
-	r.Options("/admin", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, X-Requested-With")
-		w.WriteHeader(http.StatusOK)
-	})
-	r.Post("/admin", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		admin.HandlePost(w, r)
-	})
-
-	// Guestbook routes
-	r.Group(func(r chi.Router) {
-		r.Use(requireAuth)
-		r.Get("/guestbook", func(w http.ResponseWriter, r *http.Request) {
-			msg, err := queries.GetMessage(r.Context())
-			if err != nil {
-				if err == sql.ErrNoRows {
-					msg = "Hello! Welcome to the guestbook."
-				} else {
-					http.Error(w, "Database error", http.StatusInternalServerError)
-					return
-				}
-			}
-			views.Guestbook(msg).Render(r.Context(), w)
-		})
-
-		r.Post("/guestbook", func(w http.ResponseWriter, r *http.Request) {
-			if err := r.ParseForm(); err != nil {
-				http.Error(w, "Invalid request", http.StatusBadRequest)
-				return
-			}
-			message := r.FormValue("message")
-			if err := queries.UpsertMessage(r.Context(), message); err != nil {
-				http.Error(w, "Database error", http.StatusInternalServerError)
-				return
-			}
-			http.Redirect(w, r, "/guestbook", http.StatusSeeOther)
-		})
-
-		r.Get("/account", func(w http.ResponseWriter, r *http.Request) {
-			userID := sessionManager.GetInt64(r.Context(), "userID")
-			log.Printf("User ID from session: %d", userID)
-			user, err := queries.GetUser(r.Context(), userID)
-			if err != nil {
-				http.Error(w, "Database error", http.StatusInternalServerError)
-				return
-			}
-			views.Account(user.Email).Render(r.Context(), w)
-		})
-	})
-
-	// Email test route
-	r.Get("/email", func(w http.ResponseWriter, r *http.Request) {
-		to := r.URL.Query().Get("to")
-		if err := sendEmail(to, "Test Email", "This is a test email from your Go app."); err != nil {
-			http.Error(w, "Failed to send email: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		w.Write([]byte("Email sent successfully to " + to))
-	})
-
-	// Social Auth Routes
-	r.Get("/auth/{provider}", func(w http.ResponseWriter, r *http.Request) {
-		gothic.BeginAuthHandler(w, r)
-	})
-
-	r.Get("/auth/{provider}/callback", func(w http.ResponseWriter, r *http.Request) {
-		gUser, err := gothic.CompleteUserAuth(w, r)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		// Check if user exists
-		user, err := queries.GetUserByEmail(r.Context(), gUser.Email)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				// Create new user with random password and token
-				pwBytes := make([]byte, 32)
-				rand.Read(pwBytes)
-				pwHash, _ := bcrypt.GenerateFromPassword(pwBytes, bcrypt.DefaultCost)
-
-				tokenBytes := make([]byte, 16)
-				rand.Read(tokenBytes)
-				token := hex.EncodeToString(tokenBytes)
-
-				user, err = queries.CreateUser(r.Context(), db.CreateUserParams{
-					Email:             gUser.Email,
-					PasswordHash:      string(pwHash),
-					VerificationToken: sql.NullString{String: token, Valid: true},
-				})
-				if err != nil {
-					http.Error(w, "Failed to create user", http.StatusInternalServerError)
-					return
-				}
-
-				// Mark as verified immediately since it's Google
-				queries.VerifyUser(r.Context(), sql.NullString{String: token, Valid: true})
-			} else {
-				http.Error(w, "Database error", http.StatusInternalServerError)
-				return
-			}
-		} else if !user.VerifiedAt.Valid {
-			// If user exists but wasn't verified, verify them now since we trust Google
-			queries.VerifyUser(r.Context(), user.VerificationToken)
-		}
-
-		// Log the user in
-		if err := sessionManager.RenewToken(r.Context()); err != nil {
-			http.Error(w, "Server error", http.StatusInternalServerError)
-			return
-		}
-		sessionManager.Put(r.Context(), "userID", user.ID)
-		http.Redirect(w, r, "/", http.StatusSeeOther)
-	})
-
-	// Auth routes
-	r.Get("/signup", func(w http.ResponseWriter, r *http.Request) {
-		views.Signup().Render(r.Context(), w)
-	})
-
-	r.Post("/signup", func(w http.ResponseWriter, r *http.Request) {
-		if err := r.ParseForm(); err != nil {
-			http.Error(w, "Invalid request", http.StatusBadRequest)
-			return
-		}
-		email := r.FormValue("email")
-		password := r.FormValue("password")
-
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-		if err != nil {
-			http.Error(w, "Server error", http.StatusInternalServerError)
-			return
-		}
-
-		// Generate verification token
-		tokenBytes := make([]byte, 16)
-		rand.Read(tokenBytes)
-		token := hex.EncodeToString(tokenBytes)
-
-		if _, err := queries.CreateUser(r.Context(), db.CreateUserParams{
-			Email:             email,
-			PasswordHash:      string(hashedPassword),
-			VerificationToken: sql.NullString{String: token, Valid: true},
-		}); err != nil {
-			log.Printf("Error creating user: %v", err)
-			http.Error(w, "Error creating user", http.StatusInternalServerError)
-			return
-		}
-
-		// Send verification email asynchronously
-		go func() {
-			baseURL := os.Getenv("BASE_URL")
-			link := fmt.Sprintf("%s/verify?token=%s", baseURL, token)
-			if err := sendEmail(email, "Verify your email", "Please verify your email by clicking here: "+link); err != nil {
-				log.Printf("Failed to send welcome email: %v", err)
-			}
-		}()
-
-		w.Write([]byte("User created! Please check your email to verify your account."))
-	})
-
-	r.Get("/login", func(w http.ResponseWriter, r *http.Request) {
-		views.Login().Render(r.Context(), w)
-	})
-
-	r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
-		if err := r.ParseForm(); err != nil {
-			http.Error(w, "Invalid request", http.StatusBadRequest)
-			return
-		}
-		email := r.FormValue("email")
-		password := r.FormValue("password")
-
-		user, err := queries.GetUserByEmail(r.Context(), email)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				http.Error(w, "Invalid email or password", http.StatusUnauthorized)
-			} else {
-				http.Error(w, "Database error", http.StatusInternalServerError)
-			}
-			return
-		}
-
-		if !user.VerifiedAt.Valid {
-			http.Error(w, "Please verify your email before logging in.", http.StatusUnauthorized)
-			return
-		}
-
-		err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
-		if err != nil {
-			http.Error(w, "Invalid email or password", http.StatusUnauthorized)
-			return
-		}
-
-		// Login successful
-		if err := sessionManager.RenewToken(r.Context()); err != nil {
-			http.Error(w, "Server error", http.StatusInternalServerError)
-			return
-		}
-		sessionManager.Put(r.Context(), "userID", user.ID)
-
-		http.Redirect(w, r, "/guestbook", http.StatusSeeOther)
-	})
-
-	r.Get("/logout", func(w http.ResponseWriter, r *http.Request) {
-		if err := sessionManager.Destroy(r.Context()); err != nil {
-			http.Error(w, "Server error", http.StatusInternalServerError)
-			return
-		}
-		// Redirect to home page after logout
-		http.Redirect(w, r, "/", http.StatusSeeOther)
-	})
-
-	r.Get("/verify", func(w http.ResponseWriter, r *http.Request) {
-		token := r.URL.Query().Get("token")
-		if token == "" {
-			http.Error(w, "Missing token", http.StatusBadRequest)
-			return
-		}
-
-		_, err := queries.VerifyUser(r.Context(), sql.NullString{String: token, Valid: true})
-		if err != nil {
-			if err == sql.ErrNoRows {
-				http.Error(w, "Invalid or expired token", http.StatusBadRequest)
-			} else {
-				log.Printf("Verification error: %v", err)
-				http.Error(w, "Server error", http.StatusInternalServerError)
-			}
-			return
-		}
-
-		w.Write([]byte("Email verified successfully! You can now login."))
-	})
-
-	// Route to display the application version (Git SHA)
-	r.Get("/version", func(w http.ResponseWriter, r *http.Request) {
-		gitSHA := os.Getenv("GITSHA")
-		if gitSHA == "" {
-			gitSHA = "local" // Default for local development
-		}
-		w.Header().Set("Content-Type", "text/plain")
-		w.Write([]byte(gitSHA))
-	})
-
-	// Serve static files from the ./assets directory
-	utils.FileServer(r, "/assets", http.Dir("./assets"))
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	if name == "help" {
+		usage(os.Stdout)
+		return
 	}
-
-	// Add CSRF protection middleware
-	csrfHandler := nosurf.New(r)
-	csrfHandler.ExemptPath("/admin")
-	csrfHandler.SetBaseCookie(http.Cookie{
-		HttpOnly: true,
-		Path:     "/",
-		Secure:   os.Getenv("ENV") == "production",
-	})
-
-	// Start the server
-	fmt.Printf("Server starting on port %s...\n", port)
-	err = http.ListenAndServe(":"+port, csrfHandler) // Wrap router with CSRF handler
-	if err != nil {
-		fmt.Printf("Error starting server: %s\n", err)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		fs := flag.NewFlagSet("gighub "+name, flag.ContinueOnError)
+		err := cmd.run(fs, args)
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+		case errors.Is(err, errUsage):
+			os.Exit(2)
+		default:
+			log.Fatal(err)
+		}
+		return
 	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
 }
 
-func sendEmail(to, subject, body string) error {
-	host := os.Getenv("SMTP_HOST")
-	port := os.Getenv("SMTP_PORT")
-	user := os.Getenv("SMTP_USER")
-	pass := os.Getenv("SMTP_PASS")
-	from := os.Getenv("SMTP_FROM")
-
-	if host == "" || port == "" || user == "" || pass == "" || from == "" {
-		return fmt.Errorf("SMTP environment variables are not set")
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gighub [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
 	}
-
-	auth := smtp.PlainAuth("", user, pass, host)
-	msg := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s", to, subject, body))
-
-	return smtp.SendMail(host+":"+port, auth, from, []string{to}, msg)
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "gighub COMMAND -h" for its flags.`)
 }
@@ -0,0 +1,80 @@
+package pagecache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is a Backend in this process's memory. Once it holds MaxEntries
+// values, expired ones are dropped, then any that expire at all.
+type Memory struct {
+	MaxEntries int
+
+	mu    sync.Mutex
+	items map[string]item
+}
+
+type item struct {
+	value   []byte
+	expires time.Time // zero for counters, which are kept for good
+}
+
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{MaxEntries: maxEntries, items: map[string]item{}}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	it, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !it.expires.IsZero() && time.Now().After(it.expires) {
+		delete(m.items, key)
+		return nil, false, nil
+	}
+	return it.value, true, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.items) >= m.MaxEntries {
+		m.evict()
+	}
+	it := item{value: value}
+	if ttl > 0 {
+		it.expires = time.Now().Add(ttl)
+	}
+	m.items[key] = it
+	return nil
+}
+
+func (m *Memory) Incr(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := parseCounter(m.items[key].value) + 1
+	m.items[key] = item{value: []byte(strconv.FormatInt(n, 10))}
+	return nil
+}
+
+// evict makes room for at least one more entry.
+func (m *Memory) evict() {
+	now := time.Now()
+	for k, it := range m.items {
+		if !it.expires.IsZero() && now.After(it.expires) {
+			delete(m.items, k)
+		}
+	}
+	for k, it := range m.items {
+		if len(m.items) < m.MaxEntries {
+			return
+		}
+		if !it.expires.IsZero() {
+			delete(m.items, k)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package pagecache keeps rendered public pages for anonymous visitors, so
+// a burst of traffic from a shared link is answered from the cache instead
+// of rendering every page again. Pages are grouped by tag, and writes that
+// change a page invalidate its tag.
+package pagecache
+
+import (
+	"bytes"
+	"context"
+	"expvar"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Backend stores cached pages. Memory keeps them in this process; Redis
+// shares them, and their invalidation, between processes.
+type Backend interface {
+	// Get returns the value at key, and false if there is none.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value at key for ttl, or for good if ttl is zero.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Incr adds one to the counter at key, creating it at zero first.
+	Incr(ctx context.Context, key string) error
+}
+
+// stats counts hits, misses and backend errors, published at /debug/vars.
+var stats = expvar.NewMap("pagecache")
+
+// Cache serves pages from a Backend.
+type Cache struct {
+	Backend Backend
+	TTL     time.Duration
+	// Anonymous reports whether a request may share cached pages with
+	// other visitors. Pages for logged-in users are never cached.
+	Anonymous func(*http.Request) bool
+	// Vary returns what pages depend on besides their URL, like the
+	// visitor's language. Pages are cached separately for each value.
+	Vary func(*http.Request) string
+	// Private reports, once a page is rendered, whether it was made for
+	// this visitor alone and must not be cached.
+	Private func(*http.Request) bool
+}
+
+// Page caches successful GET responses of the routes it wraps under tag.
+// Only the body and Content-Type are kept; cookies and other headers are
+// set afresh on every request by the middleware around it.
+func (c *Cache) Page(tag string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method != http.MethodGet && r.Method != http.MethodHead) || !c.Anonymous(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			vary := ""
+			if c.Vary != nil {
+				vary = c.Vary(r)
+			}
+			key, err := c.key(r.Context(), tag, vary, r.URL.RequestURI())
+			if err != nil {
+				c.failed(err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if entry, ok, err := c.Backend.Get(r.Context(), key); err != nil {
+				c.failed(err)
+			} else if ok {
+				if contentType, body, ok := bytes.Cut(entry, []byte("\n")); ok {
+					stats.Add("hits", 1)
+					w.Header().Set("Content-Type", string(contentType))
+					w.Header().Set("X-Cache", "HIT")
+					w.Write(body)
+					return
+				}
+			}
+			stats.Add("misses", 1)
+
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+			w.Header().Set("X-Cache", "MISS")
+			next.ServeHTTP(rec, r)
+			if rec.status == http.StatusOK && r.Method == http.MethodGet && (c.Private == nil || !c.Private(r)) {
+				entry := append([]byte(w.Header().Get("Content-Type")+"\n"), rec.body.Bytes()...)
+				if err := c.Backend.Set(r.Context(), key, entry, c.TTL); err != nil {
+					c.failed(err)
+				}
+			}
+		})
+	}
+}
+
+// Invalidate drops every page cached under tags. Entries aren't deleted;
+// bumping the tag's generation changes the keys pages are looked up by,
+// and the old entries expire on their own.
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) {
+	for _, tag := range tags {
+		if err := c.Backend.Incr(ctx, "gen:"+tag); err != nil {
+			c.failed(err)
+		}
+	}
+}
+
+func (c *Cache) key(ctx context.Context, tag, vary, uri string) (string, error) {
+	gen, ok, err := c.Backend.Get(ctx, "gen:"+tag)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		gen = []byte("0")
+	}
+	return "page:" + tag + ":" + string(gen) + ":" + vary + ":" + uri, nil
+}
+
+func (c *Cache) failed(err error) {
+	stats.Add("errors", 1)
+	log.Printf("Page cache error: %v", err)
+}
+
+// recorder passes a response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// parseCounter reads a counter stored by Incr.
+func parseCounter(b []byte) int64 {
+	n, _ := strconv.ParseInt(string(b), 10, 64)
+	return n
+}
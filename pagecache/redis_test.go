@@ -0,0 +1,327 @@
+package pagecache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewRedis(t *testing.T) {
+	for _, tt := range []struct {
+		url      string
+		addr     string
+		password string
+		db       int
+	}{
+		{"redis://localhost", "localhost:6379", "", 0},
+		{"redis://cache:6380", "cache:6380", "", 0},
+		{"redis://:secret@cache/2", "cache:6379", "secret", 2},
+		{"redis://user:secret@[::1]:7000/0", "[::1]:7000", "secret", 0},
+	} {
+		r, err := NewRedis(tt.url)
+		if err != nil {
+			t.Errorf("NewRedis(%s): %v", tt.url, err)
+			continue
+		}
+		if r.Addr != tt.addr || r.Password != tt.password || r.DB != tt.db {
+			t.Errorf("NewRedis(%s) = %s, %q, db %d, want %s, %q, db %d", tt.url, r.Addr, r.Password, r.DB, tt.addr, tt.password, tt.db)
+		}
+	}
+	for _, url := range []string{"", "localhost:6379", "http://localhost", "redis://", "redis://:6379", "redis://cache/x", "redis://cache/-1"} {
+		if _, err := NewRedis(url); err == nil {
+			t.Errorf("NewRedis(%q) accepted", url)
+		}
+	}
+}
+
+// readCommand reads a command the way a Redis server does.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "*"), "\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("bad array header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "$"), "\r\n"))
+		if err != nil {
+			return nil, fmt.Errorf("bad bulk header %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestCommand(t *testing.T) {
+	for _, tt := range []struct {
+		reply string
+		want  any
+		err   bool
+	}{
+		{"+OK\r\n", "OK", false},
+		{":42\r\n", int64(42), false},
+		{":-1\r\n", int64(-1), false},
+		{"$5\r\nhello\r\n", []byte("hello"), false},
+		{"$0\r\n\r\n", []byte{}, false},
+		{"$7\r\na\r\nb\r\nc\r\n", []byte("a\r\nb\r\nc"), false},
+		{"$-1\r\n", nil, false},
+		{"-ERR wrong type\r\n", nil, true},
+		{":x\r\n", nil, true},
+		{"$x\r\n", nil, true},
+		{"$5\r\nhel", nil, true},
+		{"*1\r\n$1\r\na\r\n", nil, true},
+		{"\r\n", nil, true},
+		{"+OK", nil, true},
+	} {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			readCommand(bufio.NewReader(server))
+			io.WriteString(server, tt.reply)
+		}()
+		c := &redisConn{Conn: client, r: bufio.NewReader(client)}
+		got, err := c.command(context.Background(), "GET", "k")
+		client.Close()
+		if (err != nil) != tt.err {
+			t.Errorf("%q: got error %v, want error %v", tt.reply, err, tt.err)
+		} else if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %#v, want %#v", tt.reply, got, tt.want)
+		}
+	}
+}
+
+func TestCommandEncoding(t *testing.T) {
+	args := []string{"SET", "gighub:page", "line one\r\nline two", ""}
+	client, server := net.Pipe()
+	defer client.Close()
+	got := make(chan []string, 1)
+	go func() {
+		defer server.Close()
+		cmd, err := readCommand(bufio.NewReader(server))
+		if err != nil {
+			t.Error(err)
+		}
+		got <- cmd
+		io.WriteString(server, "+OK\r\n")
+	}()
+	c := &redisConn{Conn: client, r: bufio.NewReader(client)}
+	if _, err := c.command(context.Background(), args...); err != nil {
+		t.Fatal(err)
+	}
+	if cmd := <-got; !reflect.DeepEqual(cmd, args) {
+		t.Errorf("server read %q, want %q", cmd, args)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	go readCommand(bufio.NewReader(server)) // and never answer
+	c := &redisConn{Conn: client, r: bufio.NewReader(client)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.command(ctx, "GET", "k"); !errors.Is(err, context.DeadlineExceeded) && !isTimeout(err) {
+		t.Errorf("got %v, want a timeout", err)
+	}
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// fakeRedis serves GET, SET, INCR and PING from memory, requiring AUTH
+// with password, and records every command and connection.
+type fakeRedis struct {
+	password string
+
+	mu       sync.Mutex
+	data     map[string]string
+	commands [][]string
+	conns    int
+}
+
+func (f *fakeRedis) listen(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	f.data = map[string]string{}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns++
+			f.mu.Unlock()
+			go f.serve(c)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	authed := f.password == ""
+	for {
+		cmd, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, cmd)
+		var reply string
+		switch {
+		case cmd[0] == "AUTH":
+			authed = cmd[1] == f.password
+			reply = "+OK\r\n"
+			if !authed {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case cmd[0] == "SELECT", cmd[0] == "SET":
+			if cmd[0] == "SET" {
+				f.data[cmd[1]] = cmd[2]
+			}
+			reply = "+OK\r\n"
+		case cmd[0] == "PING":
+			reply = "+PONG\r\n"
+		case cmd[0] == "GET":
+			reply = "$-1\r\n"
+			if v, ok := f.data[cmd[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			}
+		case cmd[0] == "INCR":
+			n := 0
+			if v, ok := f.data[cmd[1]]; ok {
+				if n, err = strconv.Atoi(v); err != nil {
+					reply = "-ERR value is not an integer or out of range\r\n"
+					break
+				}
+			}
+			f.data[cmd[1]] = strconv.Itoa(n + 1)
+			reply = fmt.Sprintf(":%d\r\n", n+1)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		io.WriteString(c, reply)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeRedis{password: "secret"}
+	addr := f.listen(t)
+	r, err := NewRedis("redis://:secret@" + addr + "/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if _, ok, err := r.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Get(missing) = %v, %v", ok, err)
+	}
+	if err := r.Set(ctx, "page", []byte("<p>hi</p>\r\n"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := r.Get(ctx, "page"); !ok || err != nil || string(got) != "<p>hi</p>\r\n" {
+		t.Errorf("Get(page) = %q, %v, %v", got, ok, err)
+	}
+	for range 2 {
+		if err := r.Incr(ctx, "gen:guestbook"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, _, _ := r.Get(ctx, "gen:guestbook"); string(got) != "2" {
+		t.Errorf("after two increments got %q", got)
+	}
+	// An error reply leaves the connection usable
+	if err := r.Incr(ctx, "page"); err == nil {
+		t.Error("Incr of a page succeeded")
+	}
+	if err := r.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.conns != 1 {
+		t.Errorf("opened %d connections, want 1 reused", f.conns)
+	}
+	want := [][]string{
+		{"AUTH", "secret"},
+		{"SELECT", "3"},
+		{"PING"},
+		{"GET", "gighub:missing"},
+		{"SET", "gighub:page", "<p>hi</p>\r\n", "PX", "60000"},
+		{"GET", "gighub:page"},
+		{"INCR", "gighub:gen:guestbook"},
+		{"INCR", "gighub:gen:guestbook"},
+		{"GET", "gighub:gen:guestbook"},
+		{"INCR", "gighub:page"},
+		{"PING"},
+	}
+	if !reflect.DeepEqual(f.commands, want) {
+		t.Errorf("server got\n%q\nwant\n%q", f.commands, want)
+	}
+}
+
+func TestRedisWrongPassword(t *testing.T) {
+	f := &fakeRedis{password: "secret"}
+	r, err := NewRedis("redis://:wrong@" + f.listen(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Ping = %v, want WRONGPASS", err)
+	}
+	if len(r.conns) != 0 {
+		t.Error("kept a connection that failed to log in")
+	}
+}
+
+func TestRedisUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	r, err := NewRedis("redis://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Ping(context.Background()); err == nil {
+		t.Error("Ping succeeded with nothing listening")
+	}
+}
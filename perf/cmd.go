@@ -0,0 +1,145 @@
+package perf
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// baseline holds the latencies recorded with -record on the reference
+// setup described in it. Pass -baseline to compare with another file.
+//
+//go:embed baseline.json
+var baseline []byte
+
+// Baseline is a recorded set of results, and how they were measured.
+type Baseline struct {
+	Note     string   `json:"note"`
+	Duration string   `json:"duration"`
+	Results  []Result `json:"results"`
+}
+
+// Main runs "gighub perf" with args and returns the exit code: 1 when a
+// scenario fails or is slower than its budget.
+func Main(args []string) int {
+	fs := flag.NewFlagSet("gighub perf", flag.ContinueOnError)
+	baseURL := fs.String("url", "http://localhost:3000", "instance to load-test")
+	email := fs.String("email", os.Getenv("PERF_EMAIL"), "verified user to log in as (PERF_EMAIL)")
+	password := fs.String("password", os.Getenv("PERF_PASSWORD"), "password of that user (PERF_PASSWORD)")
+	only := fs.String("scenarios", "", "comma-separated scenarios to run, all by default")
+	rate := fs.Int("rate", 0, "steps per second instead of each scenario's own rate")
+	duration := fs.Duration("duration", 10*time.Second, "how long to run each scenario")
+	workers := fs.Int("workers", 50, "steps in flight at most")
+	baselineFile := fs.String("baseline", "", "baseline to compare with instead of the recorded one")
+	tolerance := fs.Float64("tolerance", 1.5, "how many times the baseline p95 a scenario may take")
+	record := fs.String("record", "", "write the results to this file as a new baseline")
+	note := fs.String("note", "", "describe the setup when recording")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if *rate < 0 || *workers <= 0 || *duration <= 0 {
+		fmt.Fprintln(os.Stderr, "-rate, -workers and -duration must be positive")
+		return 2
+	}
+
+	data := baseline
+	if *baselineFile != "" {
+		var err error
+		if data, err = os.ReadFile(*baselineFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	var base Baseline
+	if err := json.Unmarshal(data, &base); err != nil {
+		fmt.Fprintf(os.Stderr, "error reading baseline: %v\n", err)
+		return 2
+	}
+
+	target := Target{BaseURL: strings.TrimSuffix(*baseURL, "/"), Email: *email, Password: *password}
+	ctx := context.Background()
+	var results []Result
+	failed := false
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "scenario\trate\trequests\terrors\tdropped\tp50\tp95\tp99\tmax\tbudget p95\t")
+	for _, s := range Scenarios {
+		if *only != "" && !contains(strings.Split(*only, ","), s.Name) {
+			continue
+		}
+		r := s.Rate
+		if *rate > 0 {
+			r = *rate
+		}
+		res, err := Run(ctx, target, s, r, *duration, *workers)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		results = append(results, res)
+
+		verdict := "-"
+		if b, ok := find(base.Results, s.Name); ok {
+			budget := time.Duration(float64(b.P95) * *tolerance)
+			verdict = budget.Round(time.Microsecond).String()
+			if res.P95 > budget {
+				verdict += " SLOWER"
+				failed = true
+			}
+		}
+		if res.Errors > 0 || res.Dropped > 0 {
+			failed = true
+		}
+		fmt.Fprintf(out, "%s\t%d/s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t\n", res.Scenario, res.Rate, res.Requests, res.Errors, res.Dropped,
+			round(res.P50), round(res.P95), round(res.P99), round(res.Max), verdict)
+	}
+	out.Flush()
+	for _, res := range results {
+		if res.FirstError != "" {
+			fmt.Fprintf(os.Stderr, "%s: %d errors, first: %s\n", res.Scenario, res.Errors, res.FirstError)
+		}
+	}
+
+	if *record != "" {
+		data, _ := json.MarshalIndent(Baseline{Note: *note, Duration: duration.String(), Results: results}, "", "  ")
+		if err := os.WriteFile(*record, append(data, '\n'), 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func find(results []Result, name string) (Result, bool) {
+	for _, r := range results {
+		if r.Scenario == name {
+			return r, true
+		}
+	}
+	return Result{}, false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.TrimSpace(v) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func round(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
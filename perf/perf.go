@@ -0,0 +1,226 @@
+// Package perf load-tests a running instance. Each scenario is repeated at
+// a constant rate, and its latency percentiles are compared with a recorded
+// baseline so slowdowns in hot paths show up before users notice them.
+//
+// Run it with "gighub perf" against a seeded instance; the login and
+// guestbook scenarios need a verified user, see -email and -password.
+package perf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Target is the instance under test and the user scenarios log in as.
+type Target struct {
+	BaseURL  string
+	Email    string
+	Password string
+}
+
+// Scenario is one user action, timed as a whole.
+type Scenario struct {
+	Name string
+	// Rate is how many steps a second a healthy instance keeps up with.
+	Rate int
+	// Setup prepares the client shared by every step, e.g. by logging in.
+	// Without it each step gets a fresh client with its own cookies.
+	Setup func(ctx context.Context, t Target) (*http.Client, error)
+	Step  func(ctx context.Context, c *http.Client, t Target) error
+}
+
+// Scenarios are the hot paths with recorded baselines.
+var Scenarios = []Scenario{
+	{Name: "home", Rate: 50, Step: get("/")},
+	{Name: "status", Rate: 50, Step: get("/status")},
+	// Password hashing is slow on purpose
+	{Name: "login", Rate: 5, Step: login},
+	{Name: "guestbook", Rate: 50, Setup: loggedIn, Step: get("/guestbook")},
+}
+
+// Result summarizes a scenario run. Latencies only count successful steps.
+type Result struct {
+	Scenario string        `json:"scenario"`
+	Rate     int           `json:"rate"`
+	Requests int           `json:"requests"`
+	Errors   int           `json:"errors"`
+	Dropped  int           `json:"dropped"`
+	P50      time.Duration `json:"p50"`
+	P95      time.Duration `json:"p95"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+	// FirstError is kept to explain a failed run.
+	FirstError string `json:"-"`
+}
+
+// Run starts rate steps per second of s for d. Steps still running when
+// workers are busy are counted as dropped instead of queueing, so an
+// overloaded server shows up as dropped steps and not as a slower rate.
+func Run(ctx context.Context, t Target, s Scenario, rate int, d time.Duration, workers int) (Result, error) {
+	var shared *http.Client
+	if s.Setup != nil {
+		var err error
+		if shared, err = s.Setup(ctx, t); err != nil {
+			return Result{}, fmt.Errorf("%s: setup: %w", s.Name, err)
+		}
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies []time.Duration
+		res       = Result{Scenario: s.Name, Rate: rate}
+	)
+	sem := make(chan struct{}, workers)
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+	deadline := time.After(d)
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline:
+			break loop
+		case <-ticker.C:
+		}
+		select {
+		case sem <- struct{}{}:
+		default:
+			res.Dropped++
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			c := shared
+			if c == nil {
+				c = newClient()
+			}
+			start := time.Now()
+			err := s.Step(ctx, c, t)
+			elapsed := time.Since(start)
+			mu.Lock()
+			defer mu.Unlock()
+			res.Requests++
+			if err != nil {
+				res.Errors++
+				if res.FirstError == "" {
+					res.FirstError = err.Error()
+				}
+				return
+			}
+			latencies = append(latencies, elapsed)
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(latencies)
+	res.P50 = percentile(latencies, 50)
+	res.P95 = percentile(latencies, 95)
+	res.P99 = percentile(latencies, 99)
+	if len(latencies) > 0 {
+		res.Max = latencies[len(latencies)-1]
+	}
+	return res, nil
+}
+
+// percentile returns the p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+func newClient() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{
+		Jar:     jar,
+		Timeout: 30 * time.Second,
+		// Redirects are part of the response being timed, not a new page
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func get(path string) func(context.Context, *http.Client, Target) error {
+	return func(ctx context.Context, c *http.Client, t Target) error {
+		_, err := do(ctx, c, http.MethodGet, t.BaseURL+path, nil, http.StatusOK)
+		return err
+	}
+}
+
+// do sends a request like a browser on the site would, and reads the whole
+// response as one.
+func do(ctx context.Context, c *http.Client, method, rawURL string, form url.Values, want int) (string, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return "", err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	// Passes the CSRF origin check the way browsers do
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != want {
+		return "", fmt.Errorf("%s %s: %s", method, req.URL.Path, resp.Status)
+	}
+	return string(data), nil
+}
+
+var csrfField = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// login loads the login form and submits it, as a returning user does.
+func login(ctx context.Context, c *http.Client, t Target) error {
+	if t.Email == "" {
+		return errors.New("login needs -email and -password")
+	}
+	page, err := do(ctx, c, http.MethodGet, t.BaseURL+"/login", nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	m := csrfField.FindStringSubmatch(page)
+	if m == nil {
+		return errors.New("no CSRF token on the login page")
+	}
+	_, err = do(ctx, c, http.MethodPost, t.BaseURL+"/login", url.Values{
+		"csrf_token": {m[1]},
+		"email":      {t.Email},
+		"password":   {t.Password},
+	}, http.StatusSeeOther)
+	return err
+}
+
+// loggedIn returns a client with a session, for scenarios behind login.
+func loggedIn(ctx context.Context, t Target) (*http.Client, error) {
+	c := newClient()
+	if err := login(ctx, c, t); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
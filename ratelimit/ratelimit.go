@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gighub/db"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at PerMinute.
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// ParseLimits reads a quota list like "read=120,write=30,ip=60". Each
+// value is requests per minute, which is also used as the burst size.
+func ParseLimits(spec string, defaults map[string]Limit) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for k, v := range defaults {
+		limits[k] = v
+	}
+	for _, part := range strings.Split(spec, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q", part)
+		}
+		limits[strings.TrimSpace(name)] = Limit{PerMinute: n, Burst: n}
+	}
+	return limits, nil
+}
+
+// Table holds named limits that can be replaced while requests read them.
+type Table struct {
+	limits atomic.Pointer[map[string]Limit]
+}
+
+func NewTable(limits map[string]Limit) *Table {
+	t := &Table{}
+	t.Set(limits)
+	return t
+}
+
+// Get returns the named limit.
+func (t *Table) Get(name string) (Limit, bool) {
+	l, ok := (*t.limits.Load())[name]
+	return l, ok
+}
+
+// All returns every limit. The map must not be changed.
+func (t *Table) All() map[string]Limit {
+	return *t.limits.Load()
+}
+
+// Set replaces the limits.
+func (t *Table) Set(limits map[string]Limit) {
+	t.limits.Store(&limits)
+}
+
+// Result describes the state of a bucket after a request was counted.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	dirty   bool
+}
+
+// Limiter keeps token buckets in memory. Save and Load persist them so
+// limits survive a restart.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func New() *Limiter {
+	return &Limiter{buckets: map[string]*bucket{}}
+}
+
+// Allow takes a token from the bucket for key.
+func (l *Limiter) Allow(key string, limit Limit) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	rate := float64(limit.PerMinute) / 60
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+	b.dirty = true
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / rate * float64(time.Second)))
+	return res
+}
+
+// Load restores buckets saved by a previous process.
+func (l *Limiter) Load(ctx context.Context, queries *db.Queries) error {
+	rows, err := queries.ListRateLimits(ctx)
+	if err != nil {
+		return fmt.Errorf("error loading rate limits: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, row := range rows {
+		l.buckets[row.Key] = &bucket{tokens: row.Tokens, updated: row.UpdatedAt}
+	}
+	return nil
+}
+
+// Save writes buckets touched since the last save and drops ones that have
+// been idle long enough to have refilled.
+func (l *Limiter) Save(ctx context.Context, queries *db.Queries) error {
+	l.mu.Lock()
+	var dirty []db.UpsertRateLimitParams
+	cutoff := time.Now().Add(-time.Hour)
+	for key, b := range l.buckets {
+		if b.updated.Before(cutoff) {
+			delete(l.buckets, key)
+			continue
+		}
+		if b.dirty {
+			dirty = append(dirty, db.UpsertRateLimitParams{Key: key, Tokens: b.tokens, UpdatedAt: b.updated.UTC()})
+			b.dirty = false
+		}
+	}
+	l.mu.Unlock()
+
+	for _, p := range dirty {
+		if err := queries.UpsertRateLimit(ctx, p); err != nil {
+			return fmt.Errorf("error saving rate limit: %w", err)
+		}
+	}
+	if err := queries.DeleteRateLimitsBefore(ctx, cutoff.UTC()); err != nil {
+		return fmt.Errorf("error pruning rate limits: %w", err)
+	}
+	return nil
+}
+
+// Persist saves the buckets every interval until ctx is cancelled, then
+// one final time.
+func (l *Limiter) Persist(ctx context.Context, queries *db.Queries, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := l.Save(context.Background(), queries); err != nil {
+				log.Print(err)
+			}
+			return
+		case <-ticker.C:
+			if err := l.Save(ctx, queries); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
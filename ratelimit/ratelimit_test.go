@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"gighub/db"
+)
+
+func TestParseLimits(t *testing.T) {
+	defaults := map[string]Limit{"read": {PerMinute: 120, Burst: 120}, "write": {PerMinute: 30, Burst: 30}}
+	for _, tt := range []struct {
+		spec string
+		want map[string]Limit
+	}{
+		{"", defaults},
+		{" , ", defaults},
+		{"write=10", map[string]Limit{"read": {120, 120}, "write": {10, 10}}},
+		{"ip=60, write = 5 ,", map[string]Limit{"read": {120, 120}, "write": {5, 5}, "ip": {60, 60}}},
+	} {
+		got, err := ParseLimits(tt.spec, defaults)
+		if err != nil {
+			t.Errorf("ParseLimits(%q): %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLimits(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+	for _, spec := range []string{"read", "read=", "read=x", "read=0", "read=-5", "read=1.5", "read=10,write"} {
+		if _, err := ParseLimits(spec, defaults); err == nil {
+			t.Errorf("ParseLimits(%q) accepted", spec)
+		}
+	}
+	if defaults["write"].PerMinute != 30 {
+		t.Error("ParseLimits changed the defaults")
+	}
+}
+
+func TestAllow(t *testing.T) {
+	limit := Limit{PerMinute: 60, Burst: 3}
+	l := New()
+	for i, want := range []Result{
+		{Allowed: true, Limit: 3, Remaining: 2},
+		{Allowed: true, Limit: 3, Remaining: 1},
+		{Allowed: true, Limit: 3, Remaining: 0},
+		{Allowed: false, Limit: 3, Remaining: 0},
+	} {
+		got := l.Allow("a", limit)
+		if got.Allowed != want.Allowed || got.Limit != want.Limit || got.Remaining != want.Remaining {
+			t.Errorf("request %d: got %+v, want %+v", i+1, got, want)
+		}
+	}
+
+	// Refills at a token a second, so the next one is due within a second
+	// and the bucket is full again within three
+	got := l.Allow("a", limit)
+	if got.Allowed || got.RetryAfter <= 0 || got.RetryAfter > time.Second {
+		t.Errorf("while empty: allowed %v, retry after %v", got.Allowed, got.RetryAfter)
+	}
+	if wait := time.Until(got.Reset); wait <= 2*time.Second || wait > 3*time.Second {
+		t.Errorf("resets in %v, want under 3s", wait)
+	}
+
+	// Other keys have their own bucket
+	if got := l.Allow("b", limit); !got.Allowed || got.Remaining != 2 {
+		t.Errorf("another key: got %+v", got)
+	}
+
+	l.buckets["a"].updated = time.Now().Add(-1500 * time.Millisecond)
+	if got := l.Allow("a", limit); !got.Allowed || got.Remaining != 0 {
+		t.Errorf("after 1.5s: got %+v, want allowed with 0 remaining", got)
+	}
+	// Never refills past the burst, however long it was idle
+	l.buckets["a"].updated = time.Now().Add(-time.Hour)
+	if got := l.Allow("a", limit); !got.Allowed || got.Remaining != 2 {
+		t.Errorf("after an hour: got %+v, want allowed with 2 remaining", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	ctx := context.Background()
+	conn, queries, err := db.Setup(t.TempDir(), "test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	limit := Limit{PerMinute: 1, Burst: 2}
+	l := New()
+	l.Allow("used", limit)
+	l.Allow("used", limit)
+	l.Allow("idle", limit)
+	l.buckets["idle"].updated = time.Now().Add(-2 * time.Hour)
+	if err := l.Save(ctx, queries); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("an idle bucket wasn't dropped")
+	}
+
+	// A new process carries on where the old one stopped
+	restarted := New()
+	if err := restarted.Load(ctx, queries); err != nil {
+		t.Fatal(err)
+	}
+	if len(restarted.buckets) != 1 {
+		t.Errorf("loaded %d buckets, want 1", len(restarted.buckets))
+	}
+	if got := restarted.Allow("used", limit); got.Allowed {
+		t.Errorf("an empty bucket was refilled by restarting: %+v", got)
+	}
+}
@@ -0,0 +1,109 @@
+// Package report sends unexpected errors and panics somewhere a person will
+// see them, with enough context (request, user, job) to follow up.
+package report
+
+import (
+	"context"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Reporter records unexpected errors.
+type Reporter interface {
+	Error(ctx context.Context, err error)
+	// Flush waits up to timeout for queued reports to be sent.
+	Flush(timeout time.Duration)
+}
+
+type contextKey struct{}
+
+// details is filled in as a request or job runs. It is shared by pointer so
+// middleware further down the chain can add to what an outer recoverer
+// reports.
+type details struct {
+	mu     sync.Mutex
+	method string
+	path   string
+	userID int64
+	tags   map[string]string
+}
+
+// NewContext returns a context that collects report details. r may be nil
+// for work that isn't a request.
+func NewContext(ctx context.Context, r *http.Request) context.Context {
+	d := &details{tags: map[string]string{}}
+	if r != nil {
+		d.method = r.Method
+		d.path = r.URL.Path
+	}
+	return context.WithValue(ctx, contextKey{}, d)
+}
+
+// SetUser records the user a request or job acts for. Only the ID is kept.
+func SetUser(ctx context.Context, userID int64) {
+	if d, ok := ctx.Value(contextKey{}).(*details); ok {
+		d.mu.Lock()
+		d.userID = userID
+		d.mu.Unlock()
+	}
+}
+
+// SetTag adds a searchable key/value to reports from ctx.
+func SetTag(ctx context.Context, key, value string) {
+	if d, ok := ctx.Value(contextKey{}).(*details); ok {
+		d.mu.Lock()
+		d.tags[key] = value
+		d.mu.Unlock()
+	}
+}
+
+// info is a snapshot of the details in ctx plus the request ID.
+type info struct {
+	method, path string
+	userID       int64
+	tags         map[string]string
+}
+
+func infoFrom(ctx context.Context) info {
+	i := info{tags: map[string]string{}}
+	if d, ok := ctx.Value(contextKey{}).(*details); ok {
+		d.mu.Lock()
+		i.method, i.path, i.userID = d.method, d.path, d.userID
+		for k, v := range d.tags {
+			i.tags[k] = v
+		}
+		d.mu.Unlock()
+	}
+	if id := middleware.GetReqID(ctx); id != "" {
+		i.tags["request_id"] = id
+	}
+	return i
+}
+
+// Log writes reports to the standard logger. It is used when no error
+// tracking service is configured.
+type Log struct{}
+
+func (Log) Error(ctx context.Context, err error) {
+	i := infoFrom(ctx)
+	msg := "Error"
+	if i.method != "" {
+		msg += " in " + i.method + " " + i.path
+	}
+	if i.userID != 0 {
+		msg += " for user " + strconv.FormatInt(i.userID, 10)
+	}
+	for _, k := range slices.Sorted(maps.Keys(i.tags)) {
+		msg += " " + k + "=" + i.tags[k]
+	}
+	log.Printf("%s: %v", msg, err)
+}
+
+func (Log) Flush(time.Duration) {}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// emailPattern matches addresses that end up in error messages, e.g. from
+// SMTP, so they can be scrubbed before leaving the server.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// Sentry sends reports to Sentry.
+type Sentry struct{}
+
+// NewSentry configures the Sentry client for dsn.
+func NewSentry(dsn, environment, release string) (*Sentry, error) {
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              dsn,
+		Environment:      environment,
+		Release:          release,
+		AttachStacktrace: true,
+		BeforeSend:       scrub,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Sentry{}, nil
+}
+
+func (*Sentry) Error(ctx context.Context, err error) {
+	i := infoFrom(ctx)
+	sentry.WithScope(func(scope *sentry.Scope) {
+		for k, v := range i.tags {
+			scope.SetTag(k, v)
+		}
+		if i.userID != 0 {
+			scope.SetUser(sentry.User{ID: strconv.FormatInt(i.userID, 10)})
+		}
+		if i.method != "" {
+			scope.SetContext("request", sentry.Context{"method": i.method, "path": i.path})
+		}
+		sentry.CaptureException(err)
+	})
+}
+
+func (*Sentry) Flush(timeout time.Duration) {
+	sentry.Flush(timeout)
+}
+
+// scrub strips personal data from an event: everything about the user but
+// their ID, request headers, cookies, query strings and bodies, and email
+// addresses in messages.
+func scrub(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	event.User = sentry.User{ID: event.User.ID}
+	if event.Request != nil {
+		event.Request = &sentry.Request{Method: event.Request.Method}
+	}
+	event.Message = emailPattern.ReplaceAllString(event.Message, "[email]")
+	for i := range event.Exception {
+		event.Exception[i].Value = emailPattern.ReplaceAllString(event.Exception[i].Value, "[email]")
+	}
+	event.Breadcrumbs = nil
+	return event
+}
@@ -0,0 +1,179 @@
+// Package sanitize cleans user-provided HTML down to an allow-list of
+// harmless formatting, for fields shown as rich text. Fields shown as plain
+// text don't need it, templ escapes them.
+package sanitize
+
+import (
+	"html"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// Policy lists the elements that survive and the attributes each may keep.
+// Everything else is dropped, keeping the text inside unless the element
+// is one whose content is never meant to be shown, like script.
+type Policy struct {
+	Elements map[string][]string
+	// URLSchemes are allowed in href attributes. Relative URLs are always
+	// allowed.
+	URLSchemes []string
+	// Images rewrites the src of img elements, e.g. to serve them through
+	// an image proxy. Images it refuses are dropped, and so are all images
+	// when it's nil; a src is never kept as written.
+	Images func(src string) (string, bool)
+}
+
+// Inline allows simple text formatting and links.
+var Inline = Policy{
+	Elements: map[string][]string{
+		"a":      {"href", "title"},
+		"b":      nil,
+		"br":     nil,
+		"code":   nil,
+		"em":     nil,
+		"i":      nil,
+		"s":      nil,
+		"strong": nil,
+		"u":      nil,
+	},
+	URLSchemes: []string{"http", "https", "mailto"},
+}
+
+// WithImages returns p with img elements allowed, loaded from where images
+// rewrites their src to.
+func (p Policy) WithImages(images func(src string) (string, bool)) Policy {
+	p.Elements = maps.Clone(p.Elements)
+	p.Elements["img"] = []string{"alt", "title"}
+	p.Images = images
+	return p
+}
+
+// HTML cleans s with the Inline policy.
+func HTML(s string) string {
+	return Inline.HTML(s)
+}
+
+// dropContent holds elements whose content is removed along with them.
+var dropContent = map[string]bool{
+	"iframe": true, "math": true, "noembed": true, "noframes": true, "noscript": true,
+	"object": true, "script": true, "style": true, "svg": true, "template": true,
+	"textarea": true, "title": true, "xmp": true,
+}
+
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true, "wbr": true}
+
+// HTML returns s with disallowed markup removed, attributes filtered and
+// every tag it keeps properly closed.
+func (p Policy) HTML(s string) string {
+	var b strings.Builder
+	var open []string
+	skip, skipDepth := "", 0
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		if skip != "" {
+			switch {
+			case tt == xhtml.StartTagToken && tok.Data == skip:
+				skipDepth++
+			case tt == xhtml.EndTagToken && tok.Data == skip:
+				if skipDepth--; skipDepth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+		switch tt {
+		case xhtml.TextToken:
+			b.WriteString(html.EscapeString(tok.Data))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if dropContent[tok.Data] {
+				if tt == xhtml.StartTagToken {
+					skip, skipDepth = tok.Data, 1
+				}
+				continue
+			}
+			attrs, ok := p.Elements[tok.Data]
+			if !ok {
+				continue
+			}
+			src, isImage := "", tok.Data == "img"
+			if isImage {
+				if src, ok = p.imageSrc(tok.Attr); !ok {
+					continue
+				}
+			}
+			b.WriteString("<" + tok.Data)
+			if isImage {
+				b.WriteString(` src="` + html.EscapeString(src) + `"`)
+			}
+			for _, a := range tok.Attr {
+				if a.Namespace != "" || a.Key == "src" || !slices.Contains(attrs, a.Key) {
+					continue
+				}
+				if a.Key == "href" && !p.safeURL(a.Val) {
+					continue
+				}
+				b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+			}
+			if tok.Data == "a" {
+				b.WriteString(` rel="nofollow noopener noreferrer"`)
+			}
+			b.WriteString(">")
+			if !voidElements[tok.Data] {
+				open = append(open, tok.Data)
+			}
+		case xhtml.EndTagToken:
+			// Close the innermost matching element, and anything left open
+			// inside it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+	for j := len(open) - 1; j >= 0; j-- {
+		b.WriteString("</" + open[j] + ">")
+	}
+	return b.String()
+}
+
+// imageSrc returns where an img element with attrs should load from, as
+// rewritten by Images.
+func (p Policy) imageSrc(attrs []xhtml.Attribute) (string, bool) {
+	if p.Images == nil {
+		return "", false
+	}
+	for _, a := range attrs {
+		if a.Namespace == "" && a.Key == "src" {
+			return p.Images(strings.TrimSpace(a.Val))
+		}
+	}
+	return "", false
+}
+
+// safeURL allows relative URLs and absolute ones with an allowed scheme.
+func (p Policy) safeURL(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	if strings.ContainsFunc(raw, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || slices.Contains(p.URLSchemes, strings.ToLower(u.Scheme))
+}
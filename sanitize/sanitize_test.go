@@ -0,0 +1,98 @@
+package sanitize
+
+import "testing"
+
+const rel = ` rel="nofollow noopener noreferrer"`
+
+var golden = []struct {
+	name, in, want string
+}{
+	// Formatting that is allowed survives as it is
+	{"plain", `hello <b>world</b>`, `hello <b>world</b>`},
+	{"text is escaped", `1 < 2 & 3 > 2`, `1 &lt; 2 &amp; 3 &gt; 2`},
+	{"void elements", `<br/><br>`, `<br><br>`},
+	{"link", `<a href="https://example.com/?a=1&b=2" title='t"q' target=_blank>x</a>`, `<a href="https://example.com/?a=1&amp;b=2" title="t&#34;q"` + rel + `>x</a>`},
+	{"relative link", `<a href="/gigs">x</a>`, `<a href="/gigs"` + rel + `>x</a>`},
+	{"mailto link", `<a href="mailto:me@example.com">x</a>`, `<a href="mailto:me@example.com"` + rel + `>x</a>`},
+
+	// Script and style, content included
+	{"script", `<script>alert(1)</script>after`, `after`},
+	{"script src", `<SCRIPT SRC=//evil.example/x.js></SCRIPT>ok`, `ok`},
+	{"unclosed script", `<script>alert(1)`, ``},
+	{"split script", `<scr<script>ipt>alert(1)</script>`, `ipt&gt;alert(1)`},
+	{"style", `<style>body{display:none}</style>text`, `text`},
+	{"iframe", `<iframe src="javascript:alert(1)"></iframe>after`, `after`},
+	{"textarea", `<textarea><script>alert(1)</script></textarea>after`, `after`},
+	{"xmp", `<xmp><script>alert(1)</script></xmp>`, ``},
+	{"noscript", `<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`, `&#34;&gt;`},
+
+	// Event handlers and other attributes
+	{"onclick", `<b onclick="alert(1)">x</b>`, `<b>x</b>`},
+	{"slash separated", `<b/onmouseover=alert(1)>x</b>`, `<b>x</b>`},
+	{"img onerror", `<img src=x onerror=alert(1)>`, ``},
+	{"attribute not allowed", `<b title="x">x</b>`, `<b>x</b>`},
+	{"style attribute", `<a href="https://example.com" style="position:fixed">x</a>`, `<a href="https://example.com"` + rel + `>x</a>`},
+
+	// URLs with schemes that run code, however they are spelled
+	{"javascript", `<a href="javascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+	{"mixed case", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+	{"leading space", `<a href=" javascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+	{"tab entity", `<a href="jav&#x09;ascript:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+	{"newline", "<a href=\"java\nscript:alert(1)\">x</a>", `<a` + rel + `>x</a>`},
+	{"letter entity", `<a href="jav&#97;script:alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+	{"colon entity", `<a href="javascript&colon;alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+	{"all entities", `<a href="&#106;&#97;&#118;&#97;&#115;&#99;&#114;&#105;&#112;&#116;&#58;alert(1)">x</a>`, `<a` + rel + `>x</a>`},
+	{"data", `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`, `<a` + rel + `>x</a>`},
+	{"vbscript", `<a href="vbscript:msgbox(1)">x</a>`, `<a` + rel + `>x</a>`},
+
+	// SVG and MathML, content included
+	{"svg", `<svg onload=alert(1)><script>alert(1)</script></svg>after`, `after`},
+	{"nested svg", `<svg><svg></svg><a href="javascript:alert(1)">x</a></svg>after`, `after`},
+	{"mathml", `<math><mtext><img src=x onerror=alert(1)></mtext></math>after`, `after`},
+
+	// Unclosed and malformed markup
+	{"unclosed", `<b>unclosed <i>tags`, `<b>unclosed <i>tags</i></b>`},
+	{"crossed", `<b><i>crossed</b></i>`, `<b><i>crossed</i></b>`},
+	{"stray close", `</b>stray close`, `stray close`},
+	{"unfinished tag", `<b`, ``},
+	{"unfinished attribute", `<a href="https://example.com"`, ``},
+	{"doubled brackets", `<<b>>`, `&lt;<b>&gt;</b>`},
+	{"comment", `<!-- <script>alert(1)</script> -->text`, `text`},
+	{"cdata", `<![CDATA[<script>alert(1)</script>]]>`, `alert(1)]]&gt;`},
+}
+
+func TestHTML(t *testing.T) {
+	for _, tt := range golden {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.in); got != tt.want {
+				t.Errorf("HTML(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImages(t *testing.T) {
+	policy := Inline.WithImages(func(src string) (string, bool) {
+		if src != "https://img.example/a.jpg" {
+			return "", false
+		}
+		return "/img?url=a", true
+	})
+	for _, tt := range []struct {
+		name, in, want string
+	}{
+		{"rewritten", `<img src="https://img.example/a.jpg" alt="A" onerror="alert(1)">`, `<img src="/img?url=a" alt="A">`},
+		{"refused", `<img src="https://evil.example/a.jpg">x`, `x`},
+		{"no src", `<img alt="A">`, ``},
+		{"javascript", `<img src="javascript:alert(1)">`, ``},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.HTML(tt.in); got != tt.want {
+				t.Errorf("HTML(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+	if got := HTML(`<img src="https://img.example/a.jpg">`); got != "" {
+		t.Errorf("Inline kept an image: %q", got)
+	}
+}
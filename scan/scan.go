@@ -0,0 +1,153 @@
+// Package scan checks uploaded files before they become visible. A
+// Pipeline runs Scanners in order: known duplicates are caught by hash, and
+// an external moderation service can be asked about the rest. Files that
+// need a human look are quarantined for review at /admin/uploads.
+package scan
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gighub/db"
+)
+
+// Statuses of an upload. Only Clean files are shown to anyone.
+const (
+	Pending     = "pending"
+	Clean       = "clean"
+	Quarantined = "quarantined"
+	Rejected    = "rejected"
+)
+
+// File is an upload to scan.
+type File struct {
+	ID          string
+	UserID      int64
+	SHA256      string
+	ContentType string
+	Size        int64
+	// Open reads the file's contents.
+	Open func(ctx context.Context) (io.ReadCloser, error)
+}
+
+// Verdict is a scanner's decision. Reason is shown to admins reviewing a
+// quarantined file.
+type Verdict struct {
+	Status string
+	Reason string
+}
+
+type Scanner interface {
+	Scan(ctx context.Context, f File) (Verdict, error)
+}
+
+// Pipeline runs each scanner in turn and stops at the first one that
+// doesn't find the file clean.
+type Pipeline []Scanner
+
+func (p Pipeline) Scan(ctx context.Context, f File) (Verdict, error) {
+	for _, s := range p {
+		v, err := s.Scan(ctx, f)
+		if err != nil {
+			return Verdict{}, err
+		}
+		if v.Status != Clean {
+			return v, nil
+		}
+	}
+	return Verdict{Status: Clean}, nil
+}
+
+// Duplicates compares a file's hash with earlier uploads. A copy of a
+// file an admin rejected is rejected again; a copy of someone else's file
+// is held for review, since reposting others' media is the usual abuse.
+type Duplicates struct {
+	Queries *db.Queries
+}
+
+func (d Duplicates) Scan(ctx context.Context, f File) (Verdict, error) {
+	matches, err := d.Queries.ListUploadsBySHA256(ctx, db.ListUploadsBySHA256Params{
+		Sha256: sql.NullString{String: f.SHA256, Valid: true},
+		ID:     f.ID,
+	})
+	if err != nil {
+		return Verdict{}, err
+	}
+	for _, m := range matches {
+		if m.Status == Rejected {
+			return Verdict{Status: Rejected, Reason: "Same file as rejected upload " + m.ID}, nil
+		}
+	}
+	for _, m := range matches {
+		if m.UserID != f.UserID && m.Status == Clean {
+			return Verdict{Status: Quarantined, Reason: fmt.Sprintf("Same file as upload %s by user %d", m.ID, m.UserID)}, nil
+		}
+	}
+	return Verdict{Status: Clean}, nil
+}
+
+// Moderation sends files to an external moderation API. The file is POSTed
+// as the request body with its content type, and the service answers
+// {"flagged": bool, "reason": "..."}. Flagged files are quarantined.
+type Moderation struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func NewModeration(url, token string) *Moderation {
+	return &Moderation{URL: url, Token: token, Client: &http.Client{Timeout: time.Minute}}
+}
+
+func (m *Moderation) Scan(ctx context.Context, f File) (Verdict, error) {
+	body, err := f.Open(ctx)
+	if err != nil {
+		return Verdict{}, err
+	}
+	defer body.Close()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.URL, body)
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.ContentLength = f.Size
+	req.Header.Set("Content-Type", f.ContentType)
+	req.Header.Set("X-Content-SHA256", f.SHA256)
+	req.Header.Set("X-User-ID", strconv.FormatInt(f.UserID, 10))
+	if m.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.Token)
+	}
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return Verdict{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return Verdict{}, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Verdict{}, fmt.Errorf("moderation API: %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	var result struct {
+		Flagged bool   `json:"flagged"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Verdict{}, fmt.Errorf("moderation API: %w", err)
+	}
+	if result.Flagged {
+		reason := result.Reason
+		if reason == "" {
+			reason = "Flagged by the moderation API"
+		}
+		return Verdict{Status: Quarantined, Reason: reason}, nil
+	}
+	return Verdict{Status: Clean}, nil
+}
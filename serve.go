@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"gighub/app"
+	"gighub/systemd"
+)
+
+// shutdownTimeout bounds how long in-flight requests get to finish on shutdown.
+const shutdownTimeout = 30 * time.Second
+
+// serve runs the site until SIGINT or SIGTERM. Pending migrations are
+// applied first, so deploys don't need a separate migrate step.
+func serve(fs *flag.FlagSet, args []string) error {
+	listenAddr := fs.String("listen", "", `address to listen on instead of ":PORT", like "127.0.0.1:3000" or "unix:/run/gighub/gighub.sock"`)
+	pidFile := fs.String("pid-file", "", "file to write the process ID to while running")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	// Under systemd socket activation, systemd owns the listening sockets
+	listeners, err := systemd.Listeners()
+	if err != nil {
+		return err
+	}
+	if (*listenAddr != "" || len(listeners) > 0) && cfg.TLS.Enabled() {
+		return errors.New("TLS_DOMAINS serves on PORT and HTTPS_PORT itself, and can't be combined with -listen or socket activation")
+	}
+	if len(listeners) == 0 && !cfg.TLS.Enabled() {
+		if *listenAddr == "" {
+			*listenAddr = ":" + cfg.Port
+		}
+		l, err := listen(*listenAddr)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, l)
+	}
+	if *pidFile != "" {
+		if err := os.WriteFile(*pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+			return fmt.Errorf("error writing the PID file: %w", err)
+		}
+		defer os.Remove(*pidFile)
+	}
+	dbConn, queries, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	defer queries.Close()
+
+	server, err := app.New(cfg, dbConn, queries)
+	if err != nil {
+		return err
+	}
+
+	// SIGINT/SIGTERM cancel ctx, which stops the background workers and
+	// starts the graceful shutdown below.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	workersDone := make(chan struct{})
+	go func() {
+		server.Run(ctx)
+		close(workersDone)
+	}()
+
+	// Start the server. With TLS_DOMAINS set it serves HTTPS itself, and the
+	// plain HTTP port only answers ACME challenges and redirects.
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           server.Routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	// HTTP/2 is negotiated over TLS, and spoken in cleartext to proxies
+	// that ask for it with HTTP2_CLEARTEXT
+	srv.Protocols = new(http.Protocols)
+	srv.Protocols.SetHTTP1(true)
+	srv.Protocols.SetHTTP2(true)
+	srv.Protocols.SetUnencryptedHTTP2(cfg.TLS.H2C && !cfg.TLS.Enabled())
+	servers := []*http.Server{srv}
+	serveErr := make(chan error, len(listeners)+1)
+	if cfg.TLS.Enabled() {
+		certs := server.CertManager()
+		redirect := &http.Server{
+			Addr:              ":" + cfg.Port,
+			Handler:           server.RedirectToHTTPS(certs),
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		}
+		srv.Addr = ":" + cfg.TLS.HTTPSPort
+		srv.TLSConfig = app.TLSConfig(certs)
+		servers = append(servers, redirect)
+		go func() {
+			serveErr <- redirect.ListenAndServe()
+		}()
+		go func() {
+			fmt.Printf("Server starting on port %s (HTTPS for %s)...\n", cfg.TLS.HTTPSPort, strings.Join(cfg.TLS.Domains, ", "))
+			serveErr <- srv.ListenAndServeTLS("", "")
+		}()
+	} else {
+		for _, l := range listeners {
+			fmt.Printf("Server starting on %s...\n", l.Addr())
+			go func() {
+				serveErr <- srv.Serve(l)
+			}()
+		}
+	}
+	if err := systemd.Notify("READY=1"); err != nil {
+		log.Printf("Error notifying systemd: %v", err)
+	}
+
+	select {
+	case err := <-serveErr:
+		fmt.Printf("Error starting server: %s\n", err)
+		stop()
+	case <-ctx.Done():
+		log.Println("Shutting down...")
+	}
+	systemd.Notify("STOPPING=1")
+
+	// Stop accepting connections and let in-flight requests finish, then wait
+	// for queued emails and background workers before the database closes.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down server: %v", err)
+		}
+	}
+	server.Mailer.Wait()
+	<-workersDone
+	server.Reporter.Flush(5 * time.Second)
+	log.Println("Shutdown complete")
+	return nil
+}
+
+// listen opens a TCP address, or a Unix socket for addresses starting
+// with "unix:". A socket file left behind by a crash is replaced, but one
+// another process still accepts connections on is left alone.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another process", path)
+		}
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
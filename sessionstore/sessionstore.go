@@ -0,0 +1,84 @@
+// Package sessionstore keeps scs sessions in the database so they survive
+// restarts. Tokens are stored hashed, and each row records the logged-in
+// user so their sessions can be listed and revoked.
+package sessionstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"gighub/db"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+// Store implements scs.CtxStore on the sessions table.
+type Store struct {
+	Queries *db.Queries
+	// UserKey is the session key holding the logged-in user's ID.
+	UserKey string
+}
+
+func New(queries *db.Queries, userKey string) *Store {
+	return &Store{Queries: queries, UserKey: userKey}
+}
+
+// HashToken returns the form a session token is stored in.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) FindCtx(ctx context.Context, token string) ([]byte, bool, error) {
+	data, err := s.Queries.GetSession(ctx, db.GetSessionParams{
+		TokenHash: HashToken(token),
+		Expiry:    time.Now().UTC(),
+	})
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (s *Store) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error {
+	return s.Queries.UpsertSession(ctx, db.UpsertSessionParams{
+		TokenHash: HashToken(token),
+		UserID:    s.userID(b),
+		Data:      b,
+		Expiry:    expiry.UTC(),
+	})
+}
+
+func (s *Store) DeleteCtx(ctx context.Context, token string) error {
+	return s.Queries.DeleteSession(ctx, HashToken(token))
+}
+
+// userID reads the logged-in user out of encoded session data.
+func (s *Store) userID(b []byte) sql.NullInt64 {
+	_, values, err := scs.GobCodec{}.Decode(b)
+	if err != nil {
+		return sql.NullInt64{}
+	}
+	id, ok := values[s.UserKey].(int64)
+	return sql.NullInt64{Int64: id, Valid: ok}
+}
+
+// The context-free methods complete scs.Store. scs calls the Ctx ones.
+
+func (s *Store) Find(token string) ([]byte, bool, error) {
+	return s.FindCtx(context.Background(), token)
+}
+
+func (s *Store) Commit(token string, b []byte, expiry time.Time) error {
+	return s.CommitCtx(context.Background(), token, b, expiry)
+}
+
+func (s *Store) Delete(token string) error {
+	return s.DeleteCtx(context.Background(), token)
+}
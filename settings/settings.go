@@ -0,0 +1,168 @@
+// Package settings keeps the values admins can change while the site
+// runs, so they don't need a restart. Values that were never saved come
+// from the environment.
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"gighub/db"
+	"gighub/ratelimit"
+)
+
+// cacheTTL is how long settings are reused before they are read again.
+// Changes made through the Store show up immediately.
+const cacheTTL = 30 * time.Second
+
+// Settings are the values that can be changed at runtime.
+type Settings struct {
+	// SiteName is shown in the navigation bar and footer.
+	SiteName string
+	// SignupsOpen lets new accounts be created, with a password or a
+	// social login. Existing accounts can sign in either way.
+	SignupsOpen bool
+	// APIRateLimits and Throttles are per-minute limits written like
+	// API_RATE_LIMITS and THROTTLE_LIMITS, on top of the built-in ones.
+	APIRateLimits string
+	Throttles     string
+	// EmailFooter is added to the end of every email.
+	EmailFooter string
+}
+
+// Validate checks the values that have a format.
+func (s Settings) Validate() error {
+	var errs []error
+	if s.SiteName == "" {
+		errs = append(errs, errors.New("the site name can't be empty"))
+	}
+	if _, err := ratelimit.ParseLimits(s.APIRateLimits, nil); err != nil {
+		errs = append(errs, fmt.Errorf("API rate limits: %w", err))
+	}
+	if _, err := ratelimit.ParseLimits(s.Throttles, nil); err != nil {
+		errs = append(errs, fmt.Errorf("throttles: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// values returns the settings by the keys they are stored under.
+func (s Settings) values() map[string]string {
+	return map[string]string{
+		"site_name":       s.SiteName,
+		"signups_open":    strconv.FormatBool(s.SignupsOpen),
+		"api_rate_limits": s.APIRateLimits,
+		"throttle_limits": s.Throttles,
+		"email_footer":    s.EmailFooter,
+	}
+}
+
+// Store reads and saves settings in the settings table.
+type Store struct {
+	DB      *sql.DB
+	Queries *db.Queries
+	// Defaults apply to settings that were never saved.
+	Defaults Settings
+	// OnChange is called with the new settings after they are saved or
+	// reset, to apply the ones that are read once, like rate limits.
+	OnChange func(Settings)
+
+	mu     sync.Mutex
+	cached *Settings
+	loaded time.Time
+}
+
+func New(dbConn *sql.DB, queries *db.Queries, defaults Settings) *Store {
+	return &Store{DB: dbConn, Queries: queries, Defaults: defaults}
+}
+
+// Get returns the current settings. If they can't be loaded, it returns
+// the defaults along with the error.
+func (s *Store) Get(ctx context.Context) (Settings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached != nil && time.Since(s.loaded) < cacheTTL {
+		return *s.cached, nil
+	}
+	rows, err := s.Queries.ListSettings(ctx)
+	if err != nil {
+		return s.Defaults, fmt.Errorf("error loading settings: %w", err)
+	}
+	set := s.Defaults
+	for _, row := range rows {
+		switch row.Key {
+		case "site_name":
+			set.SiteName = row.Value
+		case "signups_open":
+			set.SignupsOpen = row.Value == "true"
+		case "api_rate_limits":
+			set.APIRateLimits = row.Value
+		case "throttle_limits":
+			set.Throttles = row.Value
+		case "email_footer":
+			set.EmailFooter = row.Value
+		}
+	}
+	s.cached, s.loaded = &set, time.Now()
+	return set, nil
+}
+
+// Save stores every setting, which then no longer follows the defaults.
+func (s *Store) Save(ctx context.Context, set Settings) error {
+	if err := set.Validate(); err != nil {
+		return err
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := s.Queries.WithTx(tx)
+	for key, value := range set.values() {
+		if err := qtx.UpsertSetting(ctx, db.UpsertSettingParams{Key: key, Value: value}); err != nil {
+			return fmt.Errorf("error saving %s: %w", key, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.changed(set)
+	return nil
+}
+
+// Reset deletes the saved settings, going back to the defaults.
+func (s *Store) Reset(ctx context.Context) error {
+	if err := s.Queries.DeleteSettings(ctx); err != nil {
+		return fmt.Errorf("error resetting settings: %w", err)
+	}
+	s.changed(s.Defaults)
+	return nil
+}
+
+func (s *Store) changed(set Settings) {
+	s.mu.Lock()
+	s.cached, s.loaded = &set, time.Now()
+	s.mu.Unlock()
+	if s.OnChange != nil {
+		s.OnChange(set)
+	}
+}
+
+// Changes lists the settings that differ between old and new, for the
+// audit log.
+func Changes(old, new Settings) []string {
+	before := old.values()
+	var keys []string
+	for key, value := range new.values() {
+		if before[key] != value {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Purger asks a CDN to drop cached copies of URLs, so replaced or deleted
+// files stop being served from its edge. It POSTs {"files": [...]} with a
+// bearer token, which is the shape of Cloudflare's purge_cache endpoint;
+// other CDNs can be reached through a small adapter at URL.
+type Purger struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func NewPurger(url, token string) *Purger {
+	return &Purger{URL: url, Token: token, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Purge drops urls from the CDN cache. A nil Purger does nothing.
+func (p *Purger) Purge(ctx context.Context, urls ...string) error {
+	if p == nil || len(urls) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string][]string{"files": urls})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("CDN purge: %s: %s", resp.Status, msg)
+	}
+	return nil
+}
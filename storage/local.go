@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/keys"
+)
+
+// Local keeps files under Dir. Its URLs point at Handler, mounted at
+// BaseURL, and are signed with Keys so they can't be guessed or reused
+// after they expire.
+type Local struct {
+	Dir     string
+	BaseURL string
+	Keys    keys.Ring
+}
+
+func NewLocal(dir, baseURL string, ring keys.Ring) *Local {
+	return &Local{Dir: dir, BaseURL: strings.TrimSuffix(baseURL, "/"), Keys: ring}
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(key))
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	p := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so readers never see half a file
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	if err := checkKey(key); err != nil {
+		return nil, Object{}, err
+	}
+	f, err := os.Open(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Object{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Object{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Object{}, err
+	}
+	return f, Object{Key: key, ContentType: typeByKey(key), Size: info.Size()}, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) URL(key string, ttl time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	q := url.Values{"expires": {expires}, "sig": {l.Keys.Sign([]byte(key + "\n" + expires))}}
+	return l.BaseURL + "/" + key + "?" + q.Encode(), nil
+}
+
+// Handler serves files by signed URL. Mount it at BaseURL with the prefix
+// stripped.
+func (l *Local) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		expires := r.URL.Query().Get("expires")
+		ts, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > ts || !l.Keys.Verify([]byte(key+"\n"+expires), r.URL.Query().Get("sig")) {
+			http.Error(w, "Link invalid or expired", http.StatusForbidden)
+			return
+		}
+		rc, obj, err := l.Open(r.Context(), key)
+		if err == ErrNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Storage error", http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+		f := rc.(*os.File)
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, "Storage error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", obj.ContentType)
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(max(ts-time.Now().Unix(), 0), 10))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, "", info.ModTime(), f)
+	})
+}
@@ -0,0 +1,83 @@
+// Package storage keeps uploaded files on local disk or in an
+// S3-compatible bucket behind one interface, and hands out expiring signed
+// URLs for them.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("file not found")
+	ErrTooLarge    = errors.New("file too large")
+	ErrContentType = errors.New("file type not allowed")
+)
+
+// Object describes a stored file.
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+}
+
+// Store is a place to keep files. Keys are slash-separated paths like
+// "avatars/12/256.png".
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns a link to the file that works for ttl.
+	URL(key string, ttl time.Duration) (string, error)
+}
+
+// Upload stores what r holds under key, as long as it is at most maxSize
+// bytes and its sniffed content type is one of allowed. What the client
+// claims the type is isn't trusted.
+func Upload(ctx context.Context, s Store, key string, r io.Reader, maxSize int64, allowed ...string) (Object, error) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return Object{}, err
+	}
+	if n > maxSize {
+		return Object{}, ErrTooLarge
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(buf.Bytes()))
+	if !slices.Contains(allowed, contentType) {
+		return Object{}, fmt.Errorf("%w: %s", ErrContentType, contentType)
+	}
+	if err := s.Put(ctx, key, &buf, n, contentType); err != nil {
+		return Object{}, err
+	}
+	return Object{Key: key, ContentType: contentType, Size: n}, nil
+}
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+
+// checkKey rejects keys that could escape the storage root or need
+// escaping in URLs.
+func checkKey(key string) error {
+	if !keyPattern.MatchString(key) || strings.Contains(key, "..") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
+
+// typeByKey guesses a content type from the key's extension.
+func typeByKey(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
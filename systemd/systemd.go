@@ -0,0 +1,63 @@
+// Package systemd speaks the two small protocols systemd offers services:
+// socket activation, where systemd opens the listening sockets and passes
+// them in, and readiness notification, so dependent units start once the
+// server actually accepts requests.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor systemd passes.
+const listenFDsStart = 3
+
+// Listeners returns the sockets systemd passed to this process, or none
+// when it wasn't socket-activated. The variables describing them are
+// cleared so child processes don't take them for their own.
+func Listeners() ([]net.Listener, error) {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+	}
+	listeners := make([]net.Listener, n)
+	for i := range n {
+		f := os.NewFile(uintptr(listenFDsStart+i), "LISTEN_FD_"+strconv.Itoa(listenFDsStart+i))
+		l, err := net.FileListener(f)
+		// FileListener duplicates the descriptor
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("socket %d from systemd: %w", i, err)
+		}
+		listeners[i] = l
+	}
+	return listeners, nil
+}
+
+// Notify sends state, like "READY=1", to systemd. It does nothing unless
+// the unit has Type=notify.
+func Notify(state string) error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+	// A leading @ names an abstract socket
+	if addr[0] == '@' {
+		addr = "\x00" + addr[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Tables are exported and imported with their stored values as they are:
+// times as the text SQLite holds, BLOBs as base64 and, in CSV, NULL as an
+// empty field.
+
+// exportTable writes every row of a table as CSV or JSON.
+func exportTable(fs *flag.FlagSet, args []string) error {
+	table := fs.String("table", "", "table to export")
+	format := fs.String("format", "csv", "csv or json")
+	outFile := fs.String("out", "", "file to write instead of stdout")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if *table == "" || (*format != "csv" && *format != "json") {
+		fmt.Fprintln(os.Stderr, "Usage: gighub export -table TABLE [-format csv|json] [-out FILE]")
+		return errUsage
+	}
+	dbConn, queries, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	defer queries.Close()
+	ctx := context.Background()
+	cols, err := tableColumns(ctx, dbConn, *table)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outFile != "" {
+		f, err := os.Create(*outFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	// Unary plus drops the declared type, so the driver hands back what's
+	// stored instead of parsing DATETIME columns into time.Time
+	exprs := make([]string, len(cols))
+	for i, c := range cols {
+		exprs[i] = "+" + quoteIdent(c.name) + " AS " + quoteIdent(c.name)
+	}
+	rows, err := dbConn.QueryContext(ctx, "SELECT "+strings.Join(exprs, ", ")+" FROM "+quoteIdent(*table)+" ORDER BY rowid")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]any, len(cols))
+	dest := make([]any, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var w *csv.Writer
+	if *format == "csv" {
+		w = csv.NewWriter(out)
+		names := make([]string, len(cols))
+		for i, c := range cols {
+			names[i] = c.name
+		}
+		w.Write(names)
+	} else {
+		fmt.Fprint(out, "[")
+	}
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if w != nil {
+			record := make([]string, len(values))
+			for i, v := range values {
+				record[i] = csvField(v)
+			}
+			w.Write(record)
+		} else {
+			row := make(map[string]any, len(values))
+			for i, v := range values {
+				row[cols[i].name] = v
+			}
+			data, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				fmt.Fprint(out, ",")
+			}
+			fmt.Fprintf(out, "\n  %s", data)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if w != nil {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(out, "\n]")
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d rows from %s\n", n, *table)
+	return nil
+}
+
+// importTable inserts the rows of a CSV or JSON file made by export, in
+// one transaction so a bad row leaves the table as it was. With -dry-run
+// the rows are inserted and rolled back, which checks constraints too.
+func importTable(fs *flag.FlagSet, args []string) error {
+	table := fs.String("table", "", "table to import into")
+	format := fs.String("format", "csv", "csv or json")
+	dryRun := fs.Bool("dry-run", false, "check the rows without saving them")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if *table == "" || (*format != "csv" && *format != "json") || fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: gighub import -table TABLE [-format csv|json] [-dry-run] [FILE]")
+		return errUsage
+	}
+	in := io.Reader(os.Stdin)
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	dbConn, queries, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	defer queries.Close()
+	ctx := context.Background()
+	cols, err := tableColumns(ctx, dbConn, *table)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	var rows [][]any
+	if *format == "csv" {
+		names, rows, err = readCSV(in, cols)
+	} else {
+		names, rows, err = readJSON(in, cols)
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO "+quoteIdent(*table)+" ("+strings.Join(quoted, ", ")+") VALUES ("+placeholders+")")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return fmt.Errorf("row %d: %w", i+1, err)
+		}
+	}
+	if *dryRun {
+		fmt.Printf("%d rows are valid for %s; nothing was saved\n", len(rows), *table)
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d rows into %s\n", len(rows), *table)
+	return nil
+}
+
+type column struct {
+	name string
+	blob bool
+}
+
+// tableColumns returns the columns of table, which must exist.
+func tableColumns(ctx context.Context, dbConn *sql.DB, table string) ([]column, error) {
+	rows, err := dbConn.QueryContext(ctx, "SELECT name, upper(type) = 'BLOB' FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []column
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.name, &c.blob); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("no table named %q", table)
+	}
+	return cols, nil
+}
+
+func findColumn(cols []column, name string) (column, error) {
+	i := slices.IndexFunc(cols, func(c column) bool { return c.name == name })
+	if i < 0 {
+		return column{}, fmt.Errorf("the table has no column %q", name)
+	}
+	return cols[i], nil
+}
+
+func readCSV(in io.Reader, cols []column) ([]string, [][]any, error) {
+	r := csv.NewReader(in)
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading the header: %w", err)
+	}
+	fileCols := make([]column, len(header))
+	for i, name := range header {
+		if fileCols[i], err = findColumn(cols, name); err != nil {
+			return nil, nil, err
+		}
+	}
+	var rows [][]any
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		row := make([]any, len(record))
+		for i, field := range record {
+			if row[i], err = columnValue(fileCols[i], field); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+func readJSON(in io.Reader, cols []column) ([]string, [][]any, error) {
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+	var objects []map[string]any
+	if err := dec.Decode(&objects); err != nil {
+		return nil, nil, fmt.Errorf("the file must be an array of objects: %w", err)
+	}
+	// Columns missing from every object are left to their defaults
+	var names []string
+	for _, obj := range objects {
+		for name := range obj {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	fileCols := make([]column, len(names))
+	for i, name := range names {
+		var err error
+		if fileCols[i], err = findColumn(cols, name); err != nil {
+			return nil, nil, err
+		}
+	}
+	rows := make([][]any, len(objects))
+	for n, obj := range objects {
+		row := make([]any, len(names))
+		for i, name := range names {
+			v, err := jsonValue(fileCols[i], obj[name])
+			if err != nil {
+				return nil, nil, fmt.Errorf("row %d: %w", n+1, err)
+			}
+			row[i] = v
+		}
+		rows[n] = row
+	}
+	return names, rows, nil
+}
+
+// csvField formats a stored value for CSV.
+func csvField(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// columnValue parses a CSV field. SQLite's type affinity turns numeric
+// text into numbers in INTEGER and REAL columns, so only NULL and BLOBs
+// need converting.
+func columnValue(c column, field string) (any, error) {
+	if field == "" {
+		return nil, nil
+	}
+	if c.blob {
+		data, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not base64: %w", c.name, err)
+		}
+		return data, nil
+	}
+	return field, nil
+}
+
+func jsonValue(c column, v any) (any, error) {
+	switch v := v.(type) {
+	case nil, bool:
+		return v, nil
+	case string:
+		if c.blob {
+			return columnValue(c, v)
+		}
+		return v, nil
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	default:
+		return nil, errors.New(c.name + " must be a string, number, boolean or null")
+	}
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
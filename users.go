@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gighub/db"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const usersUsage = `Usage: gighub users list
+       gighub users create -email EMAIL [-password PASSWORD]
+       gighub users verify -email EMAIL
+       gighub users reset-password -email EMAIL [-password PASSWORD]
+
+Without -password, the password is read from the first line of stdin so it
+stays out of the shell history.`
+
+// users manages accounts from the terminal, for when the site itself is
+// what's broken. The admin pages are behind ADMIN_USERNAME and
+// ADMIN_PASSWORD instead of accounts, so there are no roles to grant.
+func users(fs *flag.FlagSet, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, usersUsage)
+		return errUsage
+	}
+	action, args := args[0], args[1:]
+	email := fs.String("email", "", "email of the user")
+	password := fs.String("password", "", "new password, read from stdin if empty")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "list":
+	case "create", "verify", "reset-password":
+		if *email == "" {
+			fmt.Fprintln(os.Stderr, "-email is required")
+			return errUsage
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown users command %q\n\n%s\n", action, usersUsage)
+		return errUsage
+	}
+	if action == "create" || action == "reset-password" {
+		if *password == "" {
+			if *password, err = readPassword(); err != nil {
+				return err
+			}
+		}
+	}
+
+	dbConn, queries, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	defer queries.Close()
+	ctx := context.Background()
+
+	if action == "list" {
+		return listUsers(ctx, queries)
+	}
+	if action == "create" {
+		if err := addVerifiedUser(ctx, queries, *email, *password); err != nil {
+			return err
+		}
+		fmt.Printf("Added %s\n", *email)
+		return nil
+	}
+
+	user, err := queries.GetUserByEmail(ctx, *email)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no user with email %s", *email)
+	}
+	if err != nil {
+		return err
+	}
+	if action == "verify" {
+		n, err := queries.MarkUserVerified(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("error verifying %s: %w", *email, err)
+		}
+		if n == 0 {
+			fmt.Printf("%s was already verified\n", *email)
+		} else {
+			fmt.Printf("Verified %s\n", *email)
+		}
+		return nil
+	}
+
+	// A reset after a compromise should also end the sessions of whoever
+	// knew the old password
+	hash, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := queries.SetUserPassword(ctx, db.SetUserPasswordParams{PasswordHash: string(hash), ID: user.ID}); err != nil {
+		return fmt.Errorf("error resetting the password of %s: %w", *email, err)
+	}
+	if err := queries.DeleteUserSessions(ctx, sql.NullInt64{Int64: user.ID, Valid: true}); err != nil {
+		return fmt.Errorf("error logging %s out: %w", *email, err)
+	}
+	fmt.Printf("Reset the password of %s and logged them out everywhere\n", *email)
+	return nil
+}
+
+func listUsers(ctx context.Context, queries *db.Queries) error {
+	list, err := queries.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "ID\tEMAIL\tCREATED\tVERIFIED\t")
+	for _, u := range list {
+		verified := "no"
+		if u.VerifiedAt.Valid {
+			verified = u.VerifiedAt.Time.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(out, "%d\t%s\t%s\t%s\t\n", u.ID, u.Email, u.CreatedAt.Time.Format("2006-01-02 15:04"), verified)
+	}
+	return out.Flush()
+}
+
+// readPassword reads a password from the first line of stdin.
+func readPassword() (string, error) {
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		if err != nil {
+			return "", fmt.Errorf("error reading the password: %w", err)
+		}
+		return "", fmt.Errorf("the password is empty")
+	}
+	return password, nil
+}
+
+// addVerifiedUser signs a user up and verifies their email, as logging in
+// with Google does.
+func addVerifiedUser(ctx context.Context, queries *db.Queries, email, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	tokenBytes := make([]byte, 16)
+	rand.Read(tokenBytes)
+	token := sql.NullString{String: hex.EncodeToString(tokenBytes), Valid: true}
+	if _, err := queries.CreateUser(ctx, db.CreateUserParams{
+		Email:             email,
+		PasswordHash:      string(hash),
+		VerificationToken: token,
+	}); err != nil {
+		return fmt.Errorf("error creating %s: %w", email, err)
+	}
+	if _, err := queries.VerifyUser(ctx, token); err != nil {
+		return fmt.Errorf("error verifying %s: %w", email, err)
+	}
+	return nil
+}
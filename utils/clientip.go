@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIP returns the address the request came from, without the port.
+// Behind trusted proxies, Proxies.RealIP has already put the visitor's
+// address in RemoteAddr.
+func ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// Proxies are the reverse proxies in front of the server. Only they are
+// believed about who the visitor is.
+type Proxies struct {
+	prefixes []netip.Prefix
+	// unix trusts whoever connects over a Unix socket, which can only be
+	// a process on this machine.
+	unix bool
+}
+
+// ParseProxies parses a list of proxy addresses and CIDR ranges, like
+// "127.0.0.1,10.0.0.0/8". "unix" trusts connections over a Unix socket.
+func ParseProxies(list []string) (Proxies, error) {
+	var p Proxies
+	for _, item := range list {
+		if item == "unix" {
+			p.unix = true
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			p.prefixes = append(p.prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return Proxies{}, fmt.Errorf("%q is not an address, a CIDR range or \"unix\"", item)
+		}
+		p.prefixes = append(p.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return p, nil
+}
+
+func (p Proxies) trusts(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RealIP replaces RemoteAddr with the visitor's address when the request
+// comes through a trusted proxy. That address is the right-most hop of
+// X-Forwarded-For that isn't a trusted proxy itself; the hops left of it
+// were added by the visitor and can't be believed. Requests from anyone
+// else keep their address, whatever headers they send.
+func (p Proxies) RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, overUnix := r.Context().Value(http.LocalAddrContextKey).(*net.UnixAddr)
+		if (overUnix && p.unix) || (!overUnix && p.trusts(ClientIP(r))) {
+			var hops []string
+			for _, header := range r.Header.Values("X-Forwarded-For") {
+				hops = append(hops, strings.Split(header, ",")...)
+			}
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop := strings.TrimSpace(hops[i])
+				if _, err := netip.ParseAddr(hop); err != nil {
+					break
+				}
+				r.RemoteAddr = hop
+				if !p.trusts(hop) {
+					break
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
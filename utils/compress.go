@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Compress encodes text responses with brotli or gzip, whichever the client
+// prefers. Event streams aren't in chi's list of compressible types, so
+// they are passed through unbuffered.
+func Compress(level int) func(http.Handler) http.Handler {
+	c := middleware.NewCompressor(level)
+	c.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	})
+	return func(next http.Handler) http.Handler {
+		return c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&sniffWriter{ResponseWriter: w}, r)
+		}))
+	}
+}
+
+// sniffWriter fills in a missing Content-Type from the first write, as
+// net/http would, but early enough for the compressor to see it. Views
+// rendered straight to the response don't set one themselves.
+type sniffWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *sniffWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sniffWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *sniffWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *sniffWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
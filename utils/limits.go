@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Timeout gives each request d to finish. The request context is cancelled
+// at the deadline, and the body must have arrived by then, so a client
+// trickling bytes can't hold a handler open. If the handler gave up without
+// responding, the client gets a 503. Requests for which exempt returns true,
+// like event streams, run without a deadline.
+func Timeout(d time.Duration, exempt func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exempt != nil && exempt(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			// Not every connection supports deadlines, e.g. in tests
+			http.NewResponseController(w).SetReadDeadline(time.Now().Add(d))
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+			if ww.Status() == 0 && ctx.Err() == context.DeadlineExceeded {
+				LimitError(w, r, http.StatusServiceUnavailable, "Request timed out")
+			}
+		})
+	}
+}
+
+// limitedBody remembers the unlimited body so a route can replace the
+// default limit with its own.
+type limitedBody struct {
+	io.ReadCloser
+	orig io.ReadCloser
+}
+
+// LimitBody rejects request bodies larger than n bytes with a 413. Bodies
+// that announce their size are turned away before the handler runs; others
+// fail with *http.MaxBytesError once they pass n. Applied again further in,
+// e.g. for an upload route, the inner limit replaces the outer one.
+func LimitBody(n int64) func(http.Handler) http.Handler {
+	return LimitBodyFunc(func(*http.Request) int64 { return n })
+}
+
+// LimitBodyFunc is LimitBody with the limit picked per request, e.g. a
+// larger one for uploads.
+func LimitBodyFunc(limit func(*http.Request) int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := limit(r)
+			if r.ContentLength > n {
+				LimitError(w, r, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			orig := r.Body
+			if lb, ok := orig.(*limitedBody); ok {
+				orig = lb.orig
+			}
+			r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, orig, n), orig: orig}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BodyErrorStatus maps an error from reading the request body to 413 when
+// it was too large, 408 when the client was too slow sending it, and 400
+// otherwise.
+func BodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return http.StatusRequestTimeout
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// LimitError writes an error in the shape the caller expects: the API's
+// JSON error body under /api/, or plain text quoting the request ID.
+func LimitError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	id := middleware.GetReqID(r.Context())
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		body := map[string]string{"error": msg}
+		if id != "" {
+			body["request_id"] = id
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+	if id != "" {
+		msg += "\nRequest ID: " + id
+	}
+	http.Error(w, msg, status)
+}
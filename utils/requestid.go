@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestID bounds IDs accepted from clients so they can't bloat logs.
+const maxRequestID = 128
+
+// RequestID takes the caller's X-Request-ID, or generates one, and stores it
+// where chi's middleware.GetReqID finds it, so the request logger and
+// anything downstream can tag their output with it. The ID is echoed on the
+// response for clients to quote when reporting a problem.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			b := make([]byte, 12)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), middleware.RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// ServerError writes a 500 page that quotes the request ID, so a user's
+// report can be matched to the log line.
+func ServerError(w http.ResponseWriter, r *http.Request, msg string) {
+	if id := middleware.GetReqID(r.Context()); id != "" {
+		msg = fmt.Sprintf("%s\nIf this keeps happening, please reference request ID %s.", msg, id)
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
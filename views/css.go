@@ -1,3 +1,107 @@
 package views
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
 var CssPath = "/assets/css/styles.css"
+
+// CDNURL is the origin of a CDN in front of this server, if there is one.
+var CDNURL string
+
+// fingerprints maps each file under /assets to a path with a hash of its
+// contents in the name, and built maps those to the contents served. They
+// are filled once by FingerprintAssets and only read after that.
+var fingerprints, built = map[string]string{}, map[string]builtAsset{}
+
+type builtAsset struct {
+	name string
+	data []byte
+}
+
+// FingerprintAssets builds the files in dir, served at /assets, and hashes
+// them, so Asset links change whenever a file does and browsers can cache
+// them for good. Scripts are bundled with what they import and minified,
+// and stylesheets are minified, with esbuild; other files are served as
+// they are. Files changed after this keep their old links, so it's skipped
+// in development where Tailwind rebuilds the stylesheet on save.
+func FingerprintAssets(dir string) error {
+	return fs.WalkDir(os.DirFS(dir), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := buildAsset(path.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		ext := path.Ext(name)
+		original := "/assets/" + name
+		fingerprinted := "/assets/" + strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+		fingerprints[original] = fingerprinted
+		built[fingerprinted] = builtAsset{name: name, data: data}
+		return nil
+	})
+}
+
+// buildAsset returns the contents to serve for the file at name.
+func buildAsset(name string) ([]byte, error) {
+	switch path.Ext(name) {
+	case ".js":
+		result := api.Build(api.BuildOptions{
+			EntryPoints:       []string{name},
+			Bundle:            true,
+			Format:            api.FormatIIFE,
+			MinifyWhitespace:  true,
+			MinifyIdentifiers: true,
+			MinifySyntax:      true,
+			Outfile:           "out.js",
+		})
+		return esbuildOutput(name, result.Errors, result.OutputFiles)
+	case ".css":
+		// The stylesheet is already a bundle, made by Tailwind
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		result := api.Transform(string(data), api.TransformOptions{
+			Loader:           api.LoaderCSS,
+			MinifyWhitespace: true,
+			MinifySyntax:     true,
+		})
+		return esbuildOutput(name, result.Errors, []api.OutputFile{{Contents: result.Code}})
+	default:
+		return os.ReadFile(name)
+	}
+}
+
+func esbuildOutput(name string, errs []api.Message, files []api.OutputFile) ([]byte, error) {
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("error building %s: %s", name, errs[0].Text)
+	}
+	return files[0].Contents, nil
+}
+
+// Asset links to a static file under /assets, through the CDN if there is
+// one, and by its fingerprinted name once FingerprintAssets has run.
+func Asset(path string) string {
+	if p, ok := fingerprints[path]; ok {
+		path = p
+	}
+	return CDNURL + path
+}
+
+// AssetContent returns the name and built contents of a fingerprinted
+// asset path.
+func AssetContent(path string) (string, []byte, bool) {
+	a, ok := built[path]
+	return a.name, a.data, ok
+}
@@ -0,0 +1,51 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/a-h/templ"
+)
+
+// fragments holds the HTML of cached fragments by key.
+var fragments = struct {
+	sync.Mutex
+	m map[string]fragment
+}{m: map[string]fragment{}}
+
+type fragment struct {
+	version string
+	html    []byte
+}
+
+// Fragment renders the component load returns once per version of key and
+// replays the HTML until the version changes. load only runs on a miss, so
+// the queries behind a fragment are skipped too. The version must change
+// whenever the content would, e.g. the newest row's timestamp.
+func Fragment(key, version string, load func(ctx context.Context) (templ.Component, error)) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		fragments.Lock()
+		f, ok := fragments.m[key]
+		fragments.Unlock()
+		if ok && f.version == version {
+			_, err := w.Write(f.html)
+			return err
+		}
+
+		c, err := load(ctx)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := c.Render(ctx, &buf); err != nil {
+			return err
+		}
+		fragments.Lock()
+		fragments.m[key] = fragment{version: version, html: buf.Bytes()}
+		fragments.Unlock()
+		_, err = w.Write(buf.Bytes())
+		return err
+	})
+}
@@ -0,0 +1,304 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gighub/db"
+	"gighub/keys"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Sources of inbound events.
+const (
+	SourceStripe   = "stripe"
+	SourceCalendly = "calendly"
+	SourceCustom   = "custom"
+)
+
+// signatureTolerance bounds how old a signed timestamp may be, to stop replays.
+const signatureTolerance = 5 * time.Minute
+
+// maxInboundBody caps the size of an inbound payload.
+const maxInboundBody = 1 << 20
+
+// ErrUnhandled marks an event nobody subscribed to. It is recorded as
+// ignored rather than failed.
+var ErrUnhandled = errors.New("no handler for event")
+
+// InboundEvent is a verified event waiting to be processed.
+type InboundEvent struct {
+	Source  string
+	Type    string
+	UserID  int64
+	Payload json.RawMessage
+}
+
+// Handler processes one inbound event.
+type Handler func(ctx context.Context, event InboundEvent) error
+
+// Receiver verifies inbound webhooks, stores them once per idempotency key
+// and processes them in the background.
+type Receiver struct {
+	Queries *db.Queries
+
+	// Signing keys for third-party providers, current first. Any of them
+	// is accepted while rotating. No keys disables the provider's endpoint.
+	StripeKeys   keys.Ring
+	CalendlyKeys keys.Ring
+
+	handlers map[string]Handler
+	wake     chan struct{}
+}
+
+func NewReceiver(queries *db.Queries) *Receiver {
+	return &Receiver{
+		Queries:  queries,
+		handlers: map[string]Handler{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler for an event type from a source.
+func (rc *Receiver) Handle(source, eventType string, h Handler) {
+	rc.handlers[source+":"+eventType] = h
+}
+
+// NewInboundToken generates the URL token for a custom inbound endpoint.
+func NewInboundToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Routes serves /hooks/stripe, /hooks/calendly and /hooks/custom/{token}.
+func (rc *Receiver) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Post("/stripe", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		if verifyTimestamped(SourceStripe, rc.StripeKeys, r.Header.Get("Stripe-Signature"), body) != nil {
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+		var event struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil || event.ID == "" {
+			http.Error(w, "Invalid payload", http.StatusBadRequest)
+			return
+		}
+		rc.accept(w, r, SourceStripe, event.ID, sql.NullInt64{}, event.Type, body)
+	})
+
+	r.Post("/calendly", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		if verifyTimestamped(SourceCalendly, rc.CalendlyKeys, r.Header.Get("Calendly-Webhook-Signature"), body) != nil {
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+		var event struct {
+			Event string `json:"event"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil || event.Event == "" {
+			http.Error(w, "Invalid payload", http.StatusBadRequest)
+			return
+		}
+		rc.accept(w, r, SourceCalendly, idempotencyKey(r, body), sql.NullInt64{}, event.Event, body)
+	})
+
+	r.Post("/custom/{token}", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		hook, err := rc.Queries.GetInboundHookByToken(r.Context(), chi.URLParam(r, "token"))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.NotFound(w, r)
+			} else {
+				http.Error(w, "Database error", http.StatusInternalServerError)
+			}
+			return
+		}
+		timestamp := r.Header.Get("X-Gighub-Timestamp")
+		if err := checkTimestamp(timestamp); err != nil || !hmac.Equal([]byte(Sign(hook.Secret, timestamp, body)), []byte(r.Header.Get("X-Gighub-Signature"))) {
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+		var event struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil || event.Type == "" {
+			http.Error(w, "Invalid payload", http.StatusBadRequest)
+			return
+		}
+		rc.accept(w, r, SourceCustom, idempotencyKey(r, body), sql.NullInt64{Int64: hook.ID, Valid: true}, event.Type, body)
+	})
+
+	return r
+}
+
+// accept stores the event and acknowledges it. Redelivery of an event that
+// was already stored is acknowledged without queueing it again.
+func (rc *Receiver) accept(w http.ResponseWriter, r *http.Request, source, key string, hookID sql.NullInt64, eventType string, body []byte) {
+	if hookID.Valid {
+		key = strconv.FormatInt(hookID.Int64, 10) + ":" + key
+	}
+	_, err := rc.Queries.CreateInboundEvent(r.Context(), db.CreateInboundEventParams{
+		Source:         source,
+		IdempotencyKey: key,
+		HookID:         hookID,
+		EventType:      eventType,
+		Payload:        string(body),
+	})
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		log.Printf("Error storing inbound event: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	select {
+	case rc.wake <- struct{}{}:
+	default:
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Run processes queued events until ctx is cancelled.
+func (rc *Receiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		rc.processPending(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-rc.wake:
+		}
+	}
+}
+
+func (rc *Receiver) processPending(ctx context.Context) {
+	pending, err := rc.Queries.ListPendingInboundEvents(ctx, 50)
+	if err != nil {
+		log.Printf("Error listing inbound events: %v", err)
+		return
+	}
+	for _, e := range pending {
+		update := db.UpdateInboundEventParams{ID: e.ID, Status: "processed", Attempts: e.Attempts + 1}
+
+		err := ErrUnhandled
+		if h, ok := rc.handlers[e.Source+":"+e.EventType]; ok {
+			err = h(ctx, InboundEvent{
+				Source:  e.Source,
+				Type:    e.EventType,
+				UserID:  e.UserID.Int64,
+				Payload: json.RawMessage(e.Payload),
+			})
+		}
+		switch {
+		case errors.Is(err, ErrUnhandled):
+			update.Status = "ignored"
+		case err != nil:
+			update.Status = "failed"
+			update.Error = sql.NullString{String: err.Error(), Valid: true}
+			log.Printf("Error processing inbound %s event %d: %v", e.Source, e.ID, err)
+		}
+		if err := rc.Queries.UpdateInboundEvent(ctx, update); err != nil {
+			log.Printf("Error updating inbound event %d: %v", e.ID, err)
+		}
+	}
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInboundBody))
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// idempotencyKey prefers the sender's Idempotency-Key header and otherwise
+// falls back to a hash of the body, so byte-identical retries collapse.
+func idempotencyKey(r *http.Request, body []byte) string {
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		return key
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyTimestamped checks a "t=<unix>,v1=<hex hmac>" header as used by
+// Stripe and Calendly, where the MAC covers "<t>.<body>", against each key
+// in the ring.
+func verifyTimestamped(source string, ring keys.Ring, header string, body []byte) error {
+	var timestamp string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	if err := checkTimestamp(timestamp); err != nil {
+		return err
+	}
+	key, ok := ring.Match(func(secret []byte) bool {
+		expected := strings.TrimPrefix(Sign(string(secret), timestamp, body), "sha256=")
+		for _, sig := range sigs {
+			if hmac.Equal([]byte(sig), []byte(expected)) {
+				return true
+			}
+		}
+		return false
+	})
+	if !ok {
+		return fmt.Errorf("signature mismatch")
+	}
+	if key.ID != ring[0].ID {
+		log.Printf("%s webhook signed with old key %q, finish rotating to %q", source, key.ID, ring[0].ID)
+	}
+	return nil
+}
+
+func checkTimestamp(timestamp string) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp")
+	}
+	if math.Abs(time.Since(time.Unix(ts, 0)).Seconds()) > signatureTolerance.Seconds() {
+		return fmt.Errorf("timestamp outside tolerance")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"gighub/db"
+)
+
+// streamPage is how many events the queries behind a stream return at
+// once. A full page means there is more backlog.
+const streamPage = 100
+
+// Stream sends events after lastID as Server-Sent Events, and then new ones
+// as they are published, until the client goes away or stop is closed.
+// list reads up to a page of events after an ID, and write sends one of
+// them. Clients that reconnect send the last ID they got in Last-Event-ID.
+func (d *Dispatcher) Stream(w http.ResponseWriter, r *http.Request, lastID int64, stop <-chan struct{},
+	list func(ctx context.Context, after int64) ([]db.Event, error), write func(w io.Writer, e db.Event)) {
+	rc := http.NewResponseController(w)
+	updates, unsubscribe := d.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	rc.Flush()
+
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		events, err := list(r.Context(), lastID)
+		if err != nil {
+			if r.Context().Err() == nil {
+				log.Printf("Error listing events for %s: %v", r.URL.Path, err)
+			}
+			return
+		}
+		for _, e := range events {
+			write(w, e)
+			lastID = e.ID
+		}
+		if len(events) > 0 {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+		// Send the rest of the backlog right away.
+		if len(events) == streamPage {
+			continue
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-stop:
+			return
+		case <-updates:
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gighub/db"
+	"gighub/utils"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Event types that can be subscribed to.
+const (
+	GuestbookUpdated = "guestbook.updated"
+	Ping             = "webhook.ping"
+)
+
+// Sources record where a webhook was created. Only REST hook subscriptions
+// can be removed through the REST hook unsubscribe endpoint.
+const (
+	SourceDashboard = "dashboard"
+	SourceAPI       = "api"
+	SourceRestHook  = "rest_hook"
+)
+
+// EventTypes lists the events users can pick when registering an endpoint.
+var EventTypes = []string{GuestbookUpdated}
+
+// ValidateEvents checks that events names at least one event, and only
+// ones in EventTypes.
+func ValidateEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("pick at least one event")
+	}
+	for _, e := range events {
+		if !slices.Contains(EventTypes, e) {
+			return fmt.Errorf("unknown event type: %s", e)
+		}
+	}
+	return nil
+}
+
+// maxAttempts is how many times a delivery is tried before it is marked failed.
+const maxAttempts = 6
+
+// Dispatcher records domain events and delivers them to registered endpoints.
+type Dispatcher struct {
+	Queries *db.Queries
+	Client  *http.Client
+
+	wake chan struct{}
+
+	mu        sync.Mutex
+	listeners map[chan struct{}]struct{}
+}
+
+func New(queries *db.Queries) *Dispatcher {
+	return &Dispatcher{
+		Queries: queries,
+		// Endpoints are URLs users give us, so deliveries must not reach
+		// internal services, redirects included.
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{Timeout: 5 * time.Second, Control: utils.PublicOnly}).DialContext,
+			},
+		},
+		wake: make(chan struct{}, 1),
+
+		listeners: map[chan struct{}]struct{}{},
+	}
+}
+
+// Subscribe returns a channel that is signalled whenever an event is
+// stored, for streaming them to connected clients. Signals are coalesced,
+// so listeners should read everything new from the events table.
+func (d *Dispatcher) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	d.mu.Lock()
+	d.listeners[ch] = struct{}{}
+	d.mu.Unlock()
+	return ch, func() {
+		d.mu.Lock()
+		delete(d.listeners, ch)
+		d.mu.Unlock()
+	}
+}
+
+func (d *Dispatcher) notify() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ch := range d.listeners {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// NewSecret generates a signing secret for a new endpoint.
+func NewSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// Publish stores an event and queues a delivery for every endpoint subscribed
+// to it. Events with a user are only sent to that user's endpoints; events
+// without one (like guestbook updates) go to everyone subscribed.
+func (d *Dispatcher) Publish(ctx context.Context, userID int64, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding event payload: %w", err)
+	}
+
+	event, err := d.Queries.CreateEvent(ctx, db.CreateEventParams{
+		UserID:    sql.NullInt64{Int64: userID, Valid: userID != 0},
+		Type:      eventType,
+		Payload:   string(data),
+		RequestID: requestID(ctx),
+	})
+	if err != nil {
+		return fmt.Errorf("error storing event: %w", err)
+	}
+	d.notify()
+
+	hooks, err := d.Queries.ListWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing webhooks: %w", err)
+	}
+	for _, hook := range hooks {
+		if event.UserID.Valid && hook.UserID != event.UserID.Int64 {
+			continue
+		}
+		if !Subscribed(hook, eventType) {
+			continue
+		}
+		if err := d.enqueue(ctx, hook.ID, event.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendPing queues a test event for a single endpoint.
+func (d *Dispatcher) SendPing(ctx context.Context, hook db.Webhook) error {
+	event, err := d.Queries.CreateEvent(ctx, db.CreateEventParams{
+		UserID:    sql.NullInt64{Int64: hook.UserID, Valid: true},
+		Type:      Ping,
+		Payload:   fmt.Sprintf(`{"webhook_id":%d}`, hook.ID),
+		RequestID: requestID(ctx),
+	})
+	if err != nil {
+		return fmt.Errorf("error storing event: %w", err)
+	}
+	return d.enqueue(ctx, hook.ID, event.ID)
+}
+
+// requestID returns the ID of the request that caused an event, if any, so
+// deliveries can be correlated with it.
+func requestID(ctx context.Context) sql.NullString {
+	id := middleware.GetReqID(ctx)
+	return sql.NullString{String: id, Valid: id != ""}
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, webhookID, eventID int64) error {
+	if err := d.Queries.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		NextAttemptAt: time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("error queueing delivery: %w", err)
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Subscribed reports whether the endpoint wants events of the given type.
+// Pings are always delivered.
+func Subscribed(hook db.Webhook, eventType string) bool {
+	return eventType == Ping || slices.Contains(strings.Split(hook.Events, ","), eventType)
+}
+
+// Run delivers pending events until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		d.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	due, err := d.Queries.ListDueWebhookDeliveries(ctx, db.ListDueWebhookDeliveriesParams{
+		NextAttemptAt: time.Now().UTC(),
+		Limit:         50,
+	})
+	if err != nil {
+		log.Printf("Error listing webhook deliveries: %v", err)
+		return
+	}
+	for _, delivery := range due {
+		d.deliver(ctx, delivery)
+	}
+}
+
+// Envelope is the body of a delivery. The API's polling triggers list
+// events in the same shape, so a client can use either.
+type Envelope struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt string          `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewEnvelope wraps an event for sending.
+func NewEnvelope(id int64, eventType string, createdAt sql.NullTime, payload string) Envelope {
+	return Envelope{
+		ID:        id,
+		Type:      eventType,
+		CreatedAt: createdAt.Time.UTC().Format(time.RFC3339),
+		Data:      json.RawMessage(payload),
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery db.ListDueWebhookDeliveriesRow) {
+	body, _ := json.Marshal(NewEnvelope(delivery.EventID, delivery.Type, delivery.CreatedAt, delivery.Payload))
+
+	update := db.UpdateWebhookDeliveryParams{
+		ID:       delivery.ID,
+		Status:   "succeeded",
+		Attempts: delivery.Attempts + 1,
+	}
+
+	code, err := d.post(ctx, delivery, body)
+	if code != 0 {
+		update.ResponseCode = sql.NullInt64{Int64: int64(code), Valid: true}
+	}
+	if err != nil {
+		update.Error = sql.NullString{String: err.Error(), Valid: true}
+		if code == http.StatusGone {
+			// Subscribers like Zapier answer 410 once the hook was removed on
+			// their side, so the endpoint is dropped instead of retried
+			update.Status = "failed"
+			if err := d.Queries.DeleteWebhook(ctx, db.DeleteWebhookParams{ID: delivery.WebhookID, UserID: delivery.UserID}); err != nil {
+				log.Printf("Error deleting webhook %d: %v", delivery.WebhookID, err)
+			}
+		} else if update.Attempts >= maxAttempts {
+			update.Status = "failed"
+		} else {
+			update.Status = "pending"
+			update.NextAttemptAt = time.Now().UTC().Add(backoff(update.Attempts))
+		}
+	}
+
+	if err := d.Queries.UpdateWebhookDelivery(ctx, update); err != nil {
+		log.Printf("Error updating webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery db.ListDueWebhookDeliveriesRow, body []byte) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gighub-webhooks")
+	req.Header.Set("X-Gighub-Event", delivery.Type)
+	req.Header.Set("X-Gighub-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Gighub-Timestamp", timestamp)
+	req.Header.Set("X-Gighub-Signature", Sign(delivery.Secret, timestamp, body))
+	if delivery.RequestID.Valid {
+		req.Header.Set("X-Request-ID", delivery.RequestID.String)
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign computes the signature header value for a payload. Receivers
+// recompute it over "<timestamp>.<body>" with their secret and compare.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff doubles the wait after each failed attempt: 1m, 2m, 4m, ...
+func backoff(attempts int64) time.Duration {
+	return time.Minute << (attempts - 1)
+}
+
+// ValidateURL checks that an endpoint URL is an absolute http(s) URL on a
+// public address. Hosts that don't resolve yet are accepted; deliveries
+// refuse non-public addresses anyway.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("endpoint must be an absolute http or https URL")
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !utils.PublicIP(ip) {
+			return fmt.Errorf("endpoint must be on a public address")
+		}
+		return nil
+	}
+	if host = strings.ToLower(strings.TrimSuffix(host, ".")); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("endpoint must be on a public address")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	addrs, _ := net.DefaultResolver.LookupIPAddr(ctx, host)
+	for _, addr := range addrs {
+		if !utils.PublicIP(addr.IP) {
+			return fmt.Errorf("endpoint must be on a public address")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gighub/db"
+)
+
+func TestSign(t *testing.T) {
+	// Computed with Python's hmac module, as a receiver would
+	const want = "sha256=2f441ba4b3b2d50d28a9ab9d9fd8880376ecd1eb5d0435401553f5d8d0a5dcf8"
+	if got := Sign("whsec_test", "1700000000", []byte(`{"id":1}`)); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	for _, tt := range []struct {
+		name, secret, timestamp, body string
+	}{
+		{"other secret", "whsec_other", "1700000000", `{"id":1}`},
+		{"other timestamp", "whsec_test", "1700000001", `{"id":1}`},
+		{"other body", "whsec_test", "1700000000", `{"id":2}`},
+		{"moved separator", "whsec_test", "170000000", `0.{"id":1}`},
+	} {
+		if Sign(tt.secret, tt.timestamp, []byte(tt.body)) == want {
+			t.Errorf("%s: same signature", tt.name)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		attempts int64
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{5, 16 * time.Minute},
+	} {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestValidateEvents(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		events []string
+		ok     bool
+	}{
+		{"known", []string{GuestbookUpdated}, true},
+		{"none", nil, false},
+		{"unknown", []string{GuestbookUpdated, "user.deleted"}, false},
+		{"ping", []string{Ping}, false},
+		{"empty name", []string{""}, false},
+	} {
+		if err := ValidateEvents(tt.events); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	for _, tt := range []struct {
+		url string
+		ok  bool
+	}{
+		{"https://93.184.216.34/hook", true},
+		{"http://[2606:2800:220:1:248:1893:25c8:1946]:8080/hook", true},
+		{"ftp://93.184.216.34/hook", false},
+		{"/hook", false},
+		{"https://", false},
+		{"http://127.0.0.1/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.64.0.1/hook", false},
+		{"http://0.0.0.0:8080/hook", false},
+		{"http://localhost:3000/hook", false},
+		{"http://api.localhost./hook", false},
+	} {
+		if err := ValidateURL(tt.url); (err == nil) != tt.ok {
+			t.Errorf("ValidateURL(%s) = %v, want ok %v", tt.url, err, tt.ok)
+		}
+	}
+}
+
+// endpoint registers a webhook for guestbook updates at a test server
+// answering with status, and returns a dispatcher that can reach it.
+func endpoint(t *testing.T, status int) (*Dispatcher, *sql.DB, db.Webhook) {
+	t.Helper()
+	ctx := context.Background()
+	conn, queries, err := db.Setup(t.TempDir(), "test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	user, err := queries.CreateUser(ctx, db.CreateUserParams{Email: "me@example.com", PasswordHash: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const secret = "whsec_test"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get("X-Gighub-Signature"); got != Sign(secret, r.Header.Get("X-Gighub-Timestamp"), body) {
+			t.Errorf("delivered with signature %q, which doesn't match the body", got)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+
+	hook, err := queries.CreateWebhook(ctx, db.CreateWebhookParams{UserID: user.ID, Url: ts.URL, Secret: secret, Events: GuestbookUpdated, Source: SourceDashboard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := New(queries)
+	// The test server is on a loopback address, which the default client refuses
+	d.Client = ts.Client()
+	if err := d.Publish(ctx, 0, GuestbookUpdated, map[string]string{"message": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	return d, conn, hook
+}
+
+func delivery(t *testing.T, d *Dispatcher, hook db.Webhook) db.ListWebhookDeliveriesRow {
+	t.Helper()
+	deliveries, err := d.Queries.ListWebhookDeliveries(context.Background(), hook.ID)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, %v", len(deliveries), err)
+	}
+	return deliveries[0]
+}
+
+func TestDeliverSucceeds(t *testing.T) {
+	d, _, hook := endpoint(t, http.StatusNoContent)
+	d.deliverDue(context.Background())
+	if got := delivery(t, d, hook); got.Status != "succeeded" || got.Attempts != 1 || got.ResponseCode.Int64 != http.StatusNoContent {
+		t.Errorf("got %s after %d attempts with %d", got.Status, got.Attempts, got.ResponseCode.Int64)
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	d, conn, hook := endpoint(t, http.StatusInternalServerError)
+	ctx := context.Background()
+
+	start := time.Now().UTC()
+	d.deliverDue(ctx)
+	got := delivery(t, d, hook)
+	if got.Status != "pending" || got.Attempts != 1 {
+		t.Fatalf("got %s after %d attempts, want pending after 1", got.Status, got.Attempts)
+	}
+	if wait := got.NextAttemptAt.Sub(start); wait < time.Minute || wait > time.Minute+5*time.Second {
+		t.Errorf("next attempt in %v, want a minute", wait)
+	}
+
+	// Not due yet
+	d.deliverDue(ctx)
+	if got := delivery(t, d, hook); got.Attempts != 1 {
+		t.Fatalf("retried after %d attempts before it was due", got.Attempts)
+	}
+
+	for i := 2; i <= maxAttempts; i++ {
+		if _, err := conn.Exec("UPDATE webhook_deliveries SET next_attempt_at = ?", start.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		d.deliverDue(ctx)
+	}
+	if got := delivery(t, d, hook); got.Status != "failed" || got.Attempts != maxAttempts {
+		t.Errorf("got %s after %d attempts, want failed after %d", got.Status, got.Attempts, maxAttempts)
+	}
+}
+
+func TestDeliverGoneDeletesWebhook(t *testing.T) {
+	d, _, hook := endpoint(t, http.StatusGone)
+	ctx := context.Background()
+	d.deliverDue(ctx)
+	if _, err := d.Queries.GetWebhook(ctx, db.GetWebhookParams{ID: hook.ID, UserID: hook.UserID}); err != sql.ErrNoRows {
+		t.Errorf("webhook still there after a 410: %v", err)
+	}
+}